@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
 	"luahelper-lsp/langserver/check/projects"
 	"luahelper-lsp/langserver/check/results"
 	"luahelper-lsp/langserver/log"
@@ -58,13 +59,25 @@ type Analysis struct {
 
 	// 特别的标记
 	extMark string
+
+	// 推导函数返回值类型时，正在推导的函数，防止递归推导
+	inferFuncMap map[*common.FuncInfo]bool
+
+	// moocscript中guard语句的代码块，跳转语句后面的代码由checkMoocGuardUnreachable单独检查
+	guardBlock *ast.Block
+
+	// 正在分析的for语句的循环变量，用于判断循环变量是否被闭包引用后又被修改了
+	loopVarMap map[*common.VarInfo]*loopVarInfo
+
+	// 所属会话的配置
+	conf *common.GlobalConfig
 }
 
 // 注解互斥锁
 var mutex sync.Mutex
 
-// CreateAnalysis 创建一个分析的结构
-func CreateAnalysis(checkTerm results.CheckTerm, entryFile string) *Analysis {
+// CreateAnalysis 创建一个分析的结构，conf为所属会话的配置
+func CreateAnalysis(checkTerm results.CheckTerm, entryFile string, conf *common.GlobalConfig) *Analysis {
 	return &Analysis{
 		checkTerm: checkTerm,
 		entryFile: entryFile,
@@ -78,6 +91,7 @@ func CreateAnalysis(checkTerm results.CheckTerm, entryFile string) *Analysis {
 		ReferenceResult:     nil,
 		realTimeFlag:        false,
 		curFunc:             nil,
+		conf:                conf,
 	}
 }
 
@@ -173,10 +187,10 @@ func (a *Analysis) HandleSecondProjectTraverseAST(initialResult *results.FileRes
 	strFile := initialResult.Name
 	mainAst := initialResult.Block
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 	entryFile := dirManager.RemovePathDirPre(a.entryFile)
 
-	fileResult := results.CreateFileResult(strFile, mainAst, results.CheckTermSecond, entryFile)
+	fileResult := results.CreateFileResult(strFile, mainAst, results.CheckTermSecond, entryFile, a.conf)
 
 	// 插入主函数
 	fileResult.InertNewFunc(fileResult.MainFunc)
@@ -188,6 +202,9 @@ func (a *Analysis) HandleSecondProjectTraverseAST(initialResult *results.FileRes
 	// 开始遍历
 	a.cgBlock(fileResult.Block)
 	a.exitScope()
+
+	// 检查当前文件定义的全局函数是否被引用过
+	a.checkGlobalFuncNotCall()
 }
 
 // 第二轮深度遍历过程中，其他地方又引入了其他的lua文件，这里统一处理
@@ -306,7 +323,7 @@ func (a *Analysis) GetReferFileResult(referInfo *common.ReferInfo, checkTerm res
 func (a *Analysis) HandleTermTraverseAST(checkTerm results.CheckTerm, firstFile *results.FileResult, parent *results.FileResult) {
 	strFile := firstFile.Name
 	mainAst := firstFile.Block
-	fileResult := results.CreateFileResult(strFile, mainAst, checkTerm, "")
+	fileResult := results.CreateFileResult(strFile, mainAst, checkTerm, "", a.conf)
 
 	if checkTerm == results.CheckTermThird {
 		a.AnalysisThird.FileResult = fileResult
@@ -327,6 +344,9 @@ func (a *Analysis) HandleTermTraverseAST(checkTerm results.CheckTerm, firstFile
 	// 开始遍历
 	a.cgBlock(fileResult.Block)
 	a.exitScope()
+
+	// 第三轮检查当前文件定义的全局函数是否被引用过
+	a.checkGlobalFuncNotCall()
 }
 
 // SetRealTimeFlag set real time flag
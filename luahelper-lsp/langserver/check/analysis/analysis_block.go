@@ -10,6 +10,9 @@ func (a *Analysis) cgBlock(node *ast.Block) {
 		a.cgStat(stat)
 	}
 
+	// 检查block中不会执行到的代码，block中的局部变量都已经定义，可以判断error等是否为局部变量
+	a.checkUnreachable(node)
+
 	if node.RetExps != nil {
 		a.cgRetStat(node.RetExps)
 	}
@@ -34,6 +37,9 @@ func (a *Analysis) cgRetStat(exps []ast.Exp) {
 		returnItem.VarType = common.GetExpType(exp)
 		returnItem.VarInfo = a.getExpReferVarInfo(exp)
 		returnItem.ReturnExp = exp
+		if callExp, ok := exp.(*ast.FuncCallExp); ok {
+			returnItem.ReferFunc = a.getCallExpReferFunc(callExp)
+		}
 
 		returnInfo.ReturnVarVec = append(returnInfo.ReturnVarVec, returnItem)
 
@@ -13,11 +13,11 @@ func (a *Analysis) checkAssignTypeSame(leftNode ast.Exp, rightNode ast.Exp) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorAssignType) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorAssignType) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorAssignType]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorAssignType]; !ok {
 		return
 	}
 
@@ -13,11 +13,11 @@ func (a *Analysis) checkBinopExpTypeSame(node *ast.BinopExp) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorBinopType) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorBinopType) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorBinopType]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorBinopType]; !ok {
 		return
 	}
 
@@ -61,6 +61,11 @@ func (a *Analysis) checkBinopExpTypeSame(node *ast.BinopExp) {
 		}
 	}
 
+	// 操作数的class类型定义了运算符元方法，例如 ---@operator add(Vector): Vector，不告警
+	if !hasMatch && len(a.getOperatorTypeByVec(node.Op, leftTypeVec, rightTypeVec)) > 0 {
+		hasMatch = true
+	}
+
 	if !hasMatch {
 		loc := common.GetExpLoc(node)
 		leftTypeVecStr := strings.Join(leftTypeVec, "|")
@@ -70,3 +75,39 @@ func (a *Analysis) checkBinopExpTypeSame(node *ast.BinopExp) {
 		a.curResult.InsertError(common.CheckErrorBinopType, errStr, loc)
 	}
 }
+
+// getOperatorTypeByVec 获取二元运算通过运算符元方法推导出来的结果类型，没有定义运算符元方法返回空
+// 与lua的规则一致，先查找左边操作数的元方法，再查找右边操作数的元方法
+func (a *Analysis) getOperatorTypeByVec(op lexer.TkKind, leftTypeVec []string, rightTypeVec []string) (retVec []string) {
+	strOp, ok := common.OperatorMetaNameMap[op]
+	if !ok || a.Projects == nil {
+		return
+	}
+
+	for _, leftType := range leftTypeVec {
+		retVec = a.Projects.GetOperatorResultType(leftType, strOp, rightTypeVec)
+		if len(retVec) > 0 {
+			return retVec
+		}
+	}
+
+	for _, rightType := range rightTypeVec {
+		retVec = a.Projects.GetOperatorResultType(rightType, strOp, leftTypeVec)
+		if len(retVec) > 0 {
+			return retVec
+		}
+	}
+
+	return
+}
+
+// getBinopOperatorType 获取二元运算表达式通过运算符元方法推导出来的结果类型，没有定义运算符元方法返回空
+func (a *Analysis) getBinopOperatorType(node *ast.BinopExp) (retVec []string) {
+	if _, ok := common.OperatorMetaNameMap[node.Op]; !ok || a.Projects == nil {
+		return
+	}
+
+	leftTypeVec := a.GetAnnTypeByExp(node.Exp1, -1)
+	rightTypeVec := a.GetAnnTypeByExp(node.Exp2, -1)
+	return a.getOperatorTypeByVec(node.Op, leftTypeVec, rightTypeVec)
+}
@@ -13,11 +13,11 @@ func (a *Analysis) checkConstAssgin(node ast.Exp) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorConstAssign) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorConstAssign) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorConstAssign]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorConstAssign]; !ok {
 		return
 	}
 
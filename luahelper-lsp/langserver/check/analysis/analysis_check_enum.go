@@ -17,7 +17,7 @@ func (a *Analysis) checkEnum(node *ast.TableConstructorExp) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
 		return
 	}
 
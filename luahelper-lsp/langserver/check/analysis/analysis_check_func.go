@@ -15,11 +15,11 @@ func (a *Analysis) funcCallParamTypeCheck(node *ast.FuncCallStat, referFunc *com
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorCallParamType) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorCallParamType) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorCallParamType]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorCallParamType]; !ok {
 		return
 	}
 
@@ -81,11 +81,11 @@ func (a *Analysis) funcReturnCheck(retInfo *common.ReturnInfo) {
 	}
 
 	// 判断是否开启了函数调用参数个数不匹配的校验
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorFuncRetErr) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorFuncRetErr) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorFuncRetErr]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorFuncRetErr]; !ok {
 		return
 	}
 
@@ -146,11 +146,11 @@ func (a *Analysis) checkLocFuncCall() {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorLocFuncNotCall) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorLocFuncNotCall) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorLocFuncNotCall]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorLocFuncNotCall]; !ok {
 		return
 	}
 
@@ -185,7 +185,7 @@ func (a *Analysis) checkLocFuncCall() {
 			// 1) 判断是否直接关联到的系统模块或函数
 			oneStr := common.GetExpSubKey(expName)
 			if oneStr != "" {
-				if common.GConfig.IsInSysNotUseMap(oneStr) {
+				if a.conf.IsInSysNotUseMap(oneStr) {
 					// 为系统的模块或函数名，忽略掉
 					continue
 				}
@@ -198,7 +198,7 @@ func (a *Analysis) checkLocFuncCall() {
 			}
 			moduleName, keyName := common.GetTableStrTwoStr(expName)
 			if moduleName != "" && keyName != "" {
-				if common.GConfig.IsInSysNotUseMap(moduleName) {
+				if a.conf.IsInSysNotUseMap(moduleName) {
 					// 为系统的模块或函数名，忽略掉
 					continue
 				}
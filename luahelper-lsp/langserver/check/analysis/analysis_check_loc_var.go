@@ -13,7 +13,7 @@ func (a *Analysis) checkLocVarCall() {
 	}
 
 	// 判断是否开启了局部变量定义了是否未使用的告警
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorLocalNoUse) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorLocalNoUse) {
 		return
 	}
 
@@ -31,7 +31,7 @@ func (a *Analysis) checkLocVarCall() {
 			continue
 		}
 
-		if common.GConfig.IsIgnoreLocNotUseVar(varName) {
+		if a.conf.IsIgnoreLocNotUseVar(varName) {
 			// 如果为系统忽略的局部变量定义了，未使用的，忽略掉
 			continue
 		}
@@ -53,7 +53,7 @@ func (a *Analysis) checkLocVarCall() {
 			// 1) 判断是否直接关联到的系统模块或函数
 			oneStr := common.GetExpSubKey(expName)
 			if oneStr != "" {
-				if common.GConfig.IsInSysNotUseMap(oneStr) {
+				if a.conf.IsInSysNotUseMap(oneStr) {
 					// 为系统的模块或函数名，忽略掉
 					continue
 				}
@@ -66,7 +66,7 @@ func (a *Analysis) checkLocVarCall() {
 			}
 			moduleName, keyName := common.GetTableStrTwoStr(expName)
 			if moduleName != "" && keyName != "" {
-				if common.GConfig.IsInSysNotUseMap(moduleName) {
+				if a.conf.IsInSysNotUseMap(moduleName) {
 					// 为系统的模块或函数名，忽略掉
 					continue
 				}
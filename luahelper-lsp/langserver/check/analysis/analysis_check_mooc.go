@@ -0,0 +1,270 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"strings"
+)
+
+// moocscript 特有语法的检查，只对.mooc文件生效
+
+// isMoocFirstCheck 第一轮非实时检查时，判断是否要进行moocscript的检查
+func (a *Analysis) isMoocFirstCheck(errType common.CheckErrorType) bool {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return false
+	}
+
+	if !strings.HasSuffix(a.entryFile, ".mooc") {
+		return false
+	}
+
+	return !a.conf.IsGlobalIgnoreErrType(errType)
+}
+
+// recordMoocExtension 第一轮记录extension语句扩展的类名位置，extension语句不算类的定义
+func (a *Analysis) recordMoocExtension(node *ast.ClassDefStat) {
+	if !a.isFirstTerm() || node.SType != lexer.TkKwExtension {
+		return
+	}
+
+	a.curResult.ExtensionLocMap[common.GetExpLoc(node.Class.VarList[0])] = struct{}{}
+}
+
+// recordMoocClass 第一轮记录class或struct定义的类变量，extension只是扩展已有的类，不算类的定义
+func (a *Analysis) recordMoocClass(node *ast.ClassDefStat) {
+	if !a.isFirstTerm() || node.SType == lexer.TkKwExtension {
+		return
+	}
+
+	nameExp, ok := node.Class.VarList[0].(*ast.NameExp)
+	if !ok {
+		return
+	}
+
+	if varInfo := a.findStrReferVarInfo(nameExp.Name, node.Loc, false, ""); varInfo != nil {
+		varInfo.IsClass = true
+	}
+}
+
+// checkMoocExtension 检查extension扩展的类是否有定义，例如 extension A {} 中的A
+func (a *Analysis) checkMoocExtension(node *ast.ClassDefStat) {
+	if !a.isNeedCheck() || a.realTimeFlag || node.SType != lexer.TkKwExtension {
+		return
+	}
+
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorMoocExtension) {
+		return
+	}
+
+	nameExp, ok := node.Class.VarList[0].(*ast.NameExp)
+	if !ok {
+		return
+	}
+
+	// 系统的模块或是忽略的变量，例如 extension string {}
+	strName := nameExp.Name
+	if a.conf.MoocIsIgnoreNameVar(strName) || a.conf.IsIgnoreNameVar(strName) {
+		return
+	}
+
+	if _, ok := a.conf.LuaInMap[strName]; ok {
+		return
+	}
+
+	// 扩展的是前面定义的局部类
+	if _, ok := a.curScope.FindLocVar(strName, nameExp.Loc); ok {
+		return
+	}
+
+	// 扩展的是工程中定义的全局类
+	if a.Projects.IsGlobalDefineInProject(strName) {
+		return
+	}
+
+	errStr := fmt.Sprintf("extension class '%s' not define", strName)
+	a.curResult.InsertError(common.CheckErrorMoocExtension, errStr, nameExp.Loc)
+}
+
+// checkMoocDuplicateMethod 检查class或struct中是否定义了同名的成员函数或变量
+func (a *Analysis) checkMoocDuplicateMethod(node *ast.ClassDefStat) {
+	if !a.isMoocFirstCheck(common.CheckErrorMoocDuplicateMethod) {
+		return
+	}
+
+	memberMap := map[string]*ast.StringExp{}
+	for _, assignStat := range node.List {
+		if len(assignStat.VarList) == 0 {
+			continue
+		}
+
+		taExp, ok := assignStat.VarList[0].(*ast.TableAccessExp)
+		if !ok {
+			continue
+		}
+
+		keyExp, ok := taExp.KeyExp.(*ast.StringExp)
+		if !ok {
+			continue
+		}
+
+		beforeExp, ok := memberMap[keyExp.Str]
+		if !ok {
+			memberMap[keyExp.Str] = keyExp
+			continue
+		}
+
+		errStr := fmt.Sprintf("class member '%s' duplicate define", keyExp.Str)
+		relateVec := []common.RelateCheckInfo{
+			{
+				LuaFile: a.curResult.Name,
+				ErrStr:  errStr,
+				Loc:     beforeExp.Loc,
+			},
+		}
+		a.curResult.InsertRelateError(common.CheckErrorMoocDuplicateMethod, errStr, keyExp.Loc, relateVec)
+	}
+}
+
+// checkMoocSelfSuper 检查Self与Super是否在class的外面使用
+func (a *Analysis) checkMoocSelfSuper(node *ast.NameExp) {
+	if node.Name != "Self" && node.Name != "Super" {
+		return
+	}
+
+	if !a.isMoocFirstCheck(common.CheckErrorMoocSelfSuper) {
+		return
+	}
+
+	// class中取巧定义了Self与Super局部变量
+	if _, ok := a.curScope.FindLocVar(node.Name, node.Loc); ok {
+		return
+	}
+
+	errStr := fmt.Sprintf("'%s' can only be used in class scope", node.Name)
+	a.curResult.InsertError(common.CheckErrorMoocSelfSuper, errStr, node.Loc)
+}
+
+// checkMoocImportNum 检查 import a, b from "lib" { A, B } 中名称与成员的个数是否一致
+func (a *Analysis) checkMoocImportNum(node *ast.ImportDefStat) {
+	if node.Name == nil || !node.SubLib {
+		return
+	}
+
+	if !a.isMoocFirstCheck(common.CheckErrorMoocImportNum) {
+		return
+	}
+
+	nameNum := len(node.Name.NameList)
+	expNum := len(node.Name.ExpList)
+	if nameNum == expNum {
+		return
+	}
+
+	errStr := fmt.Sprintf("import name num %d not equal sub lib num %d", nameNum, expNum)
+	a.curResult.InsertError(common.CheckErrorMoocImportNum, errStr, node.Name.Loc)
+}
+
+// checkMoocGuardUnreachable 检查guard代码块中，break、goto或continue后面的代码
+func (a *Analysis) checkMoocGuardUnreachable(node *ast.IfStat) {
+	if node.Stype != lexer.TkKwGuard || len(node.Blocks) == 0 {
+		return
+	}
+
+	if !a.isMoocFirstCheck(common.CheckErrorMoocUnreachable) {
+		return
+	}
+
+	block := node.Blocks[0]
+	jumpIndex := -1
+	for i, stat := range block.Stats {
+		switch stat.(type) {
+		case *ast.GotoStat, *ast.BreakStat:
+			jumpIndex = i
+		}
+
+		if jumpIndex >= 0 {
+			break
+		}
+	}
+
+	if jumpIndex < 0 {
+		return
+	}
+
+	// 跳转语句后面第一个有位置信息的语句，或是return语句
+	var loc lexer.Location
+	for _, stat := range block.Stats[jumpIndex+1:] {
+		if statLoc := common.GetStatLoc(stat); statLoc.StartLine > 0 {
+			loc = statLoc
+			break
+		}
+	}
+
+	if loc.StartLine == 0 && len(block.RetExps) > 0 {
+		loc = common.GetExpLoc(block.RetExps[0])
+	}
+
+	if loc.StartLine == 0 {
+		return
+	}
+
+	a.curResult.InsertError(common.CheckErrorMoocUnreachable, "unreachable code after guard transfer control", loc)
+}
+
+// checkMoocDuplicateCase 检查switch中是否有重复的case值
+func (a *Analysis) checkMoocDuplicateCase(node *ast.SwitchStat) {
+	if node.Case == nil {
+		return
+	}
+
+	if !a.isMoocFirstCheck(common.CheckErrorMoocDuplicateCase) {
+		return
+	}
+
+	var caseExps []ast.Exp
+	for _, exp := range node.Case.Exps {
+		caseExps = getSwitchCaseValues(exp, caseExps)
+	}
+
+	for i := range caseExps {
+		for j := i + 1; j < len(caseExps); j++ {
+			if !common.CompExp(caseExps[i], caseExps[j]) {
+				continue
+			}
+
+			errStr := "same switch case value"
+			relateVec := []common.RelateCheckInfo{
+				{
+					LuaFile: a.curResult.Name,
+					ErrStr:  errStr,
+					Loc:     common.GetExpLoc(caseExps[i]),
+				},
+			}
+			a.curResult.InsertRelateError(common.CheckErrorMoocDuplicateCase, errStr, common.GetExpLoc(caseExps[j]),
+				relateVec)
+			break
+		}
+	}
+}
+
+// getSwitchCaseValues 获取switch转换成的 __sw__ == A or __sw__ == B 中所有的case值
+func getSwitchCaseValues(exp ast.Exp, caseExps []ast.Exp) []ast.Exp {
+	binExp, ok := exp.(*ast.BinopExp)
+	if !ok {
+		return caseExps
+	}
+
+	switch binExp.Op {
+	case lexer.TkOpOr:
+		caseExps = getSwitchCaseValues(binExp.Exp1, caseExps)
+		caseExps = getSwitchCaseValues(binExp.Exp2, caseExps)
+	case lexer.TkOpEq:
+		if nameExp, ok := binExp.Exp1.(*ast.NameExp); ok && nameExp.Name == "__sw__" {
+			caseExps = append(caseExps, binExp.Exp2)
+		}
+	}
+
+	return caseExps
+}
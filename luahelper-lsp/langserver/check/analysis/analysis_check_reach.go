@@ -0,0 +1,299 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// 代码的可达性检查，判断哪些代码不会执行到，例如：
+// return、break、goto、error()、os.exit() 后面的语句
+// if false then 或是 while false do 中的代码
+// while true do 没有break时，循环后面的语句
+
+// checkUnreachable 第一轮检查block中不会执行到的代码
+func (a *Analysis) checkUnreachable(node *ast.Block) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorUnreachable) {
+		return
+	}
+
+	// moocscript guard代码块中跳转语句后面的代码，由checkMoocGuardUnreachable检查
+	if node == a.guardBlock {
+		return
+	}
+
+	for i := 0; i < len(node.Stats); i++ {
+		stat := node.Stats[i]
+		a.checkFalseCondBlock(stat)
+
+		if !a.isTerminalStat(stat) {
+			continue
+		}
+
+		// 跳转到的label是可以执行到的，只判断label前面的语句
+		endIndex := len(node.Stats)
+		for j := i + 1; j < len(node.Stats); j++ {
+			if _, ok := node.Stats[j].(*ast.LabelStat); ok {
+				endIndex = j
+				break
+			}
+		}
+
+		var retExps []ast.Exp
+		if endIndex == len(node.Stats) {
+			retExps = node.RetExps
+		}
+
+		if loc, ok := getStatsRangeLoc(node.Stats[i+1:endIndex], retExps); ok {
+			a.curResult.InsertError(common.CheckErrorUnreachable, "unreachable code", loc)
+		}
+
+		// 从label开始继续判断
+		i = endIndex - 1
+	}
+}
+
+// checkFalseCondBlock 判断 if false then 或是 while false do 中的代码
+func (a *Analysis) checkFalseCondBlock(node ast.Stat) {
+	switch stat := node.(type) {
+	case *ast.IfStat:
+		if stat.Stype == lexer.TkKwGuard || stat.Stype == lexer.TkKwSwitch {
+			return
+		}
+
+		for i, exp := range stat.Exps {
+			if !isFalseExp(exp) {
+				continue
+			}
+
+			if loc, ok := getStatsRangeLoc(stat.Blocks[i].Stats, stat.Blocks[i].RetExps); ok {
+				a.curResult.InsertError(common.CheckErrorUnreachable, "unreachable code, the condition is always false",
+					loc)
+			}
+		}
+	case *ast.WhileStat:
+		if !isFalseExp(stat.Exp) {
+			return
+		}
+
+		if loc, ok := getStatsRangeLoc(stat.Block.Stats, stat.Block.RetExps); ok {
+			a.curResult.InsertError(common.CheckErrorUnreachable, "unreachable code, the condition is always false", loc)
+		}
+	}
+}
+
+// isTerminalStat 判断语句执行后，是否不会再执行后面的语句
+func (a *Analysis) isTerminalStat(node ast.Stat) bool {
+	switch stat := node.(type) {
+	case *ast.BreakStat, *ast.GotoStat:
+		return true
+	case *ast.FuncCallStat:
+		return a.isExitCall(stat)
+	case *ast.DoStat:
+		return a.isTerminalBlock(stat.Block)
+	case *ast.IfStat:
+		// 需要有else分支，且所有的分支都不会执行后面的语句
+		if stat.Stype == lexer.TkKwGuard || len(stat.Exps) == 0 {
+			return false
+		}
+
+		if _, ok := stat.Exps[len(stat.Exps)-1].(*ast.TrueExp); !ok {
+			return false
+		}
+
+		for _, block := range stat.Blocks {
+			if !a.isTerminalBlock(block) {
+				return false
+			}
+		}
+		return true
+	case *ast.WhileStat:
+		if _, ok := stat.Exp.(*ast.TrueExp); !ok {
+			return false
+		}
+		return !hasLoopExit(stat.Block, false)
+	case *ast.RepeatStat:
+		if !isFalseExp(stat.Exp) {
+			return false
+		}
+		return !hasLoopExit(stat.Block, false)
+	}
+
+	return false
+}
+
+// isTerminalBlock 判断block执行后，是否不会再执行后面的语句
+func (a *Analysis) isTerminalBlock(node *ast.Block) bool {
+	if node.RetExps != nil {
+		return true
+	}
+
+	for _, stat := range node.Stats {
+		if _, ok := stat.(*ast.LabelStat); ok {
+			return false
+		}
+
+		if a.isTerminalStat(stat) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExitCall 判断是否为 error() 或是 os.exit() 的调用，error与os不能为局部变量
+func (a *Analysis) isExitCall(node *ast.FuncCallStat) bool {
+	if node.NameExp != nil {
+		return false
+	}
+
+	var strName string
+	var nameLoc lexer.Location
+	switch exp := node.PrefixExp.(type) {
+	case *ast.NameExp:
+		if exp.Name != "error" {
+			return false
+		}
+		strName, nameLoc = exp.Name, exp.Loc
+	case *ast.TableAccessExp:
+		nameExp, ok1 := exp.PrefixExp.(*ast.NameExp)
+		keyExp, ok2 := exp.KeyExp.(*ast.StringExp)
+		if !ok1 || !ok2 || nameExp.Name != "os" || keyExp.Str != "exit" {
+			return false
+		}
+		strName, nameLoc = nameExp.Name, nameExp.Loc
+	default:
+		return false
+	}
+
+	_, ok := a.curScope.FindLocVar(strName, nameLoc)
+	return !ok
+}
+
+// hasLoopExit 判断循环的代码块中，是否有跳出当前循环的break或是goto语句
+// nestFlag 表示是否在嵌套的循环中，嵌套循环中的break不会跳出当前循环，goto可能会跳出
+func hasLoopExit(node *ast.Block, nestFlag bool) bool {
+	for _, stat := range node.Stats {
+		switch subStat := stat.(type) {
+		case *ast.BreakStat:
+			if !nestFlag {
+				return true
+			}
+		case *ast.GotoStat:
+			return true
+		case *ast.DoStat:
+			if hasLoopExit(subStat.Block, nestFlag) {
+				return true
+			}
+		case *ast.IfStat:
+			for _, block := range subStat.Blocks {
+				if hasLoopExit(block, nestFlag) {
+					return true
+				}
+			}
+		case *ast.WhileStat:
+			if hasLoopExit(subStat.Block, true) {
+				return true
+			}
+		case *ast.RepeatStat:
+			if hasLoopExit(subStat.Block, true) {
+				return true
+			}
+		case *ast.ForNumStat:
+			if hasLoopExit(subStat.Block, true) {
+				return true
+			}
+		case *ast.ForInStat:
+			if hasLoopExit(subStat.Block, true) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isFalseExp 判断表达式是否为 false 或是 nil
+func isFalseExp(node ast.Exp) bool {
+	switch node.(type) {
+	case *ast.FalseExp, *ast.NilExp:
+		return true
+	}
+	return false
+}
+
+// getStatsRangeLoc 获取语句列表与返回值覆盖的范围，没有位置信息时返回false
+func getStatsRangeLoc(statVec []ast.Stat, retExps []ast.Exp) (loc lexer.Location, ok bool) {
+	var locVec []lexer.Location
+	for _, stat := range statVec {
+		if statLoc := common.GetStatLoc(stat); statLoc.StartLine > 0 {
+			locVec = append(locVec, statLoc)
+		}
+	}
+
+	for _, exp := range retExps {
+		if expLoc := common.GetExpLoc(exp); expLoc.StartLine > 0 {
+			locVec = append(locVec, expLoc)
+		}
+	}
+
+	if len(locVec) == 0 {
+		return loc, false
+	}
+
+	return lexer.GetRangeLoc(&locVec[0], &locVec[len(locVec)-1]), true
+}
+
+// insertReferName 第一轮记录读取过的非局部变量名称，用于判断全局函数在工程中是否被引用过
+func (a *Analysis) insertReferName(strName string, loc lexer.Location) {
+	if !a.isFirstTerm() {
+		return
+	}
+
+	if _, ok := a.curScope.FindLocVar(strName, loc); ok {
+		return
+	}
+
+	a.curResult.ReferNames[strName] = struct{}{}
+}
+
+// checkGlobalFuncNotCall 第二轮或第三轮，检查当前文件定义的全局函数，是否在整个工程中都没有被引用过
+func (a *Analysis) checkGlobalFuncNotCall() {
+	if !a.isNeedCheck() {
+		return
+	}
+
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorGlobalFuncNotCall) {
+		return
+	}
+
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorGlobalFuncNotCall]; !ok {
+		return
+	}
+
+	fileResult := a.curResult
+	for strName, varInfo := range fileResult.GlobalMaps {
+		// 协议前缀的变量，由框架调用
+		if varInfo.ExtraGlobal.StrProPre != "" {
+			continue
+		}
+
+		if a.Projects.IsGlobalReferInProject(strName) {
+			continue
+		}
+
+		for oneVar := varInfo; oneVar != nil; oneVar = oneVar.ExtraGlobal.Prev {
+			if oneVar.ReferFunc == nil || oneVar.FileName != fileResult.Name {
+				continue
+			}
+
+			errorStr := fmt.Sprintf("Unused global function '%s'", strName)
+			fileResult.InsertError(common.CheckErrorGlobalFuncNotCall, errorStr, oneVar.Loc)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"strings"
+)
+
+// 局部变量的遮蔽检查，例如：
+// 同一个作用域中重复定义 local a = 1; local a = 2
+// 内层的局部变量遮蔽了外层的局部变量、函数参数、循环变量或是全局变量
+// 局部变量遮蔽了系统的变量 local table = {}
+// 循环变量被闭包引用后，在循环中又被修改了
+
+// loopVarInfo 循环变量的信息
+type loopVarInfo struct {
+	funcInfo   *common.FuncInfo // 循环所在的函数
+	captured   bool             // 是否被闭包引用了
+	captureLoc lexer.Location   // 第一次被闭包引用的位置
+}
+
+// moocCompilerVarMap MoonCake编译器生成的局部变量，例如switch语句与struct生成的临时变量，不做遮蔽检查
+var moocCompilerVarMap = map[string]bool{
+	"__sw__": true,
+	"__st":   true,
+}
+
+// checkLocalShadow 定义局部变量之前，检查局部变量是否重复定义或是遮蔽了其他的变量
+// 第一轮检查局部变量与当前文件的全局变量，第二轮与第三轮检查工程中其他文件定义的全局变量
+// exp为局部变量的初始值，local a = a or 1 这样重新绑定同名变量的写法不告警
+func (a *Analysis) checkLocalShadow(strName string, loc lexer.Location, exp ast.Exp) {
+	if a.realTimeFlag {
+		return
+	}
+
+	if strName == "_" || (exp != nil && isExpReferName(exp, strName)) {
+		return
+	}
+
+	if moocCompilerVarMap[strName] && strings.HasSuffix(a.curResult.Name, ".mooc") {
+		return
+	}
+
+	if a.isSecondTerm() || a.isThirdTerm() {
+		a.checkProjectGlobalShadow(strName, loc)
+		return
+	}
+
+	if !a.isFirstTerm() {
+		return
+	}
+
+	scope := a.curScope
+	fileName := a.curResult.Name
+
+	// 1) 同一个作用域中的变量，函数的参数与循环变量和函数体中的局部变量在同一个作用域中
+	if locInfoList := scope.LocVarMap[strName]; locInfoList != nil && len(locInfoList.VarVec) > 0 {
+		preVar := locInfoList.VarVec[len(locInfoList.VarVec)-1]
+		var errType common.CheckErrorType = common.CheckErrorShadowVar
+		errStr := fmt.Sprintf("local '%s' shadows %s", strName, a.getShadowVarDesc(preVar))
+		if !preVar.IsParam && !a.isLoopVar(preVar) {
+			errType = common.CheckErrorLocalRedeclare
+			errStr = fmt.Sprintf("local '%s' is redeclared in the same scope", strName)
+		}
+
+		a.insertShadowError(errType, errStr, loc, fileName, preVar.Loc)
+		return
+	}
+
+	// 2) 外层作用域的局部变量
+	if scope.Parent != nil {
+		if outerVar, ok := scope.Parent.FindLocVar(strName, loc); ok {
+			errStr := fmt.Sprintf("local '%s' shadows %s", strName, a.getShadowVarDesc(outerVar))
+			a.insertShadowError(common.CheckErrorShadowVar, errStr, loc, fileName, outerVar.Loc)
+			return
+		}
+	}
+
+	// 3) 当前文件中定义的全局变量
+	if globalVar, ok := a.curResult.GlobalMaps[strName]; ok {
+		errStr := fmt.Sprintf("local '%s' shadows a global variable", strName)
+		a.insertShadowError(common.CheckErrorShadowVar, errStr, loc, globalVar.FileName, globalVar.Loc)
+		return
+	}
+
+	// 4) 系统的变量
+	if a.conf.GetSysVar(strName) != nil {
+		if a.conf.IsGlobalIgnoreErrType(common.CheckErrorShadowSys) {
+			return
+		}
+
+		errStr := fmt.Sprintf("local '%s' shadows the standard library variable", strName)
+		a.curResult.InsertError(common.CheckErrorShadowSys, errStr, loc)
+	}
+}
+
+// checkProjectGlobalShadow 第二轮或第三轮，检查局部变量是否遮蔽了工程中其他文件定义的全局变量
+// 遮蔽了局部变量、当前文件的全局变量或是系统的变量，第一轮已经告警了
+func (a *Analysis) checkProjectGlobalShadow(strName string, loc lexer.Location) {
+	if _, ok := a.curScope.FindLocVar(strName, loc); ok {
+		return
+	}
+
+	if a.conf.GetSysVar(strName) != nil {
+		return
+	}
+
+	var globalVar *common.VarInfo
+	if a.isSecondTerm() && a.SingleProjectResult != nil {
+		_, globalVar = a.SingleProjectResult.FindGlobalGInfo(strName, results.CheckTermFirst, "")
+	} else if a.isThirdTerm() && a.AnalysisThird != nil && a.AnalysisThird.ThirdStruct != nil {
+		_, globalVar = a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(false, strName, "")
+	}
+
+	if globalVar == nil || globalVar.FileName == a.curResult.Name {
+		return
+	}
+
+	errStr := fmt.Sprintf("local '%s' shadows a global variable", strName)
+	a.insertShadowError(common.CheckErrorShadowVar, errStr, loc, globalVar.FileName, globalVar.Loc)
+}
+
+// insertShadowError 插入遮蔽的告警，关联到被遮蔽的变量定义的位置
+func (a *Analysis) insertShadowError(errType common.CheckErrorType, errStr string, loc lexer.Location,
+	relateFile string, relateLoc lexer.Location) {
+	if a.conf.IsGlobalIgnoreErrType(errType) {
+		return
+	}
+
+	var relateVec []common.RelateCheckInfo
+	relateVec = append(relateVec, common.RelateCheckInfo{
+		LuaFile: relateFile,
+		ErrStr:  "previous definition",
+		Loc:     relateLoc,
+	})
+	a.curResult.InsertRelateError(errType, errStr, loc, relateVec)
+}
+
+// getShadowVarDesc 被遮蔽变量的描述
+func (a *Analysis) getShadowVarDesc(varInfo *common.VarInfo) string {
+	if varInfo.IsParam {
+		return "a function param"
+	}
+
+	if a.isLoopVar(varInfo) {
+		return "a loop variable"
+	}
+
+	return "an outer local"
+}
+
+// isExpReferName 判断表达式中是否直接引用了指定名称的变量，不判断函数定义内部的代码
+func isExpReferName(node ast.Exp, strName string) bool {
+	switch exp := node.(type) {
+	case *ast.NameExp:
+		return exp.Name == strName
+	case *ast.ParensExp:
+		return isExpReferName(exp.Exp, strName)
+	case *ast.UnopExp:
+		return isExpReferName(exp.Exp, strName)
+	case *ast.BinopExp:
+		return isExpReferName(exp.Exp1, strName) || isExpReferName(exp.Exp2, strName)
+	case *ast.ConcatExp:
+		return isExpReferName(exp.Exp1, strName) || isExpReferName(exp.Exp2, strName)
+	case *ast.TableAccessExp:
+		return isExpReferName(exp.PrefixExp, strName) || isExpReferName(exp.KeyExp, strName)
+	case *ast.FuncCallExp:
+		if isExpReferName(exp.PrefixExp, strName) {
+			return true
+		}
+		for _, argExp := range exp.Args {
+			if isExpReferName(argExp, strName) {
+				return true
+			}
+		}
+	case *ast.TableConstructorExp:
+		for i := range exp.KeyExps {
+			if isExpReferName(exp.KeyExps[i], strName) || isExpReferName(exp.ValExps[i], strName) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// insertLoopVar 第一轮记录for语句定义的循环变量
+func (a *Analysis) insertLoopVar(varInfo *common.VarInfo) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	if a.loopVarMap == nil {
+		a.loopVarMap = map[*common.VarInfo]*loopVarInfo{}
+	}
+
+	a.loopVarMap[varInfo] = &loopVarInfo{
+		funcInfo: a.curFunc,
+	}
+}
+
+// removeLoopVar for语句结束后，删除记录的循环变量
+func (a *Analysis) removeLoopVar(varInfo *common.VarInfo) {
+	delete(a.loopVarMap, varInfo)
+}
+
+// isLoopVar 判断局部变量是否为当前正在分析的for语句的循环变量
+func (a *Analysis) isLoopVar(varInfo *common.VarInfo) bool {
+	_, ok := a.loopVarMap[varInfo]
+	return ok
+}
+
+// markLoopVarCapture 第一轮读取局部变量时，判断是否为闭包中引用了循环变量
+func (a *Analysis) markLoopVarCapture(strName string, loc lexer.Location) {
+	if len(a.loopVarMap) == 0 {
+		return
+	}
+
+	locVar, ok := a.curScope.FindLocVar(strName, loc)
+	if !ok {
+		return
+	}
+
+	loopVar, ok := a.loopVarMap[locVar]
+	if !ok || loopVar.captured || loopVar.funcInfo == a.curFunc {
+		return
+	}
+
+	loopVar.captured = true
+	loopVar.captureLoc = loc
+}
+
+// checkLoopVarAssign 第一轮给局部变量赋值时，判断是否修改了被闭包引用的循环变量
+func (a *Analysis) checkLoopVarAssign(varInfo *common.VarInfo, strName string, loc lexer.Location) {
+	if varInfo == nil || len(a.loopVarMap) == 0 {
+		return
+	}
+
+	loopVar, ok := a.loopVarMap[varInfo]
+	if !ok {
+		return
+	}
+
+	// 在闭包中修改循环变量，也是引用了循环变量
+	if !loopVar.captured && loopVar.funcInfo != a.curFunc {
+		loopVar.captured = true
+		loopVar.captureLoc = loc
+	}
+
+	if !loopVar.captured || a.conf.IsGlobalIgnoreErrType(common.CheckErrorLoopVarCapture) {
+		return
+	}
+
+	var relateVec []common.RelateCheckInfo
+	relateVec = append(relateVec, common.RelateCheckInfo{
+		LuaFile: a.curResult.Name,
+		ErrStr:  "captured by the closure here",
+		Loc:     loopVar.captureLoc,
+	})
+
+	errStr := fmt.Sprintf("loop variable '%s' is captured by a closure and then modified", strName)
+	a.curResult.InsertRelateError(common.CheckErrorLoopVarCapture, errStr, loc, relateVec)
+}
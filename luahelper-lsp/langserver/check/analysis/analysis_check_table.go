@@ -22,11 +22,11 @@ func (a *Analysis) CheckTableDeclAssign(tcExp *ast.TableConstructorExp, loc *lex
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorAssignType) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorAssignType) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorAssignType]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorAssignType]; !ok {
 		return
 	}
 
@@ -83,11 +83,11 @@ func (a *Analysis) CheckTableClassField(strTableName string, strFieldNamelist []
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorClassField) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorClassField) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorClassField]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorClassField]; !ok {
 		return
 	}
 
@@ -125,11 +125,11 @@ func (a *Analysis) checkTableAccess(node *ast.TableAccessExp) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorClassField) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorClassField) {
 		return
 	}
 
-	if _, ok := common.GConfig.OpenErrorTypeMap[common.CheckErrorClassField]; !ok {
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorClassField]; !ok {
 		return
 	}
 
@@ -176,3 +176,78 @@ func (a *Analysis) checkTableAccess(node *ast.TableAccessExp) {
 	errStr := fmt.Sprintf("Property '%s' not found in '%s'", useKeyName, className)
 	a.curResult.InsertError(common.CheckErrorClassField, errStr, node.Loc)
 }
+
+// insertWriteField 第一轮中记录被赋值过的table成员名称，用于判断成员是否只读取未赋值过
+func (a *Analysis) insertWriteField(keyExp ast.Exp) {
+	if !a.isFirstTerm() {
+		return
+	}
+
+	if strExp, ok := keyExp.(*ast.StringExp); ok {
+		a.curResult.WriteFields[strExp.Str] = struct{}{}
+	}
+}
+
+// checkFieldNotWrite 判断table的成员被读取了，但是整个工程中都没有赋值过
+// 只判断由构造表达式创建的table，例如：
+// local t = { x = 1 }
+// print(t.y)  -- y在整个工程中都没有被赋值过，告警
+func (a *Analysis) checkFieldNotWrite(node *ast.TableAccessExp) {
+	if !a.isNeedCheck() || a.realTimeFlag {
+		return
+	}
+
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorFieldNotWrite) {
+		return
+	}
+
+	if _, ok := a.conf.OpenErrorTypeMap[common.CheckErrorFieldNotWrite]; !ok {
+		return
+	}
+
+	strExp, ok := node.KeyExp.(*ast.StringExp)
+	if !ok {
+		return
+	}
+
+	nameExp, ok := node.PrefixExp.(*ast.NameExp)
+	if !ok {
+		return
+	}
+
+	varInfo := a.findStrReferVarInfo(nameExp.Name, nameExp.Loc, false, "")
+	if varInfo == nil || varInfo.IsParam || varInfo.IsForParam || varInfo.ReferInfo != nil {
+		return
+	}
+
+	// table的构造表达式中有非字符串的key，例如 { [k] = v }，无法确定有哪些成员
+	tableExp, ok := varInfo.ReferExp.(*ast.TableConstructorExp)
+	if !ok {
+		return
+	}
+	for _, keyExp := range tableExp.KeyExps {
+		if keyExp == nil {
+			continue
+		}
+
+		if _, ok := keyExp.(*ast.StringExp); !ok {
+			return
+		}
+	}
+
+	if varInfo.IsExistMember(strExp.Str) {
+		return
+	}
+
+	// 有注解类型的变量，由注解的成员检查来判断
+	if len(a.Projects.GetAnnotateTypeString(varInfo, nameExp.Name, "", 1)) > 0 {
+		return
+	}
+
+	if a.Projects.IsFieldWriteInProject(strExp.Str) {
+		return
+	}
+
+	errStr := fmt.Sprintf("Field '%s' is read but never written", strExp.Str)
+	a.curResult.InsertError(common.CheckErrorFieldNotWrite, errStr, strExp.Loc)
+}
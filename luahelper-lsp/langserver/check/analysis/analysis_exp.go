@@ -58,6 +58,12 @@ func (a *Analysis) cgNameExp(node *ast.NameExp, binParentExp *ast.BinopExp) {
 		a.checkLocVarNotUse(node)
 		// 查下当前之前有没有定义，若没有定义需要插入到相应的globalNoDefineMaps中
 		a.analysisNoDefineName(node)
+		// 查下moocscript中Self与Super是否在class外面使用
+		a.checkMoocSelfSuper(node)
+		// 记录读取过的非局部变量名称
+		a.insertReferName(node.Name, node.Loc)
+		// 查下是否在闭包中引用了循环变量
+		a.markLoopVarCapture(node.Name, node.Loc)
 		return
 	}
 
@@ -104,6 +110,27 @@ func (a *Analysis) cgFuncDefExp(node *ast.FuncDefExp) *common.FuncInfo {
 	fileResult := a.curResult
 	fileResult.InertNewFunc(subFi)
 
+	// 实时分析时，记录函数体没有修改函数外部信息的顶层函数
+	if a.isFirstTerm() && a.realTimeFlag && scope == fileResult.MainFunc.MainScope {
+		beforeHash := getOuterStateHash(fileResult)
+		a.cgFuncBody(subFi, node)
+		if getOuterStateHash(fileResult) == beforeHash {
+			if fileResult.IsolatedFuncMap == nil {
+				fileResult.IsolatedFuncMap = map[*common.FuncInfo]struct{}{}
+			}
+			fileResult.IsolatedFuncMap[subFi] = struct{}{}
+		}
+		return subFi
+	}
+
+	a.cgFuncBody(subFi, node)
+	return subFi
+}
+
+// cgFuncBody 函数的参数放入到函数的局部变量中，并遍历函数体
+func (a *Analysis) cgFuncBody(subFi *common.FuncInfo, node *ast.FuncDefExp) {
+	fileResult := a.curResult
+
 	// 在第一轮中，检查函数的是否包含同名的参数
 	a.checkDuplicateFunParam(node)
 
@@ -137,7 +164,6 @@ func (a *Analysis) cgFuncDefExp(node *ast.FuncDefExp) *common.FuncInfo {
 	// 还原
 	a.curFunc = backupFunc
 	a.curScope = backupScope
-	return subFi
 }
 
 func (a *Analysis) cgTableConstructorExp(node *ast.TableConstructorExp, parentVar *common.VarInfo) {
@@ -167,6 +193,7 @@ func (a *Analysis) cgTableConstructorExp(node *ast.TableConstructorExp, parentVa
 			subVar = common.CreateVarInfo(fileResult.Name, common.GetExpType(valExp), nil, strExp.Loc, 1)
 		}
 
+		a.insertWriteField(keyExp)
 		a.cgExp(keyExp, nil, nil)
 		// key值为nil
 		oneFunc, oneRefer := a.cgExp(valExp, subVar, nil)
@@ -295,7 +322,7 @@ func (a *Analysis) cgBinopExp(node *ast.BinopExp, parentVar *common.VarInfo) {
 	}
 
 	//浮点数做等于或不等于判断 告警
-	if !common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorFloatEq) {
+	if !a.conf.IsGlobalIgnoreErrType(common.CheckErrorFloatEq) {
 		if node.Op == lexer.TkOpEq || node.Op == lexer.TkOpNe {
 			_, ok1 := node.Exp1.(*ast.FloatExp)
 			_, ok2 := node.Exp2.(*ast.FloatExp)
@@ -383,6 +410,7 @@ func (a *Analysis) cgTableAccessExp(node *ast.TableAccessExp, binParentExp *ast.
 			if oneName.Name == "_G" {
 				if strExp, ok1 := node.KeyExp.(*ast.StringExp); ok1 {
 					a.analysisNoDefineStr(strExp)
+					a.insertReferName(strExp.Str, strExp.Loc)
 				}
 			}
 		}
@@ -398,4 +426,5 @@ func (a *Analysis) cgTableAccessExp(node *ast.TableAccessExp, binParentExp *ast.
 	//      one.test_one() 是否有定义
 	a.findTableDefine(node)
 	a.checkTableAccess(node)
+	a.checkFieldNotWrite(node)
 }
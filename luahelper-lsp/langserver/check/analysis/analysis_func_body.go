@@ -0,0 +1,175 @@
+package analysis
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/results"
+	"reflect"
+)
+
+// 实时修改文件时，只修改了一个顶层函数的函数体，且函数体的分析没有修改函数外部的信息时，只重新分析这个函数体
+// 函数体是否修改了函数外部的信息，通过函数体分析前后，文件中函数外部能访问到的所有变量信息的hash来判断
+// 局部变量是否被使用过(IsUse)只用于告警，实时分析时不进行告警，这里不计算
+
+const (
+	hashOffset uint64 = 14695981039346656037
+	hashPrime  uint64 = 1099511628211
+)
+
+// HandleFirstTraverseFunc 实时分析时，只重新遍历一个顶层函数的函数体，其他的分析结果复用之前的fileResult
+// oldNode与newNode为修改前后的函数定义，除了函数体外其他的都一致
+// 返回false表示不能只分析函数体，这时fileResult可能已经修改了，调用方需要丢弃并整体重新分析
+func (a *Analysis) HandleFirstTraverseFunc(fileResult *results.FileResult, oldNode *ast.FuncDefExp,
+	newNode *ast.FuncDefExp) bool {
+	mainScope := fileResult.MainFunc.MainScope
+	funcIndex := -1
+	for i, oneFunc := range fileResult.FuncIDVec {
+		if oneFunc.FuncLv == 1 && oneFunc.Loc == oldNode.Loc && oneFunc.MainScope.Parent == mainScope {
+			funcIndex = i
+			break
+		}
+	}
+	if funcIndex < 0 {
+		return false
+	}
+
+	funcInfo := fileResult.FuncIDVec[funcIndex]
+	if _, ok := fileResult.IsolatedFuncMap[funcInfo]; !ok {
+		return false
+	}
+
+	scopeIndex := -1
+	for i, subScope := range mainScope.SubScopes {
+		if subScope == funcInfo.MainScope {
+			scopeIndex = i
+			break
+		}
+	}
+	if scopeIndex < 0 {
+		return false
+	}
+
+	// 函数体内定义的函数，在FuncIDVec中紧跟在这个函数的后面
+	endIndex := funcIndex + 1
+	for endIndex < len(fileResult.FuncIDVec) && fileResult.FuncIDVec[endIndex].FuncLv > 1 {
+		endIndex++
+	}
+	tailVec := append([]*common.FuncInfo{}, fileResult.FuncIDVec[endIndex:]...)
+
+	// 删除之前函数体内产生的告警
+	errVec := make([]common.CheckError, 0, len(fileResult.CheckErrVec))
+	for _, oneErr := range fileResult.CheckErrVec {
+		if !oldNode.Loc.IsContainLoc(oneErr.Loc) {
+			errVec = append(errVec, oneErr)
+		}
+	}
+	fileResult.CheckErrVec = errVec
+
+	funcInfo.ResetBody()
+	mainScope.SubScopes[scopeIndex] = funcInfo.MainScope
+	fileResult.ResetFuncVec(funcIndex + 1)
+
+	beforeHash := getOuterStateHash(fileResult)
+	a.curResult = fileResult
+	a.curFunc = fileResult.MainFunc
+	a.curScope = mainScope
+	a.cgFuncBody(funcInfo, newNode)
+
+	// 函数后面的funcInfo重新插入，保持与整体分析时一样的序号
+	for _, oneFunc := range tailVec {
+		fileResult.InertNewFunc(oneFunc)
+	}
+
+	if getOuterStateHash(fileResult) != beforeHash {
+		return false
+	}
+
+	// 指向之前函数定义的变量，指向新的函数定义
+	walkOuterVars(fileResult, func(varInfo *common.VarInfo) {
+		if funcExp, ok := varInfo.ReferExp.(*ast.FuncDefExp); ok && funcExp == oldNode {
+			varInfo.ReferExp = newNode
+		}
+	})
+	return true
+}
+
+// walkOuterVars 遍历文件中顶层函数能访问到的函数外部的所有变量，包括全局变量、主函数的局部变量以及它们的成员
+func walkOuterVars(fileResult *results.FileResult, handleFunc func(varInfo *common.VarInfo)) {
+	for _, varInfo := range fileResult.GlobalMaps {
+		walkVarAndSubVars(varInfo, handleFunc)
+	}
+
+	for _, varInfo := range fileResult.ProtocolMaps {
+		walkVarAndSubVars(varInfo, handleFunc)
+	}
+
+	for _, varInfo := range fileResult.NodefineMaps {
+		walkVarAndSubVars(varInfo, handleFunc)
+	}
+
+	for _, varList := range fileResult.MainFunc.MainScope.LocVarMap {
+		for _, varInfo := range varList.VarVec {
+			walkVarAndSubVars(varInfo, handleFunc)
+		}
+	}
+}
+
+func walkVarAndSubVars(varInfo *common.VarInfo, handleFunc func(varInfo *common.VarInfo)) {
+	handleFunc(varInfo)
+	for _, subVar := range varInfo.SubMaps {
+		walkVarAndSubVars(subVar, handleFunc)
+	}
+}
+
+// getOuterStateHash 获取文件中函数外部信息的hash，变量的hash相加，与遍历的顺序无关
+func getOuterStateHash(fileResult *results.FileResult) uint64 {
+	hash := hashOffset
+	numVec := []int{len(fileResult.ReferVec), len(fileResult.GlobalMaps), len(fileResult.ProtocolMaps),
+		len(fileResult.NodefineMaps), len(fileResult.WriteFields), len(fileResult.ReferNames),
+		len(fileResult.ExtensionLocMap)}
+	for _, num := range numVec {
+		hash = mixHash(hash, uint64(num))
+	}
+
+	var varSum uint64
+	walkOuterVars(fileResult, func(varInfo *common.VarInfo) {
+		varSum += getVarStateHash(varInfo)
+	})
+
+	return mixHash(hash, varSum)
+}
+
+// getVarStateHash 获取一个变量信息的hash，只计算第一轮分析中会被修改的字段
+func getVarStateHash(varInfo *common.VarInfo) uint64 {
+	hash := hashOffset
+	pointerVec := []interface{}{varInfo, varInfo.ReferExp, varInfo.ReferInfo, varInfo.ReferFunc, varInfo.MetaExp,
+		varInfo.ForCycle, varInfo.ExtraGlobal}
+	for _, onePointer := range pointerVec {
+		hash = mixHash(hash, getPointerValue(onePointer))
+	}
+
+	numVec := []int{len(varInfo.SubMaps), len(varInfo.ExpandStrMap), len(varInfo.NoUseAssignLocs),
+		len(varInfo.AssignTypeVec), int(varInfo.VarType)}
+	for _, num := range numVec {
+		hash = mixHash(hash, uint64(num))
+	}
+
+	if varInfo.IsExpEmpty {
+		hash = mixHash(hash, 1)
+	}
+
+	return hash
+}
+
+func getPointerValue(value interface{}) uint64 {
+	refValue := reflect.ValueOf(value)
+	if refValue.Kind() != reflect.Ptr {
+		return 0
+	}
+
+	return uint64(refValue.Pointer())
+}
+
+func mixHash(hash uint64, value uint64) uint64 {
+	return (hash ^ value) * hashPrime
+}
@@ -0,0 +1,214 @@
+package analysis
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// 函数没有---@return注解时，根据函数体内所有的return语句推导返回值的类型
+// 例如：
+// local function makePlayer()
+//     if not ok then
+//         return nil
+//     end
+//     return { name = "a", hp = 100 }
+// end
+// 推导出第一个返回值的类型为 nil|table
+
+// getCallExpReferFunc 获取函数调用表达式指向的函数定义，只在第一轮中构造
+// 支持 a()、a.b()、a:b() 这三种形式
+func (a *Analysis) getCallExpReferFunc(node *ast.FuncCallExp) *common.FuncInfo {
+	if !a.isFirstTerm() {
+		return nil
+	}
+
+	var varInfo *common.VarInfo
+	if node.NameExp == nil {
+		switch prefixExp := node.PrefixExp.(type) {
+		case *ast.NameExp:
+			varInfo = a.findStrReferVarInfo(prefixExp.Name, prefixExp.Loc, false, "")
+		case *ast.TableAccessExp:
+			varInfo = a.getTableAccessRelateVar(prefixExp)
+		}
+	} else {
+		preVarInfo := a.getExpReferVarInfo(node.PrefixExp)
+		if preVarInfo != nil && preVarInfo.SubMaps != nil {
+			varInfo = preVarInfo.SubMaps[node.NameExp.Str]
+		}
+	}
+
+	if varInfo == nil {
+		return nil
+	}
+
+	return varInfo.ReferFunc
+}
+
+// getFirstTermFunc 第二轮、第三轮中重新创建的FuncInfo没有存储返回值信息，找到第一轮中对应的FuncInfo
+// 同一个文件每一轮遍历的顺序是一样的，因此FuncID是一致的
+func (a *Analysis) getFirstTermFunc(referFunc *common.FuncInfo) *common.FuncInfo {
+	if len(referFunc.ReturnVecs) > 0 || a.Projects == nil {
+		return referFunc
+	}
+
+	fileStruct, _ := a.Projects.GetFirstFileStuct(referFunc.FileName)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		return referFunc
+	}
+
+	funcVec := fileStruct.FileResult.FuncIDVec
+	if referFunc.FuncID < 0 || referFunc.FuncID >= len(funcVec) {
+		return referFunc
+	}
+
+	firstFunc := funcVec[referFunc.FuncID]
+	if !lexer.CompareTwoLoc(&firstFunc.Loc, &referFunc.Loc) {
+		return referFunc
+	}
+
+	return firstFunc
+}
+
+// getFuncInferReturnType 获取函数第idx个返回值推导出来的类型，idx从1开始
+// 多处return语句返回的类型取并集，推导的结果缓存在第一轮的FuncInfo中
+func (a *Analysis) getFuncInferReturnType(referFunc *common.FuncInfo, idx int) (retVec []string) {
+	if referFunc == nil || idx <= 0 {
+		return
+	}
+
+	firstFunc := a.getFirstTermFunc(referFunc)
+
+	mutex.Lock()
+	initFlag := firstFunc.InferReturnInit
+	inferVec := firstFunc.InferReturnType
+	mutex.Unlock()
+
+	if !initFlag {
+		// 防止函数直接或间接的递归调用自己
+		if a.inferFuncMap == nil {
+			a.inferFuncMap = map[*common.FuncInfo]bool{}
+		}
+		if a.inferFuncMap[firstFunc] {
+			return
+		}
+
+		a.inferFuncMap[firstFunc] = true
+		inferVec = a.inferFuncReturnType(firstFunc)
+		delete(a.inferFuncMap, firstFunc)
+
+		mutex.Lock()
+		firstFunc.InferReturnType = inferVec
+		firstFunc.InferReturnInit = true
+		mutex.Unlock()
+	}
+
+	if idx > len(inferVec) {
+		return
+	}
+
+	return inferVec[idx-1]
+}
+
+// inferFuncReturnType 遍历函数所有的return语句，推导出每一个返回值的类型
+func (a *Analysis) inferFuncReturnType(fun *common.FuncInfo) (inferVec [][]string) {
+	maxLen := 0
+	for _, oneReturn := range fun.ReturnVecs {
+		if len(oneReturn.ReturnVarVec) > maxLen {
+			maxLen = len(oneReturn.ReturnVarVec)
+		}
+	}
+
+	for i := 0; i < maxLen; i++ {
+		var typeVec []string
+		for _, oneReturn := range fun.ReturnVecs {
+			returnLen := len(oneReturn.ReturnVarVec)
+			var oneTypeVec []string
+			if i < returnLen {
+				oneTypeVec = a.getReturnItemType(oneReturn.ReturnVarVec[i])
+			} else if returnLen == 0 || !isMultiResultExp(oneReturn.ReturnVarVec[returnLen-1].ReturnExp) {
+				// 这处return返回值的个数少，缺少的返回值为nil
+				oneTypeVec = []string{"nil"}
+			}
+
+			if len(oneTypeVec) == 0 {
+				// 有一处推导不出来，整体都推导不出来
+				typeVec = nil
+				break
+			}
+
+			typeVec = appendNotExistType(typeVec, oneTypeVec)
+		}
+
+		inferVec = append(inferVec, typeVec)
+	}
+
+	return inferVec
+}
+
+// getReturnItemType 推导一处返回值的类型，推导不出来返回空
+func (a *Analysis) getReturnItemType(item common.ReturnItem) (retVec []string) {
+	annType := common.GetAnnTypeFromLuaType(common.GetExpType(item.ReturnExp))
+	if annType != "LuaTypeRefer" && annType != "any" {
+		return []string{annType}
+	}
+
+	// 返回的为其他函数的调用，先取被调用函数的注解返回值，再进行推导
+	if _, ok := item.ReturnExp.(*ast.FuncCallExp); ok {
+		if item.ReferFunc == nil {
+			return
+		}
+
+		returnTypeVec := a.Projects.GetFuncReturnTypeVec(item.ReferFunc.FileName, item.ReferFunc.Loc.StartLine-1)
+		if len(returnTypeVec) > 0 {
+			return returnTypeVec[0]
+		}
+
+		return a.getFuncInferReturnType(item.ReferFunc, 1)
+	}
+
+	varInfo := item.VarInfo
+	if varInfo == nil {
+		return
+	}
+
+	if varInfo.ReferFunc != nil {
+		return []string{"function"}
+	}
+
+	strName := common.GetExpName(item.ReturnExp)
+	defAnnTypeVec := a.Projects.GetAnnotateTypeString(varInfo, strName, "", int(varInfo.VarIndex))
+	if len(defAnnTypeVec) > 0 {
+		return defAnnTypeVec
+	}
+
+	annType = common.GetAnnTypeFromLuaType(varInfo.VarType)
+	if annType == "LuaTypeRefer" || annType == "any" {
+		annType = common.GetAnnTypeFromLuaType(common.GetExpType(varInfo.ReferExp))
+	}
+
+	if annType == "LuaTypeRefer" || annType == "any" {
+		return
+	}
+
+	return []string{annType}
+}
+
+// isMultiResultExp 判断表达式是否可能返回多个值，例如函数调用或是...
+func isMultiResultExp(exp ast.Exp) bool {
+	switch exp.(type) {
+	case *ast.FuncCallExp, *ast.VarargExp:
+		return true
+	}
+
+	return false
+}
+
+// appendNotExistType 合并类型列表，忽略掉已经存在的类型
+func appendNotExistType(typeVec []string, otherVec []string) []string {
+	for _, otherType := range otherVec {
+		typeVec = common.AppendNotExistType(typeVec, otherType)
+	}
+
+	return typeVec
+}
@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+)
+
+// recordSetMetatable 第一轮中记录单独语句调用setmetatable设置的原表，用于后续成员的查找，例如：
+// local Derived = {}
+// setmetatable(Derived, {__index = Base})
+// Derived变量的原表表达式为 {__index = Base}
+func (a *Analysis) recordSetMetatable(node *ast.FuncCallExp) {
+	if !a.isFirstTerm() || node.NameExp != nil || len(node.Args) != 2 {
+		return
+	}
+
+	nameExp, ok := node.PrefixExp.(*ast.NameExp)
+	if !ok || nameExp.Name != "setmetatable" {
+		return
+	}
+
+	var varInfo *common.VarInfo
+	switch exp := node.Args[0].(type) {
+	case *ast.NameExp:
+		varInfo = a.findStrReferVarInfo(exp.Name, exp.Loc, false, "")
+	case *ast.TableAccessExp:
+		varInfo = a.getTableAccessRelateVar(exp)
+	}
+
+	if varInfo == nil {
+		return
+	}
+
+	varInfo.MetaExp = node.Args[1]
+}
+
+// recordClassSuper 第一轮中记录moocscript中class继承的父类，转换成的Lua代码以父类作为原表的__index，例如：
+// class Dog : Animal {}
+// Dog变量的原表表达式为 {__index = Animal}，Self与self补全时可以查找到父类的成员
+func (a *Analysis) recordClassSuper(node *ast.ClassDefStat) {
+	if !a.isFirstTerm() {
+		return
+	}
+
+	superExp, ok := node.Super.(*ast.NameExp)
+	if !ok {
+		return
+	}
+
+	nameExp, ok := node.Class.VarList[0].(*ast.NameExp)
+	if !ok {
+		return
+	}
+
+	varInfo := a.findStrReferVarInfo(nameExp.Name, node.Loc, false, "")
+	if varInfo == nil {
+		return
+	}
+
+	varInfo.MetaExp = &ast.TableConstructorExp{
+		KeyExps: []ast.Exp{&ast.StringExp{
+			Str: "__index",
+			Loc: superExp.Loc,
+		}},
+		ValExps: []ast.Exp{superExp},
+		Loc:     superExp.Loc,
+	}
+}
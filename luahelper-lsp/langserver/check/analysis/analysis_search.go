@@ -15,14 +15,14 @@ import (
 // strProPre 为协议的前缀，例如c2s. s2s
 func (a *Analysis) findStrFuncRefer(loc lexer.Location, strName string, gFlag bool, strProPre string) (f *common.FuncInfo, findTerm int) {
 	// 1) 判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.conf.IsIgnoreNameVar(strName) {
 		return nil, 0
 	}
 
 	fileResult := a.curResult
 
 	// 2) 判断是否为需要忽略的文件中的变量
-	if common.GConfig.IsIgnoreFileDefineVar(fileResult.Name, strName) {
+	if a.conf.IsIgnoreFileDefineVar(fileResult.Name, strName) {
 		return nil, 0
 	}
 
@@ -113,7 +113,7 @@ func (a *Analysis) getFuncCallReferFunc(node *ast.FuncCallStat) (referFunc *comm
 		return referFunc, strKeyName, findTerm
 	} else if strings.HasPrefix(strTabName, "!") && !strings.Contains(strTabName, ".") && common.JudgeSimpleStr(strKeyName) {
 		// 判断是否为直接协议的调用c2s 或是s2s
-		strProPre := common.GConfig.GetStrProtocol(strTabName)
+		strProPre := a.conf.GetStrProtocol(strTabName)
 		if strProPre != "" {
 			// 为协议的调用
 			referFunc, findTerm = a.findStrFuncRefer(loc, strKeyName, false, strProPre)
@@ -158,7 +158,7 @@ func (a *Analysis) getFuncCallReferFunc(node *ast.FuncCallStat) (referFunc *comm
 
 		// 排查过滤掉的文件变量
 		referLuaFile := referInfo.ReferStr
-		if common.GConfig.IsIgnoreFileDefineVar(referLuaFile, strKeyName) {
+		if a.conf.IsIgnoreFileDefineVar(referLuaFile, strKeyName) {
 			return nil, strName, 0
 		}
 
@@ -267,7 +267,7 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 	fileResult := a.curResult
 	
 	if strings.HasSuffix(a.entryFile, ".mooc") {
-		if common.GConfig.MoocIsIgnoreNameVar(strName) {
+		if a.conf.MoocIsIgnoreNameVar(strName) {
 			return
 		}
 	}	
@@ -284,12 +284,12 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 	}
 
 	// 1）判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.conf.IsIgnoreNameVar(strName) {
 		return
 	}
 
 	// 2) 判断是否为需要忽略的文件中的变量
-	if common.GConfig.IsIgnoreFileDefineVar(fileResult.Name, strName) {
+	if a.conf.IsIgnoreFileDefineVar(fileResult.Name, strName) {
 		return
 	}
 
@@ -314,11 +314,11 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 
 	// 4) 根据不同的轮数查找全局表中是否有该变量
 	if a.isSecondTerm() {
-		if _, ok := common.GConfig.LuaInMap[strName]; ok {
+		if _, ok := a.conf.LuaInMap[strName]; ok {
 			return
 		}
 
-		if strProPre != "" && common.GConfig.IsIgnoreProtocolPreVar() {
+		if strProPre != "" && a.conf.IsIgnoreProtocolPreVar() {
 			// 如果协议前缀的告警，忽略告警，不进行查找
 			return
 		}
@@ -381,11 +381,11 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 			secondFileResult.InsertError(common.CheckErrorNoDefine, errStr, loc)
 		}
 	} else if a.isThirdTerm() {
-		if _, ok := common.GConfig.LuaInMap[strName]; ok {
+		if _, ok := a.conf.LuaInMap[strName]; ok {
 			return
 		}
 
-		if strProPre != "" && common.GConfig.IsIgnoreProtocolPreVar() {
+		if strProPre != "" && a.conf.IsIgnoreProtocolPreVar() {
 			// 如果协议前缀的告警，忽略告警，不进行查找
 			return
 		}
@@ -481,7 +481,7 @@ func (a *Analysis) findNameStr(node *ast.NameExp, binParentExp *ast.BinopExp) {
 // 直接查找全局变量，判断是否有定义
 func (a *Analysis) checkfindGVar(strName string, loc lexer.Location, strProPre string, nameExp ast.Exp) {
 	// 查找_G的符号，也会扩大到非_G的
-	gFlag := !common.GConfig.GetGVarExtendFlag()
+	gFlag := !a.conf.GetGVarExtendFlag()
 	if strProPre != "" {
 		gFlag = false
 	}
@@ -536,7 +536,7 @@ func (a *Analysis) findThreeLevelCall(node ast.Exp, nameExp ast.Exp) {
 		strTableArry = strTableArry[1:]
 		// 为协议的前缀
 		strProPre := ""
-		if common.GConfig.IsStrProtocol(strTwo) {
+		if a.conf.IsStrProtocol(strTwo) {
 			strProPre = strTwo
 		}
 
@@ -597,7 +597,7 @@ func (a *Analysis) findThreeLevelCall(node ast.Exp, nameExp ast.Exp) {
 		// 引用的lua文件
 		referValidStr := referInfo.ReferValidStr
 		// 排查过滤掉的文件变量
-		if common.GConfig.IsIgnoreFileDefineVar(referValidStr, strTwo) {
+		if a.conf.IsIgnoreFileDefineVar(referValidStr, strTwo) {
 			return
 		}
 
@@ -809,7 +809,7 @@ func (a *Analysis) findFuncColon(prefixExp ast.Exp, nameExp ast.Exp, nodeLoc lex
 
 	// 第二轮或第三轮判断table取值是否有定义
 	strTable := common.GetExpName(prefixExp)
-	strProPre := common.GConfig.GetStrProtocol(strTable)
+	strProPre := a.conf.GetStrProtocol(strTable)
 
 	// self进行转换
 	if strTable == "!self" || (strings.HasSuffix(a.entryFile, ".mooc") && strTable == "!Self") {
@@ -924,7 +924,7 @@ func (a *Analysis) findFuncColon(prefixExp ast.Exp, nameExp ast.Exp, nodeLoc lex
 	// 引用的lua文件
 	referValidStr := referInfo.ReferValidStr
 	// 排查过滤掉的文件变量
-	if common.GConfig.IsIgnoreFileDefineVar(referValidStr, strKey) {
+	if a.conf.IsIgnoreFileDefineVar(referValidStr, strKey) {
 		return
 	}
 
@@ -1098,7 +1098,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	if strTable == "!self" || (strings.HasSuffix(a.entryFile, ".mooc") && strTable == "!Self") {
 		strTable = a.ChangeSelfToReferVar(strTable, "!")
 	}
-	strProPre := common.GConfig.GetStrProtocol(strTable)
+	strProPre := a.conf.GetStrProtocol(strTable)
 
 	// 5) _G.aa这样的用例，判断全局变量aa是否有定义
 	if strTable == "!_G" {
@@ -1180,7 +1180,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	// 引用的lua文件
 	referValidStr := referInfo.ReferValidStr
 	// 排查过滤掉的文件变量
-	if common.GConfig.IsIgnoreFileDefineVar(referValidStr, strKey) {
+	if a.conf.IsIgnoreFileDefineVar(referValidStr, strKey) {
 		return
 	}
 
@@ -1229,7 +1229,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	}
 
 	// 如果查找import的对象的全局函数，也是在屏蔽对象里面，返回，不进行告警
-	if _, ok := common.GConfig.LuaInMap[strKey]; ok {
+	if _, ok := a.conf.LuaInMap[strKey]; ok {
 		return
 	}
 
@@ -1244,7 +1244,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	}
 
 	// 判断被引用的文件，是否忽略特定的变量为定义
-	if common.GConfig.IsIgnoreErrorFile(referFile.Name, common.CheckErrorNoDefine) {
+	if a.conf.IsIgnoreErrorFile(referFile.Name, common.CheckErrorNoDefine) {
 		return
 	}
 
@@ -1309,7 +1309,7 @@ func (a *Analysis) GetImportReferByCallExp(funcExp *ast.FuncCallExp) *common.Ref
 		return nil
 	}
 
-	if !common.GConfig.ReferOtherFileMap[callExp.Name] {
+	if !a.conf.ReferOtherFileMap[callExp.Name] {
 		return nil
 	}
 
@@ -1319,10 +1319,13 @@ func (a *Analysis) GetImportReferByCallExp(funcExp *ast.FuncCallExp) *common.Ref
 		return nil
 	}
 
-	oneRefer := common.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
+	oneRefer := a.conf.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
 	if oneRefer == nil {
 		return nil
 	}
+	if strExp, ok := funcExp.Args[0].(*ast.StringExp); ok {
+		oneRefer.StrLoc = strExp.Loc
+	}
 
 	// 先查找该引用是否有效
 	fileResult.CheckReferFile(oneRefer, a.Projects.GetAllFilesMap(), a.Projects.GetFileIndexInfo())
@@ -1401,7 +1404,7 @@ func (a *Analysis) isNeedAnalysisNameExp(strName string, loc lexer.Location) boo
 	}
 
 	// 判断是否为协议变量，如果是则返回
-	if common.GConfig.IsStrProtocol(strName) {
+	if a.conf.IsStrProtocol(strName) {
 		return false
 	}
 
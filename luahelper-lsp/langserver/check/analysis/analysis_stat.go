@@ -68,6 +68,9 @@ func (a *Analysis) cgFuncCallStat(node *ast.FuncCallStat) {
 		a.cgExp(argExp, nil, nil)
 	}
 
+	// 记录setmetatable(a, mt)设置的原表
+	a.recordSetMetatable(node)
+
 	// 第二轮或第三轮函数参数check
 	a.cgFuncCallParamCheck(node)
 }
@@ -80,7 +83,7 @@ func (a *Analysis) cgFuncCallParamCheck(node *ast.FuncCallStat) {
 	}
 
 	// 判断是否开启了函数调用参数个数不匹配的校验
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorCallParam) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorCallParam) {
 		return
 	}
 
@@ -209,8 +212,16 @@ func (a *Analysis) cgIfStat(node *ast.IfStat) {
 	//     ss.test()
 	// end
 
-	// 检查重复条件
-	if a.isFirstTerm() && !a.realTimeFlag && !common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorDuplicateIf) {
+	// 检查guard中跳转语句后面的代码
+	a.checkMoocGuardUnreachable(node)
+	backupGuardBlock := a.guardBlock
+	if node.Stype == lexer.TkKwGuard && len(node.Blocks) > 0 {
+		a.guardBlock = node.Blocks[0]
+	}
+
+	// 检查重复条件，switch的重复case值单独检查
+	if a.isFirstTerm() && !a.realTimeFlag && !a.conf.IsGlobalIgnoreErrType(common.CheckErrorDuplicateIf) &&
+		node.Stype != lexer.TkKwSwitch {
 		for i := range node.Exps {
 			for j := i + 1; j < len(node.Exps); j++ {
 				if common.CompExp(node.Exps[i], node.Exps[j]) {
@@ -317,6 +328,7 @@ func (a *Analysis) cgIfStat(node *ast.IfStat) {
 		a.curScope = backupScope
 	}
 
+	a.guardBlock = backupGuardBlock
 }
 
 // for var=exp1,exp2,exp3 do
@@ -336,11 +348,14 @@ func (a *Analysis) cgForNumStat(node *ast.ForNumStat) {
 	a.cgExp(node.StepExp, nil, nil)
 	a.cgExp(node.LimitExp, nil, nil)
 
+	a.checkLocalShadow(node.VarName, node.VarLoc, nil)
 	locVar := subScope.AddLocVar(a.curResult.Name, node.VarName, common.LuaTypeInter, nil, node.VarLoc, 1)
 	locVar.IsUse = true
+	a.insertLoopVar(locVar)
 
 	a.cgBlock(node.Block)
 	a.exitScope()
+	a.removeLoopVar(locVar)
 
 	a.curScope = backupScope
 }
@@ -395,11 +410,15 @@ func (a *Analysis) cgForInStat(node *ast.ForInStat) {
 	}
 
 	referExp, ipairsFlag := getForCycleData(node.ExpList)
+	loopVarVec := make([]*common.VarInfo, 0, len(node.NameList))
 	for index, name := range node.NameList {
 		varIndex := uint8(index + 1)
+		a.checkLocalShadow(name, node.NameLocList[index], nil)
 		locVar := subScope.AddLocVar(a.curResult.Name, name, common.LuaTypeRefer, nil, node.NameLocList[index], varIndex)
 		locVar.IsForParam = true
 		locVar.IsUse = true
+		a.insertLoopVar(locVar)
+		loopVarVec = append(loopVarVec, locVar)
 		if referExp != nil {
 			locVar.ForCycle = &common.ForCycleInfo{
 				Exp:        referExp,
@@ -410,6 +429,9 @@ func (a *Analysis) cgForInStat(node *ast.ForInStat) {
 
 	a.cgBlock(node.Block)
 	a.exitScope()
+	for _, locVar := range loopVarVec {
+		a.removeLoopVar(locVar)
+	}
 
 	a.curScope = backupScope
 }
@@ -456,6 +478,7 @@ func (a *Analysis) cgGotoStat(node *ast.GotoStat) {
 
 func (a *Analysis) cgLocalFuncDefStat(node *ast.LocalFuncDefStat) {
 	scope := a.curScope
+	a.checkLocalShadow(node.Name, node.NameLoc, nil)
 	locVar := scope.AddLocVar(a.curResult.Name, node.Name, common.LuaTypeFunc, node.Exp, node.NameLoc, 1)
 
 	subFi := a.cgFuncDefExp(node.Exp)
@@ -509,6 +532,7 @@ func (a *Analysis) cgLocalVarDeclStat(node *ast.LocalVarDeclStat) {
 		}
 
 		nowLoc := node.VarLocList[i]
+		a.checkLocalShadow(strName, nowLoc, exp)
 		varInfo := scope.AddLocVar(a.curResult.Name, strName, common.GetExpType(exp), exp, nowLoc, varIndex)
 		oneAttr := node.AttrList[i]
 		if oneAttr == ast.RDKTOCLOSE {
@@ -549,6 +573,9 @@ func (a *Analysis) cgLocalVarDeclStat(node *ast.LocalVarDeclStat) {
 		varIndex := uint8(i + 1)
 		nowLoc := node.VarLocList[i]
 		oneAttr := node.AttrList[i]
+		if oneAttr != ast.VDKEXPORT {
+			a.checkLocalShadow(node.NameList[i], nowLoc, nil)
+		}
 		if lastExpFuncFlag {
 			locVar := scope.AddLocVar(a.curResult.Name, node.NameList[i], common.LuaTypeRefer, nil, nowLoc, varIndex)
 			if oneAttr == ast.RDKTOCLOSE {
@@ -653,7 +680,7 @@ func (a *Analysis) checkLeftAssign(valExp ast.Exp) (needDefine bool, flagG bool,
 	}
 
 	tabName := common.GetExpName(taExp.PrefixExp)
-	strProPre = common.GConfig.GetStrProtocol(tabName)
+	strProPre = a.conf.GetStrProtocol(tabName)
 
 	loc = common.GetExpLoc(taExp.KeyExp)
 	if loc.IsInitialLoc() {
@@ -779,6 +806,12 @@ func (a *Analysis) handleNotNeedDefine(node *ast.AssignStat, findVar *common.Var
 				continue
 			}
 			expNode := node.ExpList[i]
+
+			// 第一轮中记录成员后续赋值的类型，用于推导table的结构类型
+			if a.isFirstTerm() {
+				subVar.InsertAssignType(common.GetExpType(expNode))
+			}
+
 			if !common.IsReferExpEmpty(expNode, subVar.ReferExp, true) {
 				continue
 			}
@@ -871,6 +904,10 @@ func (a *Analysis) cgAssignStat(node *ast.AssignStat) {
 		var newFunc *common.FuncInfo
 		varIndex := uint8(i + 1)
 		tmpVar := common.CreateVarInfo(fileResult.Name, common.LuaTypeAll, nil, lexer.Location{}, varIndex)
+		if tabExp, ok := valExp.(*ast.TableAccessExp); ok {
+			a.insertWriteField(tabExp.KeyExp)
+		}
+
 		if nExps >= (i + 1) {
 			expNode := node.ExpList[i]
 
@@ -898,6 +935,10 @@ func (a *Analysis) cgAssignStat(node *ast.AssignStat) {
 		// strProPre 表示是否为协议的前缀 为c2s 或是s2s
 		// strName 赋值变量，前半部分名字, 去掉了_G.
 		needDefineFlag, gGlag, strName, strProPre, loc, strVec, locList, findVar := a.checkLeftAssign(valExp)
+		if a.isFirstTerm() && !needDefineFlag && len(strVec) == 0 {
+			// 查下是否修改了被闭包引用的循环变量
+			a.checkLoopVarAssign(findVar, strName, loc)
+		}
 
 		// 需要定义变量
 		if needDefineFlag {
@@ -1100,7 +1141,7 @@ func (a *Analysis) cgAssignStat(node *ast.AssignStat) {
 		} else {
 
 			//检查自我赋值
-			if !common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorSelfAssign) {
+			if !a.conf.IsGlobalIgnoreErrType(common.CheckErrorSelfAssign) {
 				isSame := true
 				for i := 0; i < nExps; i++ {
 					if !common.CompExp(node.VarList[i], node.ExpList[i]) {
@@ -1170,9 +1211,18 @@ func (a *Analysis) cgClassStat(node *ast.ClassDefStat) {
 		}
 	}
 
+	// extension 扩展的类需要有定义
+	a.checkMoocExtension(node)
+	a.recordMoocExtension(node)
+	a.checkMoocDuplicateMethod(node)
+
 	// class 作为 table 定义，在 class scope 外可见
 	a.cgAssignStat(node.Class)
 
+	// 记录继承的父类，父类的成员通过原表__index查找
+	a.recordClassSuper(node)
+	a.recordMoocClass(node)
+
 	a.enterScope()
 
 	backupScope := a.curScope
@@ -1194,6 +1244,8 @@ func (a *Analysis) cgClassStat(node *ast.ClassDefStat) {
 }
 
 func (a *Analysis) cgImportStat(node *ast.ImportDefStat) {
+	a.checkMoocImportNum(node)
+
 	if node.Lib != nil {
 		a.cgFuncCallStat(node.Lib)
 	} else {
@@ -1202,6 +1254,8 @@ func (a *Analysis) cgImportStat(node *ast.ImportDefStat) {
 }
 
 func (a *Analysis) cgSwitchStat(node *ast.SwitchStat) {
+	a.checkMoocDuplicateCase(node)
+
 	// 定义 local __sw__ = exp
 	a.cgStat(node.Name)
 	a.cgStat(node.Case)
@@ -142,6 +142,13 @@ func (a *Analysis) findVarDefineWithPre(preName string, varName string, preLoc l
 
 // GetAnnTypeByExp 获取表达式类型字符串，如果是引用，则递归查找，(即支持类型传递)
 func (a *Analysis) GetAnnTypeByExp(referExp ast.Exp, idx int) (retVec []string) {
+	// 二元运算的操作数定义了运算符元方法时，取元方法的结果类型
+	if binopExp, ok := referExp.(*ast.BinopExp); ok {
+		if retVec = a.getBinopOperatorType(binopExp); len(retVec) > 0 {
+			return retVec
+		}
+	}
+
 	expType := common.GetExpType(referExp)
 	argType := common.GetAnnTypeFromLuaType(expType)
 
@@ -243,7 +250,12 @@ func (a *Analysis) GetAnnTypeByExp(referExp ast.Exp, idx int) (retVec []string)
 		}
 	} else {
 		if varInfo.ReferFunc != nil && isFuncCall {
-			// 如果是函数调用 且函数没有返回值注解 直接返回any类型
+			// 如果是函数调用 且函数没有返回值注解 根据函数所有的return语句推导返回值类型
+			if inferVec := a.getFuncInferReturnType(varInfo.ReferFunc, varIdx); len(inferVec) > 0 {
+				return inferVec
+			}
+
+			// 推导不出来 返回any类型
 			retVec = append(retVec, "any")
 			return retVec
 		}
@@ -66,14 +66,14 @@ func (a *Analysis) getExpReferVarInfo(node ast.Exp) (locVar *common.VarInfo) {
 // 查找一个变量的直接引用, 另外一个变量VarInfo
 func (a *Analysis) findStrReferVarInfo(strName string, loc lexer.Location, gFlag bool, strProPre string) *common.VarInfo {
 	// 1) 判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.conf.IsIgnoreNameVar(strName) {
 		return nil
 	}
 
 	fileResult := a.curResult
 
 	// 2) 判断是否为需要忽略的文件中的变量
-	if common.GConfig.IsIgnoreFileDefineVar(fileResult.Name, strName) {
+	if a.conf.IsIgnoreFileDefineVar(fileResult.Name, strName) {
 		return nil
 	}
 
@@ -120,6 +120,37 @@ type AnnotateMarkState struct {
 	CommentLoc lexer.Location // 注释内容的位置信息
 }
 
+// AnnotateCastState 强制转换变量的类型，从注释所在的位置开始，在当前的作用域内生效
+// ---@cast one Player
+type AnnotateCastState struct {
+	Name       string         // 转换的变量名称
+	NameLoc    lexer.Location // 变量名称的位置
+	CastType   Type           // 转换后的类型
+	Comment    string         // 其他所有的注释内容
+	CommentLoc lexer.Location // 注释内容的位置信息
+}
+
+// AnnotateAsState 指定前面表达式的类型，写在表达式后面的长注释中
+// local one = getObj() --[[@as Player]]
+type AnnotateAsState struct {
+	AsLoc      lexer.Location // as关键字的位置
+	AsType     Type           // 表达式的类型
+	Comment    string         // 其他所有的注释内容
+	CommentLoc lexer.Location // 注释内容的位置信息
+}
+
+// AnnotateOperatorState 运算符元方法的类型，写在class的注释块中
+// ---@operator add(Vector): Vector
+// ---@operator unm: Vector
+type AnnotateOperatorState struct {
+	Name       string         // 运算符的名称，例如add、sub、mul
+	NameLoc    lexer.Location // 运算符名称的位置
+	ParamType  Type           // 另外一个操作数的类型，一元运算符为nil
+	ReturnType Type           // 运算结果的类型
+	Comment    string         // 其他所有的注释内容
+	CommentLoc lexer.Location // 注释内容的位置信息
+}
+
 // AnnotateNotValidState 无效的Stat
 type AnnotateNotValidState struct {
 }
@@ -500,6 +500,57 @@ func GetStateLocInfo(oneState AnnotateState, col int) (typeStr string, noticeStr
 			return
 		}
 
+	case *AnnotateCastState:
+		if colInLocation(state.NameLoc, col) {
+			typeStr = ""
+			noticeStr = "cast name"
+			return
+		}
+
+		typeStr, noticeStr = GetTypeLocInfo(state.CastType, col)
+		if typeStr != "" || noticeStr != "" {
+			return typeStr, noticeStr, ""
+		}
+
+		if colInLocation(state.CommentLoc, col) {
+			typeStr = ""
+			noticeStr = "comment info"
+			commentStr = state.Comment
+			return
+		}
+
+	case *AnnotateAsState:
+		typeStr, noticeStr = GetTypeLocInfo(state.AsType, col)
+		if typeStr != "" || noticeStr != "" {
+			return typeStr, noticeStr, ""
+		}
+
+	case *AnnotateOperatorState:
+		if colInLocation(state.NameLoc, col) {
+			typeStr = ""
+			noticeStr = "operator name"
+			return
+		}
+
+		if state.ParamType != nil {
+			typeStr, noticeStr = GetTypeLocInfo(state.ParamType, col)
+			if typeStr != "" || noticeStr != "" {
+				return typeStr, noticeStr, ""
+			}
+		}
+
+		typeStr, noticeStr = GetTypeLocInfo(state.ReturnType, col)
+		if typeStr != "" || noticeStr != "" {
+			return typeStr, noticeStr, ""
+		}
+
+		if colInLocation(state.CommentLoc, col) {
+			typeStr = ""
+			noticeStr = "comment info"
+			commentStr = state.Comment
+			return
+		}
+
 	case *AnnotateVarargState:
 		typeStr, noticeStr = GetTypeLocInfo(state.VarargType, col)
 		if typeStr != "" || noticeStr != "" {
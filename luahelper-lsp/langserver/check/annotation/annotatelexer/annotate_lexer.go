@@ -75,6 +75,16 @@ func (l *AnnotateLexer) CheckHeardValid() bool {
 	return false
 }
 
+// CheckAsHeadValid 校验是否为表达式后面的类型注解，以@as开头，例如 --[[@as Player]]
+func (l *AnnotateLexer) CheckAsHeadValid() bool {
+	if l.test("@as ") {
+		l.next(1)
+		return true
+	}
+
+	return false
+}
+
 func (l *AnnotateLexer) CheckMarkValid() bool {
 	if l.test(" MARK: ") {
 		l.next(7)
@@ -38,6 +38,9 @@ const (
 	ATokenKwEnum                         // enum 枚举段关键值
 	ATokenKwEnumStart                    // start enum后面跟着的开始关键字，例如完整的为enum start
 	ATokenKwEnumEnd                      // end enum后面跟着的结束关键字，例如完整的为enum end
+	ATokenKwCast                         // cast 强制转换变量的类型
+	ATokenKwAs                           // as 指定前面表达式的类型
+	ATokenKwOperator                     // operator 运算符元方法的类型
 )
 
 var keywords = map[string]ATokenType{
@@ -57,4 +60,7 @@ var keywords = map[string]ATokenType{
 	"vararg":    ATokenKwVararg,
 	"const":     ATokenKwConst,
 	"enum":      ATokenKwEnum,
+	"cast":      ATokenKwCast,
+	"as":        ATokenKwAs,
+	"operator":  ATokenKwOperator,
 }
@@ -47,6 +47,8 @@ func ParseCommentFragment(commentInfo *lexer.CommentInfo) (fragment annotateast.
 			annotateState, parseErr = ParserLine(l)
 		} else if l.CheckMarkValid() {
 			annotateState, parseErr = parseMarkState(l)
+		} else if l.CheckAsHeadValid() {
+			annotateState, parseErr = ParserLine(l)
 		} else {
 			continue
 		}
@@ -142,6 +144,12 @@ func parserOneState(l *annotatelexer.AnnotateLexer) annotateast.AnnotateState {
 		return parserVarargState(l)
 	case annotatelexer.ATokenKwEnum:
 		return parserEnumState(l)
+	case annotatelexer.ATokenKwCast:
+		return parserCastState(l)
+	case annotatelexer.ATokenKwAs:
+		return parserAsState(l)
+	case annotatelexer.ATokenKwOperator:
+		return parserOperatorState(l)
 	}
 
 	return &annotateast.AnnotateNotValidState{}
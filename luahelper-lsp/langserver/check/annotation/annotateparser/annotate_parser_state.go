@@ -366,6 +366,77 @@ func parserEnumState(l *annotatelexer.AnnotateLexer) annotateast.AnnotateState {
 	return enumState
 }
 
+// 解析@cast
+// ---@cast VAR_NAME TYPE [@comment]
+func parserCastState(l *annotatelexer.AnnotateLexer) annotateast.AnnotateState {
+	// 前面的关键词为cast 跳过
+	l.NextTokenOfKind(annotatelexer.ATokenKwCast)
+
+	castState := &annotateast.AnnotateCastState{}
+
+	// 解析变量的名称
+	castState.Name = l.NextParamName()
+	castState.NameLoc = l.GetNowLoc()
+
+	// 解析转换后的类型
+	castState.CastType = parserOneType(l)
+
+	// 获取这个state的多余注释
+	castState.Comment, castState.CommentLoc = l.GetRemainComment()
+
+	return castState
+}
+
+// 解析@as，写在表达式后面的长注释中
+// --[[@as TYPE]]
+func parserAsState(l *annotatelexer.AnnotateLexer) annotateast.AnnotateState {
+	// 前面的关键词为as 跳过
+	l.NextTokenOfKind(annotatelexer.ATokenKwAs)
+
+	asState := &annotateast.AnnotateAsState{
+		AsLoc: l.GetNowLoc(),
+	}
+
+	// 解析表达式的类型
+	asState.AsType = parserOneType(l)
+
+	// 获取这个state的多余注释
+	asState.Comment, asState.CommentLoc = l.GetRemainComment()
+
+	return asState
+}
+
+// 解析@operator
+// ---@operator OPERATOR_NAME[(PARAM_TYPE)]: RETURN_TYPE [@comment]
+func parserOperatorState(l *annotatelexer.AnnotateLexer) annotateast.AnnotateState {
+	// 前面的关键词为operator 跳过
+	l.NextTokenOfKind(annotatelexer.ATokenKwOperator)
+
+	operatorState := &annotateast.AnnotateOperatorState{}
+
+	// 解析运算符的名称
+	operatorState.Name = l.NextIdentifier()
+	operatorState.NameLoc = l.GetNowLoc()
+
+	// 解析另外一个操作数的类型，一元运算符没有
+	if l.LookAheadKind() == annotatelexer.ATokenVSepLparen {
+		l.NextTokenOfKind(annotatelexer.ATokenVSepLparen)
+		if l.LookAheadKind() != annotatelexer.ATokenVSepRparen {
+			operatorState.ParamType = parserOneType(l)
+		}
+		l.NextTokenOfKind(annotatelexer.ATokenVSepRparen)
+	}
+
+	// 解析运算结果的类型
+	l.NextTokenOfKind(annotatelexer.ATokenSepColon)
+	operatorState.ReturnType = parserOneType(l)
+
+	// 获取这个state的多余注释
+	operatorState.Comment, operatorState.CommentLoc = l.GetRemainComment()
+
+	return operatorState
+}
+
 // 解析@mark
 // --@mark anything
 func parseMarkState(l *annotatelexer.AnnotateLexer) (oneState annotateast.AnnotateState,
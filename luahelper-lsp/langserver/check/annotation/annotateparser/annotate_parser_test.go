@@ -480,3 +480,78 @@ func TestAnnotateParserEnum2(t *testing.T) {
 		t.Fatalf("parser annotate type stats is not equal")
 	}
 }
+
+func TestAnnotateParserCast(t *testing.T) {
+	commentInfo := &lexer.CommentInfo{
+		LineVec: []lexer.CommentLine{
+			{
+				Str:  "-@cast one Player",
+				Line: 1,
+				Col:  0,
+			},
+			{
+				Str:  "@as Player",
+				Line: 2,
+				Col:  0,
+			},
+		},
+	}
+	fragent, errVec := ParseCommentFragment(commentInfo)
+	if len(errVec) != 0 {
+		t.Fatalf("parser annotate return fatal, errstr=%s", errVec[0].ShowStr)
+	}
+	if len(fragent.Stats) != 2 {
+		t.Fatalf("parser annotate cast stats is not equal")
+	}
+
+	castState, ok := fragent.Stats[0].(*annotateast.AnnotateCastState)
+	if !ok || castState.Name != "one" || annotateast.TypeConvertStr(castState.CastType) != "Player" {
+		t.Fatalf("parser annotate cast state error")
+	}
+
+	asState, ok := fragent.Stats[1].(*annotateast.AnnotateAsState)
+	if !ok || annotateast.TypeConvertStr(asState.AsType) != "Player" {
+		t.Fatalf("parser annotate as state error")
+	}
+}
+
+func TestAnnotateParserOperator(t *testing.T) {
+	commentInfo := &lexer.CommentInfo{
+		LineVec: []lexer.CommentLine{
+			{
+				Str:  "-@class Vector",
+				Line: 1,
+				Col:  0,
+			},
+			{
+				Str:  "-@operator add(Vector): Vector",
+				Line: 2,
+				Col:  0,
+			},
+			{
+				Str:  "-@operator unm: Vector",
+				Line: 3,
+				Col:  0,
+			},
+		},
+	}
+	fragent, errVec := ParseCommentFragment(commentInfo)
+	if len(errVec) != 0 {
+		t.Fatalf("parser annotate return fatal, errstr=%s", errVec[0].ShowStr)
+	}
+	if len(fragent.Stats) != 3 {
+		t.Fatalf("parser annotate operator stats is not equal")
+	}
+
+	addState, ok := fragent.Stats[1].(*annotateast.AnnotateOperatorState)
+	if !ok || addState.Name != "add" || annotateast.TypeConvertStr(addState.ParamType) != "Vector" ||
+		annotateast.TypeConvertStr(addState.ReturnType) != "Vector" {
+		t.Fatalf("parser annotate operator add state error")
+	}
+
+	unmState, ok := fragent.Stats[2].(*annotateast.AnnotateOperatorState)
+	if !ok || unmState.Name != "unm" || unmState.ParamType != nil ||
+		annotateast.TypeConvertStr(unmState.ReturnType) != "Vector" {
+		t.Fatalf("parser annotate operator unm state error")
+	}
+}
@@ -29,9 +29,15 @@ type AllProject struct {
 	fileStructMap   map[string]*results.FileStruct
 	fileStructMutex sync.Mutex //  第一阶段所有文件的结果的互斥锁
 
+	// 整个工程中读取过的非局部变量名称的索引，key为名称，value为读取了该名称的文件数量，随第一阶段的文件结果更新
+	globalReferMap map[string]int
+
 	// 保存vscode客户端实时输入产生无法语法错误的文件结果 *FileStruct，代码提示会优先查找这个结构，这个是最新的
 	fileLRUMap *common.LRUCache
 
+	// 实时输入的文件最近一次没有语法错误的解析结果 *parser.ParseSnapshot，用于增量解析
+	parseSnapshotLRU *common.LRUCache
+
 	// 第二阶段分析的工程
 	analysisSecondMap map[string]*results.SingleProjectResult
 
@@ -46,23 +52,35 @@ type AllProject struct {
 
 	// 整体分析的阶段数
 	checkTerm results.CheckTerm
+
+	// 所属会话的配置，每个客户端的连接单独持有一份
+	conf *common.GlobalConfig
+
+	// 整体分析的统计，以及本次分析生成AST的累计耗时
+	statsMutex sync.Mutex
+	checkStats CheckStats
+	parseCost  time.Duration
 }
 
-// CreateAllProject 创建整个检查工程
-func CreateAllProject(allFilesList []string, entryFileArr []string, clientExpPathList []string) *AllProject {
+// CreateAllProject 创建整个检查工程，conf为所属会话的配置
+func CreateAllProject(allFilesList []string, entryFileArr []string, clientExpPathList []string,
+	conf *common.GlobalConfig) *AllProject {
 	// 第一阶段（生成AST，第一次遍历AST），用多协程分析所有的扫描出来的文件
 	allProject := &AllProject{
 		allFilesMap:       map[string]string{},
 		entryFilesList:    entryFileArr,
 		clientExpFileMap:  map[string]struct{}{},
 		fileStructMap:     map[string]*results.FileStruct{},
+		globalReferMap:    map[string]int{},
 		analysisSecondMap: map[string]*results.SingleProjectResult{},
 		thirdStruct:       nil,
 		createTypeMap:     map[string]common.CreateTypeList{},
 		checkTerm:         results.CheckTermFirst,
 		completeCache:     common.CreateCompleteCache(),
 		fileLRUMap:        common.NewLRUCache(20),
+		parseSnapshotLRU:  common.NewLRUCache(20),
 		fileIndexInfo:     common.CreateFileIndexInfo(),
+		conf:              conf,
 	}
 
 	// 传入的所有文件列表转换成map
@@ -79,12 +97,18 @@ func CreateAllProject(allFilesList []string, entryFileArr []string, clientExpPat
 	return allProject
 }
 
+// GetConfig 获取所属会话的配置
+func (a *AllProject) GetConfig() *common.GlobalConfig {
+	return a.conf
+}
+
 // HandleCheck 进行分析检查
 func (a *AllProject) HandleCheck() {
 	time1 := time.Now()
+	a.beginCheckStats()
 
 	// 0) 清除掉文件的cache
-	common.GConfig.ClearCacheFileMap()
+	a.conf.ClearCacheFileMap()
 
 	a.setCheckTerm(results.CheckTermFirst)
 	// 1) 进行第一轮分析, 所有扫描的lua文件生成AST以及第一遍扫描
@@ -95,13 +119,13 @@ func (a *AllProject) HandleCheck() {
 	var ftime2 int64 = 0
 	var ftime3 int64 = 0
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 	mainDir := dirManager.GetMainDir()
 
 	a.rebuidCreateTypeMap()
 
 	// 判断是否要进行特殊的检测
-	if len(a.entryFilesList) == 0 && !common.GConfig.IsSpecialCheck() {
+	if len(a.entryFilesList) == 0 && !a.conf.IsSpecialCheck() {
 		if mainDir != "" {
 			// 进行特殊的第三轮非检查，处理生成符号
 			time3 := time.Now()
@@ -134,6 +158,7 @@ func (a *AllProject) HandleCheck() {
 	a.checkAllAnnotateEnum()
 
 	ftime := time.Since(time1).Milliseconds()
+	a.endCheckStats(ftime, ftime1, ftime2, ftime3)
 	log.Debug("HandleCheck,  all time=%d, first=%d, second=%d, third=%d", ftime, ftime1, ftime2, ftime3)
 }
 
@@ -190,7 +215,8 @@ func (a *AllProject) RemoveFile(strFile string) {
 	a.fileIndexInfo.RemoveOneFile(strFile)
 
 	// 2)删除第一阶段的文件指针
-	_, beforeExitFlag := a.GetFirstFileStuct(strFile)
+	beforeStruct, beforeExitFlag := a.GetFirstFileStuct(strFile)
+	a.updateGlobalReferIndex(beforeStruct, -1)
 	delete(a.fileStructMap, strFile)
 	_, endExitFlag := a.GetFirstFileStuct(strFile)
 
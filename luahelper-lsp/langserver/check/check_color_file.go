@@ -56,7 +56,7 @@ func (a *AllProject) handleFindVarColor(color *results.ColorFileResult) {
 	time1 := time.Now()
 
 	// 创建第三轮遍历的包裹对象
-	analysis := analysis.CreateAnalysis(results.CheckTermFive, color.StrFile)
+	analysis := analysis.CreateAnalysis(results.CheckTermFive, color.StrFile, a.conf)
 	analysis.ColorResult = color
 	analysis.Projects = a
 	analysis.HandleTermTraverseAST(results.CheckTermFive, fileResult, nil)
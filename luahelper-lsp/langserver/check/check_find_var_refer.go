@@ -69,6 +69,11 @@ func (a *AllProject) FindVarReferSymbol(luaInFile string, node ast.Exp, comParam
 		symbol = a.findStrReferSymbol(luaInFile, exp.Name, exp.Loc, false, comParam, findExpList)
 		return symbol
 	case *ast.BinopExp:
+		// 操作数定义了运算符元方法，例如__add，关联元方法的结果类型
+		if operatorSymbol := a.getBinopOperatorSymbol(luaInFile, exp, comParam, findExpList); operatorSymbol != nil {
+			return operatorSymbol
+		}
+
 		if exp.Op == lexer.TkOpOr {
 			// 如果是or 二元表达式，关联第一个表达式
 			return a.FindVarReferSymbol(luaInFile, exp.Exp1, comParam, findExpList, varIndex)
@@ -167,7 +172,7 @@ func (a *AllProject) findStrReferSymbol(luaInFile string, strName string, loc le
 	}
 
 	// 2) 判断是否为需要忽略的文件中的变量
-	if common.GConfig.IsIgnoreFileDefineVar(luaInFile, strName) {
+	if a.conf.IsIgnoreFileDefineVar(luaInFile, strName) {
 		return nil
 	}
 
@@ -187,7 +192,7 @@ func (a *AllProject) findStrReferSymbol(luaInFile string, strName string, loc le
 	}
 
 	// 4) 判断是否为系统的函数，或是是全局变量模块
-	sysVar := common.GConfig.GetSysVar(strName)
+	sysVar := a.conf.GetSysVar(strName)
 	if sysVar == nil {
 		return nil
 	}
@@ -447,7 +452,12 @@ func (a *AllProject) symbolHasSubKey(oldSymbol *common.Symbol, strKey string,
 	// 判断这个注解类型是否包含子key
 	// 递归查找子成员
 	symbol = a.varInfoHasSubKey(oldSymbol.VarInfo, strKey, comParam, findExpList)
-	return symbol
+	if symbol != nil {
+		return symbol
+	}
+
+	// 自身没有找到，沿着原表的__index链查找
+	return a.getMetaIndexSubKey(oldSymbol, strKey, comParam, findExpList)
 }
 
 // 判断变量是否含有子的strKey子项
@@ -523,6 +533,11 @@ func (a *AllProject) getSimpleMetatable(luaInFile string, node *ast.FuncCallExp,
 	// 先获取左侧的元素
 	leftSymbol := a.FindVarReferSymbol(luaInFile, node.Args[0], comParam, findExpList, 1)
 
+	// 左侧为table的构造时，为新构造的匿名变量，记录下原表，后续沿着__index链查找成员
+	if _, ok := node.Args[0].(*ast.TableConstructorExp); ok && leftSymbol != nil && leftSymbol.VarInfo != nil {
+		leftSymbol.VarInfo.MetaExp = node.Args[1]
+	}
+
 	// 获取原表右侧的元素__call元素
 	callRightSymbol := a.getSimpleMetatableRight(luaInFile, node.Args[1], comParam, findExpList, "__call")
 	if callRightSymbol != nil {
@@ -586,7 +601,7 @@ func (a *AllProject) getBindAnnotateSetType(luaInFile string, strFuncName string
 	matchFlag = false
 
 	// 如果没有配置自动的类型推导直接返回
-	flag, oneSet := common.GConfig.MatchAnnotateSet(strFuncName)
+	flag, oneSet := a.conf.MatchAnnotateSet(strFuncName)
 	if !flag {
 		return
 	}
@@ -730,7 +745,7 @@ func (a *AllProject) getImportReferSymbol(luaInFile string, funcExp *ast.FuncCal
 		return nil
 	}
 
-	if !common.GConfig.ReferOtherFileMap[callExp.Name] {
+	if !a.conf.ReferOtherFileMap[callExp.Name] {
 		return nil
 	}
 
@@ -744,7 +759,7 @@ func (a *AllProject) getImportReferSymbol(luaInFile string, funcExp *ast.FuncCal
 	}
 
 	strFirst := firstExp.(*ast.StringExp).Str
-	oneRefer := common.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
+	oneRefer := a.conf.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
 	if oneRefer == nil {
 		return nil
 	}
@@ -811,7 +826,7 @@ func (a *AllProject) getFuncRelateSymbol(luaInFile string, node *ast.FuncCallExp
 	if nameExp, ok := node.PrefixExp.(*ast.NameExp); ok && node.NameExp == nil {
 		strName := nameExp.Name
 		// 这两个函数，在变量的referInfo里面已经存在了
-		if strName == "require" || common.GConfig.IsFrameReferOtherFile(strName) {
+		if strName == "require" || a.conf.IsFrameReferOtherFile(strName) {
 			//return nil
 			referSymbol := a.getImportReferSymbol(luaInFile, node, comParam, findExpList)
 			return referSymbol
@@ -911,50 +926,31 @@ func (a *AllProject) getFuncRelateSymbol(luaInFile string, node *ast.FuncCallExp
 		return
 	}
 
+	// table通过原表的__call当作构造函数调用，例如 local obj = Class()
+	if callSymbol := a.getMetaCallSymbol(funcSymbol, comParam, findExpList); callSymbol != nil {
+		return callSymbol
+	}
+
 	if funcSymbol.VarInfo.ReferFunc != nil {
-		// -- 这里获取第一个返回值有时推导不出来，因为函数可能有多个返回值，是否要获取最后一个返回值
-		flag, expReturn := funcSymbol.VarInfo.ReferFunc.GetReturnIndexExp(varIndex)
-		if !flag {
-			// 没有找到
-			return
-		}
+		// 函数可能有多处return，依次推导每一处的返回值，直到找到为止
+		// 有的return返回nil时，推导出来的变量可能为nil
+		referFunc := funcSymbol.VarInfo.ReferFunc
+		expReturnList := referFunc.GetReturnIndexExpList(varIndex)
+		for _, expReturn := range expReturnList {
+			symTmp := a.getReturnExpSymbol(funcSymbol.FileName, expReturn, comParam, findExpList, varIndex)
+			if symTmp == nil {
+				continue
+			}
 
-		if symTmp := extChangeSymbol(expReturn, funcSymbol.FileName); symTmp != nil {
+			if referFunc.IsReturnIndexMayNil(varIndex) {
+				mayNilSymbol := *symTmp
+				mayNilSymbol.MayNilFlag = true
+				return &mayNilSymbol
+			}
 			return symTmp
 		}
 
-		// 递归查找
-		tmpList := a.FindDeepSymbolList(funcSymbol.FileName, expReturn, comParam, findExpList, true, varIndex)
-		if len(tmpList) == 1 {
-			return tmpList[0]
-		}
-
-		if len(tmpList) == 0 {
-			return nil
-		}
-
-		firstSmbol := tmpList[0]
-		lastSmbol := tmpList[len(tmpList)-1]
-		if firstSmbol.AnnotateType != nil && lastSmbol.AnnotateType == nil {
-			return firstSmbol
-		}
-		if firstSmbol.AnnotateType == nil && lastSmbol.AnnotateType != nil {
-			return lastSmbol
-		}
-
-		if firstSmbol.VarInfo == nil {
-			return lastSmbol
-		}
-
-		if lastSmbol.VarInfo == nil {
-			return firstSmbol
-		}
-
-		if len(lastSmbol.VarInfo.SubMaps) > len(firstSmbol.VarInfo.SubMaps) {
-			return lastSmbol
-		}
-
-		return firstSmbol
+		return nil
 	}
 
 	// 没有找到，那么这个变量，它关联的上一层变量呢
@@ -990,6 +986,47 @@ func (a *AllProject) getFuncRelateSymbol(luaInFile string, node *ast.FuncCallExp
 	return nil
 }
 
+// getReturnExpSymbol 获取函数一处return表达式推导出来的symbol
+func (a *AllProject) getReturnExpSymbol(luaInFile string, expReturn ast.Exp, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile, varIndex uint8) *common.Symbol {
+	if symTmp := extChangeSymbol(expReturn, luaInFile); symTmp != nil {
+		return symTmp
+	}
+
+	// 递归查找
+	tmpList := a.FindDeepSymbolList(luaInFile, expReturn, comParam, findExpList, true, varIndex)
+	if len(tmpList) == 1 {
+		return tmpList[0]
+	}
+
+	if len(tmpList) == 0 {
+		return nil
+	}
+
+	firstSmbol := tmpList[0]
+	lastSmbol := tmpList[len(tmpList)-1]
+	if firstSmbol.AnnotateType != nil && lastSmbol.AnnotateType == nil {
+		return firstSmbol
+	}
+	if firstSmbol.AnnotateType == nil && lastSmbol.AnnotateType != nil {
+		return lastSmbol
+	}
+
+	if firstSmbol.VarInfo == nil {
+		return lastSmbol
+	}
+
+	if lastSmbol.VarInfo == nil {
+		return firstSmbol
+	}
+
+	if len(lastSmbol.VarInfo.SubMaps) > len(firstSmbol.VarInfo.SubMaps) {
+		return lastSmbol
+	}
+
+	return firstSmbol
+}
+
 // 判断是否重复了，递归可能会包含重复的
 func isHasSymbol(symbol *common.Symbol, symList []*common.Symbol) bool {
 	if len(symList) == 0 {
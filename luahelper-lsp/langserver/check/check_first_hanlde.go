@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"luahelper-lsp/langserver/check/analysis"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
 	"luahelper-lsp/langserver/check/compiler/parser"
 	"luahelper-lsp/langserver/check/results"
 	"luahelper-lsp/langserver/log"
@@ -31,9 +33,10 @@ type FirstWorkChan struct {
 // handleResult 返回0表示成功， 1表示读文件失败，2表示构造AST失败
 // changeFlag 表示内容是否有改动
 // beforeStruct 表示changeFlag如果没有改动，返回之前的FileStruct指针
+// changeVec 实时分析时，这次修改的行范围，用于增量的语法解析，只修改了一个顶层函数的函数体时，只重新分析这个函数体
 func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFile string, content []byte,
-	saveFlag bool, realTimeFlag bool) (handleResult results.FileHandleResult, changeFlag bool, beforeStruct *results.FileStruct) {
-	dirManager := common.GConfig.GetDirManager()
+	saveFlag bool, realTimeFlag bool, changeVec []parser.ChangeRange) (handleResult results.FileHandleResult, changeFlag bool, beforeStruct *results.FileStruct) {
+	dirManager := allProject.conf.GetDirManager()
 	if !dirManager.IsInWorkspace(luaFile) {
 		f.IsCommonFile = false
 	}
@@ -67,12 +70,56 @@ func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFil
 	// 获取文件的修改时间
 	time2 := time.Now()
 
-	firstFile := results.CreateFileResult(luaFile, nil, results.CheckTermFirst, "")
+	var mainAst *ast.Block
+	var commentMap map[int]*lexer.CommentInfo
+	var errList []lexer.ParseError
+	var reuseBlock *ast.Block
+	if realTimeFlag {
+		mainAst, commentMap, errList, reuseBlock = allProject.incrementalParseContent(luaFile, f.Contents, changeVec)
+	} else {
+		dialect, _ := allProject.conf.GetFileDialect(luaFile)
+		newParser := parser.CreateDialectParser(f.Contents, luaFile, dialect)
+		mainAst, commentMap, errList = newParser.BeginAnalyze()
+	}
+
+	parseCost := time.Since(time2)
+	allProject.addParseTime(parseCost)
+	ftime2 := parseCost.Milliseconds()
+	time3 := time.Now()
+
+	// 是否需要清空内容，节省空间，减少不必要的存储
+	if !saveFlag {
+		f.Contents = nil
+	}
+
+	// 实时分析时只修改了一个顶层函数的函数体，只重新分析这个函数体，否则整个文件进行第一阶段的分析
+	if len(errList) > 0 || !allProject.analysisChangeFuncBody(f, luaFile, reuseBlock, mainAst, commentMap) {
+		allProject.analysisFirstAst(f, luaFile, realTimeFlag, mainAst, commentMap, errList)
+	}
+	firstFile := f.FileResult
+
+	ftime3 := time.Since(time3).Milliseconds()
+
+	time4 := time.Now()
+
+	// 第一轮遍历完后，进行这个文件的所有注解解析
+	f.AnnotateFile.AnalysisAllComment(commentMap)
+	f.AnnotateFile.RelateTypeVarInfo(firstFile.GlobalMaps, firstFile.MainFunc.MainScope)
+	ftime4 := time.Since(time4).Milliseconds()
+
+	ftime5 := time.Since(time1).Milliseconds()
+	log.Debug("handleFirstTraverseAST strFile=%s, readTime=%d, astTime=%d, firstTraTime=%d, annotatetime=%d, alltime=%d",
+		luaFile, ftime1, ftime2, ftime3, ftime4, ftime5)
+	return handleResult, true, nil
+}
+
+// analysisFirstAst 整个文件的语法树进行第一阶段的遍历
+func (allProject *AllProject) analysisFirstAst(f *results.FileStruct, luaFile string, realTimeFlag bool,
+	mainAst *ast.Block, commentMap map[int]*lexer.CommentInfo, errList []lexer.ParseError) {
+	firstFile := results.CreateFileResult(luaFile, nil, results.CheckTermFirst, "", allProject.conf)
 	// 设置好指向的FileAnalysis
 	f.FileResult = firstFile
 
-	newParser := parser.CreateParser(f.Contents, luaFile)
-	mainAst, commentMap, errList := newParser.BeginAnalyze()
 	if len(errList) > 0 {
 		for _, oneErr := range errList {
 			firstFile.InsertError(common.CheckErrorSyntax, oneErr.ErrStr, oneErr.Loc)
@@ -87,36 +134,159 @@ func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFil
 	firstFile.MainFunc.Loc = mainAst.Loc
 	firstFile.MainFunc.MainScope.Loc = mainAst.Loc
 
-	ftime2 := time.Since(time2).Milliseconds()
-	time3 := time.Now()
-
-	// 是否需要清空内容，节省空间，减少不必要的存储
-	if !saveFlag {
-		f.Contents = nil
-	}
-
 	firstFile.InertNewFunc(firstFile.MainFunc)
 
-	analysis := analysis.CreateAnalysis(results.CheckTermFirst, luaFile)
+	analysis := analysis.CreateAnalysis(results.CheckTermFirst, luaFile, allProject.conf)
 	analysis.Projects = allProject
 	analysis.SetRealTimeFlag(realTimeFlag)
 
 	// 进行第一阶段的遍历
 	analysis.HandleFirstTraverseAST(firstFile)
+}
 
-	ftime3 := time.Since(time3).Milliseconds()
+// analysisChangeFuncBody 实时分析时，增量解析只修改了一个顶层函数的函数体，复用上一次实时分析的结果，只重新分析这个函数体
+// reuseBlock 为增量解析复用的上一次的语法树，只有上一次实时分析的结果对应这个语法树时才能复用
+// 返回false表示需要整个文件重新分析
+func (allProject *AllProject) analysisChangeFuncBody(f *results.FileStruct, luaFile string, reuseBlock *ast.Block,
+	mainAst *ast.Block, commentMap map[int]*lexer.CommentInfo) bool {
+	if reuseBlock == nil {
+		return false
+	}
 
-	time4 := time.Now()
+	value, ok, _ := allProject.fileLRUMap.Get(luaFile)
+	if !ok {
+		return false
+	}
 
-	// 第一轮遍历完后，进行这个文件的所有注解解析
-	f.AnnotateFile.AnalysisAllComment(commentMap)
-	f.AnnotateFile.RelateTypeVarInfo(firstFile.GlobalMaps, firstFile.MainFunc.MainScope)
-	ftime4 := time.Since(time4).Milliseconds()
+	oldStruct := value.(*results.FileStruct)
+	oldResult := oldStruct.FileResult
+	if oldStruct.HandleResult != results.FileHandleOk || oldResult == nil || oldResult.Block != reuseBlock {
+		return false
+	}
 
-	ftime5 := time.Since(time1).Milliseconds()
-	log.Debug("handleFirstTraverseAST strFile=%s, readTime=%d, astTime=%d, firstTraTime=%d, annotatetime=%d, alltime=%d",
-		luaFile, ftime1, ftime2, ftime3, ftime4, ftime5)
-	return handleResult, true, nil
+	oldNode, newNode := getChangeFuncDefExp(reuseBlock, mainAst)
+	if oldNode == nil {
+		return false
+	}
+
+	analysis := analysis.CreateAnalysis(results.CheckTermFirst, luaFile, allProject.conf)
+	analysis.Projects = allProject
+	analysis.SetRealTimeFlag(true)
+	if !analysis.HandleFirstTraverseFunc(oldResult, oldNode, newNode) {
+		// 上一次的结果可能已经修改了，不能再使用
+		allProject.fileLRUMap.Remove(luaFile)
+		log.Debug("analysisChangeFuncBody strFile=%s fail", luaFile)
+		return false
+	}
+
+	oldResult.Block = mainAst
+	oldResult.CommentMap = commentMap
+	oldResult.MainFunc.Loc = mainAst.Loc
+	oldResult.MainFunc.MainScope.Loc = mainAst.Loc
+	f.FileResult = oldResult
+	log.Debug("analysisChangeFuncBody strFile=%s ok, funcLine=%d", luaFile, newNode.Loc.StartLine)
+	return true
+}
+
+// getChangeFuncDefExp 修改前后的语法树只有一个顶层语句不同，且这个语句是函数定义，只有函数体不同时，返回修改前后的函数定义
+// 增量解析时，没有修改的语句直接复用之前的语法树，因此可以通过指针判断语句是否修改了
+func getChangeFuncDefExp(oldBlock *ast.Block, newBlock *ast.Block) (oldNode *ast.FuncDefExp,
+	newNode *ast.FuncDefExp) {
+	if len(oldBlock.Stats) != len(newBlock.Stats) || len(oldBlock.RetExps) != len(newBlock.RetExps) {
+		return nil, nil
+	}
+
+	for i := range oldBlock.RetExps {
+		if oldBlock.RetExps[i] != newBlock.RetExps[i] {
+			return nil, nil
+		}
+	}
+
+	changeIndex := -1
+	for i := range oldBlock.Stats {
+		if oldBlock.Stats[i] == newBlock.Stats[i] {
+			continue
+		}
+
+		if changeIndex >= 0 {
+			return nil, nil
+		}
+		changeIndex = i
+	}
+	if changeIndex < 0 {
+		return nil, nil
+	}
+
+	oldStat, oldNode := getStatFuncHead(oldBlock.Stats[changeIndex])
+	newStat, newNode := getStatFuncHead(newBlock.Stats[changeIndex])
+	if oldStat == nil || newStat == nil || !reflect.DeepEqual(oldStat, newStat) {
+		return nil, nil
+	}
+
+	return oldNode, newNode
+}
+
+// getStatFuncHead 语句为函数定义时，返回去掉函数体后的语句，用于比较函数头是否修改了
+// 例如 local function f(a) end 或是 function M.f(a) end
+func getStatFuncHead(stat ast.Stat) (headStat ast.Stat, funcNode *ast.FuncDefExp) {
+	switch node := stat.(type) {
+	case *ast.LocalFuncDefStat:
+		headFunc := *node.Exp
+		headFunc.Block = nil
+		headNode := *node
+		headNode.Exp = &headFunc
+		return &headNode, node.Exp
+	case *ast.AssignStat:
+		if len(node.VarList) != 1 || len(node.ExpList) != 1 {
+			return nil, nil
+		}
+
+		funcExp, ok := node.ExpList[0].(*ast.FuncDefExp)
+		if !ok {
+			return nil, nil
+		}
+
+		headFunc := *funcExp
+		headFunc.Block = nil
+		headNode := *node
+		headNode.ExpList = []ast.Exp{&headFunc}
+		return &headNode, funcExp
+	}
+
+	return nil, nil
+}
+
+// incrementalParseContent 实时分析时解析文件的内容，能增量解析时只重新解析修改了的顶层语句
+// 返回的是整个文件的语法树，reuseBlock为增量解析时复用的上一次的语法树，整体重新解析时为nil
+func (allProject *AllProject) incrementalParseContent(luaFile string, content []byte,
+	changeVec []parser.ChangeRange) (mainAst *ast.Block, commentMap map[int]*lexer.CommentInfo,
+	errList []lexer.ParseError, reuseBlock *ast.Block) {
+	dialect, _ := allProject.conf.GetFileDialect(luaFile)
+	incrementalFlag := false
+	if len(changeVec) > 0 {
+		if value, ok, _ := allProject.parseSnapshotLRU.Get(luaFile); ok {
+			snapshot := value.(*parser.ParseSnapshot)
+			mainAst, commentMap, incrementalFlag = snapshot.IncrementalParse(content, changeVec)
+			if incrementalFlag {
+				reuseBlock = snapshot.GetBlock()
+			}
+		}
+	}
+
+	if !incrementalFlag {
+		newParser := parser.CreateDialectParser(content, luaFile, dialect)
+		mainAst, commentMap, errList = newParser.BeginAnalyze()
+	}
+	log.Debug("incrementalParseContent strFile=%s, incrementalFlag=%t", luaFile, incrementalFlag)
+
+	snapshot := parser.CreateParseSnapshot(luaFile, dialect, content, mainAst, commentMap, errList)
+	if snapshot != nil {
+		allProject.parseSnapshotLRU.Set(luaFile, snapshot)
+	} else {
+		allProject.parseSnapshotLRU.Remove(luaFile)
+	}
+
+	return mainAst, commentMap, errList, reuseBlock
 }
 
 // GoRoutineFirstWork 第一轮分析lua的协程，接受主协程发送需要分析的文件
@@ -128,9 +298,9 @@ func GoRoutineFirstWork(ch chan FirstWorkChan) {
 			break
 		}
 
-		fileStruct := results.CreateFileStruct(request.strFile)
+		fileStruct := results.CreateFileStruct(request.strFile, request.allProject.conf)
 		handleResult, changeFlag, beforeFileStruct := request.allProject.analysisFirstLuaFile(fileStruct,
-			request.strFile, nil, request.saveContentFlag, false)
+			request.strFile, nil, request.saveContentFlag, false, nil)
 
 		// 如果没有改动，用之前的FileStruct
 		if !changeFlag {
@@ -158,7 +328,7 @@ func (a *AllProject) recvWorkChann(chanResult FirstWorkChan) (changeFlag bool) {
 		// 如果这次有语法错误，获取上一次的成功的文件，进行cache
 		// 且cache中如果不存在
 		oldStruct, flag := a.GetFirstFileStuct(chanResult.strFile)
-		_, cacheFlag, _ := a.fileLRUMap.Get(chanResult.strFile)
+		cacheFlag := a.fileLRUMap.Contains(chanResult.strFile)
 		if flag && oldStruct.GetFileHandleErr() != results.FileHandleSyntaxErr && !cacheFlag {
 			a.fileLRUMap.Set(chanResult.strFile, oldStruct)
 		}
@@ -196,7 +366,8 @@ func (a *AllProject) firstCreateAndTraverseAst(filesList []string, saveContentFl
 		chs[i] = make(chan FirstWorkChan)
 		selectCase[i].Dir = reflect.SelectRecv
 		selectCase[i].Chan = reflect.ValueOf(chs[i])
-		go GoRoutineFirstWork(chs[i])
+		ch := chs[i]
+		log.Go(func() { GoRoutineFirstWork(ch) })
 	}
 
 	//初始化协程
@@ -265,7 +436,7 @@ func (a *AllProject) HandleFirstAllProject() {
 	}
 
 	// 重建
-	common.GConfig.RebuildSameFileNameVar(a.allFilesMap)
+	a.conf.RebuildSameFileNameVar(a.allFilesMap)
 	// time2 := time.Now()
 	// // 创建所有文件的目录结构
 	// for strFile := range a.allFilesMap {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"luahelper-lsp/langserver/check/annotation/annotateast"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
 	"luahelper-lsp/langserver/log"
 )
 
@@ -21,6 +22,22 @@ func (a *AllProject) isFieldOfClass(fieldName string, className string) bool {
 		return true
 	}
 
+	// class关联的变量，推导出来的table结构中包含的成员也认为是class的成员，例如：
+	// ---@class Foo
+	// local Foo = {}
+	// Foo.count = 0
+	relateVar := createTypeList.List[0].ClassInfo.RelateVar
+	if relateVar != nil && relateVar.IsExistMember(fieldName) {
+		return true
+	}
+
+	// class关联的变量，通过原表__index继承的成员，例如：
+	// ---@class Derived
+	// local Derived = setmetatable({}, {__index = Base})
+	if relateVar != nil && a.isMetaIndexMember(relateVar, fieldName) {
+		return true
+	}
+
 	// 所有父类型也处理下，再次递归获取
 	for _, strParent := range createTypeList.List[0].ClassInfo.ClassState.ParentNameList {
 		if a.isFieldOfClass(fieldName, strParent) {
@@ -211,3 +228,192 @@ func (a *AllProject) GetFieldTypeOfClass(className string, retFieldType map[stri
 
 	return
 }
+
+// GetOperatorResultType 获取class类型className与另外一个操作数运算的结果类型，没有定义对应的运算符元方法返回空
+// strOp为运算符的名称，例如add、mul；paramTypeVec为另外一个操作数所有可能的类型
+func (a *AllProject) GetOperatorResultType(className string, strOp string, paramTypeVec []string) (retVec []string) {
+	return a.getOperatorResultType(className, strOp, paramTypeVec, map[string]bool{})
+}
+
+// getOperatorResultType 依次查找---@operator注解、class关联变量定义的元方法，最后再查找所有的父类型
+func (a *AllProject) getOperatorResultType(className string, strOp string, paramTypeVec []string,
+	visitMap map[string]bool) (retVec []string) {
+	// 防止父类型的循环引用
+	if visitMap[className] {
+		return
+	}
+	visitMap[className] = true
+
+	createTypeInfo := a.GetAnnClassInfo(className)
+	if createTypeInfo == nil || createTypeInfo.ClassInfo == nil {
+		return
+	}
+	classInfo := createTypeInfo.ClassInfo
+
+	// 1) ---@operator 注解的类型，例如 ---@operator add(Vector): Vector
+	for _, operatorState := range classInfo.OperatorMap[strOp] {
+		if operatorState.ReturnType == nil {
+			continue
+		}
+
+		if operatorState.ParamType != nil && !isOperatorParamMatch(operatorState.ParamType, paramTypeVec) {
+			continue
+		}
+
+		return a.getAnnotateTypeStringhelp(operatorState.ReturnType, "")
+	}
+
+	// 2) class关联的变量中定义的元方法，例如 function Vector.__add(a, b) end
+	if classInfo.RelateVar != nil {
+		retVec = a.getMetaOperatorType(classInfo.RelateVar, className, "__"+strOp)
+		if len(retVec) > 0 {
+			return retVec
+		}
+	}
+
+	// 3) 所有父类型也处理下，再次递归获取
+	if classInfo.ClassState == nil {
+		return
+	}
+
+	for _, strParent := range classInfo.ClassState.ParentNameList {
+		retVec = a.getOperatorResultType(strParent, strOp, paramTypeVec, visitMap)
+		if len(retVec) > 0 {
+			return retVec
+		}
+	}
+
+	return
+}
+
+// isOperatorParamMatch 判断---@operator注解的操作数类型是否与实际的操作数类型匹配
+func isOperatorParamMatch(paramType annotateast.Type, paramTypeVec []string) bool {
+	for _, strType := range annotateast.GetAllNormalStrList(paramType) {
+		if strType == "any" {
+			return true
+		}
+
+		for _, oneType := range paramTypeVec {
+			if oneType == strType || oneType == "any" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// getMetaOperatorType 获取变量自身或是变量原表中定义的运算符元方法strKey的返回类型，例如__add
+// 元方法没有---@return注解时，认为运算的结果为class类型自身
+func (a *AllProject) getMetaOperatorType(varInfo *common.VarInfo, className string, strKey string) (retVec []string) {
+	funcFile := ""
+	funcLine := 0
+	if subVar, ok := varInfo.SubMaps[strKey]; ok && subVar.ReferFunc != nil {
+		funcFile = subVar.ReferFunc.FileName
+		funcLine = subVar.ReferFunc.Loc.StartLine
+	} else {
+		mtExp := getVarMetatableExp(varInfo)
+		if mtExp == nil {
+			return
+		}
+
+		comParam := a.getCommFunc(varInfo.FileName, varInfo.Loc.StartLine-1, varInfo.Loc.StartColumn)
+		if comParam == nil {
+			return
+		}
+
+		findExpList := []common.FindExpFile{}
+		fieldExp, fieldFile := a.getMetatableFieldExp(varInfo.FileName, mtExp, strKey, comParam, &findExpList)
+		funcExp, ok := fieldExp.(*ast.FuncDefExp)
+		if !ok {
+			return
+		}
+
+		funcFile = fieldFile
+		funcLine = funcExp.Loc.StartLine
+	}
+
+	returnTypeVec := a.GetFuncReturnTypeVec(funcFile, funcLine-1)
+	if len(returnTypeVec) > 0 && len(returnTypeVec[0]) > 0 {
+		return returnTypeVec[0]
+	}
+
+	return []string{className}
+}
+
+// getBinopOperatorSymbol 二元运算的操作数定义了运算符元方法时，获取运算结果注解类型的symbol，没有定义返回nil
+// 例如 M.__add 没有---@return注解时，local m2 = m1 + 2 中m2的类型为m1的class类型
+// 与lua的规则一致，先查找左边操作数的元方法，再查找右边操作数的元方法
+func (a *AllProject) getBinopOperatorSymbol(luaInFile string, node *ast.BinopExp, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) *common.Symbol {
+	strOp, ok := common.OperatorMetaNameMap[node.Op]
+	if !ok {
+		return nil
+	}
+
+	leftTypeVec := a.getOperandTypeVec(luaInFile, node.Exp1, comParam, findExpList)
+	rightTypeVec := a.getOperandTypeVec(luaInFile, node.Exp2, comParam, findExpList)
+
+	var retVec []string
+	for _, leftType := range leftTypeVec {
+		if retVec = a.GetOperatorResultType(leftType, strOp, rightTypeVec); len(retVec) > 0 {
+			break
+		}
+	}
+
+	if len(retVec) == 0 {
+		for _, rightType := range rightTypeVec {
+			if retVec = a.GetOperatorResultType(rightType, strOp, leftTypeVec); len(retVec) > 0 {
+				break
+			}
+		}
+	}
+
+	if len(retVec) == 0 {
+		return nil
+	}
+
+	typeList := make([]annotateast.Type, 0, len(retVec))
+	for _, strType := range retVec {
+		typeList = append(typeList, &annotateast.NormalType{
+			StrName: strType,
+			NameLoc: node.Loc,
+		})
+	}
+
+	symbol := &common.Symbol{
+		FileName:     luaInFile,
+		VarFlag:      common.FirstAnnotateFlag,
+		AnnotateType: typeList[0],
+		AnnotateLine: node.Loc.StartLine,
+		AnnotateLoc:  node.Loc,
+	}
+	if len(typeList) > 1 {
+		symbol.AnnotateType = &annotateast.MultiType{
+			Loc:      node.Loc,
+			TypeList: typeList,
+		}
+	}
+
+	return symbol
+}
+
+// getOperandTypeVec 获取二元运算操作数所有可能的类型，常量为基础类型，变量取关联的注解类型
+func (a *AllProject) getOperandTypeVec(luaInFile string, exp ast.Exp, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) (typeVec []string) {
+	if _, ok := exp.(*ast.BinopExp); !ok {
+		strType := common.GetAnnTypeFromLuaType(common.GetExpType(exp))
+		if strType != "LuaTypeRefer" && strType != "any" {
+			return []string{strType}
+		}
+	}
+
+	symList := a.FindDeepSymbolList(luaInFile, exp, comParam, findExpList, true, 1)
+	for _, symbol := range symList {
+		if symbol.AnnotateType != nil {
+			return a.getAnnotateTypeStringhelp(symbol.AnnotateType, "")
+		}
+	}
+
+	return
+}
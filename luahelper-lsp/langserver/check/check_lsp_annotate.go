@@ -25,7 +25,7 @@ func (a *AllProject) checkOneFileType(annotateFile *common.AnnotateFile, fragemn
 	}
 
 	for index, str := range strList {
-		if common.GConfig.IsDefaultAnnotateType(str) {
+		if a.conf.IsDefaultAnnotateType(str) {
 			continue
 		}
 
@@ -81,6 +81,15 @@ func (a *AllProject) checkFileAnnotate(fileStruct *results.FileStruct) {
 				for _, oneField := range oneClass.FieldMap {
 					a.checkOneFileType(annotateFile, oneFragment, oneField.FiledType)
 				}
+
+				for _, operatorList := range oneClass.OperatorMap {
+					for _, oneOperator := range operatorList {
+						if oneOperator.ParamType != nil {
+							a.checkOneFileType(annotateFile, oneFragment, oneOperator.ParamType)
+						}
+						a.checkOneFileType(annotateFile, oneFragment, oneOperator.ReturnType)
+					}
+				}
 			}
 		}
 
@@ -114,6 +123,19 @@ func (a *AllProject) checkFileAnnotate(fileStruct *results.FileStruct) {
 				a.checkOneFileType(annotateFile, oneFragment, oneMark.MarkState.MarkType)
 			}
 		}
+
+		if oneFragment.CastInfo != nil {
+			for _, oneCast := range oneFragment.CastInfo.CastList {
+				a.checkOneFileType(annotateFile, oneFragment, oneCast.CastType)
+			}
+		}
+	}
+
+	// 遍历所有写在表达式后面的类型注解
+	for _, asList := range annotateFile.AsMap {
+		for _, oneAs := range asList {
+			a.checkOneFileType(annotateFile, &common.FragementInfo{}, oneAs.AsType)
+		}
 	}
 
 	// 所有的错误告警信息，进行排序，因为在比对注解告警信息的时候，希望是有序的
@@ -203,7 +225,7 @@ func (a *AllProject) checkFileMapAnnotateEnum(enumFileMap map[string]struct{}) {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
 		return
 	}
 
@@ -229,7 +251,7 @@ func (a *AllProject) checkAllAnnotateEnum() {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorEnumValue) {
 		return
 	}
 
@@ -251,7 +273,7 @@ func (a *AllProject) checkAllAnnotate() {
 		return
 	}
 
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorAnnotate) {
+	if a.conf.IsGlobalIgnoreErrType(common.CheckErrorAnnotate) {
 		return
 	}
 
@@ -1378,6 +1400,14 @@ func (a *AllProject) getInfoFileAnnotateType(strName string, symbol *common.Symb
 		}
 	}
 
+	if fragmentInfo == nil && symbol.VarInfo.ReferExp != nil {
+		// 2.2) 判断赋值的表达式后面是否有类型注解，例如 local a = getObj() --[[@as Player]]
+		asState := annotateFile.GetExpAsType(common.GetExpLoc(symbol.VarInfo.ReferExp))
+		if asState != nil {
+			return asState.AsType, asState.Comment, "type"
+		}
+	}
+
 	if fragmentInfo == nil {
 		// 判断是否关联了for pairs或ipairs里的变量
 		if symbol.VarInfo.IsForParam {
@@ -1454,6 +1484,87 @@ func (a *AllProject) setInfoFileAnnotateTypes(strName string, symbol *common.Sym
 	symbol.AnnotateLoc = annotateast.GetAstTypeLoc(astType)
 }
 
+// setCastAnnotateType 判断变量在使用的位置之前，是否被---@cast强制转换了类型，如果是设置为转换后的类型
+// 例如：
+// ---@cast obj Player
+// obj:attack() -- 这里obj的类型为Player
+// cast只在所处的作用域内，且cast之后的代码生效
+func (a *AllProject) setCastAnnotateType(strName string, symbol *common.Symbol, comParam *CommonFuncParam) {
+	if symbol == nil || symbol.VarInfo == nil || comParam == nil || comParam.scope == nil {
+		return
+	}
+
+	fileResult := comParam.fileResult
+	annotateFile := a.getAnnotateFile(fileResult.Name)
+	if annotateFile == nil || len(annotateFile.CastVec) == 0 {
+		return
+	}
+
+	varInfo := symbol.VarInfo
+	useLine := comParam.loc.StartLine
+
+	var findCast *annotateast.AnnotateCastState
+	for _, oneCast := range annotateFile.CastVec {
+		castLine := oneCast.NameLoc.StartLine
+		if castLine >= useLine {
+			break
+		}
+
+		if oneCast.Name != strName {
+			continue
+		}
+
+		// cast需要在变量定义之后
+		if varInfo.FileName == fileResult.Name && castLine < varInfo.Loc.StartLine {
+			continue
+		}
+
+		// cast所在的作用域需要包含使用的位置
+		castScope := fileResult.MainFunc.MainScope.FindMinScope(castLine, oneCast.NameLoc.StartColumn)
+		if castScope == nil || !isScopeContain(castScope, comParam.scope) {
+			continue
+		}
+
+		// cast的位置指向的变量需要为同一个
+		castLoc := lexer.Location{
+			StartLine:   castLine,
+			StartColumn: oneCast.NameLoc.StartColumn,
+			EndLine:     castLine,
+			EndColumn:   oneCast.NameLoc.StartColumn,
+		}
+		if locVar, ok := castScope.FindLocVar(strName, castLoc); ok {
+			if locVar != varInfo {
+				continue
+			}
+		} else if varInfo.ExtraGlobal == nil {
+			continue
+		}
+
+		findCast = oneCast
+	}
+
+	if findCast == nil {
+		return
+	}
+
+	symbol.AnnotateComment = findCast.Comment
+	symbol.StrPreComment = "type"
+	symbol.AnnotateLine = findCast.NameLoc.StartLine
+	symbol.AnnotateType = findCast.CastType
+	symbol.AnnotateLoc = annotateast.GetAstTypeLoc(findCast.CastType)
+}
+
+// isScopeContain 判断作用域parentScope是否包含subScope
+func isScopeContain(parentScope *common.ScopeInfo, subScope *common.ScopeInfo) bool {
+	for scope := subScope; scope != nil; scope = scope.Parent {
+		if scope == parentScope {
+			return true
+		}
+	}
+
+	return false
+}
+
 // 判断函数的一个注解返回值，是否包含子key
 func (a *AllProject) getAnnotateFunStrKey(symbol *common.Symbol, strKey string, comParam *CommonFuncParam,
 	findExpList *[]common.FindExpFile) (flag bool, subSymbol *common.Symbol) {
@@ -1692,13 +1803,7 @@ func (a *AllProject) GetAnnotateFileSymbolStruct(fileName string) (symbolVec []c
 			}
 
 			if one.ClassInfo != nil {
-				oneSymbol := common.FileSymbolStruct{
-					Name: strName,
-					Kind: common.IKAnnotateClass,
-					Loc:  one.ClassInfo.ClassState.NameLoc,
-				}
-
-				symbolVec = append(symbolVec, oneSymbol)
+				symbolVec = append(symbolVec, getAnnotateClassSymbol(one.ClassInfo))
 			}
 		}
 	}
@@ -1706,6 +1811,32 @@ func (a *AllProject) GetAnnotateFileSymbolStruct(fileName string) (symbolVec []c
 	return
 }
 
+// getAnnotateClassSymbol 获取注解class的符号，所有的field为子符号
+func getAnnotateClassSymbol(classInfo *common.OneClassInfo) common.FileSymbolStruct {
+	classState := classInfo.ClassState
+	classSymbol := common.FileSymbolStruct{
+		Name:    classState.Name,
+		Kind:    common.IKAnnotateClass,
+		Loc:     classState.NameLoc,
+		Detail:  "class " + classState.Name,
+		VarInfo: classInfo.RelateVar,
+	}
+	if len(classState.ParentNameList) > 0 {
+		classSymbol.Detail += " : " + strings.Join(classState.ParentNameList, ", ")
+	}
+
+	for _, fieldState := range classInfo.FieldMap {
+		classSymbol.Children = append(classSymbol.Children, common.FileSymbolStruct{
+			Name:   fieldState.Name,
+			Kind:   common.IKField,
+			Loc:    fieldState.NameLoc,
+			Detail: annotateast.TypeConvertStr(fieldState.FiledType),
+		})
+	}
+
+	return classSymbol
+}
+
 // typeStrFile 类型对应的文件信息，根据一个类型名查找定义时候用到
 type typeStrFile struct {
 	FileName  string         // lua所在的文件名
@@ -0,0 +1,113 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/log"
+	"sort"
+	"strings"
+)
+
+// AnnotateStubStruct 一个函数需要插入的注解桩代码
+type AnnotateStubStruct struct {
+	Line  int      // 函数定义所在的行，注解插入到这一行的前面，从1开始
+	Lines []string // 需要插入的注解，每一项为一行，例如 ---@param a any
+}
+
+// GetFileAnnotateStubs 获取指定文件中所有函数的注解桩代码
+// 已经有注解的函数会忽略，生成的参数与返回值的类型都为any，由用户自己修改
+func (a *AllProject) GetFileAnnotateStubs(strFile string) (stubVec []AnnotateStubStruct) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		log.Error("GetFileAnnotateStubs error, not find file=%s", strFile)
+		return
+	}
+
+	lineMap := map[int]bool{}
+	fileSymbolVec := a.FindFileAllSymbol(strFile)
+	stubVec = appendAnnotateStubs(stubVec, fileSymbolVec, fileStruct.FileResult.CommentMap, strFile, lineMap)
+
+	sort.Slice(stubVec, func(i, j int) bool {
+		return stubVec[i].Line < stubVec[j].Line
+	})
+	return
+}
+
+// appendAnnotateStubs 符号中的函数生成注解桩代码，子符号也一起生成，同一行只生成一次
+func appendAnnotateStubs(stubVec []AnnotateStubStruct, fileSymbolVec []common.FileSymbolStruct,
+	commentMap map[int]*lexer.CommentInfo, strFile string, lineMap map[int]bool) []AnnotateStubStruct {
+	for _, oneSymbol := range fileSymbolVec {
+		stubVec = appendAnnotateStubs(stubVec, oneSymbol.Children, commentMap, strFile, lineMap)
+
+		if oneSymbol.Kind != common.IKFunction && oneSymbol.Kind != common.IKMethod {
+			continue
+		}
+		if oneSymbol.VarInfo == nil || oneSymbol.VarInfo.ReferFunc == nil {
+			continue
+		}
+
+		funcInfo := oneSymbol.VarInfo.ReferFunc
+		if funcInfo.FileName != strFile {
+			continue
+		}
+
+		line := funcInfo.Loc.StartLine
+		if lineMap[line] || hasAnnotateComment(commentMap[line-1]) {
+			continue
+		}
+
+		lines := getFuncAnnotateStub(funcInfo)
+		if len(lines) == 0 {
+			continue
+		}
+
+		lineMap[line] = true
+		stubVec = append(stubVec, AnnotateStubStruct{
+			Line:  line,
+			Lines: lines,
+		})
+	}
+
+	return stubVec
+}
+
+// hasAnnotateComment 判断函数前面的注释是否已经包含了注解
+func hasAnnotateComment(commentInfo *lexer.CommentInfo) bool {
+	if commentInfo == nil || !commentInfo.HeadFlag {
+		return false
+	}
+
+	for _, commentLine := range commentInfo.LineVec {
+		if strings.HasPrefix(strings.TrimSpace(commentLine.Str), "-@") {
+			return true
+		}
+	}
+	return false
+}
+
+// getFuncAnnotateStub 获取函数参数与返回值的注解，冒号函数忽略掉self
+func getFuncAnnotateStub(funcInfo *common.FuncInfo) (lines []string) {
+	for index, paramName := range funcInfo.ParamList {
+		if funcInfo.IsColon && index == 0 && paramName == "self" {
+			continue
+		}
+		lines = append(lines, "---@param "+paramName+" any")
+	}
+
+	if funcInfo.IsVararg {
+		lines = append(lines, "---@param ... any")
+	}
+
+	// 函数可能有多处返回，取返回值最多的
+	returnNum := 0
+	for _, returnInfo := range funcInfo.ReturnVecs {
+		if len(returnInfo.ReturnVarVec) > returnNum {
+			returnNum = len(returnInfo.ReturnVarVec)
+		}
+	}
+
+	for i := 0; i < returnNum; i++ {
+		lines = append(lines, "---@return any")
+	}
+	return lines
+}
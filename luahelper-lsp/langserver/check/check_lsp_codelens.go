@@ -0,0 +1,195 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/log"
+	"reflect"
+)
+
+// busted 风格测试的函数名，值为true表示为测试组，可以包含子的测试
+var bustedTestFuncMap = map[string]bool{
+	"describe": true,
+	"context":  true,
+	"it":       false,
+	"spec":     false,
+	"test":     false,
+}
+
+// funcCallExpType 函数调用表达式的类型，遍历语法树查找冒号调用时使用
+var funcCallExpType = reflect.TypeOf(&ast.FuncCallExp{})
+
+// TestBlockStruct busted风格的测试块，例如 describe("name", function() end) 或是 it("name", function() end)
+type TestBlockStruct struct {
+	FuncName string         // 测试的函数名，例如describe、it
+	Name     string         // 测试块的名称
+	FullName string         // 包含所有外层测试组名称的全名，用空格分割，与busted输出的测试名称一致
+	IsGroup  bool           // 是否为测试组，测试组可以包含子的测试
+	Loc      lexer.Location // 整个调用表达式的位置
+}
+
+// FindFileTestBlocks 查找指定文件中所有busted风格的测试块
+func (a *AllProject) FindFileTestBlocks(strFile string) (testBlockVec []TestBlockStruct) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		log.Error("FindFileTestBlocks error, not find file=%s", strFile)
+		return
+	}
+
+	findBlockTests(fileStruct.FileResult.Block, "", &testBlockVec)
+	return
+}
+
+// findBlockTests 查找代码块中的测试，测试组的函数体内递归查找
+func findBlockTests(block *ast.Block, preName string, testBlockVec *[]TestBlockStruct) {
+	if block == nil {
+		return
+	}
+
+	for _, stat := range block.Stats {
+		switch subStat := stat.(type) {
+		case *ast.DoStat:
+			findBlockTests(subStat.Block, preName, testBlockVec)
+		case *ast.FuncCallStat:
+			testBlock, funcExp, ok := getTestBlock(subStat, preName)
+			if !ok {
+				continue
+			}
+
+			*testBlockVec = append(*testBlockVec, testBlock)
+			if testBlock.IsGroup && funcExp != nil {
+				findBlockTests(funcExp.Block, testBlock.FullName, testBlockVec)
+			}
+		}
+	}
+}
+
+// getTestBlock 判断函数调用是否为测试块，第一个参数为测试的名称，第二个参数为测试的函数
+func getTestBlock(callExp *ast.FuncCallExp, preName string) (testBlock TestBlockStruct, funcExp *ast.FuncDefExp,
+	ok bool) {
+	nameExp, isName := callExp.PrefixExp.(*ast.NameExp)
+	if !isName || callExp.NameExp != nil || len(callExp.Args) == 0 {
+		return
+	}
+
+	isGroup, isTest := bustedTestFuncMap[nameExp.Name]
+	if !isTest {
+		return
+	}
+
+	strExp, isStr := callExp.Args[0].(*ast.StringExp)
+	if !isStr {
+		return
+	}
+
+	if len(callExp.Args) > 1 {
+		funcExp, _ = callExp.Args[1].(*ast.FuncDefExp)
+	}
+
+	fullName := strExp.Str
+	if preName != "" {
+		fullName = preName + " " + strExp.Str
+	}
+
+	testBlock = TestBlockStruct{
+		FuncName: nameExp.Name,
+		Name:     strExp.Str,
+		FullName: fullName,
+		IsGroup:  isGroup,
+		Loc:      callExp.Loc,
+	}
+	return testBlock, funcExp, true
+}
+
+// FindColonCallReferences 查找通过实例冒号调用的方法引用，例如 local c = Counter.new(); c:add(1)
+// 查找引用是按照变量名的路径匹配的，实例变量的类型需要推导，匹配不到。这里找到工程中所有同名的冒号调用，
+// 再查找每一个调用的定义，定义为同一个方法的作为引用返回
+func (a *AllProject) FindColonCallReferences(strFile string, varStruct *common.DefineVarStruct) (findVecs []DefineStruct) {
+	if len(varStruct.StrVec) < 2 {
+		return
+	}
+
+	// 1) 先找到方法的定义
+	methodVar := copyDefineVarStruct(varStruct)
+	methodDefineVecs := a.FindVarDefineInfo(strFile, &methodVar)
+	if len(methodDefineVecs) == 0 {
+		return
+	}
+	methodDefine := methodDefineVecs[0]
+	strMethod := varStruct.StrVec[len(varStruct.StrVec)-1]
+
+	// 2) 所有同名的冒号调用，查找定义是否为这个方法
+	for fileName := range a.allFilesMap {
+		fileStruct := a.getVailidCacheFileStruct(fileName)
+		if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+			continue
+		}
+
+		var callVec []*ast.FuncCallExp
+		findColonCallExps(reflect.ValueOf(fileStruct.FileResult.Block), strMethod, &callVec)
+		for _, callExp := range callVec {
+			callVar := ExpToDefineVarStruct(&ast.TableAccessExp{
+				PrefixExp: callExp.PrefixExp,
+				KeyExp:    callExp.NameExp,
+			})
+			if !callVar.ValidFlag || len(callVar.StrVec) < 2 {
+				continue
+			}
+
+			callVar.ColonFlag = true
+			callVar.PosLine = callExp.NameExp.Loc.StartLine - 1
+			callVar.PosCh = callExp.NameExp.Loc.StartColumn
+			for _, oneDefine := range a.FindVarDefineInfo(fileName, &callVar) {
+				if oneDefine.StrFile == methodDefine.StrFile && oneDefine.Loc.StartLine == methodDefine.Loc.StartLine &&
+					oneDefine.Loc.StartColumn == methodDefine.Loc.StartColumn {
+					findVecs = append(findVecs, DefineStruct{
+						StrFile: fileName,
+						Loc:     callExp.NameExp.Loc,
+					})
+					break
+				}
+			}
+		}
+	}
+
+	return findVecs
+}
+
+// copyDefineVarStruct 拷贝一份查找的变量，查找定义时会修改切分的数据
+func copyDefineVarStruct(varStruct *common.DefineVarStruct) common.DefineVarStruct {
+	newVar := *varStruct
+	newVar.StrVec = append([]string{}, varStruct.StrVec...)
+	newVar.IsFuncVec = append([]bool{}, varStruct.IsFuncVec...)
+	return newVar
+}
+
+// findColonCallExps 遍历语法树，查找所有指定名称的冒号调用
+func findColonCallExps(v reflect.Value, strName string, callVec *[]*ast.FuncCallExp) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+
+		if v.Type() == funcCallExpType && v.CanInterface() {
+			callExp := v.Interface().(*ast.FuncCallExp)
+			if callExp.NameExp != nil && callExp.NameExp.Str == strName {
+				*callVec = append(*callVec, callExp)
+			}
+		}
+		findColonCallExps(v.Elem(), strName, callVec)
+	case reflect.Interface:
+		if !v.IsNil() {
+			findColonCallExps(v.Elem(), strName, callVec)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			findColonCallExps(v.Index(i), strName, callVec)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			findColonCallExps(v.Field(i), strName, callVec)
+		}
+	}
+}
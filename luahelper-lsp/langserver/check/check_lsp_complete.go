@@ -23,16 +23,16 @@ func (a *AllProject) CodeCompleteFile(strFile string, referNameStr string, refer
 	}
 
 	// 如果为框架中引入其他的文件，判断是否要包含文件的后缀名
-	suffixFlag := common.JudgeReferSuffixFlag(referType, referNameStr)
+	suffixFlag := a.conf.JudgeReferSuffixFlag(referType, referNameStr)
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 	mainDir := dirManager.GetMainDir()
 
 	var tipList []common.OneTipFile
 	dirManager.GetAllCompleFile(mainDir, referType, suffixFlag, &tipList)
 	for _, oneTip := range tipList {
 		var strName string
-		if common.GConfig.ReferMatchPathFlag {
+		if a.conf.ReferMatchPathFlag {
 			if !strings.HasPrefix(oneTip.StrName, preStr) {
 				continue
 			}
@@ -244,7 +244,7 @@ func (a *AllProject) gPreComplete(comParam *CommonFuncParam, completeVar *common
 	}
 
 	if lenStrVec == 1 && completeVar.LastEmptyFlag {
-		gFlag := !common.GConfig.GetGVarExtendFlag()
+		gFlag := !a.conf.GetGVarExtendFlag()
 		a.gValueComplete(comParam, completeVar, gFlag, "")
 		return
 	}
@@ -317,6 +317,11 @@ func (a *AllProject) varInfoDeepComplete(symbol *common.Symbol, symList []*commo
 	for _, symbol := range symList {
 		a.getVarInfoCompleteExt(symbol, completeVar.ColonFlag)
 
+		// 原表__index链上的成员也进行补全
+		for _, metaSymbol := range a.getMetaIndexSymbolList(symbol, comParam) {
+			a.getVarInfoCompleteExt(metaSymbol, completeVar.ColonFlag)
+		}
+
 		if isLastFuncFlag {
 			// 切割处理，最后一个是函数，获取这个函数的代码补全
 			a.getFuncReturnCompleteExt(symbol, completeVar.ColonFlag, comParam)
@@ -371,7 +376,7 @@ func (a *AllProject) varInfoDeepComplete(symbol *common.Symbol, symList []*commo
 
 // 查找所有的协议前缀
 func (a *AllProject) systemMoudleComplete(strModule string) bool {
-	oneModule, ok := common.GConfig.SystemModuleTipsMap[strModule]
+	oneModule, ok := a.conf.SystemModuleTipsMap[strModule]
 	if !ok {
 		return false
 	}
@@ -403,7 +408,7 @@ func (a *AllProject) otherPreComplete(comParam *CommonFuncParam, completeVar *co
 
 	// 1) 是否为协议前缀
 	if lenStrVec == 1 && completeVar.LastEmptyFlag {
-		if common.GConfig.IsStrProtocol(strFind) {
+		if a.conf.IsStrProtocol(strFind) {
 			// 为协议前缀，返回所有的返回
 			a.protocolCodeComplete(strFind, comParam.secondProject, comParam.thirdStruct)
 			return
@@ -460,7 +465,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	}
 
 	// 3.3) 把常用见的关键字放入进来
-	for strName := range common.GConfig.CompKeyMap {
+	for strName := range a.conf.CompKeyMap {
 		if completeVar.IgnoreKeyWord {
 			break
 		}
@@ -473,7 +478,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	}
 
 	// 3.4) 把snippet放入进来
-	for strName := range common.GConfig.CompSnippetMap {
+	for strName := range a.conf.CompSnippetMap {
 		if completeVar.IgnoreKeyWord {
 			break
 		}
@@ -489,7 +494,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	a.gValueComplete(comParam, completeVar, false, fileName)
 
 	// 3.5) 把框架中引入的其他文件的方式，函数也包含进来
-	referFrameFiles := common.GConfig.GetFrameReferFiles()
+	referFrameFiles := a.conf.GetFrameReferFiles()
 	for _, strOne := range referFrameFiles {
 		if !common.IsCompleteNeedShow(strOne, completeVar) || a.completeCache.ExistStr(strOne) {
 			continue
@@ -500,7 +505,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	}
 
 	// 3.6） 系统的全局函数放入进来
-	for strName, oneSysFunc := range common.GConfig.SystemTipsMap {
+	for strName, oneSysFunc := range a.conf.SystemTipsMap {
 		if !common.IsCompleteNeedShow(strName, completeVar) || a.completeCache.ExistStr(strName) {
 			continue
 		}
@@ -509,7 +514,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	}
 
 	// 3.7） 把系统的模块也加入进来
-	for strName, oneMudule := range common.GConfig.SystemModuleTipsMap {
+	for strName, oneMudule := range a.conf.SystemModuleTipsMap {
 		if !common.IsCompleteNeedShow(strName, completeVar) || a.completeCache.ExistStr(strName) {
 			continue
 		}
@@ -564,7 +569,7 @@ func (a *AllProject) ClearCompleteCache() {
 func (a *AllProject) getVarCompleteData(varInfo *common.VarInfo, item *common.OneCompleteData) (luaFileStr string) {
 	// 是否为特殊的冒号补全
 	colonFlag := a.completeCache.GetColonFlag()
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 判断这个变量是否关联到了注解类型，如果有提取注解信息
 	symbol := common.GetDefaultSymbol(item.LuaFile, varInfo)
@@ -593,7 +598,7 @@ func (a *AllProject) getVarCompleteData(varInfo *common.VarInfo, item *common.On
 		return
 	}
 
-	item.Detail = varInfo.GetVarTypeDetail()
+	item.Detail = varInfo.GetFieldTypeDetail()
 	expandFlag := false
 	var firstExistMap map[string]string = map[string]string{}
 	if item.Detail == "table" || len(varInfo.SubMaps) > 0 || len(varInfo.ExpandStrMap) > 0 {
@@ -680,7 +685,7 @@ func (a *AllProject) GetCompleteCacheIndexItem(index int) (item common.OneComple
 		return
 	}
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 2) 配置的为变量
 	if item.CacheKind == common.CKindVar {
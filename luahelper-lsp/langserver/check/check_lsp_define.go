@@ -104,7 +104,7 @@ func (a *AllProject) FindOpenFileDefine(strFile string, strOpenFile string) (def
 	}
 
 	// 1) 文件匹配的完整路径
-	strOpenFile = common.GConfig.GetDirManager().GetBestMatchReferFile(strFile, strOpenFile, a.allFilesMap, a.fileIndexInfo)
+	strOpenFile = a.conf.GetDirManager().GetBestMatchReferFile(strFile, strOpenFile, a.allFilesMap, a.fileIndexInfo)
 
 	// 2) 判断是否为直接打开某一个文件
 	if strOpenFile == "" {
@@ -372,18 +372,18 @@ func (a *AllProject) findOldDefineInfo(comParam *CommonFuncParam, varStruct *com
 		}
 
 		strName := varStruct.StrVec[0]
-		gFlag := !common.GConfig.GetGVarExtendFlag()
+		gFlag := !a.conf.GetGVarExtendFlag()
 
 		findVar := a.findGlobalVarDefineInfo(comParam, strName, "", gFlag)
 		return strName, findVar
 	}
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 2) 有前缀，先找到前缀指向的地方
 	// 2) 先判断是否为协议前缀
 	strProPre := ""
-	if len(varStruct.StrVec) >= 2 && common.GConfig.IsStrProtocol(varStruct.StrVec[0]) &&
+	if len(varStruct.StrVec) >= 2 && a.conf.IsStrProtocol(varStruct.StrVec[0]) &&
 		dirManager.IsInDir(comParam.fileResult.Name) && varStruct.StrVec[0] != "" {
 		// 如果为协议前缀，要进行切分
 		strProPre = varStruct.StrVec[0]
@@ -435,6 +435,7 @@ func (a *AllProject) FindVarDefine(strFile string, varStruct *common.DefineVarSt
 		}
 
 		oldSymbol = a.createAnnotateSymbol(findStrName, findVar)
+		a.setCastAnnotateType(findStrName, oldSymbol, comParam)
 	}
 	//调用链中没有函数，走这里
 	if oldSymbol != nil {
@@ -0,0 +1,83 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/log"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 注释中的url，结尾的标点符号不属于url
+var commentURLRegexp = regexp.MustCompile(`https?://[^\s<>"'()\[\]{}]+`)
+
+// DocumentLinkStruct 文件中的链接，指向引用的文件或是注释中的url
+type DocumentLinkStruct struct {
+	Loc     lexer.Location // 链接的位置
+	StrFile string         // 链接指向的文件，为注释中的url时为空
+	URL     string         // 注释中的url
+}
+
+// FindFileDocumentLinks 查找指定文件中的所有链接
+// 引用其他文件的require、dofile、loadfile、import等，以及短注释中的url
+func (a *AllProject) FindFileDocumentLinks(strFile string) (linkVec []DocumentLinkStruct) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		log.Error("FindFileDocumentLinks error, not find file=%s", strFile)
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+
+	// 1) 引用的文件，没有找到文件的引用不生成链接，保留没有找到文件的告警
+	for _, referInfo := range fileResult.ReferVec {
+		if !referInfo.Valid || referInfo.ReferValidStr == "" {
+			continue
+		}
+
+		loc := referInfo.StrLoc
+		if loc.IsInitialLoc() {
+			loc = referInfo.Loc
+		}
+
+		linkVec = append(linkVec, DocumentLinkStruct{
+			Loc:     loc,
+			StrFile: referInfo.ReferValidStr,
+		})
+	}
+
+	// 2) 注释中的url
+	for _, commentInfo := range fileResult.CommentMap {
+		for _, commentLine := range commentInfo.LineVec {
+			linkVec = append(linkVec, getCommentURLLinks(commentLine)...)
+		}
+	}
+
+	sort.Slice(linkVec, func(i, j int) bool {
+		if linkVec[i].Loc.StartLine != linkVec[j].Loc.StartLine {
+			return linkVec[i].Loc.StartLine < linkVec[j].Loc.StartLine
+		}
+		return linkVec[i].Loc.StartColumn < linkVec[j].Loc.StartColumn
+	})
+	return
+}
+
+// getCommentURLLinks 获取一行注释中的所有url，注释的列号为字符的个数
+func getCommentURLLinks(commentLine lexer.CommentLine) (linkVec []DocumentLinkStruct) {
+	for _, index := range commentURLRegexp.FindAllStringIndex(commentLine.Str, -1) {
+		strURL := strings.TrimRight(commentLine.Str[index[0]:index[1]], ".,;:!?")
+		startCol := commentLine.Col + utf8.RuneCountInString(commentLine.Str[:index[0]])
+		linkVec = append(linkVec, DocumentLinkStruct{
+			Loc: lexer.Location{
+				StartLine:   commentLine.Line,
+				StartColumn: startCol,
+				EndLine:     commentLine.Line,
+				EndColumn:   startCol + utf8.RuneCountInString(strURL),
+			},
+			URL: strURL,
+		})
+	}
+
+	return
+}
@@ -2,6 +2,7 @@ package check
 
 import (
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/parser"
 	"luahelper-lsp/langserver/check/results"
 	"luahelper-lsp/langserver/log"
 	"time"
@@ -11,7 +12,7 @@ import (
 // 返回值表示诊断信息是否有变化
 func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (changeDiagnostic bool) {
 	// 0) 清除掉文件的cache
-	common.GConfig.ClearCacheFileMap()
+	a.conf.ClearCacheFileMap()
 
 	// 判断该文件是属于哪些第二阶段的工程
 	belongProjectMap := map[string]struct{}{}
@@ -29,7 +30,7 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 	// 是否有变化
 	changeFlag := false
 	changeDiagnostic = false
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 删除文件的列表
 	deleteFileMap := map[string]struct{}{}
@@ -84,7 +85,7 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 
 	// 有文件新增或删除，重建
 	if handleAllFlag {
-		common.GConfig.RebuildSameFileNameVar(a.allFilesMap)
+		a.conf.RebuildSameFileNameVar(a.allFilesMap)
 	}
 
 	// 2.1) 如果之前的文件有包含错误码为6的错误，这次有新的文件增加，之前的文件，还需要进行扫描下
@@ -137,7 +138,7 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 	changeDiagnostic = true
 
 	// 判断是否要进行特殊的校验
-	if len(a.entryFilesList) == 0 && !common.GConfig.IsSpecialCheck() {
+	if len(a.entryFilesList) == 0 && !a.conf.IsSpecialCheck() {
 		time5 := time.Now()
 
 		if thirdFlag {
@@ -200,12 +201,15 @@ func (a *AllProject) RemoveCacheContent(strFile string) {
 	if ok := a.fileLRUMap.Remove(strFile); ok {
 		log.Debug("RemoveCacheContent ok strFile=%s", strFile)
 	}
+	a.parseSnapshotLRU.Remove(strFile)
 }
 
 // HandleFileChangeAnalysis 代码实时变化时候，进行分析判断是否要保存到cache中
-func (a *AllProject) HandleFileChangeAnalysis(strFile string, content []byte) (errList []common.CheckError) {
-	fileStruct := results.CreateFileStruct(strFile)
-	handleResult, _, _ := a.analysisFirstLuaFile(fileStruct, strFile, content, false, true)
+// changeVec 为这次修改的行范围，用于增量的语法解析，为nil时整体重新解析；只修改了一个顶层函数的函数体时，只重新分析这个函数体
+func (a *AllProject) HandleFileChangeAnalysis(strFile string, content []byte,
+	changeVec []parser.ChangeRange) (errList []common.CheckError) {
+	fileStruct := results.CreateFileStruct(strFile, a.conf)
+	handleResult, _, _ := a.analysisFirstLuaFile(fileStruct, strFile, content, false, true, changeVec)
 	fileStruct.HandleResult = handleResult
 
 	// 分析成功, 插入到cache中
@@ -26,7 +26,7 @@ func (a *AllProject) AnnotateTypeHover(strFile, strLine, strWord string, line, c
 		return
 	}
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 2) 遍历位置信息
 	typeStr, noticeStr, commentStr := annotateast.GetStateLocInfo(annotateState, col)
@@ -111,7 +111,7 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 
 	if symbol == nil && len(varStruct.StrVec) == 1 {
 		// 1) 判断是否为系统的函数提示
-		if flag, str1, str2 := judgetSystemModuleOrFuncHover(varStruct.StrVec[0]); flag {
+		if flag, str1, str2 := a.judgetSystemModuleOrFuncHover(varStruct.StrVec[0]); flag {
 			lableStr = str1
 			docStr = str2
 			docStr = strings.ReplaceAll(docStr, "\n", "  \n")
@@ -121,7 +121,7 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 
 	if symbol == nil && len(varStruct.StrVec) == 2 {
 		// 2) 判断是否为系统的模块函数提示
-		if flag, str1, str2 := judgetSystemModuleMemHover(varStruct.StrVec[0], varStruct.StrVec[1]); flag {
+		if flag, str1, str2 := a.judgetSystemModuleMemHover(varStruct.StrVec[0], varStruct.StrVec[1]); flag {
 			lableStr = str1
 			docStr = str2
 			docStr = strings.ReplaceAll(docStr, "\n", "  \n")
@@ -135,7 +135,7 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 	}
 
 	if symbol != nil && len(findList) == 0 {
-		lableStr = getVarInfoExpandStrHover(symbol.VarInfo, varStruct.StrVec, varStruct.IsFuncVec, varStruct.Str)
+		lableStr = a.getVarInfoExpandStrHover(symbol.VarInfo, varStruct.StrVec, varStruct.IsFuncVec, varStruct.Str)
 		if lableStr != "" {
 			return
 		}
@@ -157,7 +157,15 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 	strLastType := ""
 	strPreFirst := ""
 	preFirstFlag := false
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
+
+	// 变量为没有注解的函数调用推导出来的，函数有的return返回nil，类型中保留nil
+	mayNilFlag := false
+	for _, oneSymbol := range findList {
+		if oneSymbol != nil && oneSymbol.MayNilFlag {
+			mayNilFlag = true
+		}
+	}
 
 	for _, oneSymbol := range findList {
 		strType, strLabel1, strDoc1, strPre, flag := a.getVarHoverInfo(strFile, oneSymbol, varStruct)
@@ -167,6 +175,9 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 		}
 
 		if flag {
+			if mayNilFlag {
+				strLabel1 = appendNilTypeStr(strLabel1)
+			}
 			lableStr = strLabel1
 			lableStr = strPreFirst + strLabel1
 			docStr = strDoc1
@@ -185,12 +196,25 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 		}
 	}
 
+	if mayNilFlag && strLastType != "" && strLastType != "any" {
+		strLastBefore = appendNilTypeStr(strLastBefore)
+	}
+
 	lableStr = strLastBefore
 	lableStr = strPreFirst + strLastBefore
 	docStr = strOneComment
 	return
 }
 
+// appendNilTypeStr 变量可能为nil时，类型后面追加nil，例如 p : table|nil
+func appendNilTypeStr(strLabel string) string {
+	if strings.HasSuffix(strLabel, "nil") {
+		return strLabel
+	}
+
+	return strLabel + "|nil"
+}
+
 func (a *AllProject) getNodefineMapVar(strFile string, varStruct *common.DefineVarStruct) (symbol *common.Symbol) {
 	// 1）先查找该文件是否存在
 	fileStruct := a.getVailidCacheFileStruct(strFile)
@@ -252,7 +276,7 @@ func (a *AllProject) getVarHoverInfo(strFile string, symbol *common.Symbol, varS
 	if symbol.VarInfo.ReferInfo != nil {
 		strType = symbol.VarInfo.ReferInfo.GetReferComment()
 	} else {
-		strType = symbol.VarInfo.GetVarTypeDetail()
+		strType = symbol.VarInfo.GetFieldTypeDetail()
 		// 判断是否指向的一个table，如果是展开table的具体内容
 		if strType == "table" || len(symbol.VarInfo.SubMaps) > 0 || len(symbol.VarInfo.ExpandStrMap) > 0 {
 			strType, _ = a.expandTableHover(symbol)
@@ -263,7 +287,19 @@ func (a *AllProject) getVarHoverInfo(strFile string, symbol *common.Symbol, varS
 			if symbol.VarInfo.ExtraGlobal == nil && !symbol.VarInfo.IsMemFlag {
 				strPre = "local "
 			}
-			strFunc := a.getFuncShowStr(symbol.VarInfo, varStruct.StrVec[len(varStruct.StrVec)-1], true, false, true, true)
+			strFuncName := varStruct.StrVec[len(varStruct.StrVec)-1]
+			colonFlag := false
+
+			// moocscript class中定义的函数，展示所属的类，例如 Animal:speak()
+			if strings.HasSuffix(referFunc.FileName, ".mooc") && referFunc.ClassName != "" && referFunc.FuncName != "" {
+				if referFunc.IsColon {
+					strFuncName = referFunc.ClassName + ":" + referFunc.FuncName
+					colonFlag = true
+				} else {
+					strFuncName = referFunc.ClassName + "." + referFunc.FuncName
+				}
+			}
+			strFunc := a.getFuncShowStr(symbol.VarInfo, strFuncName, true, colonFlag, true, true)
 			strType = "function " + strFunc
 		}
 	}
@@ -284,15 +320,15 @@ func (a *AllProject) getVarHoverInfo(strFile string, symbol *common.Symbol, varS
 }
 
 // 判断变量是否直接为系统模块或函数的hover
-func judgetSystemModuleOrFuncHover(strName string) (flag bool, labStr, docStr string) {
-	if oneSystemTip, ok := common.GConfig.SystemTipsMap[strName]; ok {
+func (a *AllProject) judgetSystemModuleOrFuncHover(strName string) (flag bool, labStr, docStr string) {
+	if oneSystemTip, ok := a.conf.SystemTipsMap[strName]; ok {
 		flag = true
 		labStr = oneSystemTip.Detail
 		docStr = oneSystemTip.Documentation
 		return
 	}
 
-	if oneMouleInfo, ok := common.GConfig.SystemModuleTipsMap[strName]; ok {
+	if oneMouleInfo, ok := a.conf.SystemModuleTipsMap[strName]; ok {
 		flag = true
 		labStr = oneMouleInfo.Detail
 		docStr = oneMouleInfo.Documentation
@@ -303,8 +339,8 @@ func judgetSystemModuleOrFuncHover(strName string) (flag bool, labStr, docStr st
 }
 
 // 判断是否为系统的模块中的成员hover
-func judgetSystemModuleMemHover(strName string, strKey string) (flag bool, lableStr, docStr string) {
-	if oneMouleInfo, ok := common.GConfig.SystemModuleTipsMap[strName]; ok {
+func (a *AllProject) judgetSystemModuleMemHover(strName string, strKey string) (flag bool, lableStr, docStr string) {
+	if oneMouleInfo, ok := a.conf.SystemModuleTipsMap[strName]; ok {
 		flag = true
 		if oneSystemTip, ok1 := oneMouleInfo.ModuleFuncMap[strKey]; ok1 {
 			lableStr = oneSystemTip.Detail
@@ -340,6 +376,7 @@ func (a *AllProject) findVarDefineForHover(strFile string, varStruct *common.Def
 		}
 
 		oldSymbol = a.createAnnotateSymbol(findStrName, findVar)
+		a.setCastAnnotateType(findStrName, oldSymbol, comParam)
 	}
 	//调用链中没有函数，走这里
 	if oldSymbol != nil {
@@ -364,18 +401,18 @@ func (a *AllProject) findOldDefineInfoForHover(comParam *CommonFuncParam, varStr
 		}
 
 		strName := varStruct.StrVec[0]
-		gFlag := !common.GConfig.GetGVarExtendFlag()
+		gFlag := !a.conf.GetGVarExtendFlag()
 
 		findVar := a.findGlobalVarDefineInfo(comParam, strName, "", gFlag)
 		return strName, findVar
 	}
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 
 	// 2) 有前缀，先找到前缀指向的地方
 	// 2) 先判断是否为协议前缀
 	strProPre := ""
-	if len(varStruct.StrVec) >= 2 && common.GConfig.IsStrProtocol(varStruct.StrVec[0]) &&
+	if len(varStruct.StrVec) >= 2 && a.conf.IsStrProtocol(varStruct.StrVec[0]) &&
 		dirManager.IsInDir(comParam.fileResult.Name) && varStruct.StrVec[0] != "" {
 		// 如果为协议前缀，要进行切分  这里把协议截断了
 		// strProPre = varStruct.StrVec[0]
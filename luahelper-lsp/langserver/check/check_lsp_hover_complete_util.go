@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"luahelper-lsp/langserver/check/annotation/annotateast"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
 	"sort"
 	"strings"
 )
@@ -11,15 +12,15 @@ import (
 type mapEntryHandler func(string, string)
 
 // 按字母顺序遍历map
-func traverseMapInStringOrder(params map[string]string, handler mapEntryHandler) {
+func (a *AllProject) traverseMapInStringOrder(params map[string]string, handler mapEntryHandler) {
 	keys := make([]string, 0)
 	for k := range params {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 	for index, k := range keys {
-		if index == common.GConfig.PreviewFieldsNum {
-			strMore := fmt.Sprintf("...(+%d)", len(keys)-common.GConfig.PreviewFieldsNum)
+		if index == a.conf.PreviewFieldsNum {
+			strMore := fmt.Sprintf("...(+%d)", len(keys)-a.conf.PreviewFieldsNum)
 			handler(k, strMore)
 			break
 		} else {
@@ -69,7 +70,7 @@ func (a *AllProject) completeAnnotatTypeStr(astType annotateast.Type, fileName s
 		a.convertClassInfoToHovers(oneClass, existMap)
 	}
 
-	traverseMapInStringOrder(existMap, func(key string, value string) {
+	a.traverseMapInStringOrder(existMap, func(key string, value string) {
 		str = str + "\t" + value + "\n"
 	})
 
@@ -83,7 +84,7 @@ func (a *AllProject) getClassFieldStr(classInfo *common.OneClassInfo) (str strin
 	str = " = {\n"
 	a.convertClassInfoToHovers(classInfo, existMap)
 
-	traverseMapInStringOrder(existMap, func(key string, value string) {
+	a.traverseMapInStringOrder(existMap, func(key string, value string) {
 		str = str + "\t" + value + "\n"
 	})
 
@@ -168,7 +169,7 @@ func (a *AllProject) getCompleteExpandInfo(item *common.OneCompleteData) (luaFil
 	compStruct := cache.GetCompleteVar()
 	tempVec := compStruct.StrVec
 	tempVec = append(tempVec, item.Label)
-	str := getVarInfoExpandStrHover(item.ExpandVarInfo, tempVec, compStruct.IsFuncVec, "")
+	str := a.getVarInfoExpandStrHover(item.ExpandVarInfo, tempVec, compStruct.IsFuncVec, "")
 	if str != "" {
 		item.Detail = str
 	}
@@ -177,7 +178,7 @@ func (a *AllProject) getCompleteExpandInfo(item *common.OneCompleteData) (luaFil
 }
 
 // 只获取expandStrMap的hover
-func getVarInfoExpandStrHover(varInfo *common.VarInfo, inputVec []string, inputFuncVec []bool, strPre string) (str string) {
+func (a *AllProject) getVarInfoExpandStrHover(varInfo *common.VarInfo, inputVec []string, inputFuncVec []bool, strPre string) (str string) {
 	if varInfo == nil || varInfo.ExpandStrMap == nil {
 		return
 	}
@@ -216,7 +217,7 @@ func getVarInfoExpandStrHover(varInfo *common.VarInfo, inputVec []string, inputF
 		str = " table = {\n"
 	}
 
-	traverseMapInStringOrder(existMap, func(key string, value string) {
+	a.traverseMapInStringOrder(existMap, func(key string, value string) {
 		str = str + "\t" + value + "\n"
 	})
 
@@ -226,7 +227,7 @@ func getVarInfoExpandStrHover(varInfo *common.VarInfo, inputVec []string, inputF
 
 // 查看varInfo详细的类型，递归查找
 func (a *AllProject) GetVarRelateTypeStr(varInfo *common.VarInfo) (strType string) {
-	strType = varInfo.GetVarTypeDetail()
+	strType = varInfo.GetFieldTypeDetail()
 
 	if varInfo.ReferFunc != nil {
 		//strType = varInfo.ReferFunc.GetFuncCompleteStr("function", true, false)
@@ -330,6 +331,49 @@ func (a *AllProject) getVarInfoMapStr(varInfo *common.VarInfo, existMap map[stri
 	}
 }
 
+// table的结构类型单行显示时，最多的成员数量，超过了按多行展开显示
+const maxShapeFieldsNum = 6
+
+// getShapeFieldTypeVec 获取table成员的类型，用于构造table的结构类型
+func (a *AllProject) getShapeFieldTypeVec(subVar *common.VarInfo) []string {
+	if len(subVar.AssignTypeVec) > 0 {
+		return subVar.GetFieldTypeVec()
+	}
+
+	// 结构类型中只显示成员的类型，去掉常量的值，例如 x = 1 显示为 x: number
+	strType := a.GetVarRelateTypeStr(subVar)
+	if strings.Contains(strType, "\n") {
+		strType = "table"
+	} else if index := strings.Index(strType, " = "); index > 0 && subVar.ReferFunc == nil {
+		strType = strType[:index]
+	}
+
+	return []string{strType}
+}
+
+// getVarTableShapeStr 获取table变量单行显示的结构类型，成员较多、成员有注释或是有未赋值的成员时返回空，按多行展开显示
+func (a *AllProject) getVarTableShapeStr(varInfo *common.VarInfo) string {
+	if len(varInfo.SubMaps) > maxShapeFieldsNum {
+		return ""
+	}
+
+	// 只是读取过，没有赋值的成员，按多行展开显示
+	for strExpand := range varInfo.ExpandStrMap {
+		strVec := strings.Split(strExpand, ".")
+		if !varInfo.IsExistMember(strVec[0]) {
+			return ""
+		}
+	}
+
+	for _, subVar := range varInfo.SubMaps {
+		if a.GetLineComment(subVar.FileName, subVar.Loc.StartLine) != "" {
+			return ""
+		}
+	}
+
+	return varInfo.GetTableShape(a.getShapeFieldTypeVec).String()
+}
+
 func isAllClassDefault(classList []*common.OneClassInfo) bool {
 	for _, oneClass := range classList {
 		if oneClass.ClassState != nil && !isDefaultType(oneClass.ClassState.Name) {
@@ -382,10 +426,16 @@ func (a *AllProject) expandTableHover(symbol *common.Symbol) (str string, existM
 		if symbol.VarInfo == nil || (len(symbol.VarInfo.SubMaps) == 0 && len(symbol.VarInfo.ExpandStrMap) == 0) {
 			return "table = { }", existMap
 		}
+
+		// 没有注解的table，成员较少时直接显示推导出来的结构类型，例如 { x: number, name: string }
+		if shapeStr := a.getVarTableShapeStr(symbol.VarInfo); shapeStr != "" {
+			a.getVarInfoMapStr(symbol.VarInfo, existMap)
+			return shapeStr, existMap
+		}
 	}
 
 	a.getVarInfoMapStr(symbol.VarInfo, existMap)
-	traverseMapInStringOrder(existMap, func(key string, value string) {
+	a.traverseMapInStringOrder(existMap, func(key string, value string) {
 		str = str + "\t" + value + "\n"
 	})
 
@@ -503,7 +553,7 @@ func (a *AllProject) mergeTwoExistMap(symbol *common.Symbol, fristStr string, fr
 		}
 	}
 
-	traverseMapInStringOrder(secondMap, func(key string, value string) {
+	a.traverseMapInStringOrder(secondMap, func(key string, value string) {
 		mergeStr = mergeStr + "\t" + value + "\n"
 	})
 
@@ -762,20 +812,70 @@ func (a *AllProject) getFuncShowStr(varInfo *common.VarInfo, funcName string, pa
 
 	oldLen := len(resultList)
 
+	// 没有注解的返回值，多处return推导出来的类型取并集，例如 number|string
+	// 返回nil或是这处return返回值的个数少时，并集中保留nil，放在最后，例如 table|nil
+	var inferList [][]string
+	var nilFlagList []bool
+	maxLen := 0
 	for _, oneReturnInfo := range fun.ReturnVecs {
-		for i, oneReturn := range oneReturnInfo.ReturnVarVec {
-			if i < oldLen {
+		if len(oneReturnInfo.ReturnVarVec) > maxLen {
+			maxLen = len(oneReturnInfo.ReturnVarVec)
+		}
+	}
+	for len(inferList) < maxLen-oldLen {
+		inferList = append(inferList, []string{})
+		nilFlagList = append(nilFlagList, false)
+	}
+
+	for _, oneReturnInfo := range fun.ReturnVecs {
+		returnLen := len(oneReturnInfo.ReturnVarVec)
+		for i := oldLen; i < maxLen; i++ {
+			if i >= returnLen {
+				if returnLen == 0 || !isMultiResultExp(oneReturnInfo.ReturnVarVec[returnLen-1].ReturnExp) {
+					nilFlagList[i-oldLen] = true
+				}
+				continue
+			}
+
+			oneReturn := oneReturnInfo.ReturnVarVec[i]
+			if _, ok := oneReturn.ReturnExp.(*ast.NilExp); ok {
+				nilFlagList[i-oldLen] = true
 				continue
 			}
 
 			strType := a.getOneFuncReturnStr(varInfo.FileName, oneReturn)
-			if len(resultList) > i {
-				if strType != "any" {
-					resultList[i] = strType
-				}
+			if strType == "any" || isStrInList(strType, inferList[i-oldLen]) {
+				continue
+			}
+			inferList[i-oldLen] = append(inferList[i-oldLen], strType)
+		}
+	}
+
+	for i, oneInfer := range inferList {
+		if nilFlagList[i] && len(oneInfer) > 0 {
+			oneInfer = append(oneInfer, "nil")
+		}
+
+		if len(oneInfer) == 0 {
+			if nilFlagList[i] {
+				resultList = append(resultList, "nil")
 			} else {
-				resultList = append(resultList, strType)
+				resultList = append(resultList, "any")
+			}
+		} else if len(oneInfer) == 1 {
+			resultList = append(resultList, oneInfer[0])
+		} else {
+			// 多种类型时，去掉常量的值，例如 string = "a" 只保留string
+			var unionList []string
+			for _, oneStr := range oneInfer {
+				if index := strings.Index(oneStr, " = "); index > 0 {
+					oneStr = oneStr[:index]
+				}
+				if !isStrInList(oneStr, unionList) {
+					unionList = append(unionList, oneStr)
+				}
 			}
+			resultList = append(resultList, strings.Join(unionList, "|"))
 		}
 	}
 
@@ -796,6 +896,27 @@ func (a *AllProject) getFuncShowStr(varInfo *common.VarInfo, funcName string, pa
 	return funcName
 }
 
+// isMultiResultExp 判断表达式是否可能返回多个值，例如函数调用或是...
+func isMultiResultExp(exp ast.Exp) bool {
+	switch exp.(type) {
+	case *ast.FuncCallExp, *ast.VarargExp:
+		return true
+	}
+
+	return false
+}
+
+// isStrInList 判断字符串是否在列表中
+func isStrInList(str string, strList []string) bool {
+	for _, oneStr := range strList {
+		if oneStr == str {
+			return true
+		}
+	}
+
+	return false
+}
+
 // getVarCompleteExt 获取变量关联的所有子成员信息，用于代码补全
 // excludeMap 表示这次因为冒号语法剔除掉的字符串
 // colonFlag 表示是否获取冒号成员
@@ -812,7 +933,7 @@ func (a *AllProject) getVarCompleteExt(fileName string, varInfo *common.VarInfo,
 		}
 
 		// 对.的用法特殊提，能够提示：函数
-		getJugdeColon := common.GConfig.GetJudgeColonFlag()
+		getJugdeColon := a.conf.GetJudgeColonFlag()
 		if getJugdeColon != 0 && !colonFlag && (oneVar.ReferFunc != nil && oneVar.ReferFunc.IsColon) {
 			a.completeCache.InsertExcludeStr(strName)
 			continue
@@ -23,7 +23,7 @@ func (a *AllProject) handleFindferences(r *results.ReferenceFileResult) {
 	time1 := time.Now()
 
 	// 创建第四轮遍历的包裹对象
-	analysis := analysis.CreateAnalysis(results.CheckTermFour, r.StrFile)
+	analysis := analysis.CreateAnalysis(results.CheckTermFour, r.StrFile, a.conf)
 	analysis.ReferenceResult = r
 	analysis.Projects = a
 	analysis.HandleTermTraverseAST(results.CheckTermFour, fileResult, nil)
@@ -57,7 +57,7 @@ func (a *AllProject) FindReferences(strFile string, varStruct *common.DefineVarS
 	oldLocVar := oldInfoFlie.VarInfo
 
 	ignoreDefineLoc := defineLoc
-	if (checkSrc == common.CRSReference && common.GConfig.ReferenceDefineFlag) ||
+	if (checkSrc == common.CRSReference && a.conf.ReferenceDefineFlag) ||
 		(checkSrc == common.CRSHighlight && strFile == luaInFile) ||
 		(checkSrc == common.CRSRename && isWhole) {
 		findVecs = append(findVecs, DefineStruct{
@@ -109,7 +109,7 @@ func (a *AllProject) FindReferences(strFile string, varStruct *common.DefineVarS
 	}
 
 	// 查找的为局部变量，只在当前文件查找，不需要利用多协程。高亮同理
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 	if !allFileFind || checkSrc == common.CRSHighlight || !dirManager.IsInDir(strFile) {
 		// 如果为局部变量，只在当前文件查找
 		inFile := luaInFile //变量定义的文件
@@ -265,7 +265,8 @@ func handleAllFilesReference(fileList []string, allProject *AllProject, referenc
 		chs[i] = make(chan FourFileChan)
 		selectCase[i].Dir = reflect.SelectRecv
 		selectCase[i].Chan = reflect.ValueOf(chs[i])
-		go GoRoutineFourFile(chs[i])
+		ch := chs[i]
+		log.Go(func() { GoRoutineFourFile(ch) })
 	}
 	//初始化协程
 	for i := 0; i < corNum; i++ {
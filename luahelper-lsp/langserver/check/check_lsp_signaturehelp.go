@@ -274,7 +274,7 @@ func (a *AllProject) systemFuncConver(oneSystemTips *common.SystemNoticeInfo) (s
 // 判断是否为系统函数sigatrueHelp
 func (a *AllProject) judgetSystemFuncSignature(strName string) (flag bool,
 	sinatureInfo common.SignatureHelpInfo, paramInfo []common.SignatureHelpInfo) {
-	if oneSystemTips, ok := common.GConfig.SystemTipsMap[strName]; ok {
+	if oneSystemTips, ok := a.conf.SystemTipsMap[strName]; ok {
 		flag = true
 		sinatureInfo, paramInfo = a.systemFuncConver(&oneSystemTips)
 	}
@@ -285,7 +285,7 @@ func (a *AllProject) judgetSystemFuncSignature(strName string) (flag bool,
 // 判断是否为系统模块中的成员函数sigatrueHelp
 func (a *AllProject) judgetSystemModuleFuncSigatrue(strName string, strKey string) (flag bool,
 	sinatureInfo common.SignatureHelpInfo, paramInfo []common.SignatureHelpInfo) {
-	oneMouleInfo, ok := common.GConfig.SystemModuleTipsMap[strName]
+	oneMouleInfo, ok := a.conf.SystemModuleTipsMap[strName]
 	if !ok {
 		return
 	}
@@ -3,8 +3,10 @@ package check
 import (
 	"bytes"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
 	"luahelper-lsp/langserver/check/results"
 	"luahelper-lsp/langserver/log"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"sort"
@@ -13,7 +15,7 @@ import (
 )
 
 const (
-	// 最终返回的最大结果
+	// 默认最终返回的最大结果
 	maxSymbols = 200
 	// 最低分
 	minScore = -1000
@@ -38,8 +40,12 @@ func (a *AllProject) FindFileAllSymbol(strFile string) (symbolVec []common.FileS
 	annotateSymbolVec := a.GetAnnotateFileSymbolStruct(strFile)
 
 	// 3) 先排序
-	sort.Sort(commonSymbolSlice(symbolVec))
-	sort.Sort(commonSymbolSlice(annotateSymbolVec))
+	sortFileSymbolVec(symbolVec)
+	sortFileSymbolVec(annotateSymbolVec)
+
+	// 4) 注解为枚举的table与关联了注解class的table，修改为对应的符号类型
+	setEnumSymbolKind(fileStruct, symbolVec)
+	annotateSymbolVec = mergeAnnotateClassSymbol(symbolVec, annotateSymbolVec)
 
 	if strings.HasSuffix(strFile, ".mooc") {
 		symbolVec = appendFileSymbolStruct(symbolVec, annotateSymbolVec)
@@ -49,6 +55,97 @@ func (a *AllProject) FindFileAllSymbol(strFile string) (symbolVec []common.FileS
 	return
 }
 
+// sortFileSymbolVec 符号按行号排序，子符号也一起排序
+func sortFileSymbolVec(symbolVec []common.FileSymbolStruct) {
+	sort.Stable(commonSymbolSlice(symbolVec))
+	for i := range symbolVec {
+		sortFileSymbolVec(symbolVec[i].Children)
+	}
+}
+
+// setEnumSymbolKind 注解了枚举的table，符号类型为枚举，子成员为枚举值
+func setEnumSymbolKind(fileStruct *results.FileStruct, symbolVec []common.FileSymbolStruct) {
+	for i := range symbolVec {
+		oneSymbol := &symbolVec[i]
+		if oneSymbol.VarInfo == nil || len(oneSymbol.Children) == 0 {
+			continue
+		}
+
+		if !fileStruct.IsVarAnnotateEnum(oneSymbol.VarInfo) {
+			continue
+		}
+
+		oneSymbol.Kind = common.IKEnum
+		for j := range oneSymbol.Children {
+			if oneSymbol.Children[j].Kind == common.IKField {
+				oneSymbol.Children[j].Kind = common.IKEnumMember
+			}
+		}
+	}
+}
+
+// mergeAnnotateClassSymbol 注解的class关联了变量时，合并到变量的符号上，注解的field作为子成员
+// 返回没有合并的注解符号
+func mergeAnnotateClassSymbol(symbolVec []common.FileSymbolStruct,
+	annotateSymbolVec []common.FileSymbolStruct) (leftSymbolVec []common.FileSymbolStruct) {
+	for _, annotateSymbol := range annotateSymbolVec {
+		if annotateSymbol.Kind != common.IKAnnotateClass || annotateSymbol.VarInfo == nil {
+			leftSymbolVec = append(leftSymbolVec, annotateSymbol)
+			continue
+		}
+
+		varSymbol := findVarInfoSymbol(symbolVec, annotateSymbol.VarInfo)
+		if varSymbol == nil {
+			leftSymbolVec = append(leftSymbolVec, annotateSymbol)
+			continue
+		}
+
+		varSymbol.Kind = common.IKClass
+		varSymbol.Detail = annotateSymbol.Detail
+
+		// 变量中没有赋值的field，才加入
+		childNameMap := map[string]bool{}
+		for _, child := range varSymbol.Children {
+			childNameMap[child.Name] = true
+		}
+		for _, field := range annotateSymbol.Children {
+			if !childNameMap[field.Name] {
+				varSymbol.Children = append(varSymbol.Children, field)
+			}
+		}
+		sortFileSymbolVec(varSymbol.Children)
+
+		// ---@class 与 ---@field 在变量的上面，变量符号的范围扩展到整个注解块
+		extendSymbolStartLoc(varSymbol, annotateSymbol.Loc)
+		for _, child := range varSymbol.Children {
+			extendSymbolStartLoc(varSymbol, child.Loc)
+		}
+	}
+
+	return leftSymbolVec
+}
+
+// extendSymbolStartLoc 符号的开始位置往前扩展，包含loc所在的行
+func extendSymbolStartLoc(oneSymbol *common.FileSymbolStruct, loc lexer.Location) {
+	if loc.StartLine <= 0 || loc.StartLine >= oneSymbol.Loc.StartLine {
+		return
+	}
+
+	oneSymbol.Loc.StartLine = loc.StartLine
+	oneSymbol.Loc.StartColumn = 0
+}
+
+// findVarInfoSymbol 查找变量对应的顶层符号
+func findVarInfoSymbol(symbolVec []common.FileSymbolStruct, varInfo *common.VarInfo) *common.FileSymbolStruct {
+	for i := range symbolVec {
+		if symbolVec[i].VarInfo == varInfo {
+			return &symbolVec[i]
+		}
+	}
+
+	return nil
+}
+
 // 符号排序考虑 children
 func appendFileSymbolStruct(s1 []common.FileSymbolStruct, s2 []common.FileSymbolStruct) []common.FileSymbolStruct {
 	s := []common.FileSymbolStruct{}
@@ -87,10 +184,15 @@ func appendFileSymbolStruct(s1 []common.FileSymbolStruct, s2 []common.FileSymbol
 	return s
 }
 
-// FindWorkspaceAllSymbol 查找工程内所有全局符号
-func (a *AllProject) FindWorkspaceAllSymbol(strContent string) (symbolVec []common.FileSymbolStruct) {
+// FindWorkspaceAllSymbol 模糊查找工程内所有的符号，按匹配的分数排序，maxNum为返回的最大数量，小于等于0时为默认的数量
+func (a *AllProject) FindWorkspaceAllSymbol(strContent string, maxNum int) (symbolVec []common.FileSymbolStruct) {
+	if maxNum <= 0 {
+		maxNum = maxSymbols
+	}
+
 	resultSort := &resultSorter{
 		results: make([]scoredSymbol, 0),
+		maxNum:  maxNum,
 	}
 
 	// 获取需要查询的文件列表
@@ -102,12 +204,14 @@ func (a *AllProject) FindWorkspaceAllSymbol(strContent string) (symbolVec []comm
 		fileList = append(fileList, fileName)
 	}
 
-	handleAllFilesSymbols(strContent, a, resultSort, fileList)
+	if len(fileList) > 0 {
+		handleAllFilesSymbols(strContent, a, resultSort, fileList)
+	}
 
 	sort.Sort(resultSort)
 	log.Debug("handle workspace symbols, query all %d files, find all %d symbols", len(a.fileStructMap), len(resultSort.results))
-	if len(resultSort.results) > maxSymbols {
-		resultSort.results = resultSort.results[:maxSymbols]
+	if len(resultSort.results) > maxNum {
+		resultSort.results = resultSort.results[:maxNum]
 	}
 
 	for _, oneResult := range resultSort.results {
@@ -547,8 +651,10 @@ func (m *Matcher) poorMatch() bool {
 // resultSorter is a utility struct for collecting, filtering, and
 // sorting symbol results.
 type resultSorter struct {
-	m       *Matcher
-	results []scoredSymbol
+	m          *Matcher
+	results    []scoredSymbol
+	maxNum     int                 // 返回的最大数量
+	fileStruct *results.FileStruct // 当前查找的文件，用于判断变量是否注解为枚举
 }
 
 // scoredSymbol is a symbol with an attached search relevancy score.
@@ -563,7 +669,21 @@ type scoredSymbol struct {
  */
 func (rs *resultSorter) Len() int { return len(rs.results) }
 func (rs *resultSorter) Less(i, j int) bool {
-	return rs.results[i].score > rs.results[j].score
+	// 分数相同时，名称短的优先，再按名称、文件与行号排序，保证结果稳定
+	si, sj := rs.results[i], rs.results[j]
+	if si.score != sj.score {
+		return si.score > sj.score
+	}
+	if len(si.fileSymbol.Name) != len(sj.fileSymbol.Name) {
+		return len(si.fileSymbol.Name) < len(sj.fileSymbol.Name)
+	}
+	if si.fileSymbol.Name != sj.fileSymbol.Name {
+		return si.fileSymbol.Name < sj.fileSymbol.Name
+	}
+	if si.fileSymbol.FileName != sj.fileSymbol.FileName {
+		return si.fileSymbol.FileName < sj.fileSymbol.FileName
+	}
+	return si.fileSymbol.Loc.StartLine < sj.fileSymbol.Loc.StartLine
 }
 func (rs *resultSorter) Swap(i, j int) {
 	rs.results[i], rs.results[j] = rs.results[j], rs.results[i]
@@ -586,15 +706,49 @@ func addSymbolInfo(oneSymbol *common.FileSymbolStruct, varInfo *common.VarInfo,
 			oneSymbol.ContainerName = "local"
 		}
 	}
+}
+
+// getSymbolKind 获取变量符号的类型，parentVar为成员变量所属的变量，不是成员时为nil
+func (rs *resultSorter) getSymbolKind(varInfo *common.VarInfo, parentVar *common.VarInfo) common.ItemKind {
+	if varInfo.IsClass {
+		return common.IKClass
+	}
 
 	if varInfo.ReferFunc != nil {
-		oneSymbol.Kind = common.IKFunction
+		if varInfo.ReferFunc.IsColon {
+			return common.IKMethod
+		}
+		return common.IKFunction
+	}
+
+	if rs.isEnumVar(varInfo) {
+		return common.IKEnum
 	}
+
+	if parentVar != nil {
+		if rs.isEnumVar(parentVar) {
+			return common.IKEnumMember
+		}
+		return common.IKField
+	}
+
+	return common.IKVariable
+}
+
+// isEnumVar 判断变量是否为注解了枚举的table
+func (rs *resultSorter) isEnumVar(varInfo *common.VarInfo) bool {
+	if rs.fileStruct == nil || varInfo.VarType != common.LuaTypeTable || varInfo.FileName != rs.fileStruct.FileResult.Name {
+		return false
+	}
+
+	return rs.fileStruct.IsVarAnnotateEnum(varInfo)
 }
 
 // collect is a thread-safe method that will record the passed-in
 // symbol in the list of results if its score > 0.
-func (rs *resultSorter) collect(strName, fileName, prefix string, isglobal bool, varInfo *common.VarInfo) {
+// parentVar 为成员变量所属的变量，不是成员时为nil
+func (rs *resultSorter) collect(strName, fileName, prefix string, isglobal bool, varInfo *common.VarInfo,
+	parentVar *common.VarInfo) {
 
 	if prefix != "" {
 		strName = prefix + "." + strName
@@ -602,13 +756,13 @@ func (rs *resultSorter) collect(strName, fileName, prefix string, isglobal bool,
 
 	score := rs.m.Score(strName)
 
-	if score < 0 {
+	if score <= 0 {
 		return
 	}
 
 	oneSymbol := &common.FileSymbolStruct{
 		Name:     strName,
-		Kind:     common.IKVariable,
+		Kind:     rs.getSymbolKind(varInfo, parentVar),
 		Loc:      varInfo.Loc,
 		FileName: fileName,
 	}
@@ -625,13 +779,13 @@ func (rs *resultSorter) getQuerySymbols(fileResult *results.FileResult) {
 	// 查找全局变量
 	for strName, oneVar := range fileResult.GlobalMaps {
 		if oneVar.ExtraGlobal.GFlag {
-			rs.collect(strName, fileName, "_G", true, oneVar)
+			rs.collect(strName, fileName, "_G", true, oneVar, nil)
 			// 若前缀包含 且是表结构，则返回所有信息， 否则按照条件进行查找
 			// rs.getVarmapsSymbols(oneVar.SubMaps, fileName, "_G", false, true, false)
 		} else {
-			rs.collect(strName, fileName, "", true, oneVar)
+			rs.collect(strName, fileName, "", true, oneVar, nil)
 			// 若前缀包含 且是表结构，则返回所有信息， 否则按照条件进行查找
-			rs.getVarmapsSymbols(oneVar.SubMaps, fileName, strName, true, false)
+			rs.getVarmapsSymbols(oneVar, fileName, strName, true, false)
 		}
 	}
 	// 搜索协议前缀符号
@@ -676,7 +830,7 @@ func (rs *resultSorter) getProtocolMapsSymbols(protocolMaps map[string]*common.V
 	}
 	for strName, oneVar := range protocolMaps {
 		for {
-			rs.collect(strName, fileName, oneVar.ExtraGlobal.StrProPre, true, oneVar)
+			rs.collect(strName, fileName, oneVar.ExtraGlobal.StrProPre, true, oneVar, nil)
 			if oneVar.ExtraGlobal.Prev == nil {
 				break
 			}
@@ -700,28 +854,47 @@ func (rs *resultSorter) getLocVarMapsSymbols(locVarMap map[string]*common.VarInf
 			if onlyFunc && vars.SubMaps == nil && vars.ReferFunc == nil {
 				continue
 			}
-			rs.collect(strName, fileName, "", false, vars)
+			rs.collect(strName, fileName, "", false, vars, nil)
 
 			// 获取submap下的符号
-			rs.getVarmapsSymbols(vars.SubMaps, fileName, strName, false, onlyFunc)
+			rs.getVarmapsSymbols(vars, fileName, strName, false, onlyFunc)
 
 		} else {
 			for strSubName, oneVar := range vars.SubMaps {
-				rs.collect(strSubName, fileName, strName, false, oneVar)
+				rs.collect(strSubName, fileName, strName, false, oneVar, vars)
 			}
 		}
 	}
 }
 
-// 获取当前varInfoMaps下满足查询条件的symbols, 用于 getQuerySymbols 中指定查找过程
-func (rs *resultSorter) getVarmapsSymbols(varmaps map[string]*common.VarInfo, fileName, strName string,
+// 获取parentVar成员中满足查询条件的symbols, 用于 getQuerySymbols 中指定查找过程
+func (rs *resultSorter) getVarmapsSymbols(parentVar *common.VarInfo, fileName, strName string,
 	isglobal, onlyFunc bool) {
-	for strSubName, subOneVar := range varmaps {
+	for strSubName, subOneVar := range parentVar.SubMaps {
 		if onlyFunc && subOneVar.ReferFunc == nil {
 			continue
 		}
-		rs.collect(strSubName, fileName, strName, isglobal, subOneVar)
+		rs.collect(strSubName, fileName, strName, isglobal, subOneVar, parentVar)
+	}
+}
+
+// collectModule 文件作为模块的符号，名称为去掉后缀的文件名
+func (rs *resultSorter) collectModule(fileName string) {
+	strName := filepath.Base(fileName)
+	strName = strings.TrimSuffix(strName, filepath.Ext(strName))
+	score := rs.m.Score(strName)
+	if score <= 0 {
+		return
+	}
+
+	oneSymbol := &common.FileSymbolStruct{
+		Name:          strName,
+		Kind:          common.IKModule,
+		Loc:           lexer.Location{StartLine: 1, EndLine: 1},
+		FileName:      fileName,
+		ContainerName: "module",
 	}
+	rs.results = append(rs.results, scoredSymbol{score, oneSymbol})
 }
 
 // 获取文件注解参数的符号
@@ -734,7 +907,7 @@ func (a *AllProject) getAnnotateFileSymbol(rs *resultSorter, fileName string) {
 	for _, symbol := range annotateSymbolVec {
 		strName := symbol.Name
 		score := rs.m.Score(strName)
-		if score < 0 {
+		if score <= 0 {
 			continue
 		}
 
@@ -783,13 +956,15 @@ func handleAllFilesSymbols(pattern string, allProject *AllProject, results *resu
 		chs[i] = make(chan symbolsChan)
 		selectCase[i].Dir = reflect.SelectRecv
 		selectCase[i].Chan = reflect.ValueOf(chs[i])
-		go goroutineFindSymbols(chs[i])
+		ch := chs[i]
+		log.Go(func() { goroutineFindSymbols(ch) })
 	}
 	//初始化协程
 	for i := 0; i < corNum; i++ {
 		resultSorters[i] = &resultSorter{
 			m:       NewMatcher(pattern),
 			results: make([]scoredSymbol, 0),
+			maxNum:  results.maxNum,
 		}
 		chanRequest := symbolsChan{
 			strfile:          fileList[i],
@@ -817,6 +992,7 @@ func handleAllFilesSymbols(pattern string, allProject *AllProject, results *resu
 			resultSorters[recvNum+corNum] = &resultSorter{
 				m:       NewMatcher(pattern),
 				results: make([]scoredSymbol, 0),
+				maxNum:  results.maxNum,
 			}
 			chanRequest := symbolsChan{
 				strfile:          fileList[recvNum+corNum],
@@ -853,9 +1029,11 @@ func goroutineFindSymbols(ch chan symbolsChan) {
 			break
 		}
 		a := request.sendAllProject
-		fileResult := a.fileStructMap[request.strfile].FileResult
+		fileStruct := a.fileStructMap[request.strfile]
 		resultSorter := request.sendResultSorter
-		resultSorter.getQuerySymbols(fileResult)
+		resultSorter.fileStruct = fileStruct
+		resultSorter.collectModule(request.strfile)
+		resultSorter.getQuerySymbols(fileStruct.FileResult)
 
 		a.getAnnotateFileSymbol(resultSorter, request.strfile)
 
@@ -863,11 +1041,11 @@ func goroutineFindSymbols(ch chan symbolsChan) {
 		resultLen := len(resultSorter.results)
 		if resultLen == 0 {
 			returnChan = symbolsChan{returnResult: nil}
-		} else if resultLen <= maxSymbols {
+		} else if resultLen <= resultSorter.maxNum {
 			returnChan = symbolsChan{returnResult: resultSorter.results}
 		} else {
 			sort.Sort(resultSorter)
-			returnChan = symbolsChan{returnResult: resultSorter.results[0:maxSymbols]}
+			returnChan = symbolsChan{returnResult: resultSorter.results[0:resultSorter.maxNum]}
 		}
 		ch <- returnChan
 	}
@@ -0,0 +1,250 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+)
+
+// 原表继承关系的推导，支持下面常见的面向对象写法：
+// 1) local Derived = setmetatable({}, {__index = Base})
+// 2) Base.__index = Base
+//    local obj = setmetatable({}, Base)
+// 3) setmetatable(Derived, {__index = function(t, k) return Base[k] end})
+// 4) local Class = setmetatable({}, {__call = function(cls) return setmetatable({}, {__index = cls}) end})
+//    local obj = Class()
+// 查找成员时，自身没有找到，沿着__index的链逐层查找，与注解的父类型一样处理
+
+// maxMetaIndexDepth __index链最多查找的层数，防止异常的循环引用
+const maxMetaIndexDepth = 10
+
+// isSetMetatableCall 判断是否为setmetatable(a, mt)的调用
+func isSetMetatableCall(node *ast.FuncCallExp) bool {
+	if node.NameExp != nil || len(node.Args) != 2 {
+		return false
+	}
+
+	nameExp, ok := node.PrefixExp.(*ast.NameExp)
+	return ok && nameExp.Name == "setmetatable"
+}
+
+// getVarMetatableExp 获取变量设置的原表表达式，没有设置返回nil
+// 支持 local obj = setmetatable({}, mt) 与单独的语句 setmetatable(obj, mt)
+func getVarMetatableExp(varInfo *common.VarInfo) ast.Exp {
+	if callExp, ok := varInfo.ReferExp.(*ast.FuncCallExp); ok && isSetMetatableCall(callExp) {
+		return callExp.Args[1]
+	}
+
+	return varInfo.MetaExp
+}
+
+// getIndexFuncTableExp __index为函数时，获取函数返回的table表达式
+// 支持 return Base[k] 与 return rawget(Base, k) 这两种形式
+func getIndexFuncTableExp(funcExp *ast.FuncDefExp) ast.Exp {
+	if funcExp.Block == nil || len(funcExp.Block.RetExps) == 0 {
+		return nil
+	}
+
+	switch exp := funcExp.Block.RetExps[0].(type) {
+	case *ast.TableAccessExp:
+		return exp.PrefixExp
+	case *ast.FuncCallExp:
+		nameExp, ok := exp.PrefixExp.(*ast.NameExp)
+		if ok && nameExp.Name == "rawget" && exp.NameExp == nil && len(exp.Args) == 2 {
+			return exp.Args[0]
+		}
+	}
+
+	return nil
+}
+
+// isParamMetaInstance 判断表达式是否为以函数参数strParam为原表构造出来的实例，例如：
+// setmetatable({}, cls) 或 setmetatable({}, {__index = cls})
+// 表达式为局部变量时，查找局部变量定义处的表达式
+func isParamMetaInstance(funcExp *ast.FuncDefExp, exp ast.Exp, strParam string) bool {
+	if strParam == "" {
+		return false
+	}
+
+	if nameExp, ok := exp.(*ast.NameExp); ok {
+		exp = nil
+		for _, stat := range funcExp.Block.Stats {
+			localStat, ok := stat.(*ast.LocalVarDeclStat)
+			if !ok {
+				continue
+			}
+
+			for i, strName := range localStat.NameList {
+				if strName == nameExp.Name && i < len(localStat.ExpList) {
+					exp = localStat.ExpList[i]
+				}
+			}
+		}
+	}
+
+	callExp, ok := exp.(*ast.FuncCallExp)
+	if !ok || !isSetMetatableCall(callExp) {
+		return false
+	}
+
+	mtExp := callExp.Args[1]
+	if tableExp, ok := mtExp.(*ast.TableConstructorExp); ok {
+		mtExp = nil
+		for i, keyExp := range tableExp.KeyExps {
+			if keyExp != nil && common.GetExpName(keyExp) == "__index" {
+				mtExp = tableExp.ValExps[i]
+			}
+		}
+	}
+
+	nameExp, ok := mtExp.(*ast.NameExp)
+	return ok && nameExp.Name == strParam
+}
+
+// getMetatableFieldExp 获取原表中元方法strKey对应的表达式，例如__index、__call
+// mtExp 可以为table的构造 {__index = Base}，也可以为其他的变量，例如 Base.__index = Base 之后的 Base
+// 返回元方法的表达式以及表达式所在的文件
+func (a *AllProject) getMetatableFieldExp(luaInFile string, mtExp ast.Exp, strKey string, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) (fieldExp ast.Exp, fieldFile string) {
+	if tableExp, ok := mtExp.(*ast.TableConstructorExp); ok {
+		for i, keyExp := range tableExp.KeyExps {
+			if keyExp != nil && common.GetExpName(keyExp) == strKey {
+				return tableExp.ValExps[i], luaInFile
+			}
+		}
+
+		return nil, ""
+	}
+
+	mtSymbol := a.FindVarReferSymbol(luaInFile, mtExp, comParam, findExpList, 1)
+	if mtSymbol == nil || mtSymbol.VarInfo == nil {
+		return nil, ""
+	}
+
+	// 原表的元方法不会再通过原表查找，只查找变量自身以及关联的变量
+	// 关联变量的查找使用拷贝的findExpList，防止影响后续__index指向变量的查找
+	tmpExpList := append([]common.FindExpFile{}, *findExpList...)
+	symList := []*common.Symbol{mtSymbol}
+	symList = append(symList, a.FindDeepSymbolList(mtSymbol.FileName, mtSymbol.VarInfo.ReferExp, comParam,
+		&tmpExpList, false, mtSymbol.VarInfo.VarIndex)...)
+	for _, oneSymbol := range symList {
+		if oneSymbol.VarInfo == nil {
+			continue
+		}
+
+		if subVar, ok := oneSymbol.VarInfo.SubMaps[strKey]; ok && subVar.ReferExp != nil {
+			return subVar.ReferExp, subVar.FileName
+		}
+	}
+
+	return nil, ""
+}
+
+// getMetaIndexSymbol 获取变量原表中__index指向的symbol，没有返回nil
+func (a *AllProject) getMetaIndexSymbol(symbol *common.Symbol, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) *common.Symbol {
+	if symbol.VarInfo == nil {
+		return nil
+	}
+
+	mtExp := getVarMetatableExp(symbol.VarInfo)
+	if mtExp == nil {
+		return nil
+	}
+
+	indexExp, indexFile := a.getMetatableFieldExp(symbol.FileName, mtExp, "__index", comParam, findExpList)
+	if indexExp == nil {
+		return nil
+	}
+
+	// __index为函数的形式，例如 __index = function(t, k) return Base[k] end
+	if funcExp, ok := indexExp.(*ast.FuncDefExp); ok {
+		indexExp = getIndexFuncTableExp(funcExp)
+		if indexExp == nil {
+			return nil
+		}
+	}
+
+	return a.FindVarReferSymbol(indexFile, indexExp, comParam, findExpList, 1)
+}
+
+// getMetaIndexSubKey 沿着原表的__index链查找子成员strKey
+func (a *AllProject) getMetaIndexSubKey(symbol *common.Symbol, strKey string, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) *common.Symbol {
+	indexSymbol := a.getMetaIndexSymbol(symbol, comParam, findExpList)
+	if indexSymbol == nil {
+		return nil
+	}
+
+	// symbolHasSubKey 里面会继续沿着indexSymbol的__index链查找，findExpList防止循环引用
+	return a.symbolHasSubKey(indexSymbol, strKey, comParam, findExpList)
+}
+
+// getMetaIndexSymbolList 获取原表__index链上所有的symbol，按继承的先后顺序
+func (a *AllProject) getMetaIndexSymbolList(symbol *common.Symbol, comParam *CommonFuncParam) (symList []*common.Symbol) {
+	findExpList := []common.FindExpFile{}
+	for i := 0; i < maxMetaIndexDepth; i++ {
+		symbol = a.getMetaIndexSymbol(symbol, comParam, &findExpList)
+		if symbol == nil {
+			break
+		}
+
+		symList = append(symList, symbol)
+	}
+
+	return symList
+}
+
+// isMetaIndexMember 判断变量原表__index链上是否有成员strKey，用于注解class关联变量的成员判断
+func (a *AllProject) isMetaIndexMember(varInfo *common.VarInfo, strKey string) bool {
+	if getVarMetatableExp(varInfo) == nil {
+		return false
+	}
+
+	comParam := a.getCommFunc(varInfo.FileName, varInfo.Loc.StartLine-1, varInfo.Loc.StartColumn)
+	if comParam == nil {
+		return false
+	}
+
+	symbol := common.GetDefaultSymbol(varInfo.FileName, varInfo)
+	for _, oneSymbol := range a.getMetaIndexSymbolList(symbol, comParam) {
+		if oneSymbol.VarInfo != nil && oneSymbol.VarInfo.IsExistMember(strKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getMetaCallSymbol 变量通过原表的__call当作构造函数调用时，推导返回的实例
+// 当__call返回的是以第一个参数为原表构造的实例时，实例的成员从变量自身查找，返回变量自身
+func (a *AllProject) getMetaCallSymbol(symbol *common.Symbol, comParam *CommonFuncParam,
+	findExpList *[]common.FindExpFile) *common.Symbol {
+	if symbol.VarInfo == nil || symbol.VarInfo.ReferFunc != nil {
+		return nil
+	}
+
+	mtExp := getVarMetatableExp(symbol.VarInfo)
+	if mtExp == nil {
+		return nil
+	}
+
+	callExp, callFile := a.getMetatableFieldExp(symbol.FileName, mtExp, "__call", comParam, findExpList)
+	funcExp, ok := callExp.(*ast.FuncDefExp)
+	if !ok || funcExp.Block == nil || len(funcExp.Block.RetExps) == 0 {
+		return nil
+	}
+
+	strParam := ""
+	if funcExp.IsColon {
+		strParam = "self"
+	} else if len(funcExp.ParList) > 0 {
+		strParam = funcExp.ParList[0]
+	}
+
+	retExp := funcExp.Block.RetExps[0]
+	if isParamMetaInstance(funcExp, retExp, strParam) {
+		return symbol
+	}
+
+	return a.getReturnExpSymbol(callFile, retExp, comParam, findExpList, 1)
+}
@@ -69,7 +69,8 @@ func (a *AllProject) handleProjectEntryFileVec(projectVec []string) {
 		chs[i] = make(chan SecondProjectChan)
 		selectCase[i].Dir = reflect.SelectRecv
 		selectCase[i].Chan = reflect.ValueOf(chs[i])
-		go goSecondProject(chs[i])
+		ch := chs[i]
+		log.Go(func() { goSecondProject(ch) })
 	}
 
 	//初始化协程
@@ -301,7 +302,7 @@ func (a *AllProject) checkOneProject(second *results.SingleProjectResult) {
 	a.generateAllFristGlobalGMaps(second)
 
 	// 创建第二轮遍历的包裹对象
-	analysis := analysis.CreateAnalysis(results.CheckTermSecond, strFile)
+	analysis := analysis.CreateAnalysis(results.CheckTermSecond, strFile, a.conf)
 	analysis.SingleProjectResult = second
 	analysis.Projects = a
 
@@ -0,0 +1,72 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"time"
+)
+
+// CheckStats 最近一次整体分析的统计，耗时的单位为毫秒
+type CheckStats struct {
+	CheckNum  int       `json:"CheckNum"`  // 整体分析的次数
+	CheckTime time.Time `json:"CheckTime"` // 最近一次分析结束的时间
+	FileNum   int       `json:"FileNum"`   // 分析的文件数量
+	AllMs     int64     `json:"AllMs"`     // 整体的耗时
+	FirstMs   int64     `json:"FirstMs"`   // 第一阶段的耗时，包含了生成AST与第一次遍历
+	ParseMs   int64     `json:"ParseMs"`   // 第一阶段所有文件生成AST的耗时之和，多协程并行时可能大于FirstMs
+	SecondMs  int64     `json:"SecondMs"`  // 第二阶段的耗时
+	ThirdMs   int64     `json:"ThirdMs"`   // 第三阶段的耗时
+}
+
+// 缓存统计的名称
+const (
+	CacheFileLRU       = "FileLRU"       // 实时输入有语法错误时，缓存的上一次的文件结果
+	CacheParseSnapshot = "ParseSnapshot" // 增量解析的语法树快照
+	CacheComplete      = "Complete"      // 代码补全的缓存
+)
+
+// addParseTime 累加生成AST的耗时
+func (a *AllProject) addParseTime(cost time.Duration) {
+	a.statsMutex.Lock()
+	a.parseCost += cost
+	a.statsMutex.Unlock()
+}
+
+// beginCheckStats 整体分析开始时，清空生成AST的耗时
+func (a *AllProject) beginCheckStats() {
+	a.statsMutex.Lock()
+	a.parseCost = 0
+	a.statsMutex.Unlock()
+}
+
+// endCheckStats 整体分析结束时，记录各阶段的耗时
+func (a *AllProject) endCheckStats(allMs, firstMs, secondMs, thirdMs int64) {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+
+	a.checkStats = CheckStats{
+		CheckNum:  a.checkStats.CheckNum + 1,
+		CheckTime: time.Now(),
+		FileNum:   len(a.allFilesMap),
+		AllMs:     allMs,
+		FirstMs:   firstMs,
+		ParseMs:   a.parseCost.Milliseconds(),
+		SecondMs:  secondMs,
+		ThirdMs:   thirdMs,
+	}
+}
+
+// GetCheckStats 获取最近一次整体分析的统计
+func (a *AllProject) GetCheckStats() CheckStats {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.checkStats
+}
+
+// GetCacheStats 获取所有缓存的命中统计，key为缓存的名称
+func (a *AllProject) GetCacheStats() map[string]common.CacheStats {
+	return map[string]common.CacheStats{
+		CacheFileLRU:       a.fileLRUMap.Stats(),
+		CacheParseSnapshot: a.parseSnapshotLRU.Stats(),
+		CacheComplete:      a.completeCache.Stats(),
+	}
+}
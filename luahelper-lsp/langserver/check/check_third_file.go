@@ -78,7 +78,8 @@ func (a *AllProject) handleFiles(third *results.AnalysisThird) {
 		chs[i] = make(chan thirdFileChan)
 		selectCase[i].Dir = reflect.SelectRecv
 		selectCase[i].Chan = reflect.ValueOf(chs[i])
-		go goThirdFile(chs[i])
+		ch := chs[i]
+		log.Go(func() { goThirdFile(ch) })
 	}
 
 	//初始化协程
@@ -194,7 +195,7 @@ func (a *AllProject) handleOneFile(thirdStruct *results.AnalysisThird, third *re
 	time1 := time.Now()
 
 	// 创建第三轮遍历的包裹对象
-	analysis := analysis.CreateAnalysis(results.CheckTermThird, third.StrFile)
+	analysis := analysis.CreateAnalysis(results.CheckTermThird, third.StrFile, a.conf)
 	analysis.AnalysisThird = third
 	analysis.Projects = a
 
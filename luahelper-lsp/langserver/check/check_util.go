@@ -105,7 +105,7 @@ func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
 	}
 
 	// 若为非常规工程，则不做第二， 第三阶段诊断
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := a.conf.GetDirManager()
 	mainDir := dirManager.GetMainDir()
 	if mainDir == "" {
 		return fileErrorMap
@@ -141,7 +141,7 @@ func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
 // IsNeedHandle 给一个文件名，判断是否要进行处理
 func (a *AllProject) IsNeedHandle(strFile string) bool {
 	// 判断该文件是否是忽略处理的
-	if common.GConfig.IsIgnoreCompleteFile(strFile) {
+	if a.conf.IsIgnoreCompleteFile(strFile) {
 		log.Debug("strFile=%s ignore", strFile)
 		return false
 	}
@@ -181,6 +181,11 @@ func (a *AllProject) getSpecialLineComment(inLuaFile string, lastLine int, headF
 		strComment := ""
 		// 拼接新的注释
 		for _, oneLine := range oneComment.LineVec {
+			// 写在表达式后面的类型注解 --[[@as Player]]，不作为注释显示
+			if strings.HasPrefix(oneLine.Str, "@as ") {
+				continue
+			}
+
 			if strComment == "" {
 				strComment = oneLine.Str
 			} else {
@@ -211,9 +216,25 @@ func (a *AllProject) GetLineComment(luaFile string, line int) string {
 func (a *AllProject) insertFirstFileStruct(strFile string, fileStruct *results.FileStruct) {
 	a.fileStructMutex.Lock()
 	defer a.fileStructMutex.Unlock()
+	a.updateGlobalReferIndex(a.fileStructMap[strFile], -1)
+	a.updateGlobalReferIndex(fileStruct, 1)
 	a.fileStructMap[strFile] = fileStruct
 }
 
+// updateGlobalReferIndex 第一阶段的文件结果插入或是删除时，更新工程中读取过的名称索引，num为1表示插入，-1表示删除
+func (a *AllProject) updateGlobalReferIndex(fileStruct *results.FileStruct, num int) {
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		return
+	}
+
+	for strName := range fileStruct.FileResult.ReferNames {
+		a.globalReferMap[strName] += num
+		if a.globalReferMap[strName] <= 0 {
+			delete(a.globalReferMap, strName)
+		}
+	}
+}
+
 // GetFirstReferFileResult 给一个引用关系，找第一阶段引用lua文件
 func (a *AllProject) GetFirstReferFileResult(referInfo *common.ReferInfo) *results.FileResult {
 	if !referInfo.Valid || referInfo.ReferValidStr == "" {
@@ -612,6 +633,59 @@ func (a *AllProject) GetFirstFileStuct(strFile string) (*results.FileStruct, boo
 	return fileStruct, ok
 }
 
+// IsFieldWriteInProject 判断整个工程中是否有对该名称的table成员赋值过
+func (a *AllProject) IsFieldWriteInProject(strFieldName string) bool {
+	if a.checkTerm == results.CheckTermFirst {
+		a.fileStructMutex.Lock()
+		defer a.fileStructMutex.Unlock()
+	}
+
+	for _, fileStruct := range a.fileStructMap {
+		if fileStruct.FileResult == nil {
+			continue
+		}
+
+		if _, ok := fileStruct.FileResult.WriteFields[strFieldName]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsGlobalReferInProject 判断整个工程中是否有读取过该名称的全局变量或函数
+func (a *AllProject) IsGlobalReferInProject(strName string) bool {
+	if a.checkTerm == results.CheckTermFirst {
+		a.fileStructMutex.Lock()
+		defer a.fileStructMutex.Unlock()
+	}
+
+	return a.globalReferMap[strName] > 0
+}
+
+// IsGlobalDefineInProject 判断整个工程中是否定义了该名称的全局变量，忽略moocscript中extension语句产生的定义
+func (a *AllProject) IsGlobalDefineInProject(strName string) bool {
+	if a.checkTerm == results.CheckTermFirst {
+		a.fileStructMutex.Lock()
+		defer a.fileStructMutex.Unlock()
+	}
+
+	for _, fileStruct := range a.fileStructMap {
+		fileResult := fileStruct.FileResult
+		if fileResult == nil {
+			continue
+		}
+
+		for varInfo := fileResult.GlobalMaps[strName]; varInfo != nil; varInfo = varInfo.ExtraGlobal.Prev {
+			if _, ok := fileResult.ExtensionLocMap[varInfo.Loc]; !ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // GetReferFrameType 如果为自定义的引入其他lua文件的方式，获取真实的引入的子类型
 func (a *AllProject) GetReferFrameType(referInfo *common.ReferInfo) (subReferType common.ReferFrameType) {
 	subReferType = common.RtypeNotValid
@@ -628,7 +702,7 @@ func (a *AllProject) GetReferFrameType(referInfo *common.ReferInfo) (subReferTyp
 	}
 
 	// 如果是框架子定义的引入方式
-	subReferType = common.GConfig.GetReferFrameSubType(referInfo.ReferTypeStr)
+	subReferType = a.conf.GetReferFrameSubType(referInfo.ReferTypeStr)
 	if subReferType != common.RtypeAuto {
 		return subReferType
 	}
@@ -823,11 +897,11 @@ func ExpToDefineVarStruct(exp ast.Exp) (defineVar common.DefineVarStruct) {
 }
 
 // StrToDefineVarStruct change str to defineVarStruct
-func StrToDefineVarStruct(str string, strFile string) (defineVar common.DefineVarStruct) {
+func (a *AllProject) StrToDefineVarStruct(str string, strFile string) (defineVar common.DefineVarStruct) {
 	defineVar.ValidFlag = false
 
-	_, ok1 := common.GConfig.CompKeyMap[str]
-	_, ok2 := common.GConfig.CompSnippetMap[str]
+	_, ok1 := a.conf.CompKeyMap[str]
+	_, ok2 := a.conf.CompSnippetMap[str]
 	if ok1 || ok2 {
 		defineVar.ValidFlag = true
 		defineVar.ColonFlag = false
@@ -836,7 +910,8 @@ func StrToDefineVarStruct(str string, strFile string) (defineVar common.DefineVa
 		return defineVar
 	}
 
-	newParser := parser.CreateParser([]byte(str), strFile)
+	dialect, _ := a.conf.GetFileDialect(strFile)
+	newParser := parser.CreateDialectParser([]byte(str), strFile, dialect)
 	exp := newParser.BeginAnalyzeExp()
 	errList := newParser.GetErrList()
 	if exp == nil || len(errList) > 0 {
@@ -919,7 +994,7 @@ func (a *AllProject) getCommFunc(strFile string, line, ch int) (comParam *Common
 }
 
 // GetVarStruct 根据内容的坐标信息，解析出对应的表达式结构
-func GetVarStruct(contents []byte, offset int, line uint32, character uint32, strFile string) (varStruct common.DefineVarStruct) {
+func (a *AllProject) GetVarStruct(contents []byte, offset int, line uint32, character uint32, strFile string) (varStruct common.DefineVarStruct) {
 	conLen := len(contents)
 	if offset == conLen {
 		offset = offset - 1
@@ -980,7 +1055,7 @@ func GetVarStruct(contents []byte, offset int, line uint32, character uint32, st
 	}
 
 	//log.Debug("stringutil.GetVarStruct str=%s", str)
-	varStruct = StrToDefineVarStruct(str, strFile)
+	varStruct = a.StrToDefineVarStruct(str, strFile)
 	varStruct.Str = str
 	varStruct.PosLine = (int)(line)
 	varStruct.PosCh = (int)(character)
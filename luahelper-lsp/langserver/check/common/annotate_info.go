@@ -15,6 +15,8 @@ type OneClassInfo struct {
 	LastLine   int                                        // 这块class所在定义的最后行数
 	ClassState *annotateast.AnnotateClassState            // Class的信息
 	FieldMap   map[string]*annotateast.AnnotateFieldState // 所有关联的field成员，key值为filed的名称
+	// 所有运算符元方法的类型，key值为运算符的名称，例如add，同一个运算符可以有多个不同的操作数类型
+	OperatorMap map[string][]*annotateast.AnnotateOperatorState
 
 	// 这个定义的class是否关联到了变量，关联的含义是，例如下面的例子
 	//---@class A
@@ -99,6 +101,11 @@ type FragementMarkInfo struct {
 	MarkInfoList []*OneMarkInfo
 }
 
+// FragementCastInfo 强制转换变量类型的信息
+type FragementCastInfo struct {
+	CastList []*annotateast.AnnotateCastState
+}
+
 // FragementInfo 单个注释块转成的结构
 type FragementInfo struct {
 	LastLine     int   // 最后一行
@@ -112,6 +119,7 @@ type FragementInfo struct {
 	GenericInfo  *FragementGenericInfo
 	OverloadInfo *FragementOverloadInfo
 	MarkInfo     *FragementMarkInfo
+	CastInfo     *FragementCastInfo
 }
 
 // GetFirstOneClassInfo 获取注释代码段第一个ClassInfo
@@ -173,6 +181,15 @@ func (fr *FragementInfo) GetColorLocVec() (locVec []lexer.Location) {
 				typeLocVec := annotateast.GetTypeColorLocVec(oneFiled.FiledType)
 				locVec = append(locVec, typeLocVec...)
 			}
+
+			for _, operatorList := range oneClass.OperatorMap {
+				for _, oneOperator := range operatorList {
+					if oneOperator.ParamType != nil {
+						locVec = append(locVec, annotateast.GetTypeColorLocVec(oneOperator.ParamType)...)
+					}
+					locVec = append(locVec, annotateast.GetTypeColorLocVec(oneOperator.ReturnType)...)
+				}
+			}
 		}
 	}
 
@@ -237,6 +254,14 @@ func (fr *FragementInfo) GetColorLocVec() (locVec []lexer.Location) {
 		}
 	}
 
+	// 9) 获取所有的cast带来的位置信息
+	if fr.CastInfo != nil {
+		for _, oneCast := range fr.CastInfo.CastList {
+			typeLocVec := annotateast.GetTypeColorLocVec(oneCast.CastType)
+			locVec = append(locVec, typeLocVec...)
+		}
+	}
+
 	return locVec
 }
 
@@ -323,17 +348,20 @@ type EnumFragment struct {
 
 // AnnotateFile 单个文件生成的核心注解信息
 type AnnotateFile struct {
-	FragementMap    map[int]*FragementInfo    // 文件对应的所有注释块信息
-	CreateTypeMap   map[string]CreateTypeList // 文件管理的所有定义新产生的类信息
-	sortFragement   *resultSortFragement      // 用于根据行号排序的内部结构
-	LuaFile         string                    // 这个文件对应的lua名称
-	checkErrVec     []CheckError              // 注解检测到的错误信息
-	EnumFragmentVec []EnumFragment            // 所有的枚举段落
-	IsEnumType      bool                      // 是否有枚举类型的type定义信息
+	FragementMap    map[int]*FragementInfo                 // 文件对应的所有注释块信息
+	CreateTypeMap   map[string]CreateTypeList              // 文件管理的所有定义新产生的类信息
+	sortFragement   *resultSortFragement                   // 用于根据行号排序的内部结构
+	LuaFile         string                                 // 这个文件对应的lua名称
+	checkErrVec     []CheckError                           // 注解检测到的错误信息
+	EnumFragmentVec []EnumFragment                         // 所有的枚举段落
+	IsEnumType      bool                                   // 是否有枚举类型的type定义信息
+	CastVec         []*annotateast.AnnotateCastState       // 所有强制转换变量类型的注解，按行号排序
+	AsMap           map[int][]*annotateast.AnnotateAsState // 所有写在表达式后面的类型注解，key值为所在的行号
+	conf            *GlobalConfig                          // 所属的配置，用于判断是否忽略注解的告警
 }
 
 // CreateAnnotateFile 创建文件的所有注解信息
-func CreateAnnotateFile(luaFile string) *AnnotateFile {
+func CreateAnnotateFile(luaFile string, conf *GlobalConfig) *AnnotateFile {
 	return &AnnotateFile{
 		FragementMap:  map[int]*FragementInfo{},
 		CreateTypeMap: map[string]CreateTypeList{},
@@ -342,12 +370,14 @@ func CreateAnnotateFile(luaFile string) *AnnotateFile {
 		},
 		LuaFile:    luaFile,
 		IsEnumType: false,
+		AsMap:      map[int][]*annotateast.AnnotateAsState{},
+		conf:       conf,
 	}
 }
 
 // PushTypeDefineError 插入一个注解类型的错误
 func (af *AnnotateFile) PushTypeDefineError(errStr string, errLoc lexer.Location) {
-	if GConfig.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
+	if af.conf.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
 		return
 	}
 
@@ -399,7 +429,7 @@ func (af *AnnotateFile) Swap(i, j int) {
 
 // PushTypeDuplicateError 插入一个注解重复类型的错误
 func (af *AnnotateFile) PushTypeDuplicateError(errStr string, errLoc lexer.Location, relateVec []RelateCheckInfo) {
-	if GConfig.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
+	if af.conf.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
 		return
 	}
 
@@ -417,11 +447,12 @@ func (af *AnnotateFile) PushTypeDuplicateError(errStr string, errLoc lexer.Locat
 // analysisAnnotateFragement 分析单个块的注解结构
 func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment *annotateast.AnnotateFragment) {
 	oneClassInfo := &OneClassInfo{
-		ClassState: nil,
-		FieldMap:   map[string]*annotateast.AnnotateFieldState{},
-		RelateVar:  nil,
-		LuaFile:    af.LuaFile,
-		LastLine:   lastLine,
+		ClassState:  nil,
+		FieldMap:    map[string]*annotateast.AnnotateFieldState{},
+		OperatorMap: map[string][]*annotateast.AnnotateOperatorState{},
+		RelateVar:   nil,
+		LuaFile:     af.LuaFile,
+		LastLine:    lastLine,
 	}
 
 	classInfo := FragmentClassInfo{
@@ -461,6 +492,10 @@ func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment
 		MarkInfoList: nil,
 	}
 
+	castInfo := FragementCastInfo{
+		CastList: nil,
+	}
+
 	fragmentInfo := &FragementInfo{
 		LastLine: lastLine,
 	}
@@ -484,11 +519,12 @@ func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment
 
 				// 一个注释块有两个class信息, 前面的class信息被插入进去
 				oneClassInfo = &OneClassInfo{
-					ClassState: state,
-					FieldMap:   map[string]*annotateast.AnnotateFieldState{},
-					RelateVar:  nil,
-					LuaFile:    af.LuaFile,
-					LastLine:   lastLine,
+					ClassState:  state,
+					FieldMap:    map[string]*annotateast.AnnotateFieldState{},
+					OperatorMap: map[string][]*annotateast.AnnotateOperatorState{},
+					RelateVar:   nil,
+					LuaFile:     af.LuaFile,
+					LastLine:    lastLine,
 				}
 			}
 		case *annotateast.AnnotateFieldState:
@@ -498,6 +534,13 @@ func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment
 				log.Debug("before ClassState is nil, filed=%s", state.Name)
 			}
 
+		case *annotateast.AnnotateOperatorState:
+			if oneClassInfo.ClassState != nil {
+				oneClassInfo.OperatorMap[state.Name] = append(oneClassInfo.OperatorMap[state.Name], state)
+			} else {
+				log.Debug("before ClassState is nil, operator=%s", state.Name)
+			}
+
 		case *annotateast.AnnotateParamState:
 			paramInfo.ParamList = append(paramInfo.ParamList, state)
 
@@ -540,6 +583,10 @@ func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment
 				MarkState: state,
 				LuaFile:   af.LuaFile,
 			})
+
+		case *annotateast.AnnotateCastState:
+			castInfo.CastList = append(castInfo.CastList, state)
+			af.CastVec = append(af.CastVec, state)
 		}
 	}
 
@@ -591,6 +638,11 @@ func (af *AnnotateFile) analysisAnnotateFragement(lastLine int, annotateFragment
 		fragmentInfo.MarkInfo = &markInfo
 	}
 
+	// 强制转换变量类型的信息
+	if len(castInfo.CastList) > 0 {
+		fragmentInfo.CastInfo = &castInfo
+	}
+
 	af.FragementMap[lastLine] = fragmentInfo
 	af.sortFragement.results = append(af.sortFragement.results, fragmentInfo)
 }
@@ -903,9 +955,19 @@ func (af *AnnotateFile) AnalysisAllComment(commentMap map[int]*lexer.CommentInfo
 
 	// 1) 遍历所有块的注释，提取有用的注释信息
 	for lastLine, commentInfo := range commentMap {
-		// 只处理头部注释
+		// 尾部注释只处理写在表达式后面的类型注解，例如 --[[@as Player]]
+		// 尾部注释的行有代码转换成的注解时，注解作用于下一行的代码，当成头部注释处理
 		if !commentInfo.HeadFlag {
-			continue
+			af.analysisAsComment(lastLine, commentInfo)
+			if len(commentInfo.AnnotateLineVec) == 0 {
+				continue
+			}
+
+			commentInfo = &lexer.CommentInfo{
+				LineVec:   commentInfo.AnnotateLineVec,
+				ShortFlag: true,
+				HeadFlag:  true,
+			}
 		}
 
 		// 注释块解析成注释段落
@@ -922,11 +984,11 @@ func (af *AnnotateFile) AnalysisAllComment(commentMap map[int]*lexer.CommentInfo
 		}
 
 		// 判断是否忽略注解类型告警
-		if GConfig.IsGlobalIgnoreErrType(CheckErrorAnnotate) {
+		if af.conf.IsGlobalIgnoreErrType(CheckErrorAnnotate) {
 			continue
 		}
 
-		if GConfig.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
+		if af.conf.IsIgnoreErrorFile(af.LuaFile, CheckErrorAnnotate) {
 			continue
 		}
 
@@ -944,6 +1006,9 @@ func (af *AnnotateFile) AnalysisAllComment(commentMap map[int]*lexer.CommentInfo
 
 	// 2) 所有块注释信息，依据行号，进行排序
 	sort.Sort(af.sortFragement)
+	sort.Slice(af.CastVec, func(i, j int) bool {
+		return af.CastVec[i].NameLoc.StartLine < af.CastVec[j].NameLoc.StartLine
+	})
 
 	// 3) 按行号遍历所有的注释块信息，生成这个文件内所有产生的新符号
 	af.generateNewType()
@@ -960,6 +1025,37 @@ func (af *AnnotateFile) AnalysisAllComment(commentMap map[int]*lexer.CommentInfo
 	af.calcIsEnumType()
 }
 
+// analysisAsComment 分析尾部注释中写在表达式后面的类型注解
+func (af *AnnotateFile) analysisAsComment(lastLine int, commentInfo *lexer.CommentInfo) {
+	if len(commentInfo.LineVec) == 0 {
+		return
+	}
+
+	annotateFragment, _ := annotateparser.ParseCommentFragment(commentInfo)
+	for _, oneState := range annotateFragment.Stats {
+		if asState, ok := oneState.(*annotateast.AnnotateAsState); ok {
+			af.AsMap[lastLine] = append(af.AsMap[lastLine], asState)
+		}
+	}
+}
+
+// GetExpAsType 获取表达式后面紧跟着的类型注解，例如 getObj() --[[@as Player]]
+// expLoc 为表达式的位置信息，类型注解需要在表达式的同一行，且在表达式的后面
+func (af *AnnotateFile) GetExpAsType(expLoc lexer.Location) (asState *annotateast.AnnotateAsState) {
+	for _, oneState := range af.AsMap[expLoc.EndLine] {
+		if oneState.AsLoc.StartColumn < expLoc.EndColumn {
+			continue
+		}
+
+		// 取离表达式最近的一个
+		if asState == nil || oneState.AsLoc.StartColumn < asState.AsLoc.StartColumn {
+			asState = oneState
+		}
+	}
+
+	return asState
+}
+
 func (af *AnnotateFile) calcIsEnumType() {
 	for _, fragement := range af.FragementMap {
 		if fragement.TypeInfo == nil {
@@ -18,6 +18,7 @@ func (cnode *CacheNode) NewCacheNode(k, v interface{}) *CacheNode {
 
 // LRUCache 整体封装结构
 type LRUCache struct {
+	counter  cacheCounter
 	Capacity int
 	dlist    *list.List
 	cacheMap map[interface{}]*list.Element
@@ -37,6 +38,22 @@ func (lru *LRUCache) Size() int {
 	return lru.dlist.Len()
 }
 
+// Stats 返回缓存的命中统计
+func (lru *LRUCache) Stats() CacheStats {
+	lru.cacheMutex.Lock()
+	defer lru.cacheMutex.Unlock()
+	return lru.counter.stats(lru.dlist.Len())
+}
+
+// Contains 判断节点是否存在，不影响淘汰的顺序，也不计入命中统计
+func (lru *LRUCache) Contains(k interface{}) bool {
+	lru.cacheMutex.Lock()
+	defer lru.cacheMutex.Unlock()
+
+	_, ok := lru.cacheMap[k]
+	return ok
+}
+
 // Set 插入一个节点
 func (lru *LRUCache) Set(k, v interface{}) error {
 	lru.cacheMutex.Lock()
@@ -79,8 +96,10 @@ func (lru *LRUCache) Get(k interface{}) (v interface{}, ret bool, err error) {
 
 	if pElement, ok := lru.cacheMap[k]; ok {
 		lru.dlist.MoveToFront(pElement)
+		lru.counter.record(true)
 		return pElement.Value.(*CacheNode).Value, true, nil
 	}
+	lru.counter.record(false)
 	return v, false, nil
 }
 
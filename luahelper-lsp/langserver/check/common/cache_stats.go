@@ -0,0 +1,43 @@
+package common
+
+import (
+	"sync/atomic"
+)
+
+// CacheStats 缓存的命中统计
+type CacheStats struct {
+	Hits    uint64  `json:"Hits"`    // 命中的次数
+	Misses  uint64  `json:"Misses"`  // 没有命中的次数
+	HitRate float64 `json:"HitRate"` // 命中率，没有访问时为0
+	Size    int     `json:"Size"`    // 当前缓存的数量
+}
+
+// cacheCounter 缓存命中的计数，可以在多个协程中使用
+// 32位的平台上原子操作要求64位对齐，因此需要放在结构的第一个字段
+type cacheCounter struct {
+	hits   uint64
+	misses uint64
+}
+
+// record 记录一次访问
+func (c *cacheCounter) record(hit bool) {
+	if hit {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+}
+
+// stats 获取命中的统计
+func (c *cacheCounter) stats(size int) CacheStats {
+	stats := CacheStats{
+		Hits:   atomic.LoadUint64(&c.hits),
+		Misses: atomic.LoadUint64(&c.misses),
+		Size:   size,
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"sync"
 )
 
 /*
@@ -50,6 +51,7 @@ type OneCompleteData struct {
 
 // CompleteCache 缓存所有的补全信息
 type CompleteCache struct {
+	counter          cacheCounter        // completionItem/resolve 获取缓存的命中统计
 	index            int                 // 当前的index
 	maxNum           int                 // 上次提示的最大数量
 	excludeNum       int                 // 上次提示时，排除的最大数量
@@ -60,6 +62,7 @@ type CompleteCache struct {
 	beforeHashtag    bool                //  补全的词前面是否包含#
 	clearParamQuotes bool                // 补全时候，是否要清除候选词的引号
 	completeVar      *CompleteVarStruct  // 缓存的输入代码补全的结构
+	cacheMutex       sync.Mutex          // 保护dataList与existMap，统计请求可能与补全并发访问
 }
 
 // CreateCompleteCache 创建一个代码补全缓存
@@ -81,6 +84,9 @@ func CreateCompleteCache() *CompleteCache {
 
 // ResertData resert cache data
 func (cache *CompleteCache) ResertData() {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
 	cache.index = 0
 	cache.dataList = make([]OneCompleteData, 0, cache.maxNum)
 	cache.existMap = make(map[string]int, cache.maxNum)
@@ -123,6 +129,9 @@ func (cache *CompleteCache) GetBeforeHashtag() bool {
 
 // GetDataList 获取所有的数据
 func (cache *CompleteCache) GetDataList() []OneCompleteData {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
 	if len(cache.dataList) > cache.maxNum {
 		cache.maxNum = len(cache.dataList)
 	}
@@ -136,17 +145,29 @@ func (cache *CompleteCache) GetDataList() []OneCompleteData {
 
 // GetIndexData 获取所有的数据
 func (cache *CompleteCache) GetIndexData(index int) (item OneCompleteData, flag bool) {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
 	flag = false
 	itemLen := len(cache.dataList)
 	if index < 0 || index >= itemLen {
+		cache.counter.record(false)
 		return
 	}
 
 	item = cache.dataList[index]
 	flag = true
+	cache.counter.record(true)
 	return
 }
 
+// Stats 获取缓存的命中统计
+func (cache *CompleteCache) Stats() CacheStats {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+	return cache.counter.stats(len(cache.dataList))
+}
+
 // InsertCompleteData insert one data
 func (cache *CompleteCache) InsertCompleteData() {
 
@@ -174,6 +195,9 @@ func (cache *CompleteCache) InsertExcludeStr(strName string) {
 
 // ExistStr 判断是否已经存在了补全的信息
 func (cache *CompleteCache) ExistStr(strLabel string) bool {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
 	_, flag := cache.existMap[strLabel]
 	return flag
 }
@@ -202,8 +226,7 @@ func (cache *CompleteCache) InsertCompleteVar(luaFile string, label string, varI
 		oneComplete.Kind = IKField
 	}
 
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteVar 插入补全缓存类型的CKindVar
@@ -226,8 +249,7 @@ func (cache *CompleteCache) InsertCompleteVarInclude(luaFile string, label strin
 		oneComplete.Kind = IKField
 	}
 
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteKey 插入关键字的代码补全
@@ -238,8 +260,7 @@ func (cache *CompleteCache) InsertCompleteKey(label string) {
 		CacheKind: CKindNormal,
 	}
 
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteSnippet 插入Snippet
@@ -250,8 +271,7 @@ func (cache *CompleteCache) InsertCompleteSnippet(label string) {
 		CacheKind: CKindNormal,
 	}
 
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteSysModuleMem 插入系统模块的成员
@@ -278,8 +298,7 @@ func (cache *CompleteCache) InsertCompleteNormal(label, detail, documentation st
 		Kind:          kind,
 		CacheKind:     CKindNormal,
 	}
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteNormal 插入普通的
@@ -292,8 +311,7 @@ func (cache *CompleteCache) InsertCompleteExpand(label, detail, documentation st
 		CacheKind:     CKindNormal,
 		ExpandVarInfo: expandVar,
 	}
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteInnotateType 插入关键字的代码补全
@@ -304,8 +322,7 @@ func (cache *CompleteCache) InsertCompleteInnotateType(label string, creatType *
 		CacheKind:      CkindAnnotateType,
 		CreateTypeInfo: creatType,
 	}
-	cache.dataList = append(cache.dataList, oneComplete)
-	cache.existMap[label] = len(cache.dataList) - 1
+	cache.appendData(label, oneComplete)
 }
 
 // InsertCompleteClassField 插入注解系统的class field域
@@ -324,6 +341,9 @@ func (cache *CompleteCache) InsertCompleteClassField(luaFile, label string, fiel
 		oneComplete.Kind = IKFunction
 	}
 
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
 	if index, flag := cache.existMap[label]; flag {
 		// 注解类型代码补全优先级高，如果出现了替换之前的内容
 		cache.dataList[index] = oneComplete
@@ -332,3 +352,12 @@ func (cache *CompleteCache) InsertCompleteClassField(luaFile, label string, fiel
 		cache.existMap[label] = len(cache.dataList) - 1
 	}
 }
+
+// appendData 插入一个补全的信息
+func (cache *CompleteCache) appendData(label string, oneComplete OneCompleteData) {
+	cache.cacheMutex.Lock()
+	defer cache.cacheMutex.Unlock()
+
+	cache.dataList = append(cache.dataList, oneComplete)
+	cache.existMap[label] = len(cache.dataList) - 1
+}
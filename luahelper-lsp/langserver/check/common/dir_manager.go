@@ -41,10 +41,13 @@ type DirManager struct {
 
 	// 类似 LUA_CPATH 的配置
 	clientLuaCPaths []string
+
+	// 目录管理所属的配置
+	conf *GlobalConfig
 }
 
 // create default dir manager
-func createDirManager() *DirManager {
+func createDirManager(conf *GlobalConfig) *DirManager {
 	dirManager := &DirManager{
 		vSRootDir:         "",
 		subDirVec:         []string{},
@@ -52,6 +55,7 @@ func createDirManager() *DirManager {
 		mainDir:           "",
 		clientExtLuaPath:  "",
 		cacheDirMap:       make(map[string]bool),
+		conf:              conf,
 	}
 
 	return dirManager
@@ -127,16 +131,17 @@ func (d *DirManager) InitMainDir() {
 
 // 是否要设置额外的目录
 func (d *DirManager) InitOtherDir() {
-	if GConfig.OtherDir == "" {
+	otherDir := d.conf.OtherDir
+	if otherDir == "" {
 		return
 	}
 
 	strDir := ""
-	if strings.HasPrefix(GConfig.OtherDir, ".") {
+	if strings.HasPrefix(otherDir, ".") {
 		// 如果是前缀. 为相对路径
-		strDir = d.vSRootDir + GConfig.OtherDir
+		strDir = d.vSRootDir + otherDir
 	} else {
-		strDir = GConfig.OtherDir
+		strDir = otherDir
 	}
 
 	log.Debug("strDir=%s", strDir)
@@ -273,7 +278,7 @@ func (d *DirManager) GetDirFileList(path string, ignoreFlag bool) (fileList []st
 
 	// 遍历文件的chan结果
 	fileChan := make(chan string)
-	go getAllFile(run, path, path, ignoreFlag, fileChan)
+	log.Go(func() { d.getAllFile(run, path, path, ignoreFlag, fileChan) })
 
 	go func() {
 		run.Wait()
@@ -312,12 +317,12 @@ func isDir(path string) bool {
 // 递归获取目录下面所有的lua文件
 // dirStr 传入的子目录的原始路径
 // ignoreFlag 表示是否需要判断忽略文件
-func getAllFile(run *ParallelRun, pathname string, dirStr string, ignoreFlag bool, fileChan chan<- string) {
+func (d *DirManager) getAllFile(run *ParallelRun, pathname string, dirStr string, ignoreFlag bool,
+	fileChan chan<- string) {
 	defer run.Done()
 
-	g := GConfig
+	g := d.conf
 	rd := dirents(run, pathname)
-	dm := GConfig.dirManager
 
 	for _, fi := range rd {
 		// 首先判断是否linux软链接
@@ -342,14 +347,14 @@ func getAllFile(run *ParallelRun, pathname string, dirStr string, ignoreFlag boo
 				targetPath, err := os.Readlink(tmpDir)
 				if err == nil {
 					absPath, err1 := filepath.Abs(targetPath)
-					if err1 == nil && !dm.insetOneDirCache(absPath) {
+					if err1 == nil && !d.insetOneDirCache(absPath) {
 						log.Error("insetOneDirCache error=%s", absPath)
 						continue
 					}
 				}
 			}
 
-			if !dm.insetOneDirCache(completeStr + strName) {
+			if !d.insetOneDirCache(completeStr + strName) {
 				log.Error("insetOneDirCache error=%s", completeStr+strName)
 				continue
 			}
@@ -362,7 +367,8 @@ func getAllFile(run *ParallelRun, pathname string, dirStr string, ignoreFlag boo
 			}
 
 			run.Add()
-			go getAllFile(run, completeStr, dirStr, ignoreFlag, fileChan)
+			subDir := completeStr
+			log.Go(func() { d.getAllFile(run, subDir, dirStr, ignoreFlag, fileChan) })
 
 			// 如果是单纯的文件夹，后面不用再判断是否为文件
 			if !symlinkFlag {
@@ -399,7 +405,7 @@ func (d *DirManager) MatchCompleteReferFile(curFile string, referStr string) (re
 
 	// 1) 如果是包含了后缀，查看路径下面是否直接有, 直接存在返回true
 	strPath := d.GetCompletePath(matchDir, referStr)
-	g := GConfig
+	g := d.conf
 	if g.FileExistCache(strPath) {
 		// lua文件存在，正常
 		referFile = strPath
@@ -414,7 +420,7 @@ func (d *DirManager) MatchAllDirReferFile(curFile string, referStr string) (refe
 		return
 	}
 
-	g := GConfig
+	g := d.conf
 	strPath := d.GetCompletePath(d.mainDir, referStr)
 	if g.FileExistCache(strPath) {
 		// lua文件存在，正常
@@ -540,7 +546,7 @@ func (d *DirManager) GetAllCompleFile(pathname string, referType ReferType,
 		return nil
 	}
 
-	g := GConfig
+	g := d.conf
 	rd, err := ioutil.ReadDir(pathname)
 	for _, fi := range rd {
 		if fi.IsDir() {
@@ -595,7 +601,7 @@ func (d *DirManager) GetAllCompleFile(pathname string, referType ReferType,
 		}
 
 		// 获取下路径分割符
-		pathSeparator := GConfig.GetPathSeparator()
+		pathSeparator := g.GetPathSeparator()
 		if pathSeparator != "/" {
 			// 分割符进行替换
 			pathBeforeStr = strings.Replace(pathBeforeStr, "/", pathSeparator, -1)
@@ -780,6 +786,18 @@ func (d *DirManager) GetBestMatchReferFile(curFile string, referFile string, all
 	return
 }
 
+// GetRequireSearchDirs 获取引用其他文件时，按顺序查找的所有目录
+// 依次为主目录、次级目录、类似LUA_LPATH的目录、类似LUA_CPATH的目录
+func (d *DirManager) GetRequireSearchDirs() (dirList []string) {
+	if d.mainDir != "" {
+		dirList = append(dirList, d.mainDir)
+	}
+	dirList = append(dirList, d.subDirVec...)
+	dirList = append(dirList, d.clientLuaLPaths...)
+	dirList = append(dirList, d.clientLuaCPaths...)
+	return dirList
+}
+
 // IsDirExistWorkspace 判断当前文件夹下是否存在于 当前项目中的mainDir 和 subDirs中
 func (d *DirManager) IsDirExistWorkspace(path string) bool {
 	if !filefolder.IsDirExist(path) {
@@ -93,6 +93,45 @@ const (
 	// 枚举代码段中的指向的变量值不能重复
 	CheckErrorEnumValue = 29
 
+	// table的成员变量被读取了，但是整个工程中都没有对它赋值过(可选)
+	CheckErrorFieldNotWrite = 30
+
+	// CheckErrorMoocExtension moocscript 中extension扩展的类没有定义
+	CheckErrorMoocExtension = 31
+
+	// CheckErrorMoocDuplicateMethod moocscript 中class或struct定义了同名的成员函数或变量
+	CheckErrorMoocDuplicateMethod = 32
+
+	// CheckErrorMoocSelfSuper moocscript 中在class外面使用了Self或Super
+	CheckErrorMoocSelfSuper = 33
+
+	// CheckErrorMoocImportNum moocscript 中 import a, b from "x" { A } 导入的名称与成员的个数不一致
+	CheckErrorMoocImportNum = 34
+
+	// CheckErrorMoocUnreachable moocscript 中guard代码块中，跳转语句后面的代码不会执行
+	CheckErrorMoocUnreachable = 35
+
+	// CheckErrorMoocDuplicateCase moocscript 中switch有重复的case值
+	CheckErrorMoocDuplicateCase = 36
+
+	// CheckErrorUnreachable 不会执行到的代码，例如return、break、error()后面的语句，或是 if false then 中的代码
+	CheckErrorUnreachable = 37
+
+	// CheckErrorGlobalFuncNotCall 定义的全局函数，在整个工程中都没有被引用过(可选)
+	CheckErrorGlobalFuncNotCall = 38
+
+	// CheckErrorLocalRedeclare 同一个作用域中，重复定义了同名的局部变量
+	CheckErrorLocalRedeclare = 39
+
+	// CheckErrorShadowVar 局部变量遮蔽了外层的局部变量、函数参数或是全局变量
+	CheckErrorShadowVar = 40
+
+	// CheckErrorShadowSys 局部变量遮蔽了系统的变量，例如 local table = {}
+	CheckErrorShadowSys = 41
+
+	// CheckErrorLoopVarCapture 循环变量被闭包引用，且在循环中又被修改了
+	CheckErrorLoopVarCapture = 42
+
 	// CheckErrorMax
-	CheckErrorMax = 30
+	CheckErrorMax = 43
 )
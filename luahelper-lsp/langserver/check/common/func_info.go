@@ -30,9 +30,10 @@ type LabelInfo struct {
 
 // ReturnItem 一个返回数据，有可能是返回整数或是其他的数值，那么LocVar和GlobalVar都为nil
 type ReturnItem struct {
-	VarType   LuaType  // 返回值的类型
-	VarInfo   *VarInfo // 关联的变量指针
-	ReturnExp ast.Exp  // 关联的exp
+	VarType   LuaType   // 返回值的类型
+	VarInfo   *VarInfo  // 关联的变量指针
+	ReturnExp ast.Exp   // 关联的exp
+	ReferFunc *FuncInfo // 如果返回的是函数调用，例如return a.b()，指向被调用的函数，nil表示没有找到
 }
 
 // ReturnInfo 函数返回信息
@@ -61,6 +62,8 @@ type FuncInfo struct {
 	FuncName         string              // 例如 function table.func() end // func即FuncName
 	ParamType        map[string][]string // 函数所有的参数注解类型列表 参数可能有多个类型 number|string
 	ReturnType       [][]string          // 函数注解处的返回值类型 返回值只能按顺序查找
+	InferReturnType  [][]string          // 没有返回值注解时，根据所有的return语句推导出来的返回值类型，缓存起来
+	InferReturnInit  bool                // 是否推导过返回值类型
 }
 
 // CreateFuncInfo 创建一个函数指针
@@ -95,6 +98,19 @@ func CreateFuncInfo(parent *FuncInfo, funcLv int, loc lexer.Location, isVararg b
 	return funcInfo
 }
 
+// ResetBody 清空函数体分析产生的信息，重新创建函数的主scope，用于只重新分析函数体
+func (fun *FuncInfo) ResetBody() {
+	fun.labelVecs = nil
+	fun.ReturnVecs = nil
+	fun.ParamList = nil
+	fun.ScopeLv = 0
+	fun.ParamDefaultNum = -1
+	fun.ParamDefaultInit = false
+	fun.InferReturnType = nil
+	fun.InferReturnInit = false
+	fun.MainScope = CreateScopeInfo(fun.MainScope.Parent, fun, fun.Loc)
+}
+
 // EnterScope scope层+1
 func (fun *FuncInfo) EnterScope() {
 	fun.ScopeLv++
@@ -207,6 +223,51 @@ func (fun *FuncInfo) GetReturnIndexExp(index uint8) (flag bool, exp ast.Exp) {
 	return
 }
 
+// GetReturnIndexExpList 获取函数所有return语句中，指定位置的返回值，忽略掉nil
+// index 默认从1开始，如果为1表示获取第一个返回值
+func (fun *FuncInfo) GetReturnIndexExpList(index uint8) (expList []ast.Exp) {
+	for _, oneReturn := range fun.ReturnVecs {
+		if len(oneReturn.ReturnVarVec) < (int)(index) {
+			continue
+		}
+
+		exp := oneReturn.ReturnVarVec[index-1].ReturnExp
+		if _, ok := exp.(*ast.NilExp); ok {
+			continue
+		}
+
+		expList = append(expList, exp)
+	}
+
+	return expList
+}
+
+// IsReturnIndexMayNil 判断函数指定位置的返回值是否可能为nil，有return语句返回nil，或是返回值的个数少
+// index 默认从1开始，如果为1表示获取第一个返回值
+func (fun *FuncInfo) IsReturnIndexMayNil(index uint8) bool {
+	for _, oneReturn := range fun.ReturnVecs {
+		returnLen := len(oneReturn.ReturnVarVec)
+		if returnLen < (int)(index) {
+			if returnLen == 0 {
+				return true
+			}
+
+			// 最后一个返回值为函数调用或是...时，可能返回多个值
+			switch oneReturn.ReturnVarVec[returnLen-1].ReturnExp.(type) {
+			case *ast.FuncCallExp, *ast.VarargExp:
+				continue
+			}
+			return true
+		}
+
+		if _, ok := oneReturn.ReturnVarVec[index-1].ReturnExp.(*ast.NilExp); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetFuncCompleteStr 获取函数的代码提示，包含函数的参数
 // paramTipFlag 表示是否提示函数的参数
 // colonFlag 如果是冒号语法，有时候需要忽略掉self
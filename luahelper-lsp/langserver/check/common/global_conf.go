@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/parser"
 	"luahelper-lsp/langserver/filefolder"
 	"luahelper-lsp/langserver/log"
 	"path"
@@ -29,6 +30,11 @@ type GlobalConfig struct {
 	// 如果是包含工程的入口文件，配置读取，后台专门定制的特性
 	ProjectFiles []string
 
+	// 客户端设置的告警开关，以及忽略的文件与文件夹，重新读取配置文件时沿用
+	clientCheckFlagList      []bool
+	clientIgnoreFileOrDir    []string
+	clientIgnoreFileOrDirErr []string
+
 	// 是否开启告警
 	showWarnFlag bool
 
@@ -132,9 +138,18 @@ type GlobalConfig struct {
 	// 所有后缀文件关联到lua类型, 例如有的.txt后缀文件，会当成lua文件处理
 	AssocialList []string
 
-	ReferenceMaxNum     int  // 查找引用时候，返回的最大的引用数量
-	ReferenceDefineFlag bool // 查找引用时候，是否需要显示定义
-	PreviewFieldsNum    int  // 当hover一个table时，显示最多field的数量
+	// 客户端文件关联到的lua方言，key为文件的匹配模式，value为方言的名称，例如 {"*.typed.lua": "luau"}
+	clientDialectMap map[string]string
+
+	// 合并客户端与luahelper.json中配置后的文件关联的方言，只包含注册了的方言
+	dialectMap map[string]string
+
+	ReferenceMaxNum       int  // 查找引用时候，返回的最大的引用数量
+	ReferenceDefineFlag   bool // 查找引用时候，是否需要显示定义
+	PreviewFieldsNum      int  // 当hover一个table时，显示最多field的数量
+	WorkspaceSymbolMaxNum int  // 查找工程的符号时，返回的最大数量
+
+	BustedPath string // 运行busted测试时的命令路径，默认从PATH中查找busted
 
 	// 查询_G.a 这样的全局符号，a是否会扩大到全局符号定义
 	// 例如前面定义了a=1,  那么此时_G.a 会指向前面的a=1
@@ -160,19 +175,21 @@ type GlobalConfig struct {
 
 	// Mooc 中忽略的全局变量名，涉及到 import lib from "lib" 中，将 from 映射为了 require
 	MoocIgnoreVarMap map[string]string
-}
 
-// GConfig *GlobalConfig 全局配置对象初始化
-var GConfig *GlobalConfig
+	// 读取的luahelper.json配置
+	jsonConfig *JSONConfig
+}
 
 // 创建默认的GlobalConfig
-func createDefaultGlobalConfig() {
-	GConfig = &GlobalConfig{
+func createDefaultGlobalConfig() *GlobalConfig {
+	g := &GlobalConfig{
 		ReadJSONFlag:           false,
 		ReferMatchPathFlag:     false,
 		showWarnFlag:           false,
 		ReferenceMaxNum:        3000,
 		PreviewFieldsNum:       30,
+		WorkspaceSymbolMaxNum:  200,
+		BustedPath:             "busted",
 		ReferenceDefineFlag:    true,
 		GVarExtendGlobalFlag:   true,
 		colonFlag:              0,
@@ -184,9 +201,12 @@ func createDefaultGlobalConfig() {
 		IgnoreWildcarVarMap:    []string{},
 		PathSeparator:          ".",
 		anntotateSets:          []AnntotateSet{},
-		dirManager:             createDirManager(),
 		OtherDir:               "",
+		jsonConfig:             createDefaultJSONCfig(),
 	}
+	g.dirManager = createDirManager(g)
+
+	return g
 }
 
 // OneTipFile 提示的一个文件信息
@@ -271,17 +291,15 @@ type (
 		AnntotateSets         []AnntotateSet      `json:"AnntotateSets"`         // 自动推导的注解方式
 		OtherDir              string              `json:"OtherDir"`              // 引入另外一个目录，可以用于设置引入额外LuaHelper注解格式文件夹
 		OpenErrorTypes        []int               `json:"OpenErrorTypes"`        // 开启的告警项
+		Dialects              map[string]string   `json:"Dialects"`              // 文件关联的lua方言或嵌入lua代码的文件类型，例如 {"*.typed.lua": "luau", "*.conf": "nginx"}
 		ProjectLuaLPath       string              `json:"ProjectLuaLPath"`       // 工程 LuaLPath
 		ProjectLuaCPath       string              `json:"ProjectLuaCPath"`       // 工程 LuaCPath
 	}
 )
 
-// 对外封装的json全局配置信息
-var jsonConfig *JSONConfig
-
 // 创建默认的json config
-func createDefaultJSONCfig() {
-	jsonConfig = &JSONConfig{
+func createDefaultJSONCfig() *JSONConfig {
+	return &JSONConfig{
 		BaseDir:               "./",
 		ShowWarnFlag:          1,
 		ReferMatchPathFlag:    0,
@@ -302,18 +320,20 @@ func createDefaultJSONCfig() {
 		PathSeparator:         ".",
 		AnntotateSets:         []AnntotateSet{},
 		OpenErrorTypes:        []int{},
+		Dialects:              map[string]string{},
 		ProjectLuaLPath:       "",
 		ProjectLuaCPath:       "",
 	}
 }
 
-// GlobalConfigDefautInit 默认的初始化
-func GlobalConfigDefautInit() {
+// CreateGlobalConfig 创建一份默认的配置，每个客户端的连接单独持有一份配置，相互之间不会影响
+func CreateGlobalConfig() *GlobalConfig {
 	// 创建默认的GlobalConfig
-	createDefaultGlobalConfig()
+	g := createDefaultGlobalConfig()
 
-	// 创建默认的json config
-	createDefaultJSONCfig()
+	// 初始化系统的变量与代码补全的提示
+	g.IntialGlobalVar()
+	return g
 }
 
 func (g *GlobalConfig) setSysNotUseMap() {
@@ -552,11 +572,11 @@ func (g *GlobalConfig) handleNotJSONCheckFlag(checkFlagList []bool, ignoreFileOr
 	g.OtherDir = ""
 
 	// 添加引入文件的方式
-	for _, oneReferFrame := range jsonConfig.ReferFrameFiles {
-		GConfig.ReferOtherFileMap[oneReferFrame.Name] = true
-		GConfig.LuaInMap[oneReferFrame.Name] = "function"
+	for _, oneReferFrame := range g.jsonConfig.ReferFrameFiles {
+		g.ReferOtherFileMap[oneReferFrame.Name] = true
+		g.LuaInMap[oneReferFrame.Name] = "function"
 	}
-	GConfig.ReferFrameFiles = jsonConfig.ReferFrameFiles
+	g.ReferFrameFiles = g.jsonConfig.ReferFrameFiles
 
 	// 忽略对某些文件或文件夹进行check分析， 包含go语言的正则
 	g.IgnoreHandleFolderVec = make([]string, 0, 2)
@@ -619,6 +639,7 @@ func (g *GlobalConfig) handleNotJSONCheckFlag(checkFlagList []bool, ignoreFileOr
 
 // HandleChangeCheckList 客户端的告警配置有改动
 func (g *GlobalConfig) HandleChangeCheckList(checkFlagList []bool, ignoreFileOrDir []string, ignoreFileOrDirErr []string) {
+	g.setClientCheckList(checkFlagList, ignoreFileOrDir, ignoreFileOrDirErr)
 	if g.ReadJSONFlag {
 		// 如果是读取json配置，忽略客户端的配置
 		return
@@ -632,6 +653,7 @@ func (g *GlobalConfig) HandleChangeCheckList(checkFlagList []bool, ignoreFileOrD
 // 返回值为空表示成功，其他表示失败
 func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList []bool, ignoreFileOrDir []string,
 	ignoreFileOrDirErr []string) error {
+	g.setClientCheckList(checkFlagList, ignoreFileOrDir, ignoreFileOrDirErr)
 	strPath := g.dirManager.GetCompletePath(strDir, configFileName)
 
 	bytes, err := ioutil.ReadFile(strPath)
@@ -643,49 +665,49 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 	}
 
 	//var data configInfo
-	err = json.Unmarshal(bytes, jsonConfig)
+	err = json.Unmarshal(bytes, g.jsonConfig)
 	if err != nil {
 		strErr := fmt.Sprintf("read %s error=%s, json format error", configFileName, err.Error())
 		return errors.New(strErr)
 	}
 
-	g.anntotateSets = jsonConfig.AnntotateSets
+	g.anntotateSets = g.jsonConfig.AnntotateSets
 
 	// 读取到了json文件
 	g.ReadJSONFlag = true
 
-	if jsonConfig.BaseDir == "" {
-		jsonConfig.BaseDir = "./"
+	if g.jsonConfig.BaseDir == "" {
+		g.jsonConfig.BaseDir = "./"
 	}
 
-	g.OtherDir = jsonConfig.OtherDir
-	g.dirManager.setConfigRelativeDir(jsonConfig.BaseDir)
+	g.OtherDir = g.jsonConfig.OtherDir
+	g.dirManager.setConfigRelativeDir(g.jsonConfig.BaseDir)
 
-	g.ProjectFiles = jsonConfig.ProjectFiles
+	g.ProjectFiles = g.jsonConfig.ProjectFiles
 
-	g.ReferMatchPathFlag = (jsonConfig.ReferMatchPathFlag == 1)
-	g.showWarnFlag = (jsonConfig.ShowWarnFlag == 1)
+	g.ReferMatchPathFlag = (g.jsonConfig.ReferMatchPathFlag == 1)
+	g.showWarnFlag = (g.jsonConfig.ShowWarnFlag == 1)
 
-	g.IgnoreFileNameVarFlag = (jsonConfig.IgnoreFileNameVarFlag == 1)
+	g.IgnoreFileNameVarFlag = (g.jsonConfig.IgnoreFileNameVarFlag == 1)
 
-	g.ProtocolVars = jsonConfig.ProtocolVars
+	g.ProtocolVars = g.jsonConfig.ProtocolVars
 	g.ProtocolPreIngoreFlag = false
-	if jsonConfig.ProtocolPreIngoreFlag == 1 {
+	if g.jsonConfig.ProtocolPreIngoreFlag == 1 {
 		g.ProtocolPreIngoreFlag = true
 	}
 
 	// 忽略的模块
 	g.IgnoreVarMap = map[string]string{}
-	for _, modeStr := range jsonConfig.IgnoreModules {
+	for _, modeStr := range g.jsonConfig.IgnoreModules {
 		g.IgnoreVarMap[modeStr] = "module"
 	}
 
 	g.IgnoreWildcarVarMap = []string{}
-	g.IgnoreWildcarVarMap = append(g.IgnoreWildcarVarMap, jsonConfig.IgnoreWildcardModules...)
+	g.IgnoreWildcarVarMap = append(g.IgnoreWildcarVarMap, g.jsonConfig.IgnoreWildcardModules...)
 
 	// 指定文件，忽略的变量
 	g.IgnoreFileDefineVarMap = map[string](map[string]bool){}
-	for _, ignoreFileVars := range jsonConfig.IgnoreFileVars {
+	for _, ignoreFileVars := range g.jsonConfig.IgnoreFileVars {
 		fileMapVars := map[string]bool{}
 		for _, fileVarsStr := range ignoreFileVars.Vars {
 			fileMapVars[fileVarsStr] = true
@@ -696,26 +718,26 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 
 	// 忽略某些读不到的文件，不进行报错
 	g.IgnoreReferFileMap = map[string]bool{}
-	for _, ignoreFileStr := range jsonConfig.IgnoreReadFiles {
+	for _, ignoreFileStr := range g.jsonConfig.IgnoreReadFiles {
 		g.IgnoreReferFileMap[ignoreFileStr] = true
 	}
 
 	// 忽略指定类型的错误
 	g.IgnoreErrorTypeMap = map[CheckErrorType]bool{}
-	for _, errorType := range jsonConfig.IgnoreErrorTypes {
+	for _, errorType := range g.jsonConfig.IgnoreErrorTypes {
 		g.IgnoreErrorTypeMap[(CheckErrorType)(errorType)] = true
 	}
 
 	// 开启指定类型的告警检查
 	g.OpenErrorTypeMap = map[CheckErrorType]bool{}
-	for _, errorType := range jsonConfig.OpenErrorTypes {
+	for _, errorType := range g.jsonConfig.OpenErrorTypes {
 		g.OpenErrorTypeMap[(CheckErrorType)(errorType)] = true
 	}
 
 	// 忽略对某些文件或文件夹进行check分析， 包含go语言的正则
 	g.IgnoreHandleFolderVec = make([]string, 0, 2)
 	g.IgnoreHandleFileVec = make([]string, 0, 2)
-	for _, fileOrFloder := range jsonConfig.IgnoreFileOrFloder {
+	for _, fileOrFloder := range g.jsonConfig.IgnoreFileOrFloder {
 		if strings.HasSuffix(fileOrFloder, ".lua") || strings.HasSuffix(fileOrFloder, ".mooc") {
 			g.IgnoreHandleFileVec = append(g.IgnoreHandleFileVec, fileOrFloder)
 		} else {
@@ -726,7 +748,7 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 	// 忽略指定文件中的指定错误
 	g.IgnoreFileErrTypesMap = map[string](map[int]bool){}
 	g.IgnoreFileErrTypesRegexp = map[string]*regexp.Regexp{}
-	for _, ignoreTypeVars := range jsonConfig.IgnoreFileErrTypes {
+	for _, ignoreTypeVars := range g.jsonConfig.IgnoreFileErrTypes {
 		fileTypesVars := map[int]bool{}
 		for _, typeError := range ignoreTypeVars.Types {
 			fileTypesVars[typeError] = true
@@ -740,7 +762,7 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 	g.IgnoreErrorFloderVec = make([]string, 0, 2)
 	g.IgnoreErrorFileVec = make([]string, 0, 2)
 	g.IgnoreErrorFileOrFloderRegexp = map[string]*regexp.Regexp{}
-	for _, fileOrFloder := range jsonConfig.IgnoreFileErr {
+	for _, fileOrFloder := range g.jsonConfig.IgnoreFileErr {
 		if strings.HasSuffix(fileOrFloder, ".lua") || strings.HasSuffix(fileOrFloder, ".mooc") {
 			g.IgnoreErrorFileVec = append(g.IgnoreErrorFileVec, fileOrFloder)
 		} else {
@@ -754,52 +776,75 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 
 	// 局部变量定义了，未使用，忽略
 	g.IgnoreLocalNoUseVarMap = map[string]bool{}
-	for _, noUseStr := range jsonConfig.IgnoreLocalNoUseVars {
+	for _, noUseStr := range g.jsonConfig.IgnoreLocalNoUseVars {
 		g.IgnoreLocalNoUseVarMap[noUseStr] = true
 	}
 
 	// 默认为后台的hive框架，会import引入一个文件，并且包含后缀，引入的方式为 import("one.lua")
-	if len(jsonConfig.ReferFrameFiles) == 0 {
-		jsonConfig.ReferFrameFiles = []referFrameFile{{Name: "import", Type: 0, SuffixFlag: 1}}
+	if len(g.jsonConfig.ReferFrameFiles) == 0 {
+		g.jsonConfig.ReferFrameFiles = []referFrameFile{{Name: "import", Type: 0, SuffixFlag: 1}}
 	}
 
 	// 添加引入文件的方式
-	for _, oneReferFrame := range jsonConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.jsonConfig.ReferFrameFiles {
 		g.ReferOtherFileMap[oneReferFrame.Name] = true
 		g.LuaInMap[oneReferFrame.Name] = "function"
 	}
-	GConfig.ReferFrameFiles = jsonConfig.ReferFrameFiles
+	g.ReferFrameFiles = g.jsonConfig.ReferFrameFiles
 
-	if jsonConfig.PathSeparator != "" {
-		GConfig.PathSeparator = jsonConfig.PathSeparator
+	if g.jsonConfig.PathSeparator != "" {
+		g.PathSeparator = g.jsonConfig.PathSeparator
 	}
 
 	// 工程类似的 LuaPath
-	if jsonConfig.ProjectLuaLPath != "" {
-		GConfig.dirManager.clientLuaLPaths = strings.Split(jsonConfig.ProjectLuaLPath, ";")
-		for i, dir := range GConfig.dirManager.clientLuaLPaths {
+	if g.jsonConfig.ProjectLuaLPath != "" {
+		g.dirManager.clientLuaLPaths = strings.Split(g.jsonConfig.ProjectLuaLPath, ";")
+		for i, dir := range g.dirManager.clientLuaLPaths {
 			if !strings.HasSuffix(dir, "/") {
-				GConfig.dirManager.clientLuaLPaths[i] = dir + "/"
+				g.dirManager.clientLuaLPaths[i] = dir + "/"
 			}
 		}
 	}
 
 	// 工程类似的 LuaCPath
-	if jsonConfig.ProjectLuaCPath != "" {
-		GConfig.dirManager.clientLuaCPaths = strings.Split(jsonConfig.ProjectLuaCPath, ";")
-		for i, dir := range GConfig.dirManager.clientLuaCPaths {
+	if g.jsonConfig.ProjectLuaCPath != "" {
+		g.dirManager.clientLuaCPaths = strings.Split(g.jsonConfig.ProjectLuaCPath, ";")
+		for i, dir := range g.dirManager.clientLuaCPaths {
 			if !strings.HasSuffix(dir, "/") {
-				GConfig.dirManager.clientLuaCPaths[i] = dir + "/"
+				g.dirManager.clientLuaCPaths[i] = dir + "/"
 			}
 		}
 	}
 
-	GConfig.MoocInsertIngoreSystemModule()
+	// 文件关联的lua方言
+	g.updateDialectAssocial()
+
+	g.MoocInsertIngoreSystemModule()
 
 	log.Debug("read ok")
 	return nil
 }
 
+// ReloadConfig 重新读取配置文件，之前从配置文件读取到的配置丢弃，客户端的告警设置沿用之前的
+// 配置文件被删除时，恢复为没有配置文件时的默认值
+func (g *GlobalConfig) ReloadConfig(strDir, configFileName string) error {
+	g.jsonConfig = createDefaultJSONCfig()
+	g.ProjectFiles = nil
+	g.ReferMatchPathFlag = false
+	g.dirManager.setConfigRelativeDir("./")
+	g.dirManager.clientLuaLPaths = nil
+	g.dirManager.clientLuaCPaths = nil
+	return g.ReadConfig(strDir, configFileName, g.clientCheckFlagList, g.clientIgnoreFileOrDir,
+		g.clientIgnoreFileOrDirErr)
+}
+
+// setClientCheckList 保存客户端设置的告警开关，以及忽略的文件与文件夹
+func (g *GlobalConfig) setClientCheckList(checkFlagList []bool, ignoreFileOrDir []string, ignoreFileOrDirErr []string) {
+	g.clientCheckFlagList = checkFlagList
+	g.clientIgnoreFileOrDir = ignoreFileOrDir
+	g.clientIgnoreFileOrDirErr = ignoreFileOrDirErr
+}
+
 // SetRequirePathSeparator 设置require其他lua文件时候的路径分割符
 func (g *GlobalConfig) SetRequirePathSeparator(pathSeparator string) {
 	if g.ReadJSONFlag {
@@ -811,16 +856,31 @@ func (g *GlobalConfig) SetRequirePathSeparator(pathSeparator string) {
 		pathSeparator = "."
 	}
 
-	GConfig.PathSeparator = pathSeparator
+	g.PathSeparator = pathSeparator
 }
 
 // SetPreviewFieldsNum set preview fields num
 func (g *GlobalConfig) SetPreviewFieldsNum(num int) {
 	if num > 0 {
-		GConfig.PreviewFieldsNum = num
+		g.PreviewFieldsNum = num
 	}
 }
 
+// SetWorkspaceSymbolMaxNum set workspace symbol max num
+func (g *GlobalConfig) SetWorkspaceSymbolMaxNum(num int) {
+	if num > 0 {
+		g.WorkspaceSymbolMaxNum = num
+	}
+}
+
+// SetBustedPath 设置运行测试的busted命令路径，为空时使用默认的busted
+func (g *GlobalConfig) SetBustedPath(strPath string) {
+	if strPath == "" {
+		strPath = "busted"
+	}
+	g.BustedPath = strPath
+}
+
 // InsertIngoreSystemModule 如果为本地形式运行，加载不了插件前端的Lua额外文件夹，忽略系统模块。批量插入
 func (g *GlobalConfig) InsertIngoreSystemModule() {
 	g.IgnoreVarMap["debug"] = "module"
@@ -1088,7 +1148,8 @@ func (g *GlobalConfig) IsHandleAsLua(strFile string) bool {
 		return true
 	}
 
-	if ok := strings.HasSuffix(strFile, ".mooc"); ok {
+	// 判断是否为其他方言的文件，例如.mooc、.luau后缀，或是配置关联到了方言
+	if _, ok := g.GetFileDialect(strFile); ok {
 		return true
 	}
 
@@ -1296,7 +1357,7 @@ func (g *GlobalConfig) IsHasProjectEntryFile() bool {
 
 // IsFrameReferOtherFile 判断传入的函数是否为新增的框架引入其他文件的方式
 func (g *GlobalConfig) IsFrameReferOtherFile(strFile string) bool {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name == strFile {
 			return true
 		}
@@ -1307,7 +1368,7 @@ func (g *GlobalConfig) IsFrameReferOtherFile(strFile string) bool {
 
 // IsImportSuffixFlag 引起其他的框架文件，判断是否要包括.lua后缀
 func (g *GlobalConfig) IsImportSuffixFlag(referNameStr string) bool {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name != referNameStr {
 			continue
 		}
@@ -1326,7 +1387,7 @@ func (g *GlobalConfig) IsImportSuffixFlag(referNameStr string) bool {
 func (g *GlobalConfig) GetReferFrameSubType(referNameStr string) (referSubType ReferFrameType) {
 	referSubType = RtypeImport
 
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name != referNameStr {
 			continue
 		}
@@ -1350,7 +1411,7 @@ func (g *GlobalConfig) GetAllReferFileTypes() (strArray []string) {
 	strArray = append(strArray, "dofile")
 	strArray = append(strArray, "loadfile")
 	strArray = append(strArray, "require")
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		strArray = append(strArray, oneReferFrame.Name)
 	}
 
@@ -1359,7 +1420,7 @@ func (g *GlobalConfig) GetAllReferFileTypes() (strArray []string) {
 
 // GetFrameReferFiles 获取框架中引入的其他文件方式的列表
 func (g *GlobalConfig) GetFrameReferFiles() (strArray []string) {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		strArray = append(strArray, oneReferFrame.Name)
 	}
 
@@ -1382,6 +1443,31 @@ func (g *GlobalConfig) SetAssocialList(associalList []string) {
 	g.AssocialList = associalList
 }
 
+// SetClientDialectMap 设置客户端文件关联到的lua方言，与luahelper.json中配置的方言合并，json中的配置优先
+func (g *GlobalConfig) SetClientDialectMap(dialectMap map[string]string) {
+	g.clientDialectMap = dialectMap
+	g.updateDialectAssocial()
+}
+
+// updateDialectAssocial 合并客户端与luahelper.json中配置的方言
+func (g *GlobalConfig) updateDialectAssocial() {
+	dialectMap := map[string]string{}
+	for pattern, name := range g.clientDialectMap {
+		dialectMap[pattern] = name
+	}
+
+	for pattern, name := range g.jsonConfig.Dialects {
+		dialectMap[pattern] = name
+	}
+
+	g.dialectMap = parser.FilterDialectAssocial(dialectMap)
+}
+
+// GetFileDialect 获取文件对应的lua方言，ok表示是否通过后缀或是文件关联，匹配到了非标准lua的方言
+func (g *GlobalConfig) GetFileDialect(strFile string) (name string, ok bool) {
+	return parser.GetFileDialect(strFile, g.dialectMap)
+}
+
 // GetAssocialList 获取所有的关联的list
 func (g *GlobalConfig) GetAssocialList() (associalList []string) {
 	return g.AssocialList
@@ -1436,7 +1522,7 @@ func (g *GlobalConfig) IsSpecialCheck() bool {
 
 	// 需要特殊校验的告警类型
 	errTypeList := []CheckErrorType{CheckErrorNoDefine, CheckErrorCycleDefine, CheckErrorCallParam,
-		CheckErrorImportVar, CheckErrorNotIfVar}
+		CheckErrorImportVar, CheckErrorNotIfVar, CheckErrorMoocExtension, CheckErrorShadowVar}
 
 	// 判断是否屏蔽了上面的告警类型
 	for _, errType := range errTypeList {
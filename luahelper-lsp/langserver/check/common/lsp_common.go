@@ -24,6 +24,16 @@ const (
 	IKAnnotateClass ItemKind = 5
 	// IKAnnotateAlias 注解的alias类型
 	IKAnnotateAlias ItemKind = 6
+	// IKMethod 冒号定义的成员函数
+	IKMethod ItemKind = 7
+	// IKClass moocscript中定义的类
+	IKClass ItemKind = 8
+	// IKEnum 注解为枚举的table
+	IKEnum ItemKind = 9
+	// IKEnumMember 枚举table的成员
+	IKEnumMember ItemKind = 10
+	// IKModule 文件模块
+	IKModule ItemKind = 11
 	// IKSnippet 注释
 	IKSnippet ItemKind = 15
 	// IKConstant 常量
@@ -40,6 +50,8 @@ type FileSymbolStruct struct {
 	ContainerName string             // 包含者
 	FileName      string             // 包含符号的lua文件名
 	Children      []FileSymbolStruct // 当前block中包含的Symbol
+	Detail        string             // 符号的详细信息，函数为函数的签名
+	VarInfo       *VarInfo           // 符号对应的变量，注解产生的符号为nil
 }
 
 // CompletionItemStruct 代码提示字段
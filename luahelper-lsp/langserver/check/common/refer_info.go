@@ -52,13 +52,14 @@ type ReferInfo struct {
 	ReferStr      string         // 引用指向的名称,例如 one.lua
 	ReferValidStr string         // 引用指向的有效lua文件，例如require("two"), 实际上是引用的two.lua, 如果为空表示忽略引入的，可能是引入的.so
 	Loc           lexer.Location // 具体的位置信息
+	StrLoc        lexer.Location // 引用名称字符串的位置，例如require("one")中"one"的位置
 	ReferVarLocal bool           // 引用如果赋值给了变量，true表示赋值的变量是否为local变量
 	Valid         bool           // 第一遍check AST是否有效，如果无效，引用不用跟入进去分析，默认为true
 }
 
 // CreateOneReferInfo 创建一个引用信息
-func CreateOneReferInfo(referTypeStr, referStr string, loc lexer.Location) *ReferInfo {
-	referType := StrToReferType(referTypeStr)
+func (g *GlobalConfig) CreateOneReferInfo(referTypeStr, referStr string, loc lexer.Location) *ReferInfo {
+	referType := g.StrToReferType(referTypeStr)
 	if referType == ReferNotValid {
 		return nil
 	}
@@ -75,7 +76,7 @@ func CreateOneReferInfo(referTypeStr, referStr string, loc lexer.Location) *Refe
 }
 
 // StrToReferType 字符串转换为对应的ReferType
-func StrToReferType(referTypeStr string) (referType ReferType) {
+func (g *GlobalConfig) StrToReferType(referTypeStr string) (referType ReferType) {
 	if referTypeStr == "dofile" {
 		referType = ReferTypeDofile
 	} else if referTypeStr == "loadfile" {
@@ -83,7 +84,7 @@ func StrToReferType(referTypeStr string) (referType ReferType) {
 	} else if referTypeStr == "require" {
 		referType = ReferTypeRequire
 	} else {
-		if GConfig.IsFrameReferOtherFile(referTypeStr) {
+		if g.IsFrameReferOtherFile(referTypeStr) {
 			referType = ReferTypeFrame
 		} else {
 			referType = ReferNotValid
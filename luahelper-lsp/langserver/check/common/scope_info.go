@@ -429,26 +429,14 @@ func (scope *ScopeInfo) FindAllLocalVal(gScopes []*ScopeInfo) (allSymbolStruct [
 			Kind:          IKVariable,
 			Loc:           oneLocInfo.Loc,
 			ContainerName: "local",
+			VarInfo:       oneLocInfo,
 		}
 
 		if oneLocInfo.ReferFunc != nil {
 			oneSymbol.Kind = IKFunction
 			oneSymbol.Loc = oneLocInfo.ReferFunc.Loc
+			oneSymbol.Detail = oneLocInfo.GetFuncSymbolDetail(strVar)
 			curChirenScope := oneLocInfo.ReferFunc.MainScope
-			paramStr := ""
-			for _, param := range oneLocInfo.ReferFunc.ParamList {
-				if param == "self" {
-					continue
-				}
-				if paramStr == "" {
-					paramStr += param
-				} else {
-					paramStr += ", " + param
-				}
-			}
-			if paramStr != "" {
-				oneSymbol.Name = oneSymbol.Name + "(" + paramStr + ")"
-			}
 			delete(scopeInfos, curChirenScope)
 		} else {
 			if oneLocInfo.SubMaps == nil {
@@ -456,6 +444,8 @@ func (scope *ScopeInfo) FindAllLocalVal(gScopes []*ScopeInfo) (allSymbolStruct [
 				continue
 			}
 
+			oneSymbol.Kind = oneLocInfo.GetTableSymbolKind()
+
 			var maxLoc lexer.Location
 			maxLoc.EndLine = oneSymbol.Loc.EndLine
 			maxLoc.EndColumn = oneSymbol.Loc.EndColumn
@@ -483,7 +473,7 @@ func (scope *ScopeInfo) FindAllLocalVal(gScopes []*ScopeInfo) (allSymbolStruct [
 			}
 
 			oneSymbol.Loc.EndLine = maxLoc.EndLine
-			oneSymbol.Loc.StartColumn = maxLoc.EndColumn
+			oneSymbol.Loc.EndColumn = maxLoc.EndColumn
 		}
 
 		allSymbolStruct = append(allSymbolStruct, oneSymbol)
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"sort"
+	"strings"
+)
+
+// TableShapeField table结构类型中的一个成员
+type TableShapeField struct {
+	Name    string         // 成员的名称
+	TypeVec []string       // 成员所有可能的类型，多次赋值不同的类型时取并集
+	Loc     lexer.Location // 成员首次出现的位置
+}
+
+// TableShape table的结构类型，由构造表达式以及后续的 t.x = ... 赋值推导出来
+// 例如：
+// local t = { x = 1 }
+// t.name = "a"
+// t的结构类型为 { x: number, name: string }
+type TableShape struct {
+	FieldVec []*TableShapeField // 所有的成员，按首次出现的位置排序
+}
+
+// GetTypeStr 获取成员的类型字符串，多个类型用|连接
+func (field *TableShapeField) GetTypeStr() string {
+	if len(field.TypeVec) == 0 {
+		return "any"
+	}
+
+	return strings.Join(field.TypeVec, "|")
+}
+
+// GetField 获取指定名称的成员，没有找到返回nil
+func (shape *TableShape) GetField(strName string) *TableShapeField {
+	for _, field := range shape.FieldVec {
+		if field.Name == strName {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// String 转换成单行的字符串，例如 { x: number, name: string }
+func (shape *TableShape) String() string {
+	if len(shape.FieldVec) == 0 {
+		return "{ }"
+	}
+
+	strVec := make([]string, 0, len(shape.FieldVec))
+	for _, field := range shape.FieldVec {
+		strVec = append(strVec, field.Name+": "+field.GetTypeStr())
+	}
+
+	return "{ " + strings.Join(strVec, ", ") + " }"
+}
+
+// GetFieldTypeVec 获取变量作为table成员时，所有赋值过的类型，包含定义时候的类型以及后续 t.x = ... 赋值的类型
+// 推导不出来的类型忽略掉，返回空表示完全推导不出来
+func (varInfo *VarInfo) GetFieldTypeVec() (typeVec []string) {
+	strType := GetAnnTypeFromLuaType(varInfo.VarType)
+	if varInfo.ReferFunc != nil {
+		strType = "function"
+	} else if len(varInfo.SubMaps) > 0 {
+		strType = "table"
+	}
+
+	if strType != "LuaTypeRefer" && strType != "any" {
+		typeVec = append(typeVec, strType)
+	}
+
+	for _, oneType := range varInfo.AssignTypeVec {
+		typeVec = AppendNotExistType(typeVec, oneType)
+	}
+
+	return typeVec
+}
+
+// GetFieldTypeDetail 获取变量的类型信息，作为table成员后续赋值过其他类型时，显示所有类型的并集
+func (varInfo *VarInfo) GetFieldTypeDetail() string {
+	if len(varInfo.AssignTypeVec) == 0 {
+		return varInfo.GetVarTypeDetail()
+	}
+
+	return strings.Join(varInfo.GetFieldTypeVec(), "|")
+}
+
+// InsertAssignType 记录table成员变量后续赋值的类型
+func (varInfo *VarInfo) InsertAssignType(luaType LuaType) {
+	strType := GetAnnTypeFromLuaType(luaType)
+	if strType == "LuaTypeRefer" || strType == "any" {
+		return
+	}
+
+	varInfo.AssignTypeVec = AppendNotExistType(varInfo.AssignTypeVec, strType)
+}
+
+// GetTableShape 获取table变量的结构类型，成员的类型由getTypeVec获取
+func (varInfo *VarInfo) GetTableShape(getTypeVec func(subVar *VarInfo) []string) *TableShape {
+	shape := &TableShape{}
+	for strName, subVar := range varInfo.SubMaps {
+		shape.FieldVec = append(shape.FieldVec, &TableShapeField{
+			Name:    strName,
+			TypeVec: getTypeVec(subVar),
+			Loc:     subVar.Loc,
+		})
+	}
+
+	sort.Slice(shape.FieldVec, func(i, j int) bool {
+		iLoc := shape.FieldVec[i].Loc
+		jLoc := shape.FieldVec[j].Loc
+		if iLoc.StartLine != jLoc.StartLine {
+			return iLoc.StartLine < jLoc.StartLine
+		}
+
+		if iLoc.StartColumn != jLoc.StartColumn {
+			return iLoc.StartColumn < jLoc.StartColumn
+		}
+
+		return shape.FieldVec[i].Name < shape.FieldVec[j].Name
+	})
+
+	return shape
+}
+
+// AppendNotExistType 类型列表中插入一个类型，已经存在的忽略
+func AppendNotExistType(typeVec []string, strType string) []string {
+	for _, oneType := range typeVec {
+		if oneType == strType {
+			return typeVec
+		}
+	}
+
+	return append(typeVec, strType)
+}
@@ -1031,6 +1031,48 @@ func GetExpLoc(node ast.Exp) (loc lexer.Location) {
 	return loc
 }
 
+// GetStatLoc 获取语句的位置信息，break等没有位置信息的语句返回空的位置
+func GetStatLoc(node ast.Stat) (loc lexer.Location) {
+	switch stat := node.(type) {
+	case *ast.LabelStat:
+		loc = stat.Loc
+	case *ast.GotoStat:
+		loc = stat.Loc
+	case *ast.DoStat:
+		loc = stat.Loc
+	case *ast.IfStat:
+		loc = stat.Loc
+	case *ast.WhileStat:
+		loc = stat.Loc
+	case *ast.RepeatStat:
+		loc = stat.Loc
+	case *ast.ForNumStat:
+		loc = stat.Loc
+	case *ast.ForInStat:
+		loc = stat.Loc
+	case *ast.AssignStat:
+		loc = stat.Loc
+	case *ast.LocalVarDeclStat:
+		loc = stat.Loc
+	case *ast.LocalFuncDefStat:
+		loc = stat.Loc
+	case *ast.FuncCallStat:
+		loc = stat.Loc
+	case *ast.ClassDefStat:
+		loc = stat.Loc
+	case *ast.SwitchStat:
+		loc = stat.Loc
+	case *ast.ImportDefStat:
+		if stat.Lib != nil {
+			loc = stat.Lib.Loc
+		} else if stat.Name != nil {
+			loc = stat.Name.Loc
+		}
+	}
+
+	return loc
+}
+
 // ChangeFuncSelfToReferVar 冒号 函数，self语法进行转换
 // 判断是否为这样的在冒号函数内, self.b 这样的 self要进行转换为b，统一起来
 // a = {}
@@ -1425,7 +1467,7 @@ func CompleteFilePathToPreStr(pathFile string) (preStr string) {
 }
 
 // JudgeReferSuffixFlag 判断引入其他文件的方式，是不要引入的路径要包含其他文件的后缀
-func JudgeReferSuffixFlag(referType ReferType, referTypeStr string) bool {
+func (g *GlobalConfig) JudgeReferSuffixFlag(referType ReferType, referTypeStr string) bool {
 	if referType == ReferTypeDofile || referType == ReferTypeLoadfile {
 		return true
 	}
@@ -1438,7 +1480,7 @@ func JudgeReferSuffixFlag(referType ReferType, referTypeStr string) bool {
 		return true
 	}
 
-	isSuffixFlag := GConfig.IsImportSuffixFlag(referTypeStr)
+	isSuffixFlag := g.IsImportSuffixFlag(referTypeStr)
 	return isSuffixFlag
 }
 
@@ -1529,3 +1571,20 @@ func IsSelf(strFile string, name string) bool {
 		return false
 	}
 }
+
+// OperatorMetaNameMap 二元运算符对应的元方法名称，去掉了前面的__
+var OperatorMetaNameMap = map[lexer.TkKind]string{
+	lexer.TkOpAdd:    "add",
+	lexer.TkOpMinus:  "sub",
+	lexer.TkOpMul:    "mul",
+	lexer.TkOpDiv:    "div",
+	lexer.TkOpMod:    "mod",
+	lexer.TkOpPow:    "pow",
+	lexer.TkOpIdiv:   "idiv",
+	lexer.TkOpConcat: "concat",
+	lexer.TkOpBand:   "band",
+	lexer.TkOpBor:    "bor",
+	lexer.TkOpWave:   "bxor",
+	lexer.TkOpShl:    "shl",
+	lexer.TkOpShr:    "shr",
+}
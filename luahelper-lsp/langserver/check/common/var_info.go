@@ -100,6 +100,8 @@ type VarInfo struct {
 	Loc             lexer.Location      // 初始定义的位置信息
 	NoUseAssignLocs []lexer.Location    // 局部变量定义了，未直接使用，后面有对其赋值，记录下所有的位置
 	ForCycle        *ForCycleInfo       // 关联的for循环的表达式
+	AssignTypeVec   []string            // table成员变量定义后，后续t.x = ... 赋值的类型，例如 t.x = "a" 记录string
+	MetaExp         ast.Exp             // 通过setmetatable(a, mt)语句设置的原表表达式，为mt
 	VarType         LuaType             // 变量定义的类型
 	VarIndex        uint8               // 当一行语句声明了多个变量时候，例如 local a, b 语句，显示变量的index，默认的为1，例子中a的index为1，b的index为2
 	IsParam         bool                // 是否为函数定义的参数，默认为false
@@ -108,6 +110,7 @@ type VarInfo struct {
 	IsExpEmpty      bool                // 默认为false，指向的ReferExp是否为empty，例如定义的时候 a = nil， 那么IsExpEmpty为true, 当被赋值后，就不为true
 	IsMemFlag       bool                // 是否为其他的变量的成员变量，默认为false
 	IsClose         bool                // 是否为lua5.4 close熟悉的变量
+	IsClass         bool                // 是否为moocscript中class或struct定义的类
 }
 
 // VarGetFlag 变量信息获取的方式
@@ -134,6 +137,7 @@ type Symbol struct {
 	AnnotateComment string           // 注解引入的注释说明
 	StrPreComment   string           // 注解引入的hover或complete前置内容
 	StrPreClassName string           // 是注解class里面的field成员，值为class的name
+	MayNilFlag      bool             // 没有注解的函数调用推导出来的变量，函数有的return返回nil时为true
 }
 
 // GetDefaultSymbol 获取默认的symbol指针
@@ -401,40 +405,53 @@ func (varInfo *VarInfo) FindAllVar(strName string, preStrName string) (symbolStr
 		return
 	}
 
-	fullName := preStrName + "." + strName
-	if varInfo.ReferFunc != nil && varInfo.ReferFunc.IsColon {
-		fullName = preStrName + ":" + strName
-	}
-
 	symbolStruct = FileSymbolStruct{
-		Name: fullName,
-		Kind: IKVariable,
-		Loc:  varInfo.Loc,
+		Name:    strName,
+		Kind:    IKField,
+		Loc:     varInfo.Loc,
+		VarInfo: varInfo,
 	}
 
 	if varInfo.ReferFunc != nil {
+		// 子成员为函数时，详细信息为包含前缀的函数签名
+		fullName := preStrName + "." + strName
 		symbolStruct.Kind = IKFunction
-		symbolStruct.Loc = varInfo.ReferFunc.Loc
-		paramStr := ""
-		// symbolStruct.Children = varInfo.ReferFunc.MainScope.FindAllLocalVal(nil)
-		for _, param := range varInfo.ReferFunc.ParamList {
-			if param == "self" {
-				continue
-			}
-			if paramStr == "" {
-				paramStr += param
-			} else {
-				paramStr += ", " + param
-			}
-		}
-		if paramStr != "" {
-			symbolStruct.Name = symbolStruct.Name + "(" + paramStr + ")"
+		if varInfo.ReferFunc.IsColon {
+			fullName = preStrName + ":" + strName
+			symbolStruct.Kind = IKMethod
 		}
+		symbolStruct.Loc = varInfo.ReferFunc.Loc
+		symbolStruct.Detail = varInfo.GetFuncSymbolDetail(fullName)
 	}
 
 	return
 }
 
+// GetFuncSymbolDetail 变量为函数时，获取显示在符号上的函数签名，冒号函数忽略掉self
+func (varInfo *VarInfo) GetFuncSymbolDetail(fullName string) string {
+	if varInfo.ReferFunc == nil {
+		return ""
+	}
+
+	return "function " + varInfo.ReferFunc.GetFuncCompleteStr(fullName, true, true)
+}
+
+// GetTableSymbolKind 变量为table时，获取显示的符号类型
+// 关联了class注解或是mooc的class为类，包含了函数成员的为模块，否则为普通的变量
+func (varInfo *VarInfo) GetTableSymbolKind() ItemKind {
+	if varInfo.IsClass {
+		return IKClass
+	}
+
+	for _, subVar := range varInfo.SubMaps {
+		if subVar.ReferFunc != nil {
+			return IKModule
+		}
+	}
+
+	return IKVariable
+}
+
 // IsCorrectPosition 判断当前varinfo.loc 是否在查找的loc 之前
 func (varInfo *VarInfo) IsCorrectPosition(loc lexer.Location) bool {
 	// 当前varinfo.Loc 不在loc 之前 直接返回false
@@ -65,6 +65,7 @@ type IfStat struct {
 	Exps   []Exp
 	Blocks []*Block
 	Loc    lexer.Location
+	Stype  lexer.TkKind // moocscript 中 guard 与 switch 转换成的if语句，值为 TkKwGuard 或 TkKwSwitch
 }
 
 // WhileStat while代码块
@@ -166,8 +167,9 @@ type ClassDefStat struct {
 // import p, r, s from "lpeg" { P, R, S }
 // import insert, remove from table {}
 type ImportDefStat struct {
-	Lib  *FuncCallStat     // require("lpeg")
-	Name *LocalVarDeclStat // local R, P, S = require("lpeg").R, require("lpeg").P, require("lpeg").S
+	Lib    *FuncCallStat     // require("lpeg")
+	Name   *LocalVarDeclStat // local R, P, S = require("lpeg").R, require("lpeg").P, require("lpeg").S
+	SubLib bool              // 是否用 {} 指定了库的成员，例如 import p, r from "lpeg" { P, R }
 }
 
 // export *
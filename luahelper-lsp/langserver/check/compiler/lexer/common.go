@@ -32,6 +32,9 @@ type CommentInfo struct {
 	LineVec   []CommentLine // 多行的内容存储
 	ShortFlag bool          // 是否是短注释，true表示短注释
 	HeadFlag  bool          // 是否为头部注释， 例如一行中 --这样开头的就为头部注释
+
+	// AnnotateLineVec 尾部注释所在的行，由下一行代码转换成的注解，例如Luau中的 local a: number
+	AnnotateLineVec []CommentLine
 }
 
 // GetRangeLoc 获取两个位置的范围，为[]
@@ -31,6 +31,7 @@ type Token struct {
 const (
 	ModeLua  = 1
 	ModeMooc = 2
+	ModeLuau = 3
 )
 
 func (l *Token) GetLine() int {
@@ -58,6 +59,8 @@ type Lexer struct {
 	errHandler ErrorHandler // error reporting; or nil
 
 	keywords map[string]TkKind // lua and mooc have different keywords
+
+	mode int // 词法分析的模式，Luau模式下支持 ? 与插值字符串
 }
 
 // return <0 for fail, ==0 to stopped, ==1 to advance
@@ -83,13 +86,17 @@ func NewLexer(chunk []byte, chunkName string) *Lexer {
 		currentPos:    0,
 		commentMap:    map[int]*CommentInfo{},
 		keywords:      keywords,
+		mode:          ModeLua,
 	}
 }
 
 func (l *Lexer) SetMode(mode int) {
-	if mode == ModeLua {
+	l.mode = mode
+	// Luau的continue为上下文关键字，由语法分析判断，关键字与lua的相同
+	switch mode {
+	case ModeLua, ModeLuau:
 		l.keywords = keywords
-	} else {
+	default:
 		l.keywords = keywordsMooc
 	}
 }
@@ -99,6 +106,12 @@ func (l *Lexer) SetErrHandler(errHandler ErrorHandler) {
 	l.errHandler = errHandler
 }
 
+// SetStartPos 设置第一个字符所在的行号与列号，用于只分析文件中间的一段内容，行号从1开始，列号从0开始
+func (l *Lexer) SetStartPos(line int, col int) {
+	l.line = line
+	l.lineStartPos = -col
+}
+
 // GetCommentMap 获取所有的注释map
 func (l *Lexer) GetCommentMap() map[int]*CommentInfo {
 	return l.commentMap
@@ -464,6 +477,17 @@ func (l *Lexer) NextTokenStruct() {
 	case '\'', '"':
 		l.setNowToken(TkString, l.scanShortString())
 		return
+	case '?':
+		if l.mode == ModeLuau {
+			l.next(1)
+			l.setNowToken(TkSepQuestion, "?")
+			return
+		}
+	case '`':
+		if l.mode == ModeLuau {
+			l.setNowToken(TkInterpString, l.scanInterpString())
+			return
+		}
 	}
 
 	if c == '.' || isDigit(c) {
@@ -620,6 +644,9 @@ func (l *Lexer) skipWhiteSpaces() {
 
 		// 剔除掉首行的注释 \n-- 当为[[ ]] 这样的注释是，会存在
 		skipComment = strings.TrimSuffix(skipComment, "\n--")
+
+		// 表达式后面的类型注解为长注释，例如 --[[@as Player]]，需要保留注释的内容
+		inlineFlag := !shortFlag && strings.HasPrefix(skipComment, "@as ")
 		if commentInfo == nil {
 			commentInfo = &CommentInfo{
 				ShortFlag: shortFlag,
@@ -627,7 +654,7 @@ func (l *Lexer) skipWhiteSpaces() {
 			}
 
 			lastLine = l.line
-			if shortFlag {
+			if shortFlag || inlineFlag {
 				commentInfo.LineVec = append(commentInfo.LineVec, CommentLine{
 					Str:  skipComment,
 					Line: lastLine,
@@ -636,8 +663,12 @@ func (l *Lexer) skipWhiteSpaces() {
 			}
 
 			if !headFlag {
-				// 保存尾部注释
-				l.commentMap[lastLine] = commentInfo
+				// 保存尾部注释，同一行有多个尾部注释时，合并到一起
+				if beforeInfo, ok := l.commentMap[lastLine]; ok && !beforeInfo.HeadFlag {
+					beforeInfo.LineVec = append(beforeInfo.LineVec, commentInfo.LineVec...)
+				} else {
+					l.commentMap[lastLine] = commentInfo
+				}
 				commentInfo = nil
 			}
 			continue
@@ -1059,6 +1090,67 @@ func (l *Lexer) scanShortString() string {
 	return str
 }
 
+// scanInterpString 获取Luau的插值字符串，例如 `hello {name}`，返回两个`之间没有转义的原始内容
+func (l *Lexer) scanInterpString() string {
+	depth := 0
+	i := 1
+	for i < len(l.chunk) && !isNewLine(l.chunk[i]) {
+		ch := l.chunk[i]
+		if ch == '\\' {
+			i += 2
+			continue
+		}
+
+		if ch == '{' {
+			depth++
+		} else if ch == '}' && depth > 0 {
+			depth--
+		} else if ch == '`' && depth == 0 {
+			str := l.chunk[1:i]
+			l.chunk = l.chunk[i+1:]
+			// 转换为字符的个数，不在是utf8字节数
+			l.currentPos = l.currentPos + utf8.RuneCountInString(codingconv.ConvertStrToUtf8(str)) + 2
+			return str
+		}
+		i++
+	}
+
+	if i > len(l.chunk) {
+		i = len(l.chunk)
+	}
+	l.next(i)
+	nowLoc := Location{
+		StartLine:   l.line,
+		StartColumn: l.currentPos - l.lineStartPos,
+		EndLine:     l.line,
+		EndColumn:   l.currentPos - l.lineStartPos + 1,
+	}
+	l.errorPrint(nowLoc, "unfinished string, missing `")
+	return ""
+}
+
+// InsertAnnotateComment 插入一行由代码转换成的注解，注解作用于codeLine行的代码，例如Luau中的 local a: number
+// 注解保存在codeLine的前一行，前一行已经有头部注释时，拼接到注释的后面；前一行为尾部注释时，单独保存在AnnotateLineVec中
+func (l *Lexer) InsertAnnotateComment(codeLine int, commentLine CommentLine) {
+	lastLine := codeLine - 1
+	commentInfo, ok := l.commentMap[lastLine]
+	if !ok {
+		l.commentMap[lastLine] = &CommentInfo{
+			LineVec:   []CommentLine{commentLine},
+			ShortFlag: true,
+			HeadFlag:  true,
+		}
+		return
+	}
+
+	if !commentInfo.HeadFlag {
+		commentInfo.AnnotateLineVec = append(commentInfo.AnnotateLineVec, commentLine)
+		return
+	}
+
+	commentInfo.LineVec = append(commentInfo.LineVec, commentLine)
+}
+
 // SetEnd 设置结尾
 func (l *Lexer) SetEnd() {
 	l.chunk = ""
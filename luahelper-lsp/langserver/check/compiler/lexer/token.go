@@ -82,6 +82,8 @@ const (
 	TkIdentifier                     // identifier
 	TkNumber                         // number literal
 	TkString                         // string literal
+	TkSepQuestion                    // ? (Luau)
+	TkInterpString                   // interpolated string literal (Luau)
 	TkOpUnm       TkKind = TkOpMinus // unary minus
 	TkOpSub       TkKind = TkOpMinus
 	TkOpBnot      TkKind = TkOpWave
@@ -164,6 +166,8 @@ var tokenKinds = [...]string{
 	TkIdentifier:  "identifier",     // identifier
 	TkNumber:      "number literal", // number literal
 	TkString:      "string literal", // string literal
+	TkSepQuestion: "?",              // ? (Luau)
+	TkInterpString: "interpolated string", // interpolated string literal (Luau)
 }
 
 func (tok TkKind) String() string {
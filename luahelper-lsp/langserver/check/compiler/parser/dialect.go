@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 支持的lua方言，每种方言对应一个语法分析器
+const (
+	DialectLua  = "lua"  // 标准的lua
+	DialectMooc = "mooc" // moocscript
+	DialectLuau = "luau" // Luau，带类型注解的lua
+)
+
+// DialectCreator 创建一种方言的语法分析器
+type DialectCreator func(chunk []byte, chunkName string) ParserInterface
+
+// dialectRegistry 所有注册的方言，以及文件后缀默认关联到的方言
+// 配置的文件关联由每个会话的配置单独持有，查找文件的方言时传入
+type dialectRegistry struct {
+	mutex sync.RWMutex
+
+	// 方言的名称对应的语法分析器
+	creatorMap map[string]DialectCreator
+
+	// 文件后缀默认关联的方言，例如.mooc后缀为moocscript
+	suffixMap map[string]string
+}
+
+var gDialects = &dialectRegistry{
+	creatorMap: map[string]DialectCreator{
+		DialectLua: func(chunk []byte, chunkName string) ParserInterface {
+			return createLuaParser(chunk, chunkName)
+		},
+		DialectMooc: func(chunk []byte, chunkName string) ParserInterface {
+			return createMoocParser(chunk, chunkName)
+		},
+		DialectLuau: func(chunk []byte, chunkName string) ParserInterface {
+			return createLuauParser(chunk, chunkName)
+		},
+		DialectMarkdown: func(chunk []byte, chunkName string) ParserInterface {
+			return createEmbedParser(chunk, chunkName, findMarkdownLua)
+		},
+		DialectHTML: func(chunk []byte, chunkName string) ParserInterface {
+			return createEmbedParser(chunk, chunkName, findHTMLLua)
+		},
+		DialectNginx: func(chunk []byte, chunkName string) ParserInterface {
+			return createEmbedParser(chunk, chunkName, findNginxLua)
+		},
+		DialectRedis: func(chunk []byte, chunkName string) ParserInterface {
+			return createEmbedParser(chunk, chunkName, findRedisLua)
+		},
+	},
+	suffixMap: map[string]string{
+		".mooc": DialectMooc,
+		".luau": DialectLuau,
+	},
+}
+
+// RegisterDialect 注册一种方言的语法分析器，suffixVec为默认关联到这种方言的文件后缀
+func RegisterDialect(name string, creator DialectCreator, suffixVec ...string) {
+	gDialects.mutex.Lock()
+	defer gDialects.mutex.Unlock()
+
+	name = strings.ToLower(name)
+	gDialects.creatorMap[name] = creator
+	for _, suffix := range suffixVec {
+		gDialects.suffixMap[suffix] = name
+	}
+}
+
+// IsDialect 判断是否为注册了的方言名称，不区分大小写
+func IsDialect(name string) bool {
+	gDialects.mutex.RLock()
+	defer gDialects.mutex.RUnlock()
+
+	_, ok := gDialects.creatorMap[strings.ToLower(name)]
+	return ok
+}
+
+// FilterDialectAssocial 过滤配置的文件关联，key为文件路径的匹配模式，value为方言的名称，未注册的方言会被忽略
+func FilterDialectAssocial(associalMap map[string]string) map[string]string {
+	gDialects.mutex.RLock()
+	defer gDialects.mutex.RUnlock()
+
+	dialectMap := map[string]string{}
+	for pattern, name := range associalMap {
+		name = strings.ToLower(name)
+		if _, ok := gDialects.creatorMap[name]; ok {
+			dialectMap[pattern] = name
+		}
+	}
+
+	return dialectMap
+}
+
+// GetFileDialect 获取文件对应的方言，associalMap为配置的文件关联，例如 {"*.typed.lua": "luau"}
+// 配置的文件关联优先，其次为文件的后缀，都没有匹配时为标准的lua；ok表示是否匹配到了非标准lua的方言
+// 多个匹配模式都能匹配时，按 sortDialectPatterns 的顺序，越具体的模式越优先
+func GetFileDialect(chunkName string, associalMap map[string]string) (name string, ok bool) {
+	if len(associalMap) > 0 {
+		// 处理下不同平台的路径转换，不包含路径的匹配模式只匹配文件名，例如 *.typed.lua
+		filePathStr, _ := filepath.Abs(chunkName)
+		fileNameStr := path.Base(filepath.ToSlash(filePathStr))
+		for _, pattern := range sortDialectPatterns(associalMap) {
+			matchStr := filePathStr
+			if !strings.Contains(pattern, "/") {
+				matchStr = fileNameStr
+			}
+
+			if match, _ := path.Match(pattern, matchStr); match {
+				return associalMap[pattern], true
+			}
+		}
+	}
+
+	gDialects.mutex.RLock()
+	defer gDialects.mutex.RUnlock()
+
+	if name, ok := gDialects.suffixMap[filepath.Ext(chunkName)]; ok {
+		return name, true
+	}
+
+	return DialectLua, false
+}
+
+// sortDialectPatterns 对文件关联的匹配模式排序，包含路径的模式在只匹配文件名的模式之前，其次长的模式在前，长度相同时按字符串排序
+// 例如 src/*.typed.lua、*.typed.lua、*.lua 依次匹配，保证每次查找的结果一样
+func sortDialectPatterns(associalMap map[string]string) []string {
+	patternVec := make([]string, 0, len(associalMap))
+	for pattern := range associalMap {
+		patternVec = append(patternVec, pattern)
+	}
+
+	sort.Slice(patternVec, func(i, j int) bool {
+		pathFlagI := strings.Contains(patternVec[i], "/")
+		pathFlagJ := strings.Contains(patternVec[j], "/")
+		if pathFlagI != pathFlagJ {
+			return pathFlagI
+		}
+
+		if len(patternVec[i]) != len(patternVec[j]) {
+			return len(patternVec[i]) > len(patternVec[j])
+		}
+		return patternVec[i] < patternVec[j]
+	})
+	return patternVec
+}
+
+// CreateDialectParser 根据方言的名称创建语法分析器，方言没有注册时，当成标准的lua处理
+func CreateDialectParser(chunk []byte, chunkName string, name string) ParserInterface {
+	gDialects.mutex.RLock()
+	creator, ok := gDialects.creatorMap[name]
+	gDialects.mutex.RUnlock()
+	if !ok {
+		return createLuaParser(chunk, chunkName)
+	}
+
+	return creator(chunk, chunkName)
+}
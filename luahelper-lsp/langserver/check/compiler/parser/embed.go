@@ -0,0 +1,408 @@
+package parser
+
+import (
+	"bytes"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"unicode/utf8"
+)
+
+// 嵌入了lua代码的文件类型，只有嵌入的区域当成lua代码分析，例如：
+// markdown  ```lua 与 ``` 之间的代码
+// html      <script type="text/lua"> 与 </script> 之间的代码
+// nginx     OpenResty配置中 content_by_lua_block { } 等指令的代码块
+// redis     其他语言代码中，传给Redis EVAL的脚本字符串
+// 每段嵌入的代码为单独的chunk，有自己的作用域，段与段之间只共享全局变量
+const (
+	DialectMarkdown = "markdown"
+	DialectHTML     = "html"
+	DialectNginx    = "nginx"
+	DialectRedis    = "redis"
+)
+
+// EmbedRegion 文件中嵌入的一段lua代码，Begin与End为在文件内容中的字节偏移，不包含End
+type EmbedRegion struct {
+	Begin int
+	End   int
+}
+
+// EmbedFinder 查找文件内容中所有嵌入的lua代码区域，区域按照偏移从小到大排列
+type EmbedFinder func(chunk []byte) []EmbedRegion
+
+// RegisterEmbedDialect 注册一种嵌入了lua代码的文件类型，suffixVec为默认关联到这种类型的文件后缀
+func RegisterEmbedDialect(name string, finder EmbedFinder, suffixVec ...string) {
+	RegisterDialect(name, func(chunk []byte, chunkName string) ParserInterface {
+		return createEmbedParser(chunk, chunkName, finder)
+	}, suffixVec...)
+}
+
+// embedParser 嵌入了lua代码的文件的语法分析器
+type embedParser struct {
+	chunk     []byte
+	chunkName string
+	finder    EmbedFinder
+	luaParser *luaParser
+	parseErrs []lexer.ParseError
+}
+
+func createEmbedParser(chunk []byte, chunkName string, finder EmbedFinder) *embedParser {
+	return &embedParser{
+		chunk:     chunk,
+		chunkName: chunkName,
+		finder:    finder,
+		luaParser: createLuaParser(chunk, chunkName),
+	}
+}
+
+// BeginAnalyze 只分析文件中嵌入的lua代码，每段代码单独分析，保持代码在文件中的行号与列号不变
+// 每段代码的语法树放到一个do语句中，段内的局部变量与return语句不会影响其他的段
+func (p *embedParser) BeginAnalyze() (block *ast.Block, commentMap map[int]*lexer.CommentInfo, errList []lexer.ParseError) {
+	endLine, endCol := getEmbedPos(p.chunk, len(p.chunk))
+	block = &ast.Block{
+		Loc: lexer.Location{
+			StartLine:   1,
+			StartColumn: 0,
+			EndLine:     endLine,
+			EndColumn:   endCol,
+		},
+	}
+	commentMap = map[int]*lexer.CommentInfo{}
+
+	pos := 0
+	for _, region := range p.finder(p.chunk) {
+		if region.Begin < pos || region.End > len(p.chunk) || region.Begin > region.End {
+			continue
+		}
+		pos = region.End
+
+		line, col := getEmbedPos(p.chunk, region.Begin)
+		regionParser := createLuaParser(p.chunk[region.Begin:region.End], p.chunkName)
+		regionParser.l.SetStartPos(line, col)
+		regionBlock, regionComments, regionErrs := regionParser.BeginAnalyze()
+
+		block.Stats = append(block.Stats, &ast.DoStat{
+			Stype: lexer.TkKwDo,
+			Block: regionBlock,
+			Loc:   regionBlock.Loc,
+		})
+		for commentLine, commentInfo := range regionComments {
+			commentMap[commentLine] = commentInfo
+		}
+		errList = append(errList, regionErrs...)
+	}
+
+	p.parseErrs = errList
+	return block, commentMap, errList
+}
+
+// BeginAnalyzeExp 分析的为单独的表达式，例如代码补全时输入的内容，不需要查找嵌入的区域
+func (p *embedParser) BeginAnalyzeExp() (exp ast.Exp) {
+	return p.luaParser.BeginAnalyzeExp()
+}
+
+// GetErrList 获取语法分析的错误
+func (p *embedParser) GetErrList() (errList []lexer.ParseError) {
+	return append(p.parseErrs, p.luaParser.GetErrList()...)
+}
+
+// getEmbedPos 获取文件内容中字节偏移对应的行号与列号，行号从1开始，列号为这一行中前面的字符个数
+func getEmbedPos(chunk []byte, offset int) (line int, col int) {
+	lineBegin := bytes.LastIndexByte(chunk[:offset], '\n') + 1
+	line = bytes.Count(chunk[:lineBegin], []byte("\n")) + 1
+	col = utf8.RuneCount(chunk[lineBegin:offset])
+	return line, col
+}
+
+// findMarkdownLua 查找markdown中 ```lua 或 ~~~lua 的代码块
+func findMarkdownLua(chunk []byte) (regionVec []EmbedRegion) {
+	var fence []byte
+	begin := 0
+	for pos := 0; pos < len(chunk); {
+		lineEnd := bytes.IndexByte(chunk[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(chunk)
+		} else {
+			lineEnd += pos
+		}
+		line := bytes.TrimSpace(chunk[pos:lineEnd])
+
+		if fence == nil {
+			// 代码块开始，后面的语言为lua
+			if bytes.HasPrefix(line, []byte("```")) || bytes.HasPrefix(line, []byte("~~~")) {
+				fenceLen := len(line) - len(bytes.TrimLeft(line, string(line[:1])))
+				infoStr := bytes.Fields(line[fenceLen:])
+				if len(infoStr) > 0 && bytes.EqualFold(infoStr[0], []byte("lua")) {
+					fence = line[:fenceLen]
+					begin = lineEnd + 1
+				}
+			}
+		} else if bytes.HasPrefix(line, fence) && len(bytes.Trim(line, string(fence[:1]))) == 0 {
+			// 代码块结束
+			regionVec = append(regionVec, EmbedRegion{Begin: begin, End: pos})
+			fence = nil
+		}
+
+		pos = lineEnd + 1
+	}
+
+	// 没有结束的代码块，一直到文件的最后
+	if fence != nil && begin < len(chunk) {
+		regionVec = append(regionVec, EmbedRegion{Begin: begin, End: len(chunk)})
+	}
+	return regionVec
+}
+
+// findHTMLLua 查找html中 <script type="text/lua"> 的代码，type也可以为application/lua
+func findHTMLLua(chunk []byte) (regionVec []EmbedRegion) {
+	lowerChunk := toASCIILower(chunk)
+	pos := 0
+	for {
+		tagBegin := bytes.Index(lowerChunk[pos:], []byte("<script"))
+		if tagBegin < 0 {
+			break
+		}
+		tagBegin += pos
+
+		tagEnd := bytes.IndexByte(lowerChunk[tagBegin:], '>')
+		if tagEnd < 0 {
+			break
+		}
+		tagEnd += tagBegin
+
+		begin := tagEnd + 1
+		end := bytes.Index(lowerChunk[begin:], []byte("</script"))
+		if end < 0 {
+			end = len(chunk)
+		} else {
+			end += begin
+		}
+
+		if isHTMLLuaScript(lowerChunk[tagBegin:tagEnd]) {
+			regionVec = append(regionVec, EmbedRegion{Begin: begin, End: end})
+		}
+		pos = end
+	}
+
+	return regionVec
+}
+
+// toASCIILower 只转换ASCII字符的大小写，保持与文件内容的偏移一致
+func toASCIILower(chunk []byte) []byte {
+	lowerChunk := make([]byte, len(chunk))
+	for i, ch := range chunk {
+		if ch >= 'A' && ch <= 'Z' {
+			ch += 'a' - 'A'
+		}
+		lowerChunk[i] = ch
+	}
+	return lowerChunk
+}
+
+// isHTMLLuaScript 判断script标签的type属性是否为lua
+func isHTMLLuaScript(tag []byte) bool {
+	typeIndex := bytes.Index(tag, []byte(" type"))
+	if typeIndex < 0 {
+		return false
+	}
+
+	value := bytes.TrimLeft(tag[typeIndex+len(" type"):], " \t\r\n")
+	if !bytes.HasPrefix(value, []byte("=")) {
+		return false
+	}
+	value = bytes.TrimLeft(value[1:], " \t\r\n\"'")
+	for _, typeStr := range []string{"text/lua", "application/lua"} {
+		if !bytes.HasPrefix(value, []byte(typeStr)) {
+			continue
+		}
+
+		// 属性值需要完整的匹配，例如 text/luax 不是lua
+		if len(value) == len(typeStr) || bytes.IndexByte([]byte("\"' \t\r\n/"), value[len(typeStr)]) >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findNginxLua 查找OpenResty配置中 *_by_lua_block { } 指令中的代码，nginx的 # 注释会被跳过
+func findNginxLua(chunk []byte) (regionVec []EmbedRegion) {
+	directive := []byte("_by_lua_block")
+	for pos := 0; pos < len(chunk); pos++ {
+		if chunk[pos] == '#' {
+			// nginx的注释，一直到行尾
+			lineEnd := bytes.IndexByte(chunk[pos:], '\n')
+			if lineEnd < 0 {
+				break
+			}
+			pos += lineEnd
+			continue
+		}
+
+		if !bytes.HasPrefix(chunk[pos:], directive) {
+			continue
+		}
+
+		// 指令可以有参数，例如 set_by_lua_block $res { }
+		begin := pos + len(directive)
+		for begin < len(chunk) && chunk[begin] != '{' && chunk[begin] != ';' {
+			begin++
+		}
+		if begin >= len(chunk) || chunk[begin] != '{' {
+			pos = begin
+			continue
+		}
+
+		begin++
+		end := findLuaBlockEnd(chunk, begin)
+		regionVec = append(regionVec, EmbedRegion{Begin: begin, End: end})
+		pos = end
+	}
+
+	return regionVec
+}
+
+// findLuaBlockEnd 查找lua代码块结束的 }，跳过lua代码中的字符串、注释与嵌套的table
+func findLuaBlockEnd(chunk []byte, begin int) int {
+	depth := 0
+	for i := begin; i < len(chunk); i++ {
+		switch ch := chunk[i]; ch {
+		case '\'', '"':
+			for i++; i < len(chunk) && chunk[i] != ch && chunk[i] != '\n'; i++ {
+				if chunk[i] == '\\' {
+					i++
+				}
+			}
+		case '[':
+			if end, ok := skipLuaLongBracket(chunk, i); ok {
+				i = end - 1
+			}
+		case '-':
+			if i+1 >= len(chunk) || chunk[i+1] != '-' {
+				continue
+			}
+
+			if end, ok := skipLuaLongBracket(chunk, i+2); ok {
+				i = end - 1
+				continue
+			}
+			for i < len(chunk) && chunk[i] != '\n' {
+				i++
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+
+	return len(chunk)
+}
+
+// skipLuaLongBracket 跳过lua的长字符串或长注释，例如 [==[ ]==]，返回结束后的位置
+func skipLuaLongBracket(chunk []byte, begin int) (int, bool) {
+	if begin >= len(chunk) || chunk[begin] != '[' {
+		return begin, false
+	}
+
+	level := 0
+	i := begin + 1
+	for i < len(chunk) && chunk[i] == '=' {
+		level++
+		i++
+	}
+	if i >= len(chunk) || chunk[i] != '[' {
+		return begin, false
+	}
+
+	closeBracket := append(append([]byte("]"), bytes.Repeat([]byte("="), level)...), ']')
+	end := bytes.Index(chunk[i+1:], closeBracket)
+	if end < 0 {
+		return len(chunk), true
+	}
+	return i + 1 + end + len(closeBracket), true
+}
+
+// redisEvalVec 以脚本作为参数的Redis命令或函数，例如 EVAL "return 1" 0 或是 redis.eval("return 1", 0)
+var redisEvalVec = []string{"eval_ro", "eval", "register_script"}
+
+// findRedisLua 查找其他语言代码中，传给Redis EVAL的脚本字符串
+// 多行的字符串（三个引号或反引号）当成原始的内容，单行的字符串中有转义时忽略，转义后代码的位置与文件中不一致
+func findRedisLua(chunk []byte) (regionVec []EmbedRegion) {
+	lowerChunk := toASCIILower(chunk)
+	for pos := 0; pos < len(chunk); pos++ {
+		if pos > 0 && isRedisIdentChar(chunk[pos-1]) {
+			continue
+		}
+
+		begin := -1
+		for _, name := range redisEvalVec {
+			end := pos + len(name)
+			if bytes.HasPrefix(lowerChunk[pos:], []byte(name)) && (end == len(chunk) || !isRedisIdentChar(chunk[end])) {
+				begin = end
+				break
+			}
+		}
+		if begin < 0 {
+			continue
+		}
+
+		// 命令或函数名后面的空白与左括号
+		for begin < len(chunk) && bytes.IndexByte([]byte(" \t\r\n("), chunk[begin]) >= 0 {
+			begin++
+		}
+
+		if region, ok := getRedisScriptRegion(chunk, begin); ok {
+			regionVec = append(regionVec, region)
+			pos = region.End
+		}
+	}
+
+	return regionVec
+}
+
+// getRedisScriptRegion 获取begin位置开始的字符串中的内容，字符串中有转义时返回false
+func getRedisScriptRegion(chunk []byte, begin int) (EmbedRegion, bool) {
+	if begin >= len(chunk) {
+		return EmbedRegion{}, false
+	}
+
+	// 多行的字符串
+	for _, quote := range []string{`"""`, "'''", "`"} {
+		if !bytes.HasPrefix(chunk[begin:], []byte(quote)) {
+			continue
+		}
+
+		begin += len(quote)
+		end := bytes.Index(chunk[begin:], []byte(quote))
+		if end < 0 {
+			return EmbedRegion{}, false
+		}
+		return EmbedRegion{Begin: begin, End: begin + end}, true
+	}
+
+	// 单行的字符串
+	quote := chunk[begin]
+	if quote != '"' && quote != '\'' {
+		return EmbedRegion{}, false
+	}
+
+	begin++
+	for end := begin; end < len(chunk); end++ {
+		switch chunk[end] {
+		case quote:
+			return EmbedRegion{Begin: begin, End: end}, true
+		case '\\', '\n':
+			return EmbedRegion{}, false
+		}
+	}
+
+	return EmbedRegion{}, false
+}
+
+// isRedisIdentChar 判断是否为标识符中的字符，命令或函数名需要完整的匹配
+func isRedisIdentChar(ch byte) bool {
+	return ch == '_' || (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
@@ -0,0 +1,421 @@
+package parser
+
+import (
+	"hash/fnv"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 实时修改文件时的增量解析
+// 整个文件按照顶层语句切分成多段，每一段从语句的开始到下一个语句的开始，包含语句后面的注释与空行
+// 修改时只重新解析与修改范围有交集的段，其他的段通过位置与内容的hash判断没有变化后，直接复用之前的语法树
+// 没有修改的语句复用之前的语法树指针，第一阶段的分析据此判断是否只修改了一个顶层函数的函数体
+
+// ChangeRange 文件的一次修改范围，为修改之前内容的行号，从1开始
+type ChangeRange struct {
+	StartLine int
+	EndLine   int
+}
+
+// statSegment 一个顶层语句所在的一段内容
+type statSegment struct {
+	beginOffset int    // 语句开始的偏移
+	endOffset   int    // 下一个语句开始的偏移，最后一个语句为文件的结尾
+	beginLine   int    // 语句开始的行号
+	beginCol    int    // 语句开始的列号
+	hash        uint64 // 这一段内容的hash
+}
+
+// ParseSnapshot 一次没有语法错误的解析结果，用于下一次修改时的增量解析
+type ParseSnapshot struct {
+	chunkName  string
+	contents   []byte
+	lineStarts []int // 每一行开始的偏移
+	block      *ast.Block
+	commentMap map[int]*lexer.CommentInfo
+	headHash   uint64        // 第一个语句之前内容的hash
+	segmentVec []statSegment // 所有的顶层语句
+}
+
+// locationType 语法树中位置信息的类型
+var locationType = reflect.TypeOf(lexer.Location{})
+
+// CreateParseSnapshot 保存一次解析的结果，有语法错误或是不支持增量解析时返回nil
+// 只支持lua文件，dialect为文件对应的方言，且每一个顶层语句所在的行，语句前面只有空白字符
+func CreateParseSnapshot(chunkName string, dialect string, contents []byte, block *ast.Block,
+	commentMap map[int]*lexer.CommentInfo, errList []lexer.ParseError) *ParseSnapshot {
+	if len(errList) > 0 || block == nil || len(block.Stats) == 0 || dialect != DialectLua ||
+		hasUTF8BOM(contents) {
+		return nil
+	}
+
+	snapshot := &ParseSnapshot{
+		chunkName:  chunkName,
+		contents:   contents,
+		lineStarts: getLineStarts(contents),
+		block:      block,
+		commentMap: commentMap,
+	}
+
+	for _, stat := range block.Stats {
+		loc, ok := getStatLoc(stat)
+		if !ok || loc.StartLine <= 0 || loc.StartLine > len(snapshot.lineStarts) {
+			return nil
+		}
+
+		lineStart := snapshot.lineStarts[loc.StartLine-1]
+		beginOffset := lineStart + loc.StartColumn
+		if beginOffset > len(contents) || !isBlankBytes(contents[lineStart:beginOffset]) {
+			return nil
+		}
+
+		segNum := len(snapshot.segmentVec)
+		if segNum > 0 && snapshot.segmentVec[segNum-1].beginOffset >= beginOffset {
+			return nil
+		}
+
+		snapshot.segmentVec = append(snapshot.segmentVec, statSegment{
+			beginOffset: beginOffset,
+			beginLine:   loc.StartLine,
+			beginCol:    loc.StartColumn,
+		})
+	}
+
+	segNum := len(snapshot.segmentVec)
+	for i := range snapshot.segmentVec {
+		endOffset := len(contents)
+		if i+1 < segNum {
+			endOffset = snapshot.segmentVec[i+1].beginOffset
+		}
+
+		snapshot.segmentVec[i].endOffset = endOffset
+		snapshot.segmentVec[i].hash = getBytesHash(contents[snapshot.segmentVec[i].beginOffset:endOffset])
+	}
+
+	snapshot.headHash = getBytesHash(contents[:snapshot.segmentVec[0].beginOffset])
+	return snapshot
+}
+
+// GetBlock 获取保存的语法树
+func (s *ParseSnapshot) GetBlock() *ast.Block {
+	return s.block
+}
+
+// IncrementalParse 根据修改的范围，只重新解析修改了的顶层语句，其他的语句复用之前的语法树
+// ok为false表示不能增量解析，需要整体重新解析，例如修改的部分有语法错误
+func (s *ParseSnapshot) IncrementalParse(contents []byte, changeVec []ChangeRange) (block *ast.Block,
+	commentMap map[int]*lexer.CommentInfo, ok bool) {
+	if len(changeVec) == 0 || hasUTF8BOM(contents) {
+		return nil, nil, false
+	}
+
+	// 1) 找到与修改范围有交集的所有段
+	firstIndex, lastIndex := -1, -1
+	for i := range s.segmentVec {
+		segBeginLine := s.segmentVec[i].beginLine
+		segEndLine := getOffsetLine(s.lineStarts, s.segmentVec[i].endOffset-1)
+		for _, change := range changeVec {
+			if change.StartLine <= segEndLine && change.EndLine >= segBeginLine {
+				if firstIndex < 0 {
+					firstIndex = i
+				}
+				lastIndex = i
+			}
+		}
+	}
+
+	if firstIndex < 0 {
+		return nil, nil, false
+	}
+
+	// 2) 修改前后，需要重新解析的区间
+	segNum := len(s.segmentVec)
+	delta := len(contents) - len(s.contents)
+	beginOffset := s.segmentVec[firstIndex].beginOffset
+	oldEndOffset := s.segmentVec[lastIndex].endOffset
+	newEndOffset := oldEndOffset + delta
+	if newEndOffset < beginOffset || beginOffset > len(contents) {
+		return nil, nil, false
+	}
+
+	// 3) 前后复用的段，内容的hash需要一致
+	if getBytesHash(contents[:s.segmentVec[0].beginOffset]) != s.headHash {
+		return nil, nil, false
+	}
+
+	for i := 0; i < firstIndex; i++ {
+		oneSeg := &s.segmentVec[i]
+		if getBytesHash(contents[oneSeg.beginOffset:oneSeg.endOffset]) != oneSeg.hash {
+			return nil, nil, false
+		}
+	}
+
+	for i := lastIndex + 1; i < segNum; i++ {
+		oneSeg := &s.segmentVec[i]
+		if oneSeg.endOffset+delta > len(contents) ||
+			getBytesHash(contents[oneSeg.beginOffset+delta:oneSeg.endOffset+delta]) != oneSeg.hash {
+			return nil, nil, false
+		}
+	}
+
+	// 以(开始的语句可能与前面的语句组成函数调用，例如 a \n (b)()，这种情况整体重新解析
+	regionContent := contents[beginOffset:newEndOffset]
+	if strings.HasPrefix(strings.TrimLeft(string(regionContent), " \t\r\n"), "(") {
+		return nil, nil, false
+	}
+
+	hasSuffix := lastIndex+1 < segNum
+	lineDelta := 0
+	if hasSuffix {
+		suffixSeg := &s.segmentVec[lastIndex+1]
+		if s.contents[suffixSeg.beginOffset] == '(' {
+			return nil, nil, false
+		}
+
+		// 后面复用的语句，只有行号的变化，列号需要一致
+		newLineStarts := getLineStarts(contents[:newEndOffset])
+		newLine := len(newLineStarts)
+		newCol := newEndOffset - newLineStarts[newLine-1]
+		if newCol != suffixSeg.beginCol {
+			return nil, nil, false
+		}
+
+		lineDelta = newLine - suffixSeg.beginLine
+	}
+
+	// 4) 重新解析修改的区间
+	firstSeg := &s.segmentVec[firstIndex]
+	regionParser := createLuaParser(regionContent, s.chunkName)
+	regionParser.l.SetStartPos(firstSeg.beginLine, firstSeg.beginCol)
+	regionBlock, regionComments, ok := regionParser.parseRegion()
+	if !ok || (hasSuffix && regionBlock.RetExps != nil) {
+		return nil, nil, false
+	}
+
+	// 5) 拼接前后复用的语句
+	block = &ast.Block{
+		Loc: s.block.Loc,
+	}
+	block.Stats = append(block.Stats, s.block.Stats[:firstIndex]...)
+	block.Stats = append(block.Stats, regionBlock.Stats...)
+	if hasSuffix {
+		for _, stat := range s.block.Stats[lastIndex+1:] {
+			block.Stats = append(block.Stats, cloneShiftLine(stat, lineDelta))
+		}
+
+		for _, exp := range s.block.RetExps {
+			block.RetExps = append(block.RetExps, cloneShiftLine(exp, lineDelta))
+		}
+
+		block.Loc.EndLine += lineDelta
+	} else {
+		block.RetExps = regionBlock.RetExps
+		block.Loc.EndLine = regionBlock.Loc.EndLine
+		block.Loc.EndColumn = regionBlock.Loc.EndColumn
+	}
+
+	// 6) 拼接注释，修改区间内的注释用重新解析的
+	suffixLine := 0
+	if hasSuffix {
+		suffixLine = s.segmentVec[lastIndex+1].beginLine
+	}
+
+	commentMap = map[int]*lexer.CommentInfo{}
+	for line, commentInfo := range s.commentMap {
+		if line < firstSeg.beginLine {
+			commentMap[line] = commentInfo
+		} else if hasSuffix && line >= suffixLine {
+			commentMap[line+lineDelta] = shiftCommentLine(commentInfo, lineDelta)
+		}
+	}
+
+	for line, commentInfo := range regionComments {
+		commentMap[line] = commentInfo
+	}
+
+	return block, commentMap, true
+}
+
+// parseRegion 解析文件中间的一段内容，有语法错误时返回false
+func (p *luaParser) parseRegion() (block *ast.Block, commentMap map[int]*lexer.CommentInfo, ok bool) {
+	defer func() {
+		if err1 := recover(); err1 != nil {
+			block = nil
+			commentMap = nil
+			ok = false
+		}
+	}()
+
+	blockBeginLoc := p.l.GetHeardTokenLoc()
+	block = p.parseMainBlock()
+	blockEndLoc := p.l.GetNowTokenLoc()
+	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
+
+	p.l.NextTokenKind(lexer.TkEOF)
+	p.l.SetEnd()
+	return block, p.l.GetCommentMap(), len(p.parseErrs) == 0
+}
+
+// getStatLoc 获取顶层语句的位置信息
+func getStatLoc(stat ast.Stat) (loc lexer.Location, ok bool) {
+	switch node := stat.(type) {
+	case *ast.LabelStat:
+		return node.Loc, true
+	case *ast.GotoStat:
+		return node.Loc, true
+	case *ast.DoStat:
+		return node.Loc, true
+	case *ast.IfStat:
+		return node.Loc, true
+	case *ast.WhileStat:
+		return node.Loc, true
+	case *ast.RepeatStat:
+		return node.Loc, true
+	case *ast.ForNumStat:
+		return node.Loc, true
+	case *ast.ForInStat:
+		return node.Loc, true
+	case *ast.AssignStat:
+		return node.Loc, true
+	case *ast.LocalVarDeclStat:
+		return node.Loc, true
+	case *ast.LocalFuncDefStat:
+		return node.Loc, true
+	case *ast.FuncCallStat:
+		return node.Loc, true
+	}
+
+	return loc, false
+}
+
+// cloneShiftLine 深拷贝语法树的节点，所有的位置信息偏移lineDelta行，不修改之前的语法树
+func cloneShiftLine(node interface{}, lineDelta int) interface{} {
+	if lineDelta == 0 {
+		return node
+	}
+
+	return cloneShiftValue(reflect.ValueOf(node), lineDelta).Interface()
+}
+
+func cloneShiftValue(v reflect.Value, lineDelta int) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		newPtr := reflect.New(v.Elem().Type())
+		newPtr.Elem().Set(cloneShiftValue(v.Elem(), lineDelta))
+		return newPtr
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		newValue := reflect.New(v.Type()).Elem()
+		newValue.Set(cloneShiftValue(v.Elem(), lineDelta))
+		return newValue
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		newSlice := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			newSlice.Index(i).Set(cloneShiftValue(v.Index(i), lineDelta))
+		}
+		return newSlice
+	case reflect.Struct:
+		newStruct := reflect.New(v.Type()).Elem()
+		newStruct.Set(v)
+		if v.Type() == locationType {
+			// 没有位置信息的保持不变
+			loc := v.Interface().(lexer.Location)
+			if loc.StartLine > 0 || loc.EndLine > 0 {
+				loc.StartLine += lineDelta
+				loc.EndLine += lineDelta
+			}
+			newStruct.Set(reflect.ValueOf(loc))
+			return newStruct
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if newStruct.Field(i).CanSet() {
+				newStruct.Field(i).Set(cloneShiftValue(v.Field(i), lineDelta))
+			}
+		}
+		return newStruct
+	}
+
+	return v
+}
+
+// shiftCommentLine 拷贝注释的信息，所有的行号偏移lineDelta行
+func shiftCommentLine(commentInfo *lexer.CommentInfo, lineDelta int) *lexer.CommentInfo {
+	if lineDelta == 0 {
+		return commentInfo
+	}
+
+	newInfo := &lexer.CommentInfo{
+		ShortFlag: commentInfo.ShortFlag,
+		HeadFlag:  commentInfo.HeadFlag,
+		LineVec:   make([]lexer.CommentLine, len(commentInfo.LineVec)),
+	}
+	for i, oneLine := range commentInfo.LineVec {
+		oneLine.Line += lineDelta
+		newInfo.LineVec[i] = oneLine
+	}
+
+	return newInfo
+}
+
+// getLineStarts 获取每一行开始的偏移，换行的规则与词法分析一致，\r\n与\n\r都当成一个换行
+func getLineStarts(contents []byte) []int {
+	lineStarts := []int{0}
+	for i := 0; i < len(contents); i++ {
+		ch := contents[i]
+		if ch != '\r' && ch != '\n' {
+			continue
+		}
+
+		if i+1 < len(contents) && contents[i+1] != ch && (contents[i+1] == '\r' || contents[i+1] == '\n') {
+			i++
+		}
+		lineStarts = append(lineStarts, i+1)
+	}
+
+	return lineStarts
+}
+
+// getOffsetLine 获取偏移所在的行号，从1开始
+func getOffsetLine(lineStarts []int, offset int) int {
+	return sort.Search(len(lineStarts), func(i int) bool {
+		return lineStarts[i] > offset
+	})
+}
+
+// getBytesHash 获取一段内容的hash
+func getBytesHash(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	return h.Sum64()
+}
+
+// isBlankBytes 判断是否都是空白字符
+func isBlankBytes(data []byte) bool {
+	for _, ch := range data {
+		if ch != ' ' && ch != '\t' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasUTF8BOM 判断是否有UTF-8的BOM头，词法分析时会跳过BOM头，列号与偏移不一致
+func hasUTF8BOM(contents []byte) bool {
+	return len(contents) >= 3 && contents[0] == 239 && contents[1] == 187 && contents[2] == 191
+}
@@ -13,6 +13,32 @@ func (p *luaParser) parseBlock() *ast.Block {
 	}
 }
 
+// parseMainBlock 解析整个文件的block，遇到多余的end等单词时，跳过后继续解析后面的语句
+// 解析出来的语句实时保存在mainBlock中，解析中途终止时，也能返回已经解析的部分
+func (p *luaParser) parseMainBlock() *ast.Block {
+	p.mainBlock = &ast.Block{}
+	for {
+		for !p.isReturnOrBlockEnd(p.l.LookAheadKind()) {
+			stat := p.parseStat()
+			if _, ok := stat.(*ast.EmptyStat); !ok {
+				p.mainBlock.Stats = append(p.mainBlock.Stats, stat)
+			}
+		}
+
+		if retExps := p.parseRetExps(); retExps != nil {
+			p.mainBlock.RetExps = retExps
+		}
+
+		if p.l.LookAheadKind() == lexer.TkEOF {
+			break
+		}
+
+		p.mainBlock.Stats = append(p.mainBlock.Stats, p.parseIllegalStat())
+	}
+
+	return p.mainBlock
+}
+
 func (p *luaParser) parseStats() []ast.Stat {
 	stats := make([]ast.Stat, 0, 1)
 	for !p.isReturnOrBlockEnd(p.l.LookAheadKind()) {
@@ -57,3 +83,29 @@ func (p *luaParser) isReturnOrBlockEnd(tokenKind lexer.TkKind) bool {
 	}
 	return false
 }
+
+// isRecoverToken 判断是否为语法错误后可以重新开始解析的单词
+// 解析出错时，不跳过这些单词，留给外层的语句继续解析
+func (p *luaParser) isRecoverToken(tokenKind lexer.TkKind) bool {
+	switch tokenKind {
+	case lexer.TkEOF, lexer.TkKwEnd, lexer.TkKwElse, lexer.TkKwElseif, lexer.TkKwUntil,
+		lexer.TkKwThen, lexer.TkKwDo, lexer.TkKwLocal, lexer.TkKwFunction, lexer.TkKwReturn,
+		lexer.TkKwIf, lexer.TkKwFor, lexer.TkKwWhile, lexer.TkKwRepeat, lexer.TkKwGoto, lexer.TkKwBreak,
+		lexer.TkSepLabel, lexer.TkSepRcurly, lexer.TkSepRparen, lexer.TkSepRbrack:
+		return true
+	}
+	return false
+}
+
+// expectTokenKind 期望下一个单词为tokenKind，不匹配时报错
+// 下一个单词为可以重新开始解析的单词时，不跳过该单词，例如缺少)时，不会跳过后面的end
+func (p *luaParser) expectTokenKind(tokenKind lexer.TkKind) {
+	l := p.l
+	aheadKind := l.LookAheadKind()
+	if aheadKind == tokenKind || !p.isRecoverToken(aheadKind) {
+		l.NextTokenKind(tokenKind)
+		return
+	}
+
+	p.insertParserErr(l.GetHeardTokenLoc(), "expected %s, found '%s'", tokenKind.String(), aheadKind.String())
+}
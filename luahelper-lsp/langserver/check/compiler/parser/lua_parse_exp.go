@@ -130,6 +130,8 @@ func (p *luaParser) parseExp0() ast.Exp {
 		}
 	case lexer.TkNumber: // Numeral
 		return p.parseNumberExp()
+	case lexer.TkInterpString: // Luau interpolated string
+		return p.parseLuauInterpStringExp()
 	case lexer.TkSepLcurly: // tableconstructor
 		return p.parseTableConstructorExp()
 	case lexer.TkKwFunction: // functiondef
@@ -172,16 +174,23 @@ func (p *luaParser) parseNumberExp() ast.Exp {
 // funcbody ::= ‘(’ [parlist] ‘)’ block end
 func (p *luaParser) parseFuncDefExp(beginLoc *lexer.Location) *ast.FuncDefExp {
 	l := p.l
+	p.beginLuauFuncType()                             // [‘<’ generics ‘>’]
 	l.NextTokenKind(lexer.TkSepLparen)                // (
 	parList, parLocList, isVararg := p.parseParList() // [parlist]
-	l.NextTokenKind(lexer.TkSepRparen)                // )
+	p.expectTokenKind(lexer.TkSepRparen)              // )
+	p.finishLuauFuncType(beginLoc.StartLine)          // [‘:’ rettype]
+
+	// continue不能跳出函数
+	loopVec := p.loopVec
+	p.loopVec = nil
 
 	blockBeginLoc := l.GetHeardTokenLoc()
 	block := p.parseBlock() // block
 	blockEndLoc := l.GetNowTokenLoc()
+	p.loopVec = loopVec
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -205,12 +214,14 @@ func (p *luaParser) parseParList() (names []string, locVec []lexer.Location, isV
 		return nil, nil, false
 	case lexer.TkVararg:
 		l.NextToken()
+		p.parseLuauParamType("...", l.GetNowTokenLoc())
 		return nil, nil, true
 	}
 
 	_, name := l.NextIdentifier()
 	names = append(names, name)
 	locVec = append(locVec, l.GetNowTokenLoc())
+	p.parseLuauParamType(name, l.GetNowTokenLoc())
 
 	for l.LookAheadKind() == lexer.TkSepComma {
 		l.NextToken()
@@ -218,8 +229,10 @@ func (p *luaParser) parseParList() (names []string, locVec []lexer.Location, isV
 			_, name := l.NextIdentifier()
 			names = append(names, name)
 			locVec = append(locVec, l.GetNowTokenLoc())
+			p.parseLuauParamType(name, l.GetNowTokenLoc())
 		} else {
 			l.NextTokenKind(lexer.TkVararg)
+			p.parseLuauParamType("...", l.GetNowTokenLoc())
 			isVararg = true
 			break
 		}
@@ -233,7 +246,7 @@ func (p *luaParser) parseTableConstructorExp() *ast.TableConstructorExp {
 	l.NextTokenKind(lexer.TkSepLcurly) // {
 	beginLoc := l.GetNowTokenLoc()
 	keyExps, valExps := p.parseFieldList() // [fieldlist]
-	l.NextTokenKind(lexer.TkSepRcurly)     // }
+	p.expectTokenKind(lexer.TkSepRcurly)   // }
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -258,8 +271,14 @@ func (p *luaParser) parseFieldList() (ks, vs []ast.Exp) {
 		ks = append(ks, k)
 		vs = append(vs, v)
 
-		for _isFieldSep(l.LookAheadKind()) {
-			l.NextToken()
+		for _isFieldSep(l.LookAheadKind()) || _isFieldStart(l.LookAheadKind()) {
+			if !_isFieldSep(l.LookAheadKind()) {
+				// 缺少分隔符，报错后继续解析后面的成员，例如 { x = 1 y = 2 }
+				p.insertParserErr(l.GetHeardTokenLoc(), "missing ',' or ';' between table fields")
+			} else {
+				l.NextToken()
+			}
+
 			if l.LookAheadKind() != lexer.TkSepRcurly {
 				k, v := p.parseField()
 				ks = append(ks, k)
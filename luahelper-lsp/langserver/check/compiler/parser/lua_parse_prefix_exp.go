@@ -30,6 +30,14 @@ func (p *luaParser) parsePrefixExp() ast.Exp {
 		}
 	} else if aheadKind == lexer.TkSepLparen { // ‘(’ exp ‘)’
 		exp = p.parseParensExp()
+	} else if p.isRecoverToken(aheadKind) {
+		// 缺少表达式，不跳过后面的单词，留给外层的语句继续解析，例如 local a = end
+		loc := l.GetHeardTokenLoc()
+		exp = &ast.BadExpr{
+			Loc: loc,
+		}
+		p.insertParserErr(loc, "unexpected symbol near '%s'", aheadKind.String())
+		return exp
 	} else {
 		l.NextToken()
 		loc := l.GetNowTokenLoc()
@@ -47,7 +55,7 @@ func (p *luaParser) parseParensExp() ast.Exp {
 	beginLoc := l.GetNowTokenLoc()
 	exp := p.parseExp() // exp
 
-	l.NextTokenKind(lexer.TkSepRparen) // )
+	p.expectTokenKind(lexer.TkSepRparen) // )
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -68,9 +76,9 @@ func (p *luaParser) finishPrefixExp(exp ast.Exp, beginLoc *lexer.Location) ast.E
 	for {
 		switch l.LookAheadKind() {
 		case lexer.TkSepLbrack: // prefixexp ‘[’ exp ‘]’
-			l.NextToken()                      // ‘[’
-			keyExp := p.parseExp()             // exp
-			l.NextTokenKind(lexer.TkSepRbrack) // ‘]’
+			l.NextToken()                        // ‘[’
+			keyExp := p.parseExp()               // exp
+			p.expectTokenKind(lexer.TkSepRbrack) // ‘]’
 			endLoc := l.GetNowTokenLoc()
 			loc := lexer.GetRangeLoc(beginLoc, &endLoc)
 			exp = &ast.TableAccessExp{
@@ -157,10 +165,12 @@ func (p *luaParser) parseArgs() (args []ast.Exp) {
 	switch l.LookAheadKind() {
 	case lexer.TkSepLparen: // ‘(’ [explist] ‘)’
 		l.NextToken() // lexer.TkSepLparen
-		if l.LookAheadKind() != lexer.TkSepRparen {
+		// 缺少)时，不把后面可以重新开始解析的单词当成参数，例如 print(
+		aheadKind := l.LookAheadKind()
+		if aheadKind != lexer.TkSepRparen && (aheadKind == lexer.TkKwFunction || !p.isRecoverToken(aheadKind)) {
 			args = p.parseExpList()
 		}
-		l.NextTokenKind(lexer.TkSepRparen)
+		p.expectTokenKind(lexer.TkSepRparen)
 	case lexer.TkSepLcurly: // ‘{’ [fieldlist] ‘}’
 		args = []ast.Exp{p.parseTableConstructorExp()}
 	default: // LiteralString
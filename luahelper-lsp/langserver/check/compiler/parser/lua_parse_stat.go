@@ -46,10 +46,13 @@ func (p *luaParser) parseStat() ast.Stat {
 		return p.parseFuncDefStat()
 	case lexer.TkKwLocal:
 		return p.parseLocalAssignOrFuncDefStat()
-	case lexer.IKIllegal:
-		return p.parseIKIllegalStat()
-	default:
+	case lexer.TkIdentifier, lexer.TkSepLparen:
+		if p.luauFlag && p.l.LookAheadKind() == lexer.TkIdentifier {
+			return p.parseLuauIdentStat()
+		}
 		return p.parseAssignOrFuncCallStat()
+	default:
+		return p.parseIllegalStat()
 	}
 }
 
@@ -93,7 +96,7 @@ func (p *luaParser) parseGotoStat() *ast.GotoStat {
 // do block end
 func (p *luaParser) parseDoStat() *ast.DoStat {
 	l := p.l
-	l.NextTokenKind(lexer.TkKwDo) // do
+	p.expectTokenKind(lexer.TkKwDo) // do
 	beginLoc := l.GetNowTokenLoc()
 
 	blockBeginLoc := l.GetHeardTokenLoc()
@@ -101,7 +104,7 @@ func (p *luaParser) parseDoStat() *ast.DoStat {
 	blockEndLoc := l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 	endLoc := l.GetNowTokenLoc()
 
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
@@ -116,15 +119,15 @@ func (p *luaParser) parseWhileStat() *ast.WhileStat {
 	l := p.l
 	l.NextTokenKind(lexer.TkKwWhile) // while
 	beginLoc := l.GetNowTokenLoc()
-	exp := p.parseExp()           // exp
-	l.NextTokenKind(lexer.TkKwDo) // do
+	exp := p.parseExp()             // exp
+	p.expectTokenKind(lexer.TkKwDo) // do
 
 	blockBeginLoc := l.GetHeardTokenLoc()
-	block := p.parseBlock() // block
+	block := p.parseLoopBlock() // block
 	blockEndLoc := l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -142,12 +145,12 @@ func (p *luaParser) parseRepeatStat() *ast.RepeatStat {
 	beginLoc := l.GetNowTokenLoc()
 
 	blockBeginLoc := l.GetHeardTokenLoc()
-	block := p.parseBlock() // block
+	block := p.parseLoopBlock() // block
 	blockEndLoc := l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwUntil) // until
-	exp := p.parseExp()              // exp
+	p.expectTokenKind(lexer.TkKwUntil) // until
+	exp := p.parseExp()                // exp
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -168,7 +171,7 @@ func (p *luaParser) parseIfStat() *ast.IfStat {
 	beginLoc := l.GetNowTokenLoc()
 
 	exps = append(exps, p.parseExp()) // exp
-	l.NextTokenKind(lexer.TkKwThen)   // then
+	p.expectTokenKind(lexer.TkKwThen) // then
 
 	thenBlockBeginLoc := l.GetHeardTokenLoc()
 	thenBlock := p.parseBlock()
@@ -179,7 +182,7 @@ func (p *luaParser) parseIfStat() *ast.IfStat {
 	for l.LookAheadKind() == lexer.TkKwElseif {
 		l.NextToken()                     // elseif
 		exps = append(exps, p.parseExp()) // exp
-		l.NextTokenKind(lexer.TkKwThen)   // then
+		p.expectTokenKind(lexer.TkKwThen) // then
 
 		elseifBlockBeginLoc := l.GetHeardTokenLoc()
 		elseifBlock := p.parseBlock()
@@ -205,7 +208,7 @@ func (p *luaParser) parseIfStat() *ast.IfStat {
 		blocks = append(blocks, elseBlock) // block
 	}
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
@@ -224,16 +227,18 @@ func (p *luaParser) parseForStat() ast.Stat {
 	beginLoc := l.GetNowTokenLoc()
 
 	_, name := l.NextIdentifier()
+	varNameLoc := l.GetNowTokenLoc()
+	p.skipLuauVarType()
 	if l.LookAheadKind() == lexer.TkOpAssign {
-		return p.finishForNumStat(lineOfFor, name, &beginLoc)
+		return p.finishForNumStat(lineOfFor, name, varNameLoc, &beginLoc)
 	}
-	return p.finishForInStat(name, &beginLoc)
+	return p.finishForInStat(name, varNameLoc, &beginLoc)
 }
 
 // for Name ‘=’ exp ‘,’ exp [‘,’ exp] do block end
-func (p *luaParser) finishForNumStat(lineOfFor int, varName string, beginLoc *lexer.Location) *ast.ForNumStat {
+func (p *luaParser) finishForNumStat(lineOfFor int, varName string, varNameLoc lexer.Location,
+	beginLoc *lexer.Location) *ast.ForNumStat {
 	l := p.l
-	varNameLoc := l.GetNowTokenLoc()
 	l.NextTokenKind(lexer.TkOpAssign) // for name =
 	initExp := p.parseExp()           // exp
 	l.NextTokenKind(lexer.TkSepComma) // ,
@@ -251,14 +256,14 @@ func (p *luaParser) finishForNumStat(lineOfFor int, varName string, beginLoc *le
 		}
 	}
 
-	l.NextTokenKind(lexer.TkKwDo) // do
+	p.expectTokenKind(lexer.TkKwDo) // do
 
 	blockBeginLoc := l.GetHeardTokenLoc()
-	block := p.parseBlock() // block
+	block := p.parseLoopBlock() // block
 	blockEndLoc := l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -277,20 +282,19 @@ func (p *luaParser) finishForNumStat(lineOfFor int, varName string, beginLoc *le
 // for namelist in explist do block end
 // namelist ::= Name {‘,’ Name}
 // explist ::= exp {‘,’ exp}
-func (p *luaParser) finishForInStat(name0 string, beginLoc *lexer.Location) *ast.ForInStat {
+func (p *luaParser) finishForInStat(name0 string, varLoc0 lexer.Location, beginLoc *lexer.Location) *ast.ForInStat {
 	l := p.l
-	varLoc0 := l.GetNowTokenLoc()
 	nameList, nameLocList := p.finishNameList(name0, varLoc0) // for namelist
 	l.NextTokenKind(lexer.TkKwIn)                             // in
 	expList := p.parseExpList()                               // explist
 	l.NextTokenKind(lexer.TkKwDo)                             // do
 
 	blockBeginLoc := l.GetHeardTokenLoc()
-	block := p.parseBlock() // block
+	block := p.parseLoopBlock() // block
 	blockEndLoc := l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
-	l.NextTokenKind(lexer.TkKwEnd) // end
+	p.expectTokenKind(lexer.TkKwEnd) // end
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -313,6 +317,7 @@ func (p *luaParser) finishNameList(name0 string, varLoc0 lexer.Location) ([]stri
 		l.NextToken()                 // ,
 		_, name := l.NextIdentifier() // Name
 		loc := l.GetNowTokenLoc()
+		p.skipLuauVarType()
 		locs = append(locs, loc)
 		names = append(names, name)
 	}
@@ -361,6 +366,7 @@ func (p *luaParser) finishLocalNameList(name0 string, varLoc0 lexer.Location, ki
 		_, name := l.NextIdentifier() // Name
 		loc := l.GetNowTokenLoc()
 		kind := p.getLocalAttribute()
+		p.parseLuauLocalType(name, loc)
 		if kind == ast.RDKTOCLOSE {
 			if index != -1 {
 				p.insertParserErr(l.GetPreTokenLoc(), "more than one to_be_close variables found in local list")
@@ -427,8 +433,11 @@ func (p *luaParser) finishLocalVarDeclStat() *ast.LocalVarDeclStat {
 	beginLoc := l.GetNowTokenLoc()
 	_, name0 := l.NextIdentifier() // local Name
 	varLoc0 := l.GetNowTokenLoc()
-	kind0 := p.getLocalAttribute()                                              // added to support lua5.4
+	kind0 := p.getLocalAttribute() // added to support lua5.4
+	p.localTypeVec = nil
+	p.parseLuauLocalType(name0, varLoc0)
 	nameList, locList, attrList := p.finishLocalNameList(name0, varLoc0, kind0) // { , Name attrib}
+	p.insertLuauLocalType(beginLoc.StartLine)
 	var expList []ast.Exp
 	if l.LookAheadKind() == lexer.TkOpAssign {
 		l.NextToken()              // ==
@@ -452,6 +461,12 @@ func (p *luaParser) parseAssignOrFuncCallStat() ast.Stat {
 	l := p.l
 	beginLoc := l.GetHeardTokenLoc()
 	prefixExp := p.parsePrefixExp()
+	return p.finishAssignOrFuncCallStat(beginLoc, prefixExp)
+}
+
+// 已经解析了语句开头的prefixexp，继续解析赋值或是函数调用语句
+func (p *luaParser) finishAssignOrFuncCallStat(beginLoc lexer.Location, prefixExp ast.Exp) ast.Stat {
+	l := p.l
 	if _, ok := prefixExp.(*ast.BadExpr); ok {
 		return &ast.EmptyStat{}
 	}
@@ -473,6 +488,10 @@ func (p *luaParser) parseAssignStat(preLoc lexer.Location, var0 ast.Exp) ast.Sta
 	symList := p.finishVarList(var0) // varlist
 
 	aheadKind := l.LookAheadKind()
+	if p.luauFlag && len(symList) == 1 && isLuauCompoundOp(aheadKind) {
+		return p.finishLuauCompoundAssignStat(preLoc, symList)
+	}
+
 	if aheadKind != lexer.TkOpAssign {
 		nowLoc := l.GetNowTokenLoc()
 		loc := lexer.GetRangeLoc(&preLoc, &nowLoc)
@@ -614,17 +633,30 @@ func (p *luaParser) parseFuncName() (exp ast.Exp, hasColon bool, className strin
 	return
 }
 
-// func (p *luaParser) parseIKIllegalStat() *ast.IllegalStat{
-// 	l := p.l
-// 	loc := l.GetNowTokenLoc()
-// 	l.NextToken()
-// 	return &ast.IllegalStat{
-// 		Name: "",
-// 		Loc:  loc,
-// 	}
-// }
-
-func (p *luaParser) parseIKIllegalStat() *ast.EmptyStat {
-	p.l.NextToken()
-	return _statEmpty
+// parseIllegalStat 不能作为语句开始的单词，跳过同一行后面的单词，只报一个错误
+// 遇到可以重新开始解析的单词时停止，例如end、local、function
+func (p *luaParser) parseIllegalStat() *ast.IllegalStat {
+	l := p.l
+	aheadKind := l.LookAheadKind()
+	line, _, token := l.NextToken()
+	beginLoc := l.GetNowTokenLoc()
+	if aheadKind != lexer.IKIllegal {
+		// 非法的字符，词法分析的时候已经报错了
+		p.insertParserErr(beginLoc, "unexpected symbol near '%s'", token)
+	}
+
+	for {
+		aheadKind = l.LookAheadKind()
+		if aheadKind == lexer.TkEOF || p.isRecoverToken(aheadKind) || l.GetHeardTokenLoc().StartLine != line {
+			break
+		}
+
+		l.NextToken()
+	}
+
+	endLoc := l.GetNowTokenLoc()
+	return &ast.IllegalStat{
+		Name: token,
+		Loc:  lexer.GetRangeLoc(&beginLoc, &endLoc),
+	}
 }
@@ -12,6 +12,27 @@ type luaParser struct {
 	l *lexer.Lexer
 
 	parseErrs []lexer.ParseError
+
+	// 语法错误的总数量，包含没有保存的
+	errNum int
+
+	// 整个文件的block，解析中途终止时，返回已经解析的部分
+	mainBlock *ast.Block
+
+	// 是否为Luau语法，Luau支持类型注解、continue、复合赋值与插值字符串
+	luauFlag bool
+
+	// Luau中所在的每一层循环，是否使用了continue
+	loopVec []bool
+
+	// Luau中类型注解转换成的注解，例如 local a: number 转换成 ---@type number
+	annotateVec []lexer.CommentLine
+
+	// Luau中局部变量定义的类型注解，例如 local a: number, b
+	localTypeVec []luauField
+
+	// Luau类型别名中的泛型名称，转换时当成any
+	aliasGenericMap map[string]bool
 }
 
 // CreateParser 创建一个分析对象
@@ -24,11 +45,27 @@ func createLuaParser(chunk []byte, chunkName string) *luaParser {
 	return parser
 }
 
+// createLuauParser 创建一个Luau的分析对象
+func createLuauParser(chunk []byte, chunkName string) *luaParser {
+	parser := createLuaParser(chunk, chunkName)
+	parser.luauFlag = true
+	parser.l.SetMode(lexer.ModeLuau)
+
+	return parser
+}
+
 // BeginAnalyze 开始分析
 func (p *luaParser) BeginAnalyze() (block *ast.Block, commentMap map[int]*lexer.CommentInfo, errList []lexer.ParseError) {
+	var blockBeginLoc lexer.Location
 	defer func() {
 		if err1 := recover(); err1 != nil {
-			block = &ast.Block{}
+			// 保留已经解析的部分语法树
+			block = p.mainBlock
+			if block == nil {
+				block = &ast.Block{}
+			}
+			blockEndLoc := p.l.GetNowTokenLoc()
+			block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 			commentMap = p.l.GetCommentMap()
 			errList = p.parseErrs
 			return
@@ -37,8 +74,8 @@ func (p *luaParser) BeginAnalyze() (block *ast.Block, commentMap map[int]*lexer.
 
 	p.l.SkipFirstLineComment()
 
-	blockBeginLoc := p.l.GetHeardTokenLoc()
-	block = p.parseBlock() // block
+	blockBeginLoc = p.l.GetHeardTokenLoc()
+	block = p.parseMainBlock() // block
 	blockEndLoc := p.l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
@@ -76,14 +113,19 @@ func (p *luaParser) insertParserErr(loc lexer.Location, f string, a ...interface
 	p.insertErr(paseError)
 }
 
+// insertErr 插入一个语法错误，错误过多时只保存前面的部分，继续往后解析
 func (p *luaParser) insertErr(oneErr lexer.ParseError) {
-	if len(p.parseErrs) < 30 {
+	p.errNum++
+	if p.errNum <= maxShowErrNum {
 		p.parseErrs = append(p.parseErrs, oneErr)
-	} else {
+	} else if p.errNum == maxShowErrNum+1 {
 		oneErr.ErrStr = oneErr.ErrStr + "(too many err...)"
 		p.parseErrs = append(p.parseErrs, oneErr)
+	}
+
+	if p.errNum >= maxParseErrNum {
 		manyError := &lexer.TooManyErr{
-			ErrNum: 30,
+			ErrNum: p.errNum,
 		}
 
 		panic(manyError)
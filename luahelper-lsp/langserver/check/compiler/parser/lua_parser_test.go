@@ -2,9 +2,12 @@ package parser
 
 import (
 	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/ast"
 	"luahelper-lsp/langserver/check/compiler/lexer"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -479,46 +482,410 @@ func TestParseIllega1(t *testing.T) {
 
 	var expectLocList []lexer.Location
 
-	// 0
+	// 0 缺少表达式，不跳过后面的}，后续的语句都能正常解析
 	expectLocList = append(expectLocList, lexer.Location{
-		StartLine:   1,
-		StartColumn: 0,
-		EndLine:     1,
-		EndColumn:   9,
+		StartLine:   7,
+		StartColumn: 4,
+		EndLine:     7,
+		EndColumn:   5,
 	})
 	// 1
 	expectLocList = append(expectLocList, lexer.Location{
-		StartLine:   2,
+		StartLine:   14,
 		StartColumn: 0,
-		EndLine:     2,
-		EndColumn:   8,
-	})
-	// 3
-	expectLocList = append(expectLocList, lexer.Location{
-		StartLine:   3,
-		StartColumn: 7,
-		EndLine:     3,
-		EndColumn:   10,
-	})
-	// 4
-	expectLocList = append(expectLocList, lexer.Location{
-		StartLine:   4,
-		StartColumn: 11,
-		EndLine:     4,
-		EndColumn:   14,
+		EndLine:     14,
+		EndColumn:   9,
 	})
 
 	if len(errList) != len(expectLocList) {
 		t.Fatalf("parser errList len err")
 	}
 
-	// for i, oneErr := range errList {
-	// 	if !lexer.CompareTwoLoc(&oneErr.Loc, &expectLocList[i]) {
-	// 		t.Fatalf("index=%d loc err", i)
-	// 	}
-	// }
+	for i, oneErr := range errList {
+		if !lexer.CompareTwoLoc(&oneErr.Loc, &expectLocList[i]) {
+			t.Fatalf("index=%d loc err", i)
+		}
+	}
 
 	if block == nil {
 		t.Logf("is nil")
 	}
 }
+
+// 有语法错误时，保留出错语句前后的语法树
+func TestParseRecoverPartialAst(t *testing.T) {
+	strContent := "local a = 1\nlocal function f()\n    local b =\nend\nlocal t = { x = 1 y = 2 }\nend\nlocal c = 3"
+	parser := CreateParser([]byte(strContent), "test")
+	block, _, errList := parser.BeginAnalyze()
+	if len(errList) != 3 {
+		t.Fatalf("parser errList len err, len=%d", len(errList))
+	}
+
+	if len(block.Stats) != 5 {
+		t.Fatalf("parser stats len err, len=%d", len(block.Stats))
+	}
+
+	if _, ok := block.Stats[1].(*ast.LocalFuncDefStat); !ok {
+		t.Fatalf("parser local function err")
+	}
+
+	tableStat, ok := block.Stats[2].(*ast.LocalVarDeclStat)
+	if !ok {
+		t.Fatalf("parser local table err")
+	}
+
+	tableExp, ok := tableStat.ExpList[0].(*ast.TableConstructorExp)
+	if !ok || len(tableExp.KeyExps) != 2 {
+		t.Fatalf("parser table fields err")
+	}
+
+	if _, ok := block.Stats[3].(*ast.IllegalStat); !ok {
+		t.Fatalf("parser illegal end err")
+	}
+
+	lastStat, ok := block.Stats[4].(*ast.LocalVarDeclStat)
+	if !ok || lastStat.NameList[0] != "c" {
+		t.Fatalf("parser last local err")
+	}
+}
+
+// 语法错误过多时，不再保存错误，但是继续解析后面的语句
+func TestParseTooManyErr(t *testing.T) {
+	strContent := strings.Repeat("a b\n", 50) + "local c = 3"
+	parser := CreateParser([]byte(strContent), "test")
+	block, _, errList := parser.BeginAnalyze()
+	if len(errList) != maxShowErrNum+1 {
+		t.Fatalf("parser errList len err, len=%d", len(errList))
+	}
+
+	lastStat, ok := block.Stats[len(block.Stats)-1].(*ast.LocalVarDeclStat)
+	if !ok || lastStat.NameList[0] != "c" {
+		t.Fatalf("parser last local err")
+	}
+}
+
+// moocscript 有语法错误时，保留出错语句前后的语法树
+func TestParseMoocRecoverPartialAst(t *testing.T) {
+	strContent := "fn foo(a) {\n    local b =\n}\n}\nlocal c = 3\nfn bar() {\n    print(c\n}"
+	parser := CreateParser([]byte(strContent), "test.mooc")
+	block, _, errList := parser.BeginAnalyze()
+	if len(errList) != 3 {
+		t.Fatalf("parser errList len err, len=%d", len(errList))
+	}
+
+	if len(block.Stats) != 4 {
+		t.Fatalf("parser stats len err, len=%d", len(block.Stats))
+	}
+
+	if _, ok := block.Stats[1].(*ast.IllegalStat); !ok {
+		t.Fatalf("parser illegal } err")
+	}
+
+	lastStat, ok := block.Stats[2].(*ast.LocalVarDeclStat)
+	if !ok || lastStat.NameList[0] != "c" {
+		t.Fatalf("parser local err")
+	}
+}
+
+// 增量解析的结果需要与整体解析的结果一致
+func checkIncrementalParse(t *testing.T, oldContent string, newContent string, changeVec []ChangeRange) {
+	oldBlock, oldCommentMap, oldErrList := CreateParser([]byte(oldContent), "test.lua").BeginAnalyze()
+	snapshot := CreateParseSnapshot("test.lua", DialectLua, []byte(oldContent), oldBlock, oldCommentMap, oldErrList)
+	if snapshot == nil {
+		t.Fatalf("create parse snapshot err")
+	}
+
+	block, commentMap, ok := snapshot.IncrementalParse([]byte(newContent), changeVec)
+	if !ok {
+		t.Fatalf("incremental parse err")
+	}
+
+	fullBlock, fullCommentMap, _ := CreateParser([]byte(newContent), "test.lua").BeginAnalyze()
+	if !reflect.DeepEqual(block, fullBlock) {
+		t.Fatalf("incremental parse block not equal full parse")
+	}
+
+	if !reflect.DeepEqual(commentMap, fullCommentMap) {
+		t.Fatalf("incremental parse comment not equal full parse")
+	}
+
+	// 复用的语法树不能被修改
+	newOldBlock, _, _ := CreateParser([]byte(oldContent), "test.lua").BeginAnalyze()
+	if !reflect.DeepEqual(oldBlock, newOldBlock) {
+		t.Fatalf("incremental parse modify old block")
+	}
+}
+
+func TestParseIncremental(t *testing.T) {
+	oldContent := "-- head\nlocal a = 1\n\nfunction foo(b)\n    return b + a -- tail\nend\n\n---@type number\nlocal c = foo(2)\nreturn c"
+
+	// 函数内部增加了一行
+	newContent := "-- head\nlocal a = 1\n\nfunction foo(b)\n    local d = b * 2\n    return d + a -- tail\nend\n\n---@type number\nlocal c = foo(2)\nreturn c"
+	checkIncrementalParse(t, oldContent, newContent, []ChangeRange{{StartLine: 5, EndLine: 5}})
+
+	// 删除了一个语句
+	newContent = "-- head\nlocal a = 1\n\n---@type number\nlocal c = foo(2)\nreturn c"
+	checkIncrementalParse(t, oldContent, newContent, []ChangeRange{{StartLine: 4, EndLine: 7}})
+
+	// 修改最后的语句
+	newContent = "-- head\nlocal a = 1\n\nfunction foo(b)\n    return b + a -- tail\nend\n\n---@type number\nlocal c = foo(2)\nprint(c)\n"
+	checkIncrementalParse(t, oldContent, newContent, []ChangeRange{{StartLine: 10, EndLine: 10}})
+}
+
+// 修改的部分有语法错误，或是修改了第一个语句之前的内容时，不能增量解析
+func TestParseIncrementalFallback(t *testing.T) {
+	oldContent := "local a = 1\nlocal b = 2\nlocal c = 3"
+	oldBlock, oldCommentMap, oldErrList := CreateParser([]byte(oldContent), "test.lua").BeginAnalyze()
+	snapshot := CreateParseSnapshot("test.lua", DialectLua, []byte(oldContent), oldBlock, oldCommentMap, oldErrList)
+	if snapshot == nil {
+		t.Fatalf("create parse snapshot err")
+	}
+
+	newContent := "local a = 1\nlocal b = \nlocal c = 3"
+	if _, _, ok := snapshot.IncrementalParse([]byte(newContent), []ChangeRange{{StartLine: 2, EndLine: 2}}); ok {
+		t.Fatalf("incremental parse illegal content err")
+	}
+
+	newContent = "local a = 1\nlocal b = 2\n(print)(b)\nlocal c = 3"
+	if _, _, ok := snapshot.IncrementalParse([]byte(newContent), []ChangeRange{{StartLine: 3, EndLine: 3}}); ok {
+		t.Fatalf("incremental parse call stat err")
+	}
+
+	headContent := "-- head\nlocal a = 1"
+	headBlock, headCommentMap, headErrList := CreateParser([]byte(headContent), "test.lua").BeginAnalyze()
+	headSnapshot := CreateParseSnapshot("test.lua", DialectLua, []byte(headContent), headBlock, headCommentMap, headErrList)
+	newContent = "-- head comment\nlocal a = 1"
+	if _, _, ok := headSnapshot.IncrementalParse([]byte(newContent), []ChangeRange{{StartLine: 1, EndLine: 1}}); ok {
+		t.Fatalf("incremental parse head err")
+	}
+
+	if CreateParseSnapshot("test.lua", DialectLua, []byte(newContent), oldBlock, oldCommentMap, []lexer.ParseError{{}}) != nil {
+		t.Fatalf("create parse snapshot with err")
+	}
+}
+
+// 获取注释块中所有的注解内容
+func getCommentStrVec(commentInfo *lexer.CommentInfo) (strVec []string) {
+	if commentInfo == nil {
+		return nil
+	}
+
+	for _, commentLine := range commentInfo.LineVec {
+		strVec = append(strVec, commentLine.Str)
+	}
+	return strVec
+}
+
+// Luau的类型注解转换成注解，continue、复合赋值与插值字符串转换成lua的语法树
+func TestParseLuau(t *testing.T) {
+	strContent := "type Point = {x: number, y: number?}\n" +
+		"export type Id = string | number\n" +
+		"local p: Point, n = {x = 1}, 2\n" +
+		"function move<T>(pt: Point, dx: number?, ...: string): (boolean, {T})\n" +
+		"    for i: number = 1, 10 do\n" +
+		"        if i > 5 then continue end\n" +
+		"        pt.x += i\n" +
+		"    end\n" +
+		"    return true, {}\n" +
+		"end\n" +
+		"local s = `x={p.x} y={n}`\n" +
+		"local f: (Id) -> {[string]: Point} = nil\n"
+	parser := CreateParser([]byte(strContent), "test.luau")
+	block, commentMap, errList := parser.BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser luau err, errstr=%s", errList[0].ErrStr)
+	}
+
+	expectMap := map[int][]string{
+		0:  {"-@class Point", "-@field x number", "-@field y number|nil"},
+		1:  {"-@alias Id string|number"},
+		2:  {"-@type Point, any"},
+		3:  {"-@generic T", "-@param pt Point", "-@param dx? number", "-@vararg string", "-@return boolean, T[]"},
+		11: {"-@type fun(p1: Id): table<string, Point>"},
+	}
+	for line, expectVec := range expectMap {
+		if strVec := getCommentStrVec(commentMap[line]); !reflect.DeepEqual(strVec, expectVec) {
+			t.Fatalf("luau annotate err, line=%d, comment=%v", line, strVec)
+		}
+	}
+
+	// 列号对应代码中类型的位置
+	if commentLine := commentMap[2].LineVec[0]; commentLine.Line != 3 || commentLine.Col+len("-@type ") != 9 {
+		t.Fatalf("luau annotate loc err, line=%d, col=%d", commentLine.Line, commentLine.Col)
+	}
+
+	// continue 转换成goto到循环最后的label
+	funcExp := block.Stats[1].(*ast.AssignStat).ExpList[0].(*ast.FuncDefExp)
+	forStat := funcExp.Block.Stats[0].(*ast.ForNumStat)
+	ifStat := forStat.Block.Stats[0].(*ast.IfStat)
+	gotoStat := ifStat.Blocks[0].Stats[0].(*ast.GotoStat)
+	labelStat, ok := forStat.Block.Stats[len(forStat.Block.Stats)-1].(*ast.LabelStat)
+	if !ok || labelStat.Name != gotoStat.Name {
+		t.Fatalf("luau continue err")
+	}
+
+	// pt.x += i 转换成 pt.x = pt.x + i
+	assignStat := forStat.Block.Stats[1].(*ast.AssignStat)
+	if binopExp, ok := assignStat.ExpList[0].(*ast.BinopExp); !ok || binopExp.Op != lexer.TkOpAdd {
+		t.Fatalf("luau compound assign err")
+	}
+
+	// 插值字符串转换成字符串的拼接，表达式的位置为文件中的位置
+	localStat := block.Stats[2].(*ast.LocalVarDeclStat)
+	concatExp, ok := localStat.ExpList[0].(*ast.BinopExp)
+	if !ok || concatExp.Op != lexer.TkOpConcat {
+		t.Fatalf("luau interpolated string err")
+	}
+	nameExp, ok := concatExp.Exp2.(*ast.NameExp)
+	if !ok || nameExp.Name != "n" || nameExp.Loc.StartLine != 11 || nameExp.Loc.StartColumn != 22 {
+		t.Fatalf("luau interpolated string exp loc err")
+	}
+
+	// 非Luau文件中，continue不是关键字
+	_, _, errList = CreateParser([]byte("while true do continue end"), "test.lua").BeginAnalyze()
+	if len(errList) == 0 {
+		t.Fatalf("parser lua continue err")
+	}
+}
+
+// Luau的continue为上下文关键字，前一行为尾部注释时，转换成的注解仍然保留
+func TestParseLuauContextual(t *testing.T) {
+	strContent := "local continue = 1 -- count\n" +
+		"local n: number = continue\n" +
+		"continue += 1\n" +
+		"while n > 0 do\n" +
+		"    n -= 1\n" +
+		"    continue\n" +
+		"end\n"
+	block, commentMap, errList := CreateParser([]byte(strContent), "test.luau").BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser luau err, errstr=%s", errList[0].ErrStr)
+	}
+
+	commentInfo := commentMap[1]
+	if commentInfo == nil || commentInfo.HeadFlag || getCommentStrVec(commentInfo)[0] != " count" {
+		t.Fatalf("luau tail comment err")
+	}
+	if len(commentInfo.AnnotateLineVec) != 1 || commentInfo.AnnotateLineVec[0].Str != "-@type number" {
+		t.Fatalf("luau annotate after tail comment err")
+	}
+
+	if localStat, ok := block.Stats[0].(*ast.LocalVarDeclStat); !ok || localStat.NameList[0] != "continue" {
+		t.Fatalf("luau continue local err")
+	}
+	if _, ok := block.Stats[2].(*ast.AssignStat); !ok {
+		t.Fatalf("luau continue assign err")
+	}
+
+	whileStat := block.Stats[3].(*ast.WhileStat)
+	if _, ok := whileStat.Block.Stats[1].(*ast.GotoStat); !ok {
+		t.Fatalf("luau continue stat err")
+	}
+}
+
+// 配置关联到方言的文件，使用对应的语法分析器；未注册的方言当成lua处理
+func TestParseDialectAssocial(t *testing.T) {
+	associalMap := FilterDialectAssocial(map[string]string{"*.typed.lua": "luau", "*.other": "unknown"})
+
+	if !IsDialect("Luau") || IsDialect("unknown") {
+		t.Fatalf("dialect register err")
+	}
+
+	getDialect := func(chunkName string) string {
+		name, _ := GetFileDialect(chunkName, associalMap)
+		return name
+	}
+	if getDialect("/a/b.typed.lua") != DialectLuau || getDialect("/a/b.lua") != DialectLua ||
+		getDialect("/a/b.mooc") != DialectMooc || getDialect("/a/b.other") != DialectLua {
+		t.Fatalf("file dialect err")
+	}
+
+	_, _, errList := CreateDialectParser([]byte("local a: number = 1"), "/a/b.typed.lua",
+		getDialect("/a/b.typed.lua")).BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser associal luau err, errstr=%s", errList[0].ErrStr)
+	}
+}
+
+// 多个文件关联都能匹配时，包含路径的、更长的匹配模式优先，结果与map的遍历顺序无关
+func TestDialectPatternOrder(t *testing.T) {
+	associalMap := map[string]string{
+		"*.lua":            "mooc",
+		"*.typed.lua":      "luau",
+		"/a/src/*.lua":     "lua",
+		"/a/src/*.mod.lua": "luau",
+	}
+
+	expectMap := map[string]string{
+		"/b/c.lua":           DialectMooc,
+		"/b/c.typed.lua":     DialectLuau,
+		"/a/src/c.lua":       DialectLua,
+		"/a/src/c.typed.lua": DialectLua,
+		"/a/src/c.mod.lua":   DialectLuau,
+	}
+
+	for i := 0; i < 20; i++ {
+		for chunkName, expect := range expectMap {
+			if name, _ := GetFileDialect(chunkName, associalMap); name != expect {
+				t.Fatalf("file %s dialect=%s, expect=%s", chunkName, name, expect)
+			}
+		}
+	}
+}
+
+// 嵌入了lua代码的文件，只分析嵌入的区域，代码的位置与原文件一致，每段代码在单独的do语句中
+func TestParseEmbedLua(t *testing.T) {
+	associalMap := map[string]string{"*.conf": "nginx", "*.md": "markdown", "*.html": "html", "*.py": "redis"}
+
+	strVec := []string{
+		"# 注释 content_by_lua_block {\nlocation / {\n    content_by_lua_block {\n        local t = {a = \"}\"} -- }\n        ngx.say(t.a)\n    }\n}\n",
+		"# 标题\n\n```lua\nlocal t = {}\nprint(t)\n```\n\n```js\nlet a = 1;\n```\n",
+		"<html><script>let a = 1;</script><script type=\"text/lua\">\nlocal t = {}\nprint(t)\n</script></html>\n",
+		"r.eval(\"local t = redis.call('get', KEYS[1]) return t\", 1, \"k\")\nr.evalsha(\"abc\", 0)\nr.eval(\"return '\\\\n'\", 0)\n",
+	}
+	fileVec := []string{"/a/nginx.conf", "/a/readme.md", "/a/index.html", "/a/redis.py"}
+	lineVec := []int{4, 4, 2, 1}
+	statNumVec := []int{2, 2, 2, 1}
+
+	for i, str := range strVec {
+		dialect, _ := GetFileDialect(fileVec[i], associalMap)
+		block, _, errList := CreateDialectParser([]byte(str), fileVec[i], dialect).BeginAnalyze()
+		if len(errList) > 0 {
+			t.Fatalf("parser embed lua err, file=%s, errstr=%s", fileVec[i], errList[0].ErrStr)
+		}
+
+		if len(block.Stats) != 1 {
+			t.Fatalf("parser embed lua region err, file=%s, num=%d", fileVec[i], len(block.Stats))
+		}
+
+		regionBlock := block.Stats[0].(*ast.DoStat).Block
+		if len(regionBlock.Stats) != statNumVec[i] {
+			t.Fatalf("parser embed lua stats err, file=%s, num=%d", fileVec[i], len(regionBlock.Stats))
+		}
+
+		localStat, ok := regionBlock.Stats[0].(*ast.LocalVarDeclStat)
+		if !ok || localStat.Loc.StartLine != lineVec[i] {
+			t.Fatalf("parser embed lua loc err, file=%s", fileVec[i])
+		}
+	}
+
+	// 前一段代码中的return，不影响后面的代码
+	block, _, errList := CreateDialectParser([]byte("```lua\nlocal a = 1\nreturn a\n```\n\n```lua\nlocal a = 2\nprint(a)\n```\n"),
+		"/a/readme.md", DialectMarkdown).BeginAnalyze()
+	if len(errList) > 0 || len(block.Stats) != 2 {
+		t.Fatalf("parser embed lua multi region err, errnum=%d, num=%d", len(errList), len(block.Stats))
+	}
+	if regionBlock := block.Stats[0].(*ast.DoStat).Block; len(regionBlock.RetExps) != 1 {
+		t.Fatalf("parser embed lua region return err")
+	}
+	if localStat := block.Stats[1].(*ast.DoStat).Block.Stats[0].(*ast.LocalVarDeclStat); localStat.Loc.StartLine != 7 {
+		t.Fatalf("parser embed lua second region loc err")
+	}
+
+	// 嵌入区域中的语法错误，位置为原文件中的位置
+	_, _, errList = CreateDialectParser([]byte("set_by_lua_block $res {\n  local a = = 1\n  return a\n}\n"), "/a/err.conf",
+		DialectNginx).BeginAnalyze()
+	if len(errList) == 0 || errList[0].Loc.StartLine != 2 {
+		t.Fatalf("parser embed lua err loc err")
+	}
+}
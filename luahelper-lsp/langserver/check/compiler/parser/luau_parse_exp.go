@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"unicode/utf8"
+)
+
+// parseLuauInterpStringExp Luau的插值字符串，转换成字符串拼接的表达式
+// 例如 `a{b}c` 转换成 "a" .. b .. "c"，{}中的表达式单独进行语法分析
+func (p *luaParser) parseLuauInterpStringExp() ast.Exp {
+	l := p.l
+	_, _, raw := l.NextToken()
+	loc := l.GetNowTokenLoc()
+
+	var exp ast.Exp
+	appendExp := func(oneExp ast.Exp) {
+		if exp == nil {
+			exp = oneExp
+			return
+		}
+
+		exp = &ast.BinopExp{
+			Op:   lexer.TkOpConcat,
+			Exp1: exp,
+			Exp2: oneExp,
+			Loc:  loc,
+		}
+	}
+
+	strBegin := 0
+	i := 0
+	for i < len(raw) {
+		ch := raw[i]
+		if ch == '\\' {
+			i += 2
+			continue
+		}
+
+		if ch != '{' {
+			i++
+			continue
+		}
+
+		if strBegin < i {
+			appendExp(&ast.StringExp{
+				Str: raw[strBegin:i],
+				Loc: loc,
+			})
+		}
+
+		expEnd := findInterpExpEnd(raw, i+1)
+		// 表达式开始的列号，需要加上前面的`
+		col := loc.StartColumn + 1 + utf8.RuneCountInString(raw[:i+1])
+		appendExp(p.parseInterpSubExp(raw[i+1:expEnd], loc.StartLine, col))
+
+		i = expEnd + 1
+		strBegin = i
+	}
+
+	if strBegin < len(raw) || exp == nil {
+		if strBegin > len(raw) {
+			strBegin = len(raw)
+		}
+		appendExp(&ast.StringExp{
+			Str: raw[strBegin:],
+			Loc: loc,
+		})
+	}
+
+	return exp
+}
+
+// findInterpExpEnd 查找插值字符串中 { 对应的 }，跳过表达式中的字符串与嵌套的table
+func findInterpExpEnd(raw string, begin int) int {
+	depth := 0
+	for i := begin; i < len(raw); i++ {
+		switch raw[i] {
+		case '\'', '"':
+			delimiter := raw[i]
+			for i++; i < len(raw) && raw[i] != delimiter; i++ {
+				if raw[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+
+	return len(raw)
+}
+
+// parseInterpSubExp 单独分析插值字符串中的表达式，line与col为表达式在文件中开始的位置
+func (p *luaParser) parseInterpSubExp(expStr string, line int, col int) ast.Exp {
+	subParser := &luaParser{
+		luauFlag: true,
+	}
+	subParser.l = lexer.NewLexer([]byte(expStr), "")
+	subParser.l.SetMode(lexer.ModeLuau)
+	subParser.l.SetStartPos(line, col)
+	subParser.l.SetErrHandler(p.insertErr)
+
+	exp := subParser.parseExp()
+	if subParser.l.LookAheadKind() != lexer.TkEOF {
+		subParser.l.NextToken()
+		p.insertParserErr(subParser.l.GetNowTokenLoc(), "unexpected symbol in interpolated string")
+	}
+
+	for _, oneErr := range subParser.parseErrs {
+		p.insertErr(oneErr)
+	}
+	return exp
+}
@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"strings"
+)
+
+// Luau语法在lua语法上的扩展，Luau的类型注解转换成注解系统的注释，例如：
+// local a: number                => ---@type number
+// function f<T>(a: T, b: string?): T => ---@generic T ---@param a T ---@param b? string ---@return T
+// type Point = {x: number}       => ---@class Point ---@field x number
+// type Id = string | number      => ---@alias Id string|number
+
+// continue，转换成goto到循环block最后的label，例如 goto __continue1
+// continue为上下文关键字，loc为已经解析的continue名称的位置
+func (p *luaParser) parseContinueStat(loc lexer.Location) *ast.GotoStat {
+	name := "continue"
+	loopLevel := len(p.loopVec)
+	if loopLevel == 0 {
+		p.insertParserErr(loc, "continue should inside loop for/while/repeat")
+	} else {
+		p.loopVec[loopLevel-1] = true
+		name = fmt.Sprintf("__continue%d", loopLevel)
+	}
+
+	return &ast.GotoStat{
+		Name: name,
+		Loc:  loc,
+	}
+}
+
+// parseLoopBlock 解析循环语句的block，Luau中循环内使用了continue时，在block的最后插入continue跳转的label
+func (p *luaParser) parseLoopBlock() *ast.Block {
+	if !p.luauFlag {
+		return p.parseBlock()
+	}
+
+	p.loopVec = append(p.loopVec, false)
+	block := p.parseBlock()
+
+	loopLevel := len(p.loopVec)
+	if p.loopVec[loopLevel-1] {
+		block.Stats = append(block.Stats, &ast.LabelStat{
+			Name: fmt.Sprintf("__continue%d", loopLevel),
+			Loc:  p.l.GetNowTokenLoc(),
+		})
+	}
+	p.loopVec = p.loopVec[:loopLevel-1]
+
+	return block
+}
+
+// parseLuauIdentStat Luau中以名称开头的语句，type与export为上下文关键字，后面跟着名称时为类型别名
+// continue也是上下文关键字，单独的continue名称不是函数调用也不是赋值时，为continue语句，例如 local continue = 1 仍然为变量
+func (p *luaParser) parseLuauIdentStat() ast.Stat {
+	l := p.l
+	beginLoc := l.GetHeardTokenLoc()
+	_, name := l.NextIdentifier()
+	nameLoc := l.GetNowTokenLoc()
+	if (name == "type" || name == "export") && l.LookAheadKind() == lexer.TkIdentifier {
+		return p.parseLuauTypeAliasStat(name, beginLoc)
+	}
+
+	prefixExp := p.finishPrefixExp(&ast.NameExp{
+		Name: name,
+		Loc:  nameLoc,
+	}, &beginLoc)
+
+	if nameExp, ok := prefixExp.(*ast.NameExp); ok && nameExp.Name == "continue" && !isLuauAssignAhead(l.LookAheadKind()) {
+		return p.parseContinueStat(nameLoc)
+	}
+
+	return p.finishAssignOrFuncCallStat(beginLoc, prefixExp)
+}
+
+// isLuauAssignAhead 判断名称后面是否为赋值，例如 continue = 1，continue, a = 1，continue += 1
+func isLuauAssignAhead(kind lexer.TkKind) bool {
+	return kind == lexer.TkOpAssign || kind == lexer.TkSepComma || isLuauCompoundOp(kind)
+}
+
+// [export] type Name [‘<’ generics ‘>’] ‘=’ type
+// 记录类型转换成class，其他的类型转换成alias
+func (p *luaParser) parseLuauTypeAliasStat(keyword string, beginLoc lexer.Location) ast.Stat {
+	l := p.l
+	if keyword == "export" {
+		if _, name := l.NextIdentifier(); name != "type" {
+			p.insertParserErr(l.GetNowTokenLoc(), "expected 'type' after 'export', found '%s'", name)
+		}
+	}
+
+	_, name := l.NextIdentifier()
+	nameLoc := l.GetNowTokenLoc()
+
+	p.aliasGenericMap = map[string]bool{}
+	if l.LookAheadKind() == lexer.TkOpLt {
+		for _, genericName := range p.parseLuauGenericNames() {
+			p.aliasGenericMap[genericName] = true
+		}
+	}
+	p.expectTokenKind(lexer.TkOpAssign)
+
+	var typeStr string
+	var fieldVec []luauField
+	if l.LookAheadKind() == lexer.TkSepLcurly {
+		typeStr, fieldVec = p.parseLuauTableType()
+		if aheadKind := l.LookAheadKind(); aheadKind == lexer.TkSepQuestion || aheadKind == lexer.TkOpBor ||
+			aheadKind == lexer.TkOpBand {
+			typeStr = p.finishLuauType(typeStr)
+			fieldVec = nil
+		}
+	} else {
+		typeStr = p.parseLuauType()
+	}
+	p.aliasGenericMap = nil
+
+	if len(fieldVec) > 0 {
+		p.appendLuauAnnotate("-@class ", name, nameLoc)
+		for _, field := range fieldVec {
+			p.appendLuauAnnotate("-@field ", field.name+" "+field.typeStr, field.nameLoc)
+		}
+	} else {
+		p.appendLuauAnnotate("-@alias ", name+" "+typeStr, nameLoc)
+	}
+	p.flushLuauAnnotate(beginLoc.StartLine)
+
+	return _statEmpty
+}
+
+// isLuauCompoundOp 判断是否为Luau复合赋值的运算符，例如 += 中的 +
+func isLuauCompoundOp(kind lexer.TkKind) bool {
+	switch kind {
+	case lexer.TkOpAdd, lexer.TkOpMinus, lexer.TkOpMul, lexer.TkOpDiv, lexer.TkOpIdiv, lexer.TkOpMod,
+		lexer.TkOpPow, lexer.TkOpConcat:
+		return true
+	}
+	return false
+}
+
+// var binop ‘=’ exp，例如 a += 1 转换成 a = a + 1
+func (p *luaParser) finishLuauCompoundAssignStat(preLoc lexer.Location, symList []ast.Exp) ast.Stat {
+	l := p.l
+	_, opKind, _ := l.NextToken()
+	if l.LookAheadKind() != lexer.TkOpAssign {
+		nowLoc := l.GetNowTokenLoc()
+		loc := lexer.GetRangeLoc(&preLoc, &nowLoc)
+		p.insertParserErr(loc, "expression cannot be used as a statement")
+		return &ast.EmptyStat{}
+	}
+
+	l.NextTokenKind(lexer.TkOpAssign) // =
+	exp := p.parseExp()               // exp
+	endLoc := l.GetNowTokenLoc()
+	loc := lexer.GetRangeLoc(&preLoc, &endLoc)
+
+	return &ast.AssignStat{
+		VarList: symList,
+		ExpList: []ast.Exp{&ast.BinopExp{
+			Op:   opKind,
+			Exp1: symList[0],
+			Exp2: exp,
+			Loc:  loc,
+		}},
+		Loc: loc,
+	}
+}
+
+// skipLuauVarType 跳过for循环变量后面的类型注解，例如 for i: number = 1, 10 do
+func (p *luaParser) skipLuauVarType() {
+	if !p.luauFlag || p.l.LookAheadKind() != lexer.TkSepColon {
+		return
+	}
+
+	p.l.NextToken()
+	p.parseLuauType()
+}
+
+// parseLuauLocalType 解析局部变量名称后面的类型注解，例如 local a: number
+func (p *luaParser) parseLuauLocalType(name string, nameLoc lexer.Location) {
+	if !p.luauFlag {
+		return
+	}
+
+	field := luauField{
+		name:    name,
+		nameLoc: nameLoc,
+	}
+	if p.l.LookAheadKind() == lexer.TkSepColon {
+		p.l.NextToken()
+		field.typeLoc = p.l.GetHeardTokenLoc()
+		field.typeStr = p.parseLuauType()
+	}
+	p.localTypeVec = append(p.localTypeVec, field)
+}
+
+// insertLuauLocalType 局部变量的类型注解转换成 ---@type，没有类型的变量为any
+func (p *luaParser) insertLuauLocalType(codeLine int) {
+	if len(p.localTypeVec) == 0 {
+		return
+	}
+
+	var typeLoc lexer.Location
+	typeFlag := false
+	typeVec := make([]string, 0, len(p.localTypeVec))
+	for _, field := range p.localTypeVec {
+		if field.typeStr == "" {
+			typeVec = append(typeVec, "any")
+			continue
+		}
+
+		typeVec = append(typeVec, field.typeStr)
+		if !typeFlag {
+			typeFlag = true
+			typeLoc = field.typeLoc
+		}
+	}
+	p.localTypeVec = nil
+
+	if !typeFlag {
+		return
+	}
+
+	p.appendLuauAnnotate("-@type ", strings.Join(typeVec, ", "), typeLoc)
+	p.flushLuauAnnotate(codeLine)
+}
+
+// beginLuauFuncType 开始解析函数的类型注解，函数名称后面可能有泛型，例如 function f<T>(a: T): T
+func (p *luaParser) beginLuauFuncType() {
+	if !p.luauFlag {
+		return
+	}
+
+	p.annotateVec = nil
+	if p.l.LookAheadKind() != lexer.TkOpLt {
+		return
+	}
+
+	loc := p.l.GetHeardTokenLoc()
+	loc.StartColumn++
+	if nameVec := p.parseLuauGenericNames(); len(nameVec) > 0 {
+		p.appendLuauAnnotate("-@generic ", strings.Join(nameVec, ", "), loc)
+	}
+}
+
+// parseLuauParamType 解析函数参数后面的类型注解，例如 function f(a: number, ...: string)
+func (p *luaParser) parseLuauParamType(name string, nameLoc lexer.Location) {
+	if !p.luauFlag || p.l.LookAheadKind() != lexer.TkSepColon {
+		return
+	}
+
+	p.l.NextToken()
+	typeLoc := p.l.GetHeardTokenLoc()
+	typeStr := p.parseLuauType()
+	if name == "..." {
+		p.appendLuauAnnotate("-@vararg ", typeStr, typeLoc)
+		return
+	}
+
+	// 可选的参数，例如 a: number? 转换成 ---@param a? number
+	if strings.HasSuffix(typeStr, "|nil") {
+		name = name + "?"
+		typeStr = strings.TrimSuffix(typeStr, "|nil")
+	}
+	p.appendLuauAnnotate("-@param ", name+" "+typeStr, nameLoc)
+}
+
+// finishLuauFuncType 解析函数的返回值类型，函数的类型注解作用于codeLine行的函数定义
+func (p *luaParser) finishLuauFuncType(codeLine int) {
+	if !p.luauFlag {
+		return
+	}
+
+	l := p.l
+	if l.LookAheadKind() == lexer.TkSepColon {
+		l.NextToken()
+		typeLoc := l.GetHeardTokenLoc()
+		if retVec := p.parseLuauRetTypes(); len(retVec) > 0 {
+			p.appendLuauAnnotate("-@return ", strings.Join(retVec, ", "), typeLoc)
+		}
+	}
+	p.flushLuauAnnotate(codeLine)
+}
+
+// appendLuauAnnotate 添加一行转换成的注解，loc为注解中第一个单词对应的代码位置
+func (p *luaParser) appendLuauAnnotate(prefix string, str string, loc lexer.Location) {
+	p.annotateVec = append(p.annotateVec, lexer.CommentLine{
+		Str:  prefix + str,
+		Line: loc.StartLine,
+		Col:  loc.StartColumn - len(prefix),
+	})
+}
+
+// flushLuauAnnotate 转换成的注解作用于codeLine行的代码，插入到词法分析的注释中
+func (p *luaParser) flushLuauAnnotate(codeLine int) {
+	for _, commentLine := range p.annotateVec {
+		p.l.InsertAnnotateComment(codeLine, commentLine)
+	}
+	p.annotateVec = nil
+}
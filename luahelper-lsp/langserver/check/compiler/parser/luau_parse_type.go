@@ -0,0 +1,350 @@
+package parser
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"strings"
+)
+
+// Luau的类型注解，转换成注解系统中的类型字符串，例如：
+// T?              => T|nil
+// {T}             => T[]
+// {[K]: V}        => table<K, V>
+// {a: T}          => table
+// (a: T) -> R     => fun(a: T): R
+// unknown、never  => any
+
+// luauTypeNameMap Luau中的内置类型，转换成注解系统中的类型
+var luauTypeNameMap = map[string]string{
+	"unknown": "any",
+	"never":   "any",
+	"buffer":  "userdata",
+	"vector":  "any",
+}
+
+// luauField Luau记录类型中的一个成员或是带类型的变量，例如 {name: string} 中的name
+type luauField struct {
+	name    string
+	nameLoc lexer.Location
+	typeStr string         // 转换后的类型，没有类型注解时为空
+	typeLoc lexer.Location // 类型注解开始的位置
+}
+
+// type ::= simpletype [‘?’] {(‘|’ | ‘&’) simpletype [‘?’]}
+func (p *luaParser) parseLuauType() string {
+	l := p.l
+	// 联合类型前面可以有 |，例如 type A = | "a" | "b"
+	if aheadKind := l.LookAheadKind(); aheadKind == lexer.TkOpBor || aheadKind == lexer.TkOpBand {
+		l.NextToken()
+	}
+
+	return p.finishLuauType(p.parseLuauSimpleType())
+}
+
+// finishLuauType 已经解析了第一个类型，继续解析后面的 ? 与联合类型
+func (p *luaParser) finishLuauType(firstStr string) string {
+	l := p.l
+	typeVec := []string{p.finishLuauOptionalType(firstStr)}
+	for {
+		aheadKind := l.LookAheadKind()
+		if aheadKind != lexer.TkOpBor && aheadKind != lexer.TkOpBand {
+			break
+		}
+
+		l.NextToken()
+		oneStr := p.finishLuauOptionalType(p.parseLuauSimpleType())
+
+		// 交叉类型不能转换成注解，只保留第一个类型
+		if aheadKind == lexer.TkOpBor {
+			typeVec = append(typeVec, oneStr)
+		}
+	}
+
+	return strings.Join(typeVec, "|")
+}
+
+// finishLuauOptionalType 可选的类型 T? 转换成 T|nil
+func (p *luaParser) finishLuauOptionalType(typeStr string) string {
+	l := p.l
+	if l.LookAheadKind() != lexer.TkSepQuestion {
+		return typeStr
+	}
+
+	for l.LookAheadKind() == lexer.TkSepQuestion {
+		l.NextToken()
+	}
+	return typeStr + "|nil"
+}
+
+// simpletype ::= nil | true | false | String | Name [‘.’ Name] [‘<’ typeparams ‘>’] | typeof ‘(’ exp ‘)’ |
+// tabletype | functype | ‘(’ type ‘)’ | ‘...’ type
+func (p *luaParser) parseLuauSimpleType() string {
+	l := p.l
+	aheadKind := l.LookAheadKind()
+	switch aheadKind {
+	case lexer.TkKwNil:
+		l.NextToken()
+		return "nil"
+	case lexer.TkKwTrue, lexer.TkKwFalse:
+		l.NextToken()
+		return "boolean"
+	case lexer.TkString:
+		l.NextToken()
+		return "string"
+	case lexer.TkVararg:
+		l.NextToken()
+		return p.parseLuauSimpleType()
+	case lexer.TkSepLcurly:
+		typeStr, _ := p.parseLuauTableType()
+		return typeStr
+	case lexer.TkSepLparen, lexer.TkOpLt:
+		typeVec, funcStr := p.parseLuauParenTypes()
+		if funcStr != "" {
+			return funcStr
+		}
+		if len(typeVec) == 1 {
+			return typeVec[0]
+		}
+		return "any"
+	case lexer.TkIdentifier:
+		_, name := l.NextIdentifier()
+		return p.finishLuauNamedType(name)
+	}
+
+	p.insertParserErr(l.GetHeardTokenLoc(), "unexpected symbol near '%s' in type", aheadKind.String())
+	if !p.isRecoverToken(aheadKind) {
+		l.NextToken()
+	}
+	return "any"
+}
+
+// finishLuauNamedType 已经解析了类型的名称，继续解析后面可能的模块类型与泛型参数
+func (p *luaParser) finishLuauNamedType(name string) string {
+	l := p.l
+	// typeof(exp)
+	if name == "typeof" && l.LookAheadKind() == lexer.TkSepLparen {
+		l.NextToken()
+		p.parseExp()
+		p.expectTokenKind(lexer.TkSepRparen)
+		return "any"
+	}
+
+	// 其他模块中导出的类型，例如 Module.Type，注解系统中无法找到
+	moduleFlag := false
+	for l.LookAheadKind() == lexer.TkSepDot {
+		l.NextToken()
+		l.NextIdentifier()
+		moduleFlag = true
+	}
+
+	if l.LookAheadKind() == lexer.TkOpLt {
+		p.skipLuauTypeArgs()
+	}
+
+	if moduleFlag || p.aliasGenericMap[name] {
+		return "any"
+	}
+
+	if typeStr, ok := luauTypeNameMap[name]; ok {
+		return typeStr
+	}
+	return name
+}
+
+// skipLuauTypeArgs 跳过泛型参数，例如 Map<string, {number}>，嵌套时 >> 为两个 >
+func (p *luaParser) skipLuauTypeArgs() {
+	l := p.l
+	l.NextTokenKind(lexer.TkOpLt)
+	depth := 1
+	for depth > 0 {
+		switch l.LookAheadKind() {
+		case lexer.TkOpLt:
+			depth++
+		case lexer.TkOpGt:
+			depth--
+		case lexer.TkOpShr:
+			depth -= 2
+		case lexer.TkEOF:
+			return
+		}
+		l.NextToken()
+	}
+}
+
+// parseLuauGenericNames 解析泛型的名称列表，例如 <T, U...>，泛型可以有默认值 <T = number>
+func (p *luaParser) parseLuauGenericNames() (nameVec []string) {
+	l := p.l
+	l.NextTokenKind(lexer.TkOpLt)
+	for l.LookAheadKind() == lexer.TkIdentifier {
+		_, name := l.NextIdentifier()
+		nameVec = append(nameVec, name)
+		if l.LookAheadKind() == lexer.TkVararg {
+			l.NextToken()
+		}
+
+		if l.LookAheadKind() == lexer.TkOpAssign {
+			l.NextToken()
+			p.parseLuauType()
+		}
+
+		if l.LookAheadKind() != lexer.TkSepComma {
+			break
+		}
+		l.NextToken()
+	}
+	p.expectTokenKind(lexer.TkOpGt)
+	return nameVec
+}
+
+// tabletype ::= ‘{’ ‘}’ | ‘{’ type ‘}’ | ‘{’ field {fieldsep field} [fieldsep] ‘}’
+// field ::= ‘[’ type ‘]’ ‘:’ type | Name ‘:’ type
+// 记录类型时，返回所有的成员
+func (p *luaParser) parseLuauTableType() (typeStr string, fieldVec []luauField) {
+	l := p.l
+	l.NextTokenKind(lexer.TkSepLcurly)
+	typeStr = "table"
+
+	aheadKind := l.LookAheadKind()
+	if aheadKind != lexer.TkIdentifier && aheadKind != lexer.TkSepLbrack && aheadKind != lexer.TkSepRcurly {
+		// 数组类型 {T}
+		typeStr = getLuauArrayType(p.parseLuauType())
+		p.expectTokenKind(lexer.TkSepRcurly)
+		return typeStr, nil
+	}
+
+	fieldNum := 0
+	for {
+		aheadKind = l.LookAheadKind()
+		if aheadKind == lexer.TkSepLbrack {
+			// 索引类型 {[K]: V}
+			l.NextToken()
+			keyStr := p.parseLuauType()
+			p.expectTokenKind(lexer.TkSepRbrack)
+			p.expectTokenKind(lexer.TkSepColon)
+			valueStr := p.parseLuauType()
+			fieldNum++
+			if fieldNum == 1 {
+				typeStr = fmt.Sprintf("table<%s, %s>", keyStr, valueStr)
+			}
+		} else if aheadKind == lexer.TkIdentifier {
+			_, name := l.NextIdentifier()
+			// 成员的访问修饰，例如 {read name: string}
+			if (name == "read" || name == "write") && l.LookAheadKind() == lexer.TkIdentifier {
+				_, name = l.NextIdentifier()
+			}
+			nameLoc := l.GetNowTokenLoc()
+
+			if fieldNum == 0 && l.LookAheadKind() != lexer.TkSepColon {
+				// 数组类型，例如 {string}
+				typeStr = getLuauArrayType(p.finishLuauType(p.finishLuauNamedType(name)))
+				p.expectTokenKind(lexer.TkSepRcurly)
+				return typeStr, nil
+			}
+
+			p.expectTokenKind(lexer.TkSepColon)
+			fieldVec = append(fieldVec, luauField{
+				name:    name,
+				nameLoc: nameLoc,
+				typeStr: p.parseLuauType(),
+			})
+			fieldNum++
+			typeStr = "table"
+		} else {
+			break
+		}
+
+		if aheadKind = l.LookAheadKind(); aheadKind != lexer.TkSepComma && aheadKind != lexer.TkSepSemi {
+			break
+		}
+		l.NextToken()
+	}
+
+	p.expectTokenKind(lexer.TkSepRcurly)
+	return typeStr, fieldVec
+}
+
+// getLuauArrayType 数组类型转换为 T[]，元素为联合类型或是函数类型时，无法表示为数组，转换成table
+func getLuauArrayType(elemStr string) string {
+	if strings.ContainsAny(elemStr, "|(") {
+		return "table"
+	}
+	return elemStr + "[]"
+}
+
+// functype ::= [‘<’ generics ‘>’] ‘(’ [param {‘,’ param}] ‘)’ ‘->’ rettype
+// param ::= [Name ‘:’] type | ‘...’ type
+// 没有 -> 时为括号中的类型列表，例如函数返回值 (number, string)
+func (p *luaParser) parseLuauParenTypes() (typeVec []string, funcStr string) {
+	l := p.l
+	if l.LookAheadKind() == lexer.TkOpLt {
+		p.skipLuauTypeArgs()
+	}
+
+	l.NextTokenKind(lexer.TkSepLparen)
+	var paramVec []string
+	for l.LookAheadKind() != lexer.TkSepRparen && l.LookAheadKind() != lexer.TkEOF {
+		paramName := ""
+		var typeStr string
+		switch l.LookAheadKind() {
+		case lexer.TkVararg:
+			l.NextToken()
+			paramName = "..."
+			typeStr = p.parseLuauType()
+		case lexer.TkIdentifier:
+			_, name := l.NextIdentifier()
+			if l.LookAheadKind() == lexer.TkSepColon {
+				l.NextToken()
+				paramName = name
+				typeStr = p.parseLuauType()
+			} else {
+				typeStr = p.finishLuauType(p.finishLuauNamedType(name))
+			}
+		default:
+			typeStr = p.parseLuauType()
+		}
+
+		if paramName == "" {
+			paramName = fmt.Sprintf("p%d", len(paramVec)+1)
+		}
+		typeVec = append(typeVec, typeStr)
+		paramVec = append(paramVec, paramName+": "+typeStr)
+
+		if l.LookAheadKind() != lexer.TkSepComma {
+			break
+		}
+		l.NextToken()
+	}
+	p.expectTokenKind(lexer.TkSepRparen)
+
+	// ->
+	if l.LookAheadKind() != lexer.TkOpMinus {
+		return typeVec, ""
+	}
+	l.NextToken()
+	p.expectTokenKind(lexer.TkOpGt)
+
+	funcStr = "fun(" + strings.Join(paramVec, ", ") + ")"
+	if retVec := p.parseLuauRetTypes(); len(retVec) > 0 {
+		funcStr = funcStr + ": " + strings.Join(retVec, ", ")
+	}
+	return typeVec, funcStr
+}
+
+// rettype ::= type | ‘(’ [type {‘,’ type}] ‘)’
+func (p *luaParser) parseLuauRetTypes() []string {
+	l := p.l
+	if l.LookAheadKind() != lexer.TkSepLparen {
+		return []string{p.parseLuauType()}
+	}
+
+	typeVec, funcStr := p.parseLuauParenTypes()
+	if funcStr != "" {
+		return []string{funcStr}
+	}
+
+	// 括号中只有一个类型时，后面可能还有 ? 与联合类型，例如 (number)?
+	if len(typeVec) == 1 {
+		return []string{p.finishLuauType(typeVec[0])}
+	}
+	return typeVec
+}
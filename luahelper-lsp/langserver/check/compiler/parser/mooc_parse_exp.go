@@ -197,8 +197,8 @@ func (p *moocParser) parseFuncDefExp(isAnonymous bool, beginLoc *lexer.Location)
 	if isAnonymous {
 		l.NextTokenKind(lexer.TkKwIn)
 	} else {
-		l.NextTokenKind(lexer.TkSepRparen) // )
-		l.NextTokenKind(lexer.TkSepLcurly) // {
+		p.expectTokenKind(lexer.TkSepRparen) // )
+		l.NextTokenKind(lexer.TkSepLcurly)   // {
 	}
 
 	p.scopes.push(pscope_fn, "fn")
@@ -210,7 +210,7 @@ func (p *moocParser) parseFuncDefExp(isAnonymous bool, beginLoc *lexer.Location)
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -262,7 +262,7 @@ func (p *moocParser) parseTableConstructorExp() *ast.TableConstructorExp {
 	l.NextTokenKind(lexer.TkSepLcurly) // {
 	beginLoc := l.GetNowTokenLoc()
 	keyExps, valExps := p.parseFieldList() // [fieldlist]
-	l.NextTokenKind(lexer.TkSepRcurly)     // }
+	p.expectTokenKind(lexer.TkSepRcurly)   // }
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -287,8 +287,14 @@ func (p *moocParser) parseFieldList() (ks, vs []ast.Exp) {
 		ks = append(ks, k)
 		vs = append(vs, v)
 
-		for _isFieldSep(l.LookAheadKind()) {
-			l.NextToken()
+		for _isFieldSep(l.LookAheadKind()) || _isFieldStart(l.LookAheadKind()) {
+			if !_isFieldSep(l.LookAheadKind()) {
+				// 缺少分隔符，报错后继续解析后面的成员，例如 { x = 1 y = 2 }
+				p.insertParserErr(l.GetHeardTokenLoc(), "missing ',' or ';' between table fields")
+			} else {
+				l.NextToken()
+			}
+
 			if l.LookAheadKind() != lexer.TkSepRcurly {
 				k, v := p.parseField()
 				ks = append(ks, k)
@@ -305,11 +311,11 @@ func (p *moocParser) parseFieldList() (ks, vs []ast.Exp) {
 func (p *moocParser) parseField() (k, v ast.Exp) {
 	l := p.l
 	if l.LookAheadKind() == lexer.TkSepLbrack {
-		l.NextToken()                      // [
-		k = p.parseExp()                   // exp
-		l.NextTokenKind(lexer.TkSepRbrack) // ]
-		l.NextTokenKind(lexer.TkOpAssign)  // =
-		v = p.parseExp()                   // exp
+		l.NextToken()                        // [
+		k = p.parseExp()                     // exp
+		p.expectTokenKind(lexer.TkSepRbrack) // ]
+		l.NextTokenKind(lexer.TkOpAssign)    // =
+		v = p.parseExp()                     // exp
 		return
 	}
 
@@ -84,10 +84,10 @@ func (p *moocParser) parseStat() ast.Stat {
 		default:
 			return p.parseAssignOrFuncCallStat(true)
 		}
-	case lexer.IKIllegal:
-		return p.parseIKIllegalStat()
-	default:
+	case lexer.TkIdentifier, lexer.TkSepLparen:
 		return p.parseAssignOrFuncCallStat(false)
+	default:
+		return p.parseIllegalStat()
 	}
 }
 
@@ -169,7 +169,7 @@ func (p *moocParser) parseDoStat(token lexer.TkKind) *ast.DoStat {
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 	endLoc := l.GetNowTokenLoc()
 
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
@@ -197,7 +197,7 @@ func (p *moocParser) parseWhileStat() *ast.WhileStat {
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
 
@@ -225,7 +225,7 @@ func (p *moocParser) parseRepeatStat() *ast.RepeatStat {
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // {
+	p.expectTokenKind(lexer.TkSepRcurly) // {
 
 	l.NextTokenKind(lexer.TkKwUntil) // until
 	exp := p.parseExp()              // exp
@@ -261,7 +261,7 @@ func (p *moocParser) parseIfStat() *ast.IfStat {
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 
 	for l.LookAheadKind() == lexer.TkKwElseif {
 		l.NextToken()                      // elseif
@@ -279,7 +279,7 @@ func (p *moocParser) parseIfStat() *ast.IfStat {
 
 		p.scopes.pop()
 
-		l.NextTokenKind(lexer.TkSepRcurly)
+		p.expectTokenKind(lexer.TkSepRcurly)
 	}
 
 	// else block => elseif true then block
@@ -303,7 +303,7 @@ func (p *moocParser) parseIfStat() *ast.IfStat {
 
 		p.scopes.pop()
 
-		l.NextTokenKind(lexer.TkSepRcurly) // }
+		p.expectTokenKind(lexer.TkSepRcurly) // }
 	}
 
 	endLoc := l.GetNowTokenLoc()
@@ -361,7 +361,7 @@ func (p *moocParser) finishForNumStat(lineOfFor int, varName string, beginLoc *l
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -397,7 +397,7 @@ func (p *moocParser) finishForInStat(name0 string, beginLoc *lexer.Location) *as
 
 	p.scopes.pop()
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(beginLoc, &endLoc)
@@ -460,7 +460,7 @@ func (p *moocParser) parseGuardStat() *ast.IfStat {
 
 success:
 	p.scopes.pop()
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 
 	endLoc := l.GetNowTokenLoc()
 	loc := lexer.GetRangeLoc(&beginLoc, &endLoc)
@@ -468,6 +468,7 @@ success:
 		Exps:   exps,
 		Blocks: blocks,
 		Loc:    loc,
+		Stype:  lexer.TkKwGuard,
 	}
 }
 
@@ -523,7 +524,7 @@ func (p *moocParser) parseSwitchStat() *ast.SwitchStat {
 				Loc:  l.GetNowTokenLoc(),
 			})
 		}
-		for orExp := range orList {
+		for _, orExp := range orList {
 			exp = &ast.BinopExp{
 				Op:   lexer.TkOpOr,
 				Exp1: exp,
@@ -565,13 +566,14 @@ func (p *moocParser) parseSwitchStat() *ast.SwitchStat {
 		p.scopes.pop()
 	}
 
-	l.NextTokenKind(lexer.TkSepRcurly) // }
+	p.expectTokenKind(lexer.TkSepRcurly) // }
 	ifEndLoc := l.GetNowTokenLoc()
 
 	ifStat := &ast.IfStat{
 		Exps:   exps,
 		Blocks: blocks,
 		Loc:    lexer.GetRangeLoc(&ifBeginLoc, &ifEndLoc),
+		Stype:  lexer.TkKwSwitch,
 	}
 
 	return &ast.SwitchStat{
@@ -978,14 +980,34 @@ func (p *moocParser) parseFuncName(isStaticAttr bool) (exp ast.Exp, hasColon boo
 	return
 }
 
-// func (p *moocParser) parseIKIllegalStat() *ast.IllegalStat{
-// 	l := p.l
-// 	loc := l.GetNowTokenLoc()
-// 	l.NextToken()
-// 	return &ast.IllegalStat{
-// 		Name: "",
-// 		Loc:  loc,
-// 	}
+// parseIllegalStat 不能作为语句开始的单词，跳过同一行后面的单词，只报一个错误
+// 遇到可以重新开始解析的单词时停止，例如}、local、fn
+func (p *moocParser) parseIllegalStat() *ast.IllegalStat {
+	l := p.l
+	aheadKind := l.LookAheadKind()
+	line, _, token := l.NextToken()
+	beginLoc := l.GetNowTokenLoc()
+	if aheadKind != lexer.IKIllegal {
+		// 非法的字符，词法分析的时候已经报错了
+		p.insertParserErr(beginLoc, "unexpected symbol near '%s'", token)
+	}
+
+	for {
+		aheadKind = l.LookAheadKind()
+		if aheadKind == lexer.TkEOF || p.isRecoverToken(aheadKind) || l.GetHeardTokenLoc().StartLine != line {
+			break
+		}
+
+		l.NextToken()
+	}
+
+	endLoc := l.GetNowTokenLoc()
+	return &ast.IllegalStat{
+		Name: token,
+		Loc:  lexer.GetRangeLoc(&beginLoc, &endLoc),
+	}
+}
+
 // }
 
 func (p *moocParser) parseIKIllegalStat() *ast.EmptyStat {
@@ -1124,7 +1146,7 @@ func (p *moocParser) parserClassDefStat(global bool, keyToken lexer.TkKind) ast.
 		}
 	}
 
-	l.NextTokenKind(lexer.TkSepRcurly)
+	p.expectTokenKind(lexer.TkSepRcurly)
 	endLoc := l.GetNowTokenLoc()
 
 	p.scopes.pop()
@@ -1152,6 +1174,7 @@ func (p *moocParser) parseImportStat() ast.Stat {
 
 	var libStat *ast.FuncCallStat
 	var nameStat *ast.LocalVarDeclStat
+	subLib := false
 
 	if l.LookAheadKind() == lexer.TkString {
 		// import "lpeg" as require "lpeg"
@@ -1207,6 +1230,7 @@ func (p *moocParser) parseImportStat() ast.Stat {
 				if l.LookAheadKind() == lexer.TkIdentifier {
 					_, key := l.NextIdentifier()
 					if len(libLib) > 0 {
+						expList = append(expList, p.importSubLib(libLib, "", key, l.GetNowTokenLoc()))
 					} else {
 						expList = append(expList, p.importSubLib("require", libStr, key, l.GetNowTokenLoc()))
 					}
@@ -1225,10 +1249,9 @@ func (p *moocParser) parseImportStat() ast.Stat {
 				}
 				l.NextToken()
 			}
-			l.NextTokenKind(lexer.TkSepRcurly)
-			if len(nameList) != len(expList) {
-				panic("name list and var list should be same")
-			}
+			p.expectTokenKind(lexer.TkSepRcurly)
+			// 名称与成员的个数不一致时，在语义检查中告警
+			subLib = true
 		} else {
 			if len(libStr) > 0 { // import A from "a"
 				prefixExp := &ast.NameExp{
@@ -1272,8 +1295,9 @@ func (p *moocParser) parseImportStat() ast.Stat {
 	}
 
 	return &ast.ImportDefStat{
-		Lib:  libStat,
-		Name: nameStat,
+		Lib:    libStat,
+		Name:   nameStat,
+		SubLib: subLib,
 	}
 }
 
@@ -85,6 +85,12 @@ type moocParser struct {
 	// 作用域栈
 	scopes    scopeStack
 	parseErrs []lexer.ParseError
+
+	// 语法错误的总数量，包含没有保存的
+	errNum int
+
+	// 整个文件的block，解析中途终止时，返回已经解析的部分
+	mainBlock *ast.Block
 }
 
 // CreateParser 创建一个分析对象
@@ -101,9 +107,16 @@ func createMoocParser(chunk []byte, chunkName string) *moocParser {
 
 // BeginAnalyze 开始分析
 func (p *moocParser) BeginAnalyze() (block *ast.Block, commentMap map[int]*lexer.CommentInfo, errList []lexer.ParseError) {
+	var blockBeginLoc lexer.Location
 	defer func() {
 		if err1 := recover(); err1 != nil {
-			block = &ast.Block{}
+			// 保留已经解析的部分语法树
+			block = p.mainBlock
+			if block == nil {
+				block = &ast.Block{}
+			}
+			blockEndLoc := p.l.GetNowTokenLoc()
+			block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 			commentMap = p.l.GetCommentMap()
 			errList = p.parseErrs
 			return
@@ -113,8 +126,8 @@ func (p *moocParser) BeginAnalyze() (block *ast.Block, commentMap map[int]*lexer
 	p.scopes.push(pscope_fi, "fi")
 	p.l.SkipFirstLineComment()
 
-	blockBeginLoc := p.l.GetHeardTokenLoc()
-	block = p.parseBlock() // block
+	blockBeginLoc = p.l.GetHeardTokenLoc()
+	block = p.parseMainBlock() // block
 	blockEndLoc := p.l.GetNowTokenLoc()
 	block.Loc = lexer.GetRangeLoc(&blockBeginLoc, &blockEndLoc)
 
@@ -153,14 +166,19 @@ func (p *moocParser) insertParserErr(loc lexer.Location, f string, a ...interfac
 	p.insertErr(paseError)
 }
 
+// insertErr 插入一个语法错误，错误过多时只保存前面的部分，继续往后解析
 func (p *moocParser) insertErr(oneErr lexer.ParseError) {
-	if len(p.parseErrs) < 30 {
+	p.errNum++
+	if p.errNum <= maxShowErrNum {
 		p.parseErrs = append(p.parseErrs, oneErr)
-	} else {
+	} else if p.errNum == maxShowErrNum+1 {
 		oneErr.ErrStr = oneErr.ErrStr + "(too many err...)"
 		p.parseErrs = append(p.parseErrs, oneErr)
+	}
+
+	if p.errNum >= maxParseErrNum {
 		manyError := &lexer.TooManyErr{
-			ErrNum: 30,
+			ErrNum: p.errNum,
 		}
 
 		panic(manyError)
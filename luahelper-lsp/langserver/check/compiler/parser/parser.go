@@ -3,7 +3,6 @@ package parser
 import (
 	"luahelper-lsp/langserver/check/compiler/ast"
 	"luahelper-lsp/langserver/check/compiler/lexer"
-	"strings"
 )
 
 type ParserInterface interface {
@@ -12,11 +11,8 @@ type ParserInterface interface {
 	GetErrList() (errList []lexer.ParseError)
 }
 
-// CreateParser 创建一个分析对象
+// CreateParser 创建一个分析对象，根据文件后缀默认关联的方言选择语法分析器
 func CreateParser(chunk []byte, chunkName string) ParserInterface {
-	if ok := strings.HasSuffix(chunkName, ".mooc"); ok {
-		return createMoocParser(chunk, chunkName)
-	} else {
-		return createLuaParser(chunk, chunkName)
-	}
+	name, _ := GetFileDialect(chunkName, nil)
+	return CreateDialectParser(chunk, chunkName, name)
 }
@@ -7,6 +7,12 @@ import (
 
 var _statEmpty = &ast.EmptyStat{}
 
+// maxShowErrNum 最多保存的语法错误数量，超过后不再保存，继续往后解析
+const maxShowErrNum = 30
+
+// maxParseErrNum 语法错误的数量超过该值时终止解析，防止异常的情况下无法结束
+const maxParseErrNum = 1000
+
 // 通过tokenKind 获取优先级
 func getPriority(tokenKind lexer.TkKind) int {
 	switch tokenKind {
@@ -42,3 +48,13 @@ func getPriority(tokenKind lexer.TkKind) int {
 func _isFieldSep(tokenKind lexer.TkKind) bool {
 	return tokenKind == lexer.TkSepComma || tokenKind == lexer.TkSepSemi
 }
+
+// _isFieldStart 判断是否为table成员开始的单词，用于缺少分隔符时的错误恢复
+func _isFieldStart(tokenKind lexer.TkKind) bool {
+	switch tokenKind {
+	case lexer.TkIdentifier, lexer.TkSepLbrack, lexer.TkString, lexer.TkNumber, lexer.TkSepLcurly,
+		lexer.TkKwNil, lexer.TkKwTrue, lexer.TkKwFalse:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,813 @@
+package printer
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 把语法树输出成等价的Lua代码，主要用于查看MoonCake文件转换成的Lua代码
+// MoonCake特有的语句按照语法分析转换的结构输出：
+// class 输出为类的table定义，加上包含Self、Super与所有成员的do代码块
+// switch 输出为 local __sw__ 与 if 语句，guard 输出为 if not，continue 输出为 goto 与循环末尾的标签
+// defer 与MoonCake一样在函数返回前执行：函数开始处定义 __df 列表与执行列表的 __dr 函数，defer 输出为加入列表的函数，
+// 每一个return输出为 return __dr(...)，先计算返回值再按相反的顺序执行，函数末尾没有return时加上 __dr()
+
+// indentStr 每一层缩进的字符串
+const indentStr = "    "
+
+// LuaPrinter 语法树输出成Lua代码
+type LuaPrinter struct {
+	buf       strings.Builder
+	indent    int   // 当前的缩进层数
+	lineVec   []int // 输出的每一行对应的源文件行号，从1开始，0表示没有对应的行
+	nowLine   int   // 当前正在输出的源文件行号
+	lineEmpty bool  // 当前输出的行是否还没有内容
+	deferFlag bool  // 当前的函数是否包含defer，包含时return需要先执行defer的代码
+}
+
+// PrintLua 把语法树输出成Lua代码，startLine与endLine不为0时，只输出与这个行范围有交集的顶层语句
+// lineVec 为输出的每一行对应的源文件行号，从1开始，0表示没有对应的行
+func PrintLua(block *ast.Block, startLine int, endLine int) (code string, lineVec []int) {
+	p := &LuaPrinter{
+		lineEmpty: true,
+		deferFlag: hasDeferStat(block),
+	}
+
+	if p.deferFlag {
+		p.printDeferBegin()
+	}
+
+	for _, stat := range block.Stats {
+		if startLine > 0 && endLine > 0 {
+			loc := getStatLoc(stat)
+			if loc.EndLine < startLine || loc.StartLine > endLine {
+				continue
+			}
+		}
+		p.printStat(stat)
+	}
+
+	if block.RetExps != nil {
+		retLine := block.Loc.EndLine
+		if len(block.RetExps) > 0 {
+			retLine = getExpLoc(block.RetExps[0]).StartLine
+		}
+		if startLine <= 0 || endLine <= 0 || (retLine >= startLine && retLine <= endLine) {
+			p.printRetExps(block.RetExps, retLine)
+		}
+	} else if p.deferFlag {
+		p.newLine(0)
+		p.write("__dr()")
+	}
+
+	p.endLine()
+	return p.buf.String(), p.lineVec
+}
+
+// newLine 开始输出新的一行，line为对应的源文件行号
+func (p *LuaPrinter) newLine(line int) {
+	p.endLine()
+	if line > 0 {
+		p.nowLine = line
+	}
+	p.buf.WriteString(strings.Repeat(indentStr, p.indent))
+	p.lineVec = append(p.lineVec, p.nowLine)
+	p.lineEmpty = false
+}
+
+// endLine 结束当前的行
+func (p *LuaPrinter) endLine() {
+	if p.lineEmpty {
+		return
+	}
+	p.buf.WriteString("\n")
+	p.lineEmpty = true
+}
+
+func (p *LuaPrinter) write(str string) {
+	p.buf.WriteString(str)
+}
+
+func (p *LuaPrinter) printBlock(block *ast.Block) {
+	if block == nil {
+		return
+	}
+
+	p.indent++
+	for _, stat := range block.Stats {
+		p.printStat(stat)
+	}
+
+	if block.RetExps != nil {
+		retLine := 0
+		if len(block.RetExps) > 0 {
+			retLine = getExpLoc(block.RetExps[0]).StartLine
+		}
+		p.printRetExps(block.RetExps, retLine)
+	}
+	p.indent--
+}
+
+func (p *LuaPrinter) printRetExps(exps []ast.Exp, line int) {
+	p.newLine(line)
+	p.write("return")
+	if p.deferFlag {
+		p.write(" __dr(")
+		p.printExpList(exps)
+		p.write(")")
+	} else if len(exps) > 0 {
+		p.write(" ")
+		p.printExpList(exps)
+	}
+}
+
+// printFuncBlock 输出函数体，函数包含defer时，开始处定义defer的列表，末尾没有return时执行defer的代码
+func (p *LuaPrinter) printFuncBlock(block *ast.Block) {
+	backupFlag := p.deferFlag
+	p.deferFlag = hasDeferStat(block)
+	if p.deferFlag {
+		p.indent++
+		p.printDeferBegin()
+		p.indent--
+	}
+
+	p.printBlock(block)
+	if p.deferFlag && block.RetExps == nil {
+		p.indent++
+		p.newLine(0)
+		p.write("__dr()")
+		p.indent--
+	}
+	p.deferFlag = backupFlag
+}
+
+// printDeferBegin 输出defer的列表，以及按相反的顺序执行列表后返回参数的 __dr 函数
+func (p *LuaPrinter) printDeferBegin() {
+	p.newLine(0)
+	p.write("local __df = {}")
+	p.newLine(0)
+	p.write("local function __dr(...)")
+	p.indent++
+	p.newLine(0)
+	p.write("for __i = #__df, 1, -1 do __df[__i]() end")
+	p.newLine(0)
+	p.write("return ...")
+	p.indent--
+	p.newLine(0)
+	p.write("end")
+}
+
+// hasDeferStat 判断函数体中是否包含defer，不包括嵌套定义的函数
+func hasDeferStat(block *ast.Block) bool {
+	if block == nil {
+		return false
+	}
+
+	for _, stat := range block.Stats {
+		switch node := stat.(type) {
+		case *ast.DoStat:
+			if node.Stype == lexer.TkKwDefer || hasDeferStat(node.Block) {
+				return true
+			}
+		case *ast.WhileStat:
+			if hasDeferStat(node.Block) {
+				return true
+			}
+		case *ast.RepeatStat:
+			if hasDeferStat(node.Block) {
+				return true
+			}
+		case *ast.ForNumStat:
+			if hasDeferStat(node.Block) {
+				return true
+			}
+		case *ast.ForInStat:
+			if hasDeferStat(node.Block) {
+				return true
+			}
+		case *ast.IfStat:
+			if hasIfDeferStat(node) {
+				return true
+			}
+		case *ast.SwitchStat:
+			if node.Case != nil && hasIfDeferStat(node.Case) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasIfDeferStat(node *ast.IfStat) bool {
+	for _, block := range node.Blocks {
+		if hasDeferStat(block) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *LuaPrinter) printStat(stat ast.Stat) {
+	switch node := stat.(type) {
+	case *ast.EmptyStat:
+	case *ast.BreakStat:
+		p.newLine(0)
+		p.write("break")
+	case *ast.LabelStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("::" + node.Name + "::")
+	case *ast.GotoStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("goto " + node.Name)
+	case *ast.DoStat:
+		p.newLine(node.Loc.StartLine)
+		if node.Stype == lexer.TkKwDefer {
+			// defer的代码块与MoonCake一样当成函数，在函数返回前执行
+			p.write("__df[#__df + 1] = function() -- defer: runs when the function returns")
+			p.printFuncBlock(node.Block)
+		} else {
+			p.write("do")
+			p.printBlock(node.Block)
+		}
+		p.newLine(node.Loc.EndLine)
+		p.write("end")
+	case *ast.WhileStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("while ")
+		p.printExp(node.Exp)
+		p.write(" do")
+		p.printBlock(node.Block)
+		p.newLine(node.Loc.EndLine)
+		p.write("end")
+	case *ast.RepeatStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("repeat")
+		p.printBlock(node.Block)
+		p.newLine(node.Loc.EndLine)
+		p.write("until ")
+		p.printExp(node.Exp)
+	case *ast.IfStat:
+		p.printIfStat(node)
+	case *ast.ForNumStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("for " + node.VarName + " = ")
+		p.printExp(node.InitExp)
+		p.write(", ")
+		p.printExp(node.LimitExp)
+		if node.StepExp != nil {
+			p.write(", ")
+			p.printExp(node.StepExp)
+		}
+		p.write(" do")
+		p.printBlock(node.Block)
+		p.newLine(node.Loc.EndLine)
+		p.write("end")
+	case *ast.ForInStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("for " + strings.Join(node.NameList, ", ") + " in ")
+		p.printExpList(node.ExpList)
+		p.write(" do")
+		p.printBlock(node.Block)
+		p.newLine(node.Loc.EndLine)
+		p.write("end")
+	case *ast.LocalVarDeclStat:
+		p.printLocalVarDeclStat(node)
+	case *ast.LocalFuncDefStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("local function " + node.Name)
+		p.printFuncBody(node.Exp, false)
+	case *ast.AssignStat:
+		p.printAssignStat(node)
+	case *ast.FuncCallStat:
+		p.newLine(node.Loc.StartLine)
+		// 以(开始的语句，加上;防止与前面的语句连在一起
+		if _, ok := node.PrefixExp.(*ast.ParensExp); ok {
+			p.write(";")
+		}
+		p.printExp(node)
+	case *ast.IllegalStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("-- illegal: " + node.Name)
+	case *ast.ClassDefStat:
+		p.printClassDefStat(node)
+	case *ast.ImportDefStat:
+		if node.Lib != nil {
+			p.printStat(node.Lib)
+		} else if node.Name != nil {
+			p.printStat(node.Name)
+		}
+	case *ast.SwitchStat:
+		p.printStat(node.Name)
+		p.printStat(node.Case)
+	case *ast.ExportAllStat:
+		p.newLine(node.Loc.StartLine)
+		p.write("-- export *")
+	}
+}
+
+// if exp then block {elseif exp then block} [else block] end
+// 语法分析时 else 转换成了条件为 true 的 elseif，最后一个条件为true时输出为else
+func (p *LuaPrinter) printIfStat(node *ast.IfStat) {
+	for i, exp := range node.Exps {
+		line := getExpLoc(exp).StartLine
+		if i == 0 {
+			p.newLine(node.Loc.StartLine)
+			p.write("if ")
+		} else if _, ok := exp.(*ast.TrueExp); ok && i == len(node.Exps)-1 {
+			p.newLine(line)
+			p.write("else")
+			p.printBlock(node.Blocks[i])
+			break
+		} else {
+			p.newLine(line)
+			p.write("elseif ")
+		}
+
+		p.printExp(exp)
+		p.write(" then")
+		p.printBlock(node.Blocks[i])
+	}
+
+	p.newLine(node.Loc.EndLine)
+	p.write("end")
+}
+
+func (p *LuaPrinter) printLocalVarDeclStat(node *ast.LocalVarDeclStat) {
+	p.newLine(node.Loc.StartLine)
+	p.write("local ")
+	for i, name := range node.NameList {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(name)
+		if i < len(node.AttrList) {
+			switch node.AttrList[i] {
+			case ast.RDKCONST:
+				p.write(" <const>")
+			case ast.RDKTOCLOSE:
+				p.write(" <close>")
+			}
+		}
+	}
+
+	if len(node.ExpList) > 0 {
+		p.write(" = ")
+		p.printExpList(node.ExpList)
+	}
+}
+
+// printAssignStat 给函数赋值时，输出为 function a.b() end 的形式
+func (p *LuaPrinter) printAssignStat(node *ast.AssignStat) {
+	p.newLine(node.Loc.StartLine)
+	if len(node.VarList) == 1 && len(node.ExpList) == 1 {
+		if funcExp, ok := node.ExpList[0].(*ast.FuncDefExp); ok {
+			if funcName, ok := getFuncName(node.VarList[0], funcExp.IsColon); ok {
+				p.write("function " + funcName)
+				p.printFuncBody(funcExp, funcExp.IsColon)
+				return
+			}
+		}
+	}
+
+	p.printExpList(node.VarList)
+	p.write(" = ")
+	p.printExpList(node.ExpList)
+}
+
+// printClassDefStat 类的table定义在外层，Self、Super与成员的定义在类的作用域中
+func (p *LuaPrinter) printClassDefStat(node *ast.ClassDefStat) {
+	p.printAssignStat(node.Class)
+	p.newLine(node.Loc.StartLine)
+	p.write("do -- " + node.SType.String())
+	p.indent++
+	for _, varStat := range node.Vars {
+		p.printLocalVarDeclStat(varStat)
+	}
+	for _, assignStat := range node.List {
+		p.printAssignStat(assignStat)
+	}
+	p.indent--
+	p.newLine(node.Loc.EndLine)
+	p.write("end")
+}
+
+// printFuncBody 输出函数的参数与函数体，skipSelf表示函数名用:定义的，不输出第一个self参数
+func (p *LuaPrinter) printFuncBody(node *ast.FuncDefExp, skipSelf bool) {
+	parList := node.ParList
+	if skipSelf && len(parList) > 0 {
+		parList = parList[1:]
+	}
+	if node.IsVararg {
+		parList = append(append([]string{}, parList...), "...")
+	}
+
+	p.write("(" + strings.Join(parList, ", ") + ")")
+	p.printFuncBlock(node.Block)
+	p.newLine(node.Loc.EndLine)
+	p.write("end")
+}
+
+func (p *LuaPrinter) printExpList(exps []ast.Exp) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.printExp(exp)
+	}
+}
+
+func (p *LuaPrinter) printExp(exp ast.Exp) {
+	switch node := exp.(type) {
+	case nil:
+		p.write("nil")
+	case *ast.NilExp:
+		p.write("nil")
+	case *ast.BadExpr:
+		p.write("nil --[[bad expr]]")
+	case *ast.TrueExp:
+		p.write("true")
+	case *ast.FalseExp:
+		p.write("false")
+	case *ast.VarargExp:
+		p.write("...")
+	case *ast.IntegerExp:
+		p.write(strconv.FormatInt(node.Val, 10))
+	case *ast.LuajitNum:
+		p.write(strconv.FormatInt(node.Val, 10) + "LL")
+	case *ast.FloatExp:
+		p.write(formatFloat(node.Val))
+	case *ast.StringExp:
+		p.write(quoteString(node.Str))
+	case *ast.NameExp:
+		p.write(node.Name)
+	case ast.NameExp:
+		p.write(node.Name)
+	case *ast.ParensExp:
+		p.write("(")
+		p.printExp(node.Exp)
+		p.write(")")
+	case *ast.UnopExp:
+		p.write(node.Op.String())
+		if node.Op == lexer.TkOpNot {
+			p.write(" ")
+		}
+		if node.Op == lexer.TkOpMinus && getExpPriority(node.Exp) == unaryPriority {
+			// 防止输出成 -- 注释
+			p.write(" ")
+		}
+		p.printSubExp(node.Exp, isNeedParensInUnop(node.Exp))
+	case *ast.BinopExp:
+		priority, rightAssoc := getBinopPriority(node.Op)
+		p.printSubExp(node.Exp1, isNeedParens(node.Exp1, priority, rightAssoc))
+		p.write(" " + node.Op.String() + " ")
+		p.printSubExp(node.Exp2, isNeedParens(node.Exp2, priority, !rightAssoc))
+	case *ast.ConcatExp:
+		p.printExp(&ast.BinopExp{Op: lexer.TkOpConcat, Exp1: node.Exp1, Exp2: node.Exp2, Loc: node.Loc})
+	case *ast.TableConstructorExp:
+		p.printTableExp(node)
+	case *ast.FuncDefExp:
+		p.write("function")
+		p.printFuncBody(node, false)
+	case *ast.TableAccessExp:
+		p.printPrefixExp(node.PrefixExp)
+		if keyStr, ok := node.KeyExp.(*ast.StringExp); ok && isIdentifier(keyStr.Str) {
+			p.write("." + keyStr.Str)
+		} else {
+			p.write("[")
+			p.printExp(node.KeyExp)
+			p.write("]")
+		}
+	case *ast.FuncCallExp:
+		p.printPrefixExp(node.PrefixExp)
+		if node.NameExp != nil {
+			p.write(":" + node.NameExp.Str)
+		}
+		p.write("(")
+		p.printExpList(node.Args)
+		p.write(")")
+	default:
+		p.write(fmt.Sprintf("nil --[[%T]]", exp))
+	}
+}
+
+func (p *LuaPrinter) printSubExp(exp ast.Exp, needParens bool) {
+	if needParens {
+		p.write("(")
+		p.printExp(exp)
+		p.write(")")
+		return
+	}
+	p.printExp(exp)
+}
+
+// printPrefixExp 函数调用与成员获取的前缀，不是变量或函数调用时需要加上括号
+func (p *LuaPrinter) printPrefixExp(exp ast.Exp) {
+	switch exp.(type) {
+	case *ast.NameExp, ast.NameExp, *ast.ParensExp, *ast.TableAccessExp, *ast.FuncCallExp:
+		p.printExp(exp)
+	default:
+		p.printSubExp(exp, true)
+	}
+}
+
+func (p *LuaPrinter) printTableExp(node *ast.TableConstructorExp) {
+	if len(node.ValExps) == 0 {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	for i, valExp := range node.ValExps {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		var keyExp ast.Exp
+		if i < len(node.KeyExps) {
+			keyExp = node.KeyExps[i]
+		}
+
+		if keyExp != nil {
+			if keyStr, ok := keyExp.(*ast.StringExp); ok && isIdentifier(keyStr.Str) {
+				p.write(keyStr.Str)
+			} else {
+				p.write("[")
+				p.printExp(keyExp)
+				p.write("]")
+			}
+			p.write(" = ")
+		}
+		p.printExp(valExp)
+	}
+	p.write("}")
+}
+
+// getFuncName 获取 function a.b:c() 形式的函数名，不能转换时返回false
+func getFuncName(exp ast.Exp, isColon bool) (string, bool) {
+	switch node := exp.(type) {
+	case *ast.NameExp:
+		return node.Name, !isColon
+	case *ast.TableAccessExp:
+		keyStr, ok := node.KeyExp.(*ast.StringExp)
+		if !ok || !isIdentifier(keyStr.Str) {
+			return "", false
+		}
+
+		preName, ok := getFuncName(node.PrefixExp, false)
+		if !ok {
+			return "", false
+		}
+
+		if isColon {
+			return preName + ":" + keyStr.Str, true
+		}
+		return preName + "." + keyStr.Str, true
+	}
+
+	return "", false
+}
+
+// 运算符的优先级，与Lua语言的优先级一致
+const (
+	unaryPriority = 12
+	maxPriority   = 100
+)
+
+// getBinopPriority 获取二元运算符的优先级，以及是否为右结合
+func getBinopPriority(op lexer.TkKind) (priority int, rightAssoc bool) {
+	switch op {
+	case lexer.TkOpOr:
+		return 1, false
+	case lexer.TkOpAnd:
+		return 2, false
+	case lexer.TkOpLt, lexer.TkOpGt, lexer.TkOpLe, lexer.TkOpGe, lexer.TkOpNe, lexer.TkOpEq:
+		return 3, false
+	case lexer.TkOpBor:
+		return 4, false
+	case lexer.TkOpBxor:
+		return 5, false
+	case lexer.TkOpBand:
+		return 6, false
+	case lexer.TkOpShl, lexer.TkOpShr:
+		return 7, false
+	case lexer.TkOpConcat:
+		return 9, true
+	case lexer.TkOpAdd, lexer.TkOpSub:
+		return 10, false
+	case lexer.TkOpMul, lexer.TkOpDiv, lexer.TkOpIdiv, lexer.TkOpMod:
+		return 11, false
+	case lexer.TkOpPow:
+		return 14, true
+	}
+
+	return maxPriority, false
+}
+
+// getExpPriority 获取表达式的优先级，不是运算表达式时为最高的优先级，负数与一元运算的优先级相同
+func getExpPriority(exp ast.Exp) int {
+	switch node := exp.(type) {
+	case *ast.IntegerExp:
+		if node.Val < 0 {
+			return unaryPriority
+		}
+	case *ast.FloatExp:
+		if node.Val < 0 || math.IsInf(node.Val, -1) {
+			return unaryPriority
+		}
+	case *ast.BinopExp:
+		priority, _ := getBinopPriority(node.Op)
+		return priority
+	case *ast.ConcatExp:
+		priority, _ := getBinopPriority(lexer.TkOpConcat)
+		return priority
+	case *ast.UnopExp:
+		return unaryPriority
+	}
+
+	return maxPriority
+}
+
+// isNeedParens 二元运算的子表达式是否需要加上括号
+// sameNeedParens 表示优先级相同时是否需要括号，左结合的运算符右边的子表达式需要加上括号
+func isNeedParens(exp ast.Exp, priority int, sameNeedParens bool) bool {
+	expPriority := getExpPriority(exp)
+	return expPriority < priority || (expPriority == priority && sameNeedParens)
+}
+
+// isNeedParensInUnop 一元运算的子表达式是否需要加上括号，只有^的优先级比一元运算高
+func isNeedParensInUnop(exp ast.Exp) bool {
+	return getExpPriority(exp) < unaryPriority
+}
+
+// getStatLoc 获取语句的位置信息
+func getStatLoc(stat ast.Stat) lexer.Location {
+	switch node := stat.(type) {
+	case *ast.LabelStat:
+		return node.Loc
+	case *ast.GotoStat:
+		return node.Loc
+	case *ast.DoStat:
+		return node.Loc
+	case *ast.IfStat:
+		return node.Loc
+	case *ast.WhileStat:
+		return node.Loc
+	case *ast.RepeatStat:
+		return node.Loc
+	case *ast.ForNumStat:
+		return node.Loc
+	case *ast.ForInStat:
+		return node.Loc
+	case *ast.AssignStat:
+		return node.Loc
+	case *ast.LocalVarDeclStat:
+		return node.Loc
+	case *ast.LocalFuncDefStat:
+		return node.Loc
+	case *ast.FuncCallStat:
+		return node.Loc
+	case *ast.IllegalStat:
+		return node.Loc
+	case *ast.ClassDefStat:
+		loc := node.Loc
+		if node.Class != nil {
+			loc.StartLine = node.Class.Loc.StartLine
+			loc.StartColumn = node.Class.Loc.StartColumn
+		}
+		return loc
+	case *ast.ImportDefStat:
+		if node.Lib != nil {
+			return node.Lib.Loc
+		} else if node.Name != nil {
+			return node.Name.Loc
+		}
+	case *ast.SwitchStat:
+		return node.Loc
+	case *ast.ExportAllStat:
+		return node.Loc
+	}
+
+	return lexer.Location{}
+}
+
+// getExpLoc 获取表达式的位置信息
+func getExpLoc(exp ast.Exp) lexer.Location {
+	switch node := exp.(type) {
+	case *ast.NilExp:
+		return node.Loc
+	case *ast.BadExpr:
+		return node.Loc
+	case *ast.TrueExp:
+		return node.Loc
+	case *ast.FalseExp:
+		return node.Loc
+	case *ast.VarargExp:
+		return node.Loc
+	case *ast.IntegerExp:
+		return node.Loc
+	case *ast.FloatExp:
+		return node.Loc
+	case *ast.LuajitNum:
+		return node.Loc
+	case *ast.StringExp:
+		return node.Loc
+	case *ast.UnopExp:
+		return node.Loc
+	case *ast.BinopExp:
+		return node.Loc
+	case *ast.ConcatExp:
+		return node.Loc
+	case *ast.TableConstructorExp:
+		return node.Loc
+	case *ast.FuncDefExp:
+		return node.Loc
+	case *ast.NameExp:
+		return node.Loc
+	case *ast.ParensExp:
+		return node.Loc
+	case *ast.TableAccessExp:
+		return node.Loc
+	case *ast.FuncCallExp:
+		return node.Loc
+	}
+
+	return lexer.Location{}
+}
+
+// formatFloat 输出浮点数，保证输出的结果仍然为浮点数
+func formatFloat(val float64) string {
+	if math.IsInf(val, 1) {
+		return "math.huge"
+	} else if math.IsInf(val, -1) {
+		return "-math.huge"
+	} else if math.IsNaN(val) {
+		return "(0/0)"
+	}
+
+	str := strconv.FormatFloat(val, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eEn") {
+		str = str + ".0"
+	}
+	return str
+}
+
+// quoteString 输出双引号包含的字符串，转义特殊的字符
+func quoteString(str string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(str); i++ {
+		ch := str[i]
+		switch ch {
+		case '"':
+			buf.WriteString("\\\"")
+		case '\\':
+			buf.WriteString("\\\\")
+		case '\n':
+			buf.WriteString("\\n")
+		case '\r':
+			buf.WriteString("\\r")
+		case '\t':
+			buf.WriteString("\\t")
+		default:
+			if ch < 32 || ch == 127 {
+				// 后面为数字时，需要输出3位数字
+				buf.WriteString(fmt.Sprintf("\\%03d", ch))
+			} else {
+				buf.WriteByte(ch)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// isIdentifier 判断是否为合法的变量名，并且不是关键字
+func isIdentifier(str string) bool {
+	if str == "" {
+		return false
+	}
+
+	for i := 0; i < len(str); i++ {
+		ch := str[i]
+		if ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (i > 0 && ch >= '0' && ch <= '9') {
+			continue
+		}
+		return false
+	}
+
+	return !luaKeywords[str]
+}
+
+// luaKeywords Lua语言的关键字
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true, "false": true,
+	"for": true, "function": true, "goto": true, "if": true, "in": true, "local": true, "nil": true,
+	"not": true, "or": true, "repeat": true, "return": true, "then": true, "true": true, "until": true,
+	"while": true,
+}
@@ -0,0 +1,166 @@
+package printer
+
+import (
+	"luahelper-lsp/langserver/check/compiler/parser"
+	"strings"
+	"testing"
+)
+
+// 输出的Lua代码再次解析后输出，结果需要一致
+func TestPrintLuaRoundTrip(t *testing.T) {
+	strContent := `local a <const> = -(-1) + 2 ^ -3
+local t = {1, x = 2, ["a b"] = (a - 1) * 2, [3] = "x\n\"y\""}
+function t.foo(b, ...)
+    if not (b > 0) then
+        return
+    elseif b == 1 then
+        return (...)
+    else
+        return t:bar(b)("c") .. "d" .. ("e" .. "f")
+    end
+end
+for i = 1, 10 do
+    repeat
+        break
+    until i > 2
+end
+;(print)(a)
+return t`
+	block, _, errList := parser.CreateParser([]byte(strContent), "test.lua").BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser err: %s", errList[0].ErrStr)
+	}
+
+	code, lineVec := PrintLua(block, 0, 0)
+	if len(lineVec) != strings.Count(code, "\n") {
+		t.Fatalf("line map len err, len=%d", len(lineVec))
+	}
+
+	block2, _, errList2 := parser.CreateParser([]byte(code), "test.lua").BeginAnalyze()
+	if len(errList2) > 0 {
+		t.Fatalf("parser print code err: %s\n%s", errList2[0].ErrStr, code)
+	}
+
+	code2, _ := PrintLua(block2, 0, 0)
+	if code != code2 {
+		t.Fatalf("print code not same:\n%s\n%s", code, code2)
+	}
+}
+
+// MoonCake 的 guard、continue 与 switch 转换成的Lua代码
+func TestPrintMoocToLua(t *testing.T) {
+	strContent := "fn foo(a) {\n    guard a else {\n        return\n    }\n    for i = 1, a {\n        continue\n    }\n}"
+	block, _, errList := parser.CreateParser([]byte(strContent), "test.mooc").BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser err: %s", errList[0].ErrStr)
+	}
+
+	code, lineVec := PrintLua(block, 0, 0)
+	for _, str := range []string{"if not a then", "goto __continue", "::__continue"} {
+		if !strings.Contains(code, str) {
+			t.Fatalf("print mooc code err, not find %s:\n%s", str, code)
+		}
+	}
+
+	codeLines := strings.Split(code, "\n")
+	for i, codeLine := range codeLines {
+		if strings.Contains(codeLine, "goto __continue") && lineVec[i] != 6 {
+			t.Fatalf("continue line map err, line=%d", lineVec[i])
+		}
+	}
+
+	// 只输出指定范围内的顶层语句
+	code, _ = PrintLua(block, 100, 200)
+	if code != "" {
+		t.Fatalf("print range err:\n%s", code)
+	}
+}
+
+// Lua代码的行号映射回MoonCake文件的行号
+func TestSourceMap(t *testing.T) {
+	strContent := "class Foo {\n    fn bar(a) {\n        guard a else {\n            return\n        }\n        print(a)\n    }\n}"
+	block, _, errList := parser.CreateParser([]byte(strContent), "test.mooc").BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser err: %s", errList[0].ErrStr)
+	}
+
+	code, _ := PrintLua(block, 0, 0)
+	luaLine := 0
+	for i, codeLine := range strings.Split(code, "\n") {
+		if strings.Contains(codeLine, "print(a)") {
+			luaLine = i + 1
+		}
+	}
+
+	sourceMap := CreateSourceMap(block)
+	if sourceLine := sourceMap.GetSourceLine(luaLine); sourceLine != 6 {
+		t.Fatalf("source map err, luaLine=%d, sourceLine=%d", luaLine, sourceLine)
+	}
+
+	if sourceMap.GetSourceLine(1000) != 0 {
+		t.Fatalf("source map out of range err")
+	}
+}
+
+// defer在函数返回前按相反的顺序执行，return先计算返回值再执行defer
+func TestPrintMoocDefer(t *testing.T) {
+	strContent := `fn foo(a) {
+    defer {
+        print("first")
+    }
+    defer {
+        print("second")
+    }
+    if a {
+        return a + 1
+    }
+    print(a)
+}
+fn bar() {
+    return 1
+}`
+	block, _, errList := parser.CreateParser([]byte(strContent), "test.mooc").BeginAnalyze()
+	if len(errList) > 0 {
+		t.Fatalf("parser err: %s", errList[0].ErrStr)
+	}
+
+	code, lineVec := PrintLua(block, 0, 0)
+	codeLines := strings.Split(code, "\n")
+	expectVec := []string{
+		"function foo(a)",
+		"    local __df = {}",
+		"    local function __dr(...)",
+		"        for __i = #__df, 1, -1 do __df[__i]() end",
+		"        return ...",
+		"    end",
+		"    __df[#__df + 1] = function() -- defer: runs when the function returns",
+		`        print("first")`,
+		"    end",
+		"    __df[#__df + 1] = function() -- defer: runs when the function returns",
+		`        print("second")`,
+		"    end",
+		"    if a then",
+		"        return __dr(a + 1)",
+		"    end",
+		"    print(a)",
+		"    __dr()",
+		"end",
+		"function bar()",
+		"    return 1",
+		"end",
+	}
+	for i, expectLine := range expectVec {
+		if i >= len(codeLines) || codeLines[i] != expectLine {
+			t.Fatalf("print defer err, line=%d, expect=%s:\n%s", i+1, expectLine, code)
+		}
+	}
+
+	// defer的代码对应MoonCake文件中defer的行
+	if lineVec[7] != 3 || lineVec[13] != 9 {
+		t.Fatalf("defer line map err, lineVec=%v", lineVec)
+	}
+
+	if _, _, errList = parser.CreateParser([]byte(code), "test.lua").BeginAnalyze(); len(errList) > 0 {
+		t.Fatalf("parser lua code err: %s\n%s", errList[0].ErrStr, code)
+	}
+}
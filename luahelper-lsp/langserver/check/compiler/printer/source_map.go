@@ -0,0 +1,157 @@
+package printer
+
+import (
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"strings"
+)
+
+// SourceMap 转换后的Lua代码行号与原文件行号的映射，行号都从1开始
+// Lua代码为LuaHelper转换成的代码时（luahelper/moocToLua与 -mooctolua 输出的代码），映射由语法树中的位置信息得到，是准确的
+// 其他编译器（例如MoonCake编译器）生成的Lua代码布局不同，映射通过两份代码每一行中的名称与常量对齐得到，是尽量匹配的
+type SourceMap struct {
+	code        string // LuaHelper转换后的Lua代码
+	lineVec     []int  // Lua代码每一行对应的原文件行号，0表示没有对应的行
+	approximate bool   // 是否为尽量匹配的映射
+}
+
+// alignWindow 对齐代码的行时，原文件往后查找的最多行数
+const alignWindow = 100
+
+// lineAnchor 一行代码中的名称、字符串与数字常量，转换前后的代码中一般保持不变，用于对齐两份代码的行
+type lineAnchor struct {
+	line      int
+	anchorVec []string
+}
+
+// CreateSourceMap 根据语法树中的位置信息，创建LuaHelper转换后Lua代码的行号映射
+func CreateSourceMap(block *ast.Block) *SourceMap {
+	code, lineVec := PrintLua(block, 0, 0)
+	return &SourceMap{
+		code:    code,
+		lineVec: lineVec,
+	}
+}
+
+// CreateLuaSourceMap 创建已有的Lua文件的行号映射，block与source为原文件的语法树与内容，luaContents为Lua文件的内容
+// Lua文件为LuaHelper转换成的代码时，使用语法树中的位置信息；否则按行中的名称与常量，依次对齐到原文件的行
+func CreateLuaSourceMap(block *ast.Block, source []byte, luaContents []byte) *SourceMap {
+	sourceMap := CreateSourceMap(block)
+	if sourceMap.MatchLua(luaContents) {
+		return sourceMap
+	}
+
+	sourceMap.approximate = true
+	sourceMap.lineVec = alignLines(getLineAnchors(source, lexer.ModeMooc),
+		getLineAnchors(luaContents, lexer.ModeLua), strings.Count(string(luaContents), "\n")+1)
+	return sourceMap
+}
+
+// MatchLua 判断Lua文件的内容是否为LuaHelper转换成的代码，忽略换行符的差异与结尾的空行
+func (s *SourceMap) MatchLua(contents []byte) bool {
+	trimFunc := func(str string) string {
+		return strings.TrimRight(strings.ReplaceAll(str, "\r\n", "\n"), "\n")
+	}
+
+	return trimFunc(string(contents)) == trimFunc(s.code)
+}
+
+// IsApproximate 是否为尽量匹配的映射，Lua文件不是LuaHelper转换成的代码时为true
+func (s *SourceMap) IsApproximate() bool {
+	return s.approximate
+}
+
+// GetSourceLine 获取Lua代码的行对应的原文件行号，没有对应的行时，往前查找最近的有对应的行，找不到返回0
+func (s *SourceMap) GetSourceLine(luaLine int) int {
+	if luaLine <= 0 || luaLine > len(s.lineVec) {
+		return 0
+	}
+
+	for i := luaLine - 1; i >= 0; i-- {
+		if s.lineVec[i] > 0 {
+			return s.lineVec[i]
+		}
+	}
+
+	return 0
+}
+
+// getLineAnchors 词法分析代码，获取每一行中的名称与常量，没有的行不返回，词法错误时只返回前面的部分
+func getLineAnchors(contents []byte, mode int) (lineVec []lineAnchor) {
+	l := lexer.NewLexer(contents, "")
+	l.SetMode(mode)
+	errFlag := false
+	l.SetErrHandler(func(oneErr lexer.ParseError) {
+		errFlag = true
+	})
+
+	for !errFlag {
+		line, kind, token := l.NextToken()
+		if kind == lexer.TkEOF {
+			break
+		}
+
+		if kind != lexer.TkIdentifier && kind != lexer.TkString && kind != lexer.TkNumber {
+			continue
+		}
+
+		if len(lineVec) == 0 || lineVec[len(lineVec)-1].line != line {
+			lineVec = append(lineVec, lineAnchor{
+				line: line,
+			})
+		}
+		oneLine := &lineVec[len(lineVec)-1]
+		oneLine.anchorVec = append(oneLine.anchorVec, token)
+	}
+
+	return lineVec
+}
+
+// alignLines 把Lua代码的行依次对齐到原文件的行，原文件一行中的名称与常量都出现在Lua代码的行中时认为对应
+// 只往后查找alignWindow行，优先选择名称与常量完全一样的行，返回Lua代码每一行对应的原文件行号
+func alignLines(sourceVec []lineAnchor, luaVec []lineAnchor, luaLineNum int) []int {
+	lineVec := make([]int, luaLineNum)
+	curIndex := 0
+	for _, luaLine := range luaVec {
+		luaMap := make(map[string]struct{}, len(luaLine.anchorVec))
+		for _, anchor := range luaLine.anchorVec {
+			luaMap[anchor] = struct{}{}
+		}
+
+		bestIndex := -1
+		for i := curIndex; i < len(sourceVec) && i < curIndex+alignWindow; i++ {
+			if !isAnchorContain(luaMap, sourceVec[i].anchorVec) {
+				continue
+			}
+
+			if len(sourceVec[i].anchorVec) == len(luaLine.anchorVec) {
+				bestIndex = i
+				break
+			}
+
+			if bestIndex < 0 {
+				bestIndex = i
+			}
+		}
+
+		if bestIndex < 0 || luaLine.line > luaLineNum {
+			continue
+		}
+
+		lineVec[luaLine.line-1] = sourceVec[bestIndex].line
+		curIndex = bestIndex
+	}
+
+	return lineVec
+}
+
+// isAnchorContain 判断anchorVec中的名称与常量，是否都在luaMap中
+func isAnchorContain(luaMap map[string]struct{}, anchorVec []string) bool {
+	for _, anchor := range anchorVec {
+		if _, ok := luaMap[anchor]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -62,5 +62,17 @@ type Projects interface {
 
 	IsFieldOfClass(className string, fieldName string) bool
 
+	// GetOperatorResultType 获取class类型与另外一个操作数运算的结果类型，没有定义对应的运算符元方法返回空
+	GetOperatorResultType(className string, strOp string, paramTypeVec []string) (retVec []string)
+
 	GetVarAnnType(fileName string, lastLine int) (string, bool)
+
+	// IsFieldWriteInProject 判断整个工程中是否有对该名称的table成员赋值过
+	IsFieldWriteInProject(strFieldName string) bool
+
+	// IsGlobalDefineInProject 判断整个工程中是否定义了该名称的全局变量，忽略moocscript中extension语句产生的定义
+	IsGlobalDefineInProject(strName string) bool
+
+	// IsGlobalReferInProject 判断整个工程中是否有读取过该名称的全局变量或函数
+	IsGlobalReferInProject(strName string) bool
 }
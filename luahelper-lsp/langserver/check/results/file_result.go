@@ -33,23 +33,29 @@ const (
 
 // FileResult 单个lua文件分析的结果
 type FileResult struct {
-	Name         string                     // lua文件名，唯一标识
-	checkTerm    CheckTerm                  // 分析的轮数，默认从第1轮开始
-	entryFile    string                     // 输出错误的时候，是否打印入口文件
-	Block        *ast.Block                 // 整个ast结构
-	MainFunc     *common.FuncInfo           // ast生成的主function
-	ReferVec     []*common.ReferInfo        // 整体引用的vector，按先后顺序插入到其中
-	CheckErrVec  []common.CheckError        // 所有检测错误的信息
-	GlobalMaps   map[string]*common.VarInfo // 所有的全局信息, 包含没有_G的与含有_G前缀的变量
-	ProtocolMaps map[string]*common.VarInfo // 所有的协议前缀符号表
-	NodefineMaps map[string]*common.VarInfo // 第一阶段当中，没有定义但是出现的全局变量
-	FuncIDVec    []*common.FuncInfo         // 保存的所有funcInfo信息，可以通过id来查找
-	funcID       int                        // 自增的funcID，默认值为0，每产生一个新的funcID自增1
-	CommentMap   map[int]*lexer.CommentInfo // 第一轮分析时候，保存所有的注释信息, key值为行号
+	Name            string                        // lua文件名，唯一标识
+	checkTerm       CheckTerm                     // 分析的轮数，默认从第1轮开始
+	entryFile       string                        // 输出错误的时候，是否打印入口文件
+	Block           *ast.Block                    // 整个ast结构
+	MainFunc        *common.FuncInfo              // ast生成的主function
+	ReferVec        []*common.ReferInfo           // 整体引用的vector，按先后顺序插入到其中
+	CheckErrVec     []common.CheckError           // 所有检测错误的信息
+	GlobalMaps      map[string]*common.VarInfo    // 所有的全局信息, 包含没有_G的与含有_G前缀的变量
+	ProtocolMaps    map[string]*common.VarInfo    // 所有的协议前缀符号表
+	NodefineMaps    map[string]*common.VarInfo    // 第一阶段当中，没有定义但是出现的全局变量
+	FuncIDVec       []*common.FuncInfo            // 保存的所有funcInfo信息，可以通过id来查找
+	funcID          int                           // 自增的funcID，默认值为0，每产生一个新的funcID自增1
+	CommentMap      map[int]*lexer.CommentInfo    // 第一轮分析时候，保存所有的注释信息, key值为行号
+	WriteFields     map[string]struct{}           // 第一轮分析时候，所有被赋值过的table成员名称，例如t.x = 1 或是 { x = 1 } 中的x
+	ExtensionLocMap map[lexer.Location]struct{}   // 第一轮分析时候，moocscript中extension语句扩展的类名位置，这些位置不算类的定义
+	ReferNames      map[string]struct{}           // 第一轮分析时候，所有读取过的非局部变量名称，例如 foo() 或是 _G.foo 中的foo
+	IsolatedFuncMap map[*common.FuncInfo]struct{} // 实时分析时，函数体没有修改函数外部信息的顶层函数，修改这些函数的函数体时只重新分析函数体
+	conf            *common.GlobalConfig          // 所属会话的配置
 }
 
-// CreateFileResult 创建一个新的文件分析结果
-func CreateFileResult(strName string, block *ast.Block, checkTerm CheckTerm, entryFile string) *FileResult {
+// CreateFileResult 创建一个新的文件分析结果，conf为所属会话的配置
+func CreateFileResult(strName string, block *ast.Block, checkTerm CheckTerm, entryFile string,
+	conf *common.GlobalConfig) *FileResult {
 	loc := lexer.Location{}
 	if block != nil {
 		loc = block.Loc
@@ -57,16 +63,20 @@ func CreateFileResult(strName string, block *ast.Block, checkTerm CheckTerm, ent
 	funcInfo := common.CreateFuncInfo(nil, 0, loc, true, nil, strName)
 
 	return &FileResult{
-		Name:         strName,
-		Block:        block,
-		MainFunc:     funcInfo,
-		GlobalMaps:   map[string]*common.VarInfo{},
-		ProtocolMaps: map[string]*common.VarInfo{},
-		NodefineMaps: map[string]*common.VarInfo{},
-		checkTerm:    checkTerm,
-		funcID:       -1,
-		CommentMap:   map[int]*lexer.CommentInfo{},
-		entryFile:    entryFile,
+		Name:            strName,
+		Block:           block,
+		MainFunc:        funcInfo,
+		GlobalMaps:      map[string]*common.VarInfo{},
+		ProtocolMaps:    map[string]*common.VarInfo{},
+		NodefineMaps:    map[string]*common.VarInfo{},
+		checkTerm:       checkTerm,
+		funcID:          -1,
+		CommentMap:      map[int]*lexer.CommentInfo{},
+		WriteFields:     map[string]struct{}{},
+		ExtensionLocMap: map[lexer.Location]struct{}{},
+		ReferNames:      map[string]struct{}{},
+		entryFile:       entryFile,
+		conf:            conf,
 	}
 }
 
@@ -77,6 +87,12 @@ func (f *FileResult) InertNewFunc(newFunc *common.FuncInfo) {
 	newFunc.FuncID = f.funcID
 }
 
+// ResetFuncVec 只保留前面funcNum个funcInfo，后面的重新插入
+func (f *FileResult) ResetFuncVec(funcNum int) {
+	f.FuncIDVec = f.FuncIDVec[:funcNum]
+	f.funcID = funcNum - 1
+}
+
 func (f *FileResult) GetFileTerm() CheckTerm {
 	return f.checkTerm
 }
@@ -164,7 +180,7 @@ func (f *FileResult) InsertRelateError(errType common.CheckErrorType, errStr str
 	}
 
 	// 如果不是语法错误，判断是否要忽略该文件错误
-	if common.GConfig.IsIgnoreErrorFile(f.Name, errType) {
+	if f.conf.IsIgnoreErrorFile(f.Name, errType) {
 		log.Debug("ErrType:%d, luaFile:%s, errorInfo:%s, loc[(%d, %d), (%d, %d)] is ignore", errType,
 			f.Name, errStr, loc.StartLine, loc.StartColumn, loc.EndLine, loc.EndColumn)
 		return
@@ -228,15 +244,15 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	curFile := f.Name
 
 	// 如果该文件是需要被忽略，直接返回
-	if common.GConfig.IsIngoreNeedReadFile(strFile) {
+	if f.conf.IsIngoreNeedReadFile(strFile) {
 		referInfo.Valid = false
 		return
 	}
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := f.conf.GetDirManager()
 
 	// 判断引入方式，是否要包含后缀的方式
-	suffixFlag := common.JudgeReferSuffixFlag(referInfo.ReferType, referInfo.ReferTypeStr)
+	suffixFlag := f.conf.JudgeReferSuffixFlag(referInfo.ReferType, referInfo.ReferTypeStr)
 	// 1) 是带后缀的
 	if suffixFlag {
 		// 1.1) 如果是包含了后缀，查看路径下面是否直接有, 直接存在返回true
@@ -248,9 +264,9 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 		}
 
 		// 1.2) 非全路径匹配
-		if !common.GConfig.ReferMatchPathFlag {
+		if !f.conf.ReferMatchPathFlag {
 			// 如果配置为非全路径匹配，尝试模糊匹配路径
-			bestFilePath := common.GConfig.GetDirManager().GetBestMatchReferFile(curFile, strFile, allFilesMap, fileIndexInfo)
+			bestFilePath := f.conf.GetDirManager().GetBestMatchReferFile(curFile, strFile, allFilesMap, fileIndexInfo)
 			if bestFilePath != "" {
 				// lua文件存在，正常
 				referInfo.ReferValidStr = bestFilePath
@@ -269,7 +285,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	}
 
 	// 判断是否忽略require 该模块变量
-	if referInfo.ReferType == common.ReferTypeRequire && common.GConfig.IsIgnoreRequireModuleError(strFile) {
+	if referInfo.ReferType == common.ReferTypeRequire && f.conf.IsIgnoreRequireModuleError(strFile) {
 		referInfo.Valid = false
 		return
 	}
@@ -277,7 +293,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	// 下面处理，引入不包含后缀的
 	// require可能包含. 替换为/
 	strNewFile := strings.Replace(strFile, ".", "/", -1)
-	if common.GConfig.ReferMatchPathFlag {
+	if f.conf.ReferMatchPathFlag {
 		// a) 优先尝试找so
 		// 如果不匹配，但是下面存在.so的，优先匹配到so的
 		soFile := strNewFile + ".so"
@@ -306,6 +322,14 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 			return
 		}
 
+		referLuaFile = strNewFile + ".luau"
+		matchAllDirFile = dirManager.MatchAllDirReferFile(curFile, referLuaFile)
+		if matchAllDirFile != "" {
+			// 匹配到了
+			referInfo.ReferValidStr = matchAllDirFile
+			return
+		}
+
 		// c) 判断拼接的 init.lua是否存在
 		initFile := strNewFile + "/init.lua"
 		matchAllDirFile = dirManager.MatchAllDirReferFile(curFile, initFile)
@@ -418,6 +442,13 @@ func (f *FileResult) isReferFileContainFiles(needReferFileMap map[string]struct{
 					return true
 				}
 			}
+
+			if !strings.HasSuffix(strFile, ".luau") {
+				strTemp := strFile + ".luau"
+				if strings.HasSuffix(oneStr, strTemp) {
+					return true
+				}
+			}
 		}
 	}
 
@@ -493,7 +524,7 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 
 	// 2) 查找这个文件的所有全局变量
 	for strVar, oneVar := range f.GlobalMaps {
-		if common.GConfig.IsStrProtocol(strVar) {
+		if f.conf.IsStrProtocol(strVar) {
 			continue
 		}
 
@@ -506,30 +537,22 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 			Kind:          common.IKVariable,
 			Loc:           oneVar.Loc,
 			ContainerName: containerName,
+			VarInfo:       oneVar,
 		}
 
 		// 若是函数
 		if oneVar.ReferFunc != nil {
 			oneSymbol.Loc = oneVar.ReferFunc.Loc
-			oneSymbol.Kind = 2
-			paramStr := ""
-			// oneSymbol.Children = oneVar.ReferFunc.MainScope.FindAllLocalVal(nil)
-			for _, param := range oneVar.ReferFunc.ParamList {
-				if paramStr == "" {
-					paramStr += param
-				} else {
-					paramStr += ", " + param
-				}
-			}
-			if paramStr != "" {
-				oneSymbol.Name = oneSymbol.Name + "(" + paramStr + ")"
-			}
+			oneSymbol.Kind = common.IKFunction
+			oneSymbol.Detail = oneVar.GetFuncSymbolDetail(strVar)
 		} else {
 			if oneVar.SubMaps == nil {
 				symbolVec = append(symbolVec, oneSymbol)
 				continue
 			}
 
+			oneSymbol.Kind = oneVar.GetTableSymbolKind()
+
 			var maxLoc lexer.Location
 			maxLoc.EndLine = oneSymbol.Loc.EndLine
 			maxLoc.EndColumn = oneSymbol.Loc.EndColumn
@@ -552,7 +575,7 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 			}
 
 			oneSymbol.Loc.EndLine = maxLoc.EndLine
-			oneSymbol.Loc.StartColumn = maxLoc.EndColumn
+			oneSymbol.Loc.EndColumn = maxLoc.EndColumn
 		}
 		symbolVec = append(symbolVec, oneSymbol)
 	}
@@ -564,28 +587,18 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 		protocolSymbols := make(map[string]common.FileSymbolStruct)
 		for strVar, oneVar := range f.ProtocolMaps {
 			for {
+				strProPre := oneVar.ExtraGlobal.StrProPre
 				oneSymbol := common.FileSymbolStruct{
-					Kind: common.IKVariable,
-					Loc:  oneVar.Loc,
+					Name:    strVar,
+					Kind:    common.IKField,
+					Loc:     oneVar.Loc,
+					VarInfo: oneVar,
 				}
 
-				strProPre := oneVar.ExtraGlobal.StrProPre
-				oneSymbol.Name = strProPre + "." + strVar
 				if oneVar.ReferFunc != nil {
 					oneSymbol.Kind = common.IKFunction
 					oneSymbol.Loc = oneVar.ReferFunc.Loc
-					paramStr := ""
-					// oneSymbol.Children = oneVar.ReferFunc.MainScope.FindAllLocalVal(nil)
-					for _, param := range oneVar.ReferFunc.ParamList {
-						if paramStr == "" {
-							paramStr += param
-						} else {
-							paramStr += ", " + param
-						}
-					}
-					if paramStr != "" {
-						oneSymbol.Name = oneSymbol.Name + "(" + paramStr + ")"
-					}
+					oneSymbol.Detail = oneVar.GetFuncSymbolDetail(strProPre + "." + strVar)
 				}
 
 				if symbols, ok := protocolSymbols[strProPre]; ok {
@@ -594,8 +607,9 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 				} else {
 					protocolVar := f.GlobalMaps[strProPre]
 					preSybmbol := common.FileSymbolStruct{
-						Name: strProPre,
-						Kind: common.IKVariable,
+						Name:    strProPre,
+						Kind:    common.IKModule,
+						VarInfo: protocolVar,
 					}
 					if protocolVar != nil {
 						preSybmbol.Loc = protocolVar.Loc
@@ -728,7 +742,7 @@ func (f *FileResult) GetFuncInfoReferGlobalName(funcInfo *common.FuncInfo) strin
 // FindGMapsScopes 找到当前全局变量 和 全局协议变量下的所有Scope
 func (f *FileResult) FindGMapsScopes() (scopes []*common.ScopeInfo) {
 	for strName, varInfo := range f.GlobalMaps {
-		if common.GConfig.IsStrProtocol(strName) {
+		if f.conf.IsStrProtocol(strName) {
 			continue
 		}
 
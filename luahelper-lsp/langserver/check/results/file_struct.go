@@ -29,13 +29,13 @@ type FileStruct struct {
 	Contents     []byte               // 文件内容的切片，用于比较文件是否有变动
 }
 
-// CreateFileStruct 创建一个新的FileStruct
-func CreateFileStruct(strFile string) *FileStruct {
+// CreateFileStruct 创建一个新的FileStruct，conf为所属会话的配置
+func CreateFileStruct(strFile string, conf *common.GlobalConfig) *FileStruct {
 	return &FileStruct{
 		StrFile:      strFile,
 		HandleResult: FileHandleOk,
 		FileResult:   nil,
-		AnnotateFile: common.CreateAnnotateFile(strFile),
+		AnnotateFile: common.CreateAnnotateFile(strFile, conf),
 		IsCommonFile: true,
 	}
 }
@@ -91,7 +91,7 @@ func (f *FileStruct) checkVarAnnotateEnum(varInfo *common.VarInfo) {
 	}
 
 	// 判断是否有枚举的定义
-	if !f.isVarAnnotateEnum(varInfo) {
+	if !f.IsVarAnnotateEnum(varInfo) {
 		return
 	}
 
@@ -134,8 +134,8 @@ func (f *FileStruct) checkVarAnnotateEnum(varInfo *common.VarInfo) {
 	}
 }
 
-// 判断变量关联的注解是否有标明为枚举的类型
-func (f *FileStruct) isVarAnnotateEnum(varInfo *common.VarInfo) bool {
+// IsVarAnnotateEnum 判断变量关联的注解是否有标明为枚举的类型
+func (f *FileStruct) IsVarAnnotateEnum(varInfo *common.VarInfo) bool {
 	fragmentInfo := f.AnnotateFile.GetLineFragementInfo(varInfo.Loc.StartLine - 1)
 	if fragmentInfo == nil || fragmentInfo.TypeInfo == nil {
 		return false
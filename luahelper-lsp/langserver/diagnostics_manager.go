@@ -16,7 +16,7 @@ func (l *LspServer) pushFileErrList(ctx context.Context, strFile string, fileErr
 	diagnostics.URI = lspcommon.GetFileDocumentURI(strFile)
 	diagnostics.Diagnostics = []lsp.Diagnostic{}
 	for _, oneErr := range fileErrVec {
-		diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+		diagnostics.Diagnostics = append(diagnostics.Diagnostics, l.changeErrToDiagnostic(&oneErr))
 	}
 
 	// 发送单个文件的诊断信息
@@ -110,7 +110,7 @@ func (l *LspServer) pushFileChangeDiagnostic(ctx context.Context, strFile string
 	diagnostics.URI = lspcommon.GetFileDocumentURI(strFile)
 	diagnostics.Diagnostics = []lsp.Diagnostic{}
 	for _, oneErr := range errList {
-		diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+		diagnostics.Diagnostics = append(diagnostics.Diagnostics, l.changeErrToDiagnostic(&oneErr))
 	}
 
 	// 发送单个文件的诊断信息
@@ -133,7 +133,7 @@ func (l *LspServer) pushFileDiagnostic(ctx context.Context, strFile string, igno
 		if oneErr.ErrType == common.CheckErrorSyntax && ignoreSyntax {
 			continue
 		}
-		diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+		diagnostics.Diagnostics = append(diagnostics.Diagnostics, l.changeErrToDiagnostic(&oneErr))
 	}
 
 	// 发送单个文件的诊断信息
@@ -201,7 +201,7 @@ func (l *LspServer) pushAllChangeFileDiagnosticErr(ctx context.Context) {
 }
 
 // changeErrToDiagnostic 该文件为所有分析文件的诊断管理
-func changeErrToDiagnostic(checkErr *common.CheckError) lsp.Diagnostic {
+func (l *LspServer) changeErrToDiagnostic(checkErr *common.CheckError) lsp.Diagnostic {
 	var diagnostic lsp.Diagnostic
 	if checkErr.ErrType == common.CheckErrorSyntax {
 		diagnostic.Severity = lsp.SeverityError
@@ -219,7 +219,7 @@ func changeErrToDiagnostic(checkErr *common.CheckError) lsp.Diagnostic {
 
 	diagnostic.Message = strPre + checkErr.ErrStr
 
-	if checkErr.EntryFile != "" && !common.GConfig.IsHasProjectEntryFile() {
+	if checkErr.EntryFile != "" && !l.conf.IsHasProjectEntryFile() {
 		if checkErr.EntryFile == "common project" {
 			diagnostic.Message = strPre + checkErr.ErrStr + ". <" + checkErr.EntryFile + ">"
 		} else {
@@ -238,6 +238,11 @@ func changeErrToDiagnostic(checkErr *common.CheckError) lsp.Diagnostic {
 		diagnostic.RelatedInformation = append(diagnostic.RelatedInformation, oneRelateLsp)
 	}
 
+	// 不会执行到的代码与没有被引用的全局函数，客户端淡化显示
+	if checkErr.ErrType == common.CheckErrorUnreachable || checkErr.ErrType == common.CheckErrorGlobalFuncNotCall {
+		diagnostic.Tags = []lsp.DiagnosticTag{lsp.Unnecessary}
+	}
+
 	diagnostic.Range = lspcommon.LocToRange(&checkErr.Loc)
 	return diagnostic
 }
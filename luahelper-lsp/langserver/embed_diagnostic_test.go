@@ -0,0 +1,131 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/yinfei8/jrpc2"
+)
+
+// waitDiagnostics 等待服务器推送诊断信息，直到expectMap中每个文件的第一个告警都在期望的行
+// 客户端的通知在不同的协程中回调，到达的顺序不确定，因此一直等到推送的告警符合期望或是超时
+func waitDiagnostics(t *testing.T, diagChan chan lsp.PublishDiagnosticsParams, expectMap map[string]int) {
+	lineMap := map[string][]int{}
+	isExpect := func() bool {
+		for strName, line := range expectMap {
+			if len(lineMap[strName]) == 0 || lineMap[strName][0] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	timeout := time.After(10 * time.Second)
+	for !isExpect() {
+		select {
+		case params := <-diagChan:
+			var lineVec []int
+			for _, diagnostic := range params.Diagnostics {
+				lineVec = append(lineVec, int(diagnostic.Range.Start.Line))
+			}
+			lineMap[path.Base(string(params.URI))] = lineVec
+		case <-timeout:
+			t.Fatalf("wait diagnostics timeout, received=%v, expect=%v", lineMap, expectMap)
+		}
+	}
+}
+
+// 客户端没有配置嵌入的文件关联时，默认分析 .conf、.md、.html 中嵌入的lua代码，告警的行号为原文件中的行号
+func TestEmbedLuaDiagnostics(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/embed"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+
+	diagChan := make(chan lsp.PublishDiagnosticsParams, 64)
+	_, client := startLspTestServer(t, &jrpc2.ClientOptions{
+		OnNotify: func(req *jrpc2.Request) {
+			if req.Method() != "textDocument/publishDiagnostics" {
+				return
+			}
+
+			var params lsp.PublishDiagnosticsParams
+			if req.UnmarshalParams(&params) != nil {
+				return
+			}
+
+			// 回调时持有客户端的锁，不能阻塞
+			select {
+			case diagChan <- params:
+			default:
+			}
+		},
+	})
+
+	ctx := context.Background()
+	initializeParams := InitializeParams{
+		InitializeParams: lsp.InitializeParams{
+			InnerInitializeParams: lsp.InnerInitializeParams{
+				RootPath: strRootPath,
+				RootURI:  lsp.DocumentURI(strRootURI),
+			},
+		},
+		InitializationOptions: &InitializationOptions{
+			AllEnable:   true,
+			CheckSyntax: true,
+		},
+	}
+	if _, err := client.Call(ctx, "initialize", initializeParams); err != nil {
+		t.Fatalf("initialize err=%s", err.Error())
+	}
+	if err := client.Notify(ctx, "initialized", InitializedParams{}); err != nil {
+		t.Fatalf("initialized err=%s", err.Error())
+	}
+
+	waitDiagnostics(t, diagChan, map[string]int{
+		"nginx.conf": 6,
+		"readme.md":  6,
+		"index.html": 5,
+	})
+
+	// 打开并修改nginx配置，实时分析的告警也对应到原文件的行
+	fileName := strRootPath + "/nginx.conf"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	fileURI := lsp.DocumentURI("file://" + fileName)
+	client.Notify(ctx, "textDocument/didOpen", lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  fileURI,
+			Text: string(data),
+		},
+	})
+	client.Notify(ctx, "textDocument/didChange", lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: lsp.TextDocumentIdentifier{
+				URI: fileURI,
+			},
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{
+				Range: &lsp.Range{
+					Start: lsp.Position{Line: 6, Character: 0},
+					End:   lsp.Position{Line: 6, Character: 0},
+				},
+				Text: "                local other = 1\n",
+			},
+		},
+	})
+
+	waitDiagnostics(t, diagChan, map[string]int{"nginx.conf": 7})
+}
@@ -0,0 +1,25 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFieldNotWriteCheck(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/fieldwrite"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+
+	// t.y 在整个工程中都没有赋值过，t.size 在other.lua中赋值过
+	// d 的构造表达式中有非字符串的key，函数的参数p，都无法确定有哪些成员，不告警
+	assertFileErrors(t, lspServer, []common.CheckErrorType{common.CheckErrorFieldNotWrite}, map[string][]checkErrorLine{
+		"fieldwrite.lua": {{4, common.CheckErrorFieldNotWrite}},
+		"other.lua":      nil,
+	})
+}
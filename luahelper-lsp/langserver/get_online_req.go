@@ -3,13 +3,16 @@ package langserver
 import (
 	"context"
 	"encoding/json"
-	"net"
 	"time"
 
 	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/telemetry"
 )
 
-// 客户端获取当前查找在线人数，查找会去中心服上查询所有在线人数
+// 客户端获取当前查找在线人数，只有配置了统计上报的地址，才会上报并获取在线人数
+
+// 每120秒上报一次
+const telemetryReportInterval = 120 * time.Second
 
 // GetOnlineParams 全局颜色配置
 type GetOnlineParams struct {
@@ -18,69 +21,81 @@ type GetOnlineParams struct {
 
 // GetOnlineReturn 在线的返回
 type GetOnlineReturn struct {
-	Num int `json:"Num"` // 所有在线的人数
+	Num     int    `json:"Num"`            // 所有在线的人数，上报的方式不支持时为0
+	Enabled bool   `json:"Enabled"`        // 是否开启了统计上报
+	Sink    string `json:"Sink,omitempty"` // 上报的方式，例如 udp、file
 }
 
-// 插件在线人数
-var onlinePeopleNum int = 0
-
 // GetOnlineReq 获取当前所有在线人数的接口
 func (l *LspServer) GetOnlineReq(ctx context.Context, vs GetOnlineParams) (onlineReturn GetOnlineReturn, err error) {
-	log.Debug("GetOnlineReq num=%d", onlinePeopleNum)
-	onlineReturn.Num = onlinePeopleNum
+	sink := l.getTelemetrySink()
+	if sink == nil {
+		log.Debug("GetOnlineReq telemetry is disabled")
+		return
+	}
+
+	onlineReturn.Enabled = true
+	onlineReturn.Sink = sink.Name()
+	if counter, ok := sink.(telemetry.OnlineCounter); ok {
+		onlineReturn.Num = counter.OnlineNum()
+	}
+	log.Debug("GetOnlineReq num=%d, sink=%s", onlineReturn.Num, onlineReturn.Sink)
 	return
 }
 
-// UDPReportOnline 上报协程
-func (l *LspServer) UDPReportOnline() {
-	log.Debug("UDPReportOnline \n")
-	conn, err := net.Dial("udp", "42.194.136.76:7778") //打开监听端口
+// getTelemetrySink 获取统计上报的Sink，没有开启时返回nil
+func (l *LspServer) getTelemetrySink() telemetry.Sink {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
+	return l.telemetrySink
+}
+
+// setTelemetryEndpoint 设置统计上报的地址，地址为空时关闭上报
+func (l *LspServer) setTelemetryEndpoint(endpoint string) {
+	sink, err := telemetry.CreateSink(endpoint)
 	if err != nil {
-		log.Error("conn fail...")
-		return
+		log.Error("create telemetry sink err=%s", err.Error())
 	}
-	
-	defer conn.Close()
-	log.Debug("client connect server successed \n")
 
-	//创建协程，收取udp的在线回包数据
-	go handleRecv(conn)
+	l.reportMutex.Lock()
+	oldSink := l.telemetrySink
+	l.telemetrySink = sink
+	l.reportMutex.Unlock()
 
+	if oldSink != nil {
+		oldSink.Close()
+	}
+}
+
+// closeTelemetry 关闭统计上报，上报的协程也会退出
+func (l *LspServer) closeTelemetry() {
+	l.setTelemetryEndpoint("")
+}
+
+// runTelemetryReport 上报协程，没有开启上报或是上报关闭后退出
+func (l *LspServer) runTelemetryReport() {
 	l.SetReportOtherInfo()
 
 	for {
-		if l.enableReport {
-			user := l.GetOnlineReportData()
-			info, err := json.Marshal(user)
-			if err != nil {
-				log.Error("report Data err=%s", err.Error())
-			} else {
-				conn.Write(info)
-			}
-			l.SetFirstReportFlag(0)
+		sink := l.getTelemetrySink()
+		if sink == nil {
+			log.Debug("telemetry report exit")
+			return
 		}
 
-		// 每120秒上报一次
-		time.Sleep(time.Second * 120)
+		l.sendTelemetryReport(sink)
+		time.Sleep(telemetryReportInterval)
 	}
 }
 
-// 处理udp的回包，获取在线人数
-func handleRecv(conn net.Conn) {
-	var reportReturn OnlineReportReturn
-	data := make([]byte, 2048)
-	for {
-		msgRead, err := conn.Read(data) //将读取的字节流赋值给msg_read和err
-		if msgRead == 0 || err != nil { //如果字节流为0或者有错误
-			continue
-		}
-
-		err1 := json.Unmarshal(data[0:msgRead], &reportReturn)
-		if err1 != nil {
-			log.Debug("handleRecv error:%s", err1.Error())
-		} else {
-			log.Debug("handleRecv ok, num=%d", reportReturn.Num)
-			onlinePeopleNum = reportReturn.Num
-		}
+// sendTelemetryReport 上报一次统计信息
+func (l *LspServer) sendTelemetryReport(sink telemetry.Sink) {
+	user := l.GetOnlineReportData()
+	info, err := json.Marshal(user)
+	if err != nil {
+		log.Error("report Data err=%s", err.Error())
+	} else if err := sink.Send(info); err != nil {
+		log.Debug("report Data send err=%s", err.Error())
 	}
+	l.SetFirstReportFlag(0)
 }
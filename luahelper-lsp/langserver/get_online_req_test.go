@@ -0,0 +1,74 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTelemetryDisabledByDefault(t *testing.T) {
+	lspServer := CreateLspServer()
+	if lspServer.getTelemetrySink() != nil {
+		t.Fatalf("telemetry sink is created without endpoint")
+	}
+
+	onlineReturn, _ := lspServer.GetOnlineReq(context.Background(), GetOnlineParams{})
+	if onlineReturn.Enabled || onlineReturn.Sink != "" || onlineReturn.Num != 0 {
+		t.Fatalf("getOnlineReq=%v, expect disabled", onlineReturn)
+	}
+
+	// 不支持的地址，不上报
+	lspServer.setTelemetryEndpoint("http://127.0.0.1:7778")
+	if lspServer.getTelemetrySink() != nil {
+		t.Fatalf("telemetry sink is created with unsupported endpoint")
+	}
+}
+
+func TestTelemetryFileSink(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "telemetry")
+	if err != nil {
+		t.Fatalf("create temp dir err=%s", err.Error())
+	}
+	defer os.RemoveAll(tempDir)
+
+	strFile := filepath.Join(tempDir, "report.log")
+	lspServer := CreateLspServer()
+	lspServer.setTelemetryEndpoint("file://" + filepath.ToSlash(strFile))
+
+	onlineReturn, _ := lspServer.GetOnlineReq(context.Background(), GetOnlineParams{})
+	if !onlineReturn.Enabled || onlineReturn.Sink != "file" {
+		t.Fatalf("getOnlineReq=%v, expect file sink", onlineReturn)
+	}
+
+	lspServer.SetOnlineReportParam("vsc", 10, 20, 1)
+	lspServer.sendTelemetryReport(lspServer.getTelemetrySink())
+	lspServer.sendTelemetryReport(lspServer.getTelemetrySink())
+	lspServer.closeTelemetry()
+
+	data, err := ioutil.ReadFile(strFile)
+	if err != nil {
+		t.Fatalf("read report file err=%s", err.Error())
+	}
+
+	lineVec := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lineVec) != 2 {
+		t.Fatalf("report line num=%d, expect=2", len(lineVec))
+	}
+
+	var report OnlineReport
+	if err := json.Unmarshal([]byte(lineVec[1]), &report); err != nil {
+		t.Fatalf("unmarshal report err=%s", err.Error())
+	}
+	if report.FileNumber != 10 || report.FirstReport != 0 {
+		t.Fatalf("report=%v, expect FileNumber=10 FirstReport=0", report)
+	}
+
+	onlineReturn, _ = lspServer.GetOnlineReq(context.Background(), GetOnlineParams{})
+	if onlineReturn.Enabled {
+		t.Fatalf("telemetry is enabled after close")
+	}
+}
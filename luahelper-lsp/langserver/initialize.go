@@ -3,7 +3,7 @@ package langserver
 import (
 	"context"
 	"luahelper-lsp/langserver/check"
-	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/parser"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/pathpre"
 	lsp "luahelper-lsp/langserver/protocol"
@@ -20,36 +20,48 @@ type InitializationOptions struct {
 	LocalRun               bool        `json:"LocalRun,omitempty"`
 	FileAssociationsConfig interface{} `json:"FileAssociationsConfig,omitempty"`
 
-	AllEnable                      bool     `json:"AllEnable,omitempty"`
-	CheckSyntax                    bool     `json:"CheckSyntax,omitempty"`
-	CheckNoDefine                  bool     `json:"CheckNoDefine,omitempty"`
-	CheckAfterDefine               bool     `json:"CheckAfterDefine,omitempty"`
-	CheckLocalNoUse                bool     `json:"CheckLocalNoUse,omitempty"`
-	CheckTableDuplicateKey         bool     `json:"CheckTableDuplicateKey,omitempty"`
-	CheckReferNoFile               bool     `json:"CheckReferNoFile,omitempty"`
-	CheckAssignParamNum            bool     `json:"CheckAssignParamNum,omitempty"`
-	CheckLocalDefineParamNum       bool     `json:"CheckLocalDefineParamNum,omitempty"`
-	CheckGotoLable                 bool     `json:"CheckGotoLable,omitempty"`
-	CheckFuncParam                 bool     `json:"CheckFuncParam,omitempty"`
-	CheckImportModuleVar           bool     `json:"CheckImportModuleVar,omitempty"`
-	CheckIfNotVar                  bool     `json:"CheckIfNotVar,omitempty"`
-	CheckFunctionDuplicateParam    bool     `json:"CheckFunctionDuplicateParam,omitempty"`
-	CheckBinaryExpressionDuplicate bool     `json:"CheckBinaryExpressionDuplicate,omitempty"`
-	CheckErrorOrAlwaysTrue         bool     `json:"CheckErrorOrAlwaysTrue,omitempty"`
-	CheckErrorAndAlwaysFalse       bool     `json:"CheckErrorAndAlwaysFalse,omitempty"`
-	CheckNoUseAssign               bool     `json:"CheckNoUseAssign,omitempty"`
-	CheckAnnotateType              bool     `json:"CheckAnnotateType,omitempty"`
-	CheckDuplicateIf               bool     `json:"CheckDuplicateIf,omitempty"`
-	CheckSelfAssign                bool     `json:"CheckSelfAssign,omitempty"`
-	CheckFloatEq                   bool     `json:"CheckFloatEq,omitempty"`
-	CheckClassField                bool     `json:"CheckClassField,omitempty"`
-	CheckConstAssign               bool     `json:"CheckConstAssign,omitempty"`
-	CheckFuncParamType             bool     `json:"CheckFuncParamType,omitempty"`
-	CheckFuncReturnType            bool     `json:"CheckFuncReturnType,omitempty"`
-	IgnoreFileOrDir                []string `json:"IgnoreFileOrDir,omitempty"`
-	IgnoreFileOrDirError           []string `json:"IgnoreFileOrDirError,omitempty"`
-	RequirePathSeparator           string   `json:"RequirePathSeparator,omitempty"`
-	EnableReport                   bool     `json:"EnableReport,omitempty"`
+	AllEnable                      bool              `json:"AllEnable,omitempty"`
+	CheckSyntax                    bool              `json:"CheckSyntax,omitempty"`
+	CheckNoDefine                  bool              `json:"CheckNoDefine,omitempty"`
+	CheckAfterDefine               bool              `json:"CheckAfterDefine,omitempty"`
+	CheckLocalNoUse                bool              `json:"CheckLocalNoUse,omitempty"`
+	CheckTableDuplicateKey         bool              `json:"CheckTableDuplicateKey,omitempty"`
+	CheckReferNoFile               bool              `json:"CheckReferNoFile,omitempty"`
+	CheckAssignParamNum            bool              `json:"CheckAssignParamNum,omitempty"`
+	CheckLocalDefineParamNum       bool              `json:"CheckLocalDefineParamNum,omitempty"`
+	CheckGotoLable                 bool              `json:"CheckGotoLable,omitempty"`
+	CheckFuncParam                 bool              `json:"CheckFuncParam,omitempty"`
+	CheckImportModuleVar           bool              `json:"CheckImportModuleVar,omitempty"`
+	CheckIfNotVar                  bool              `json:"CheckIfNotVar,omitempty"`
+	CheckFunctionDuplicateParam    bool              `json:"CheckFunctionDuplicateParam,omitempty"`
+	CheckBinaryExpressionDuplicate bool              `json:"CheckBinaryExpressionDuplicate,omitempty"`
+	CheckErrorOrAlwaysTrue         bool              `json:"CheckErrorOrAlwaysTrue,omitempty"`
+	CheckErrorAndAlwaysFalse       bool              `json:"CheckErrorAndAlwaysFalse,omitempty"`
+	CheckNoUseAssign               bool              `json:"CheckNoUseAssign,omitempty"`
+	CheckAnnotateType              bool              `json:"CheckAnnotateType,omitempty"`
+	CheckDuplicateIf               bool              `json:"CheckDuplicateIf,omitempty"`
+	CheckSelfAssign                bool              `json:"CheckSelfAssign,omitempty"`
+	CheckFloatEq                   bool              `json:"CheckFloatEq,omitempty"`
+	CheckClassField                bool              `json:"CheckClassField,omitempty"`
+	CheckConstAssign               bool              `json:"CheckConstAssign,omitempty"`
+	CheckFuncParamType             bool              `json:"CheckFuncParamType,omitempty"`
+	CheckFuncReturnType            bool              `json:"CheckFuncReturnType,omitempty"`
+	CheckMoocExtension             bool              `json:"CheckMoocExtension,omitempty"`
+	CheckMoocDuplicateMethod       bool              `json:"CheckMoocDuplicateMethod,omitempty"`
+	CheckMoocSelfSuper             bool              `json:"CheckMoocSelfSuper,omitempty"`
+	CheckMoocImportNum             bool              `json:"CheckMoocImportNum,omitempty"`
+	CheckMoocUnreachable           bool              `json:"CheckMoocUnreachable,omitempty"`
+	CheckMoocDuplicateCase         bool              `json:"CheckMoocDuplicateCase,omitempty"`
+	CheckUnreachable               bool              `json:"CheckUnreachable,omitempty"`
+	CheckLocalRedeclare            bool              `json:"CheckLocalRedeclare,omitempty"`
+	CheckShadowVar                 bool              `json:"CheckShadowVar,omitempty"`
+	CheckShadowSys                 bool              `json:"CheckShadowSys,omitempty"`
+	CheckLoopVarCapture            bool              `json:"CheckLoopVarCapture,omitempty"`
+	IgnoreFileOrDir                []string          `json:"IgnoreFileOrDir,omitempty"`
+	EmbedAssociationsConfig        map[string]string `json:"EmbedAssociationsConfig,omitempty"`
+	IgnoreFileOrDirError           []string          `json:"IgnoreFileOrDirError,omitempty"`
+	RequirePathSeparator           string            `json:"RequirePathSeparator,omitempty"`
+	TelemetryEndpoint              string            `json:"TelemetryEndpoint,omitempty"`
 }
 
 // InitializeParams 初始化参数
@@ -65,11 +77,13 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 		user.Current()
 	}()
 
-	pathpre.InitialRootURIAndPath(string(vs.RootURI), string(vs.RootPath))
-
 	log.Debug("Initialize ..., rootDir=%s, rooturl=%s", vs.RootPath, vs.RootURI)
+	l.setTraceValue(vs.Trace)
+	l.setWorkspaceSymbolCapabilities(&vs.Capabilities.Workspace.Symbol)
+	l.applyEditSupport = vs.Capabilities.Workspace.ApplyEdit
+
 	vscodeRoot := pathpre.VscodeURIToString(string(vs.RootURI))
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	dirManager.SetVSRootDir(vscodeRoot)
 
 	workspaceFolderNum := len(vs.WorkspaceFolders)
@@ -80,14 +94,19 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 	}
 	dirManager.SetClientPluginPath(initOptions.PluginPath)
 
-	// 初始化时获取其他后缀关联到的lua
-	associalList := getInitAssociationList(initOptions.FileAssociationsConfig)
-	log.Debug("associalList len:%d", len(associalList))
-	common.GConfig.SetAssocialList(associalList)
+	// 初始化时获取其他后缀关联到的lua，以及关联到的lua方言
+	associalList, dialectMap := getInitAssociationList(initOptions.FileAssociationsConfig)
+	dialectMap = mergeEmbedAssociations(initOptions.EmbedAssociationsConfig, dialectMap)
+	log.Debug("associalList len:%d, dialectMap len:%d", len(associalList), len(dialectMap))
+	l.conf.SetAssocialList(associalList)
+	l.conf.SetClientDialectMap(dialectMap)
 
 	// 按顺序插入
 	checkFlagList := getCheckFlagList(initOptions)
 
+	// 只有显式配置了上报的地址，才会上报统计信息
+	l.setTelemetryEndpoint(initOptions.TelemetryEndpoint)
+
 	initErr := l.initialCheckProject(ctx, checkFlagList, initOptions.Client, workspaceFolderNum, vs.WorkspaceFolders,
 		initOptions.LocalRun, initOptions.IgnoreFileOrDir, initOptions.IgnoreFileOrDirError)
 	if initErr != nil {
@@ -97,8 +116,7 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 	log.Debug("initial luahelper ok")
 
 	// 设置require其他lua文件的路径分割
-	common.GConfig.SetRequirePathSeparator(initOptions.RequirePathSeparator)
-	l.enableReport = initOptions.EnableReport
+	l.conf.SetRequirePathSeparator(initOptions.RequirePathSeparator)
 
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
@@ -116,17 +134,22 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 					TriggerCharacters: []string{".", "\"", "'", ":", "-", "@", "#", " "},
 					//AllCommitCharacters:[]string{".", "\"", "'", ":", "-", "@"},
 				},
-				ColorProvider:           false,
-				HoverProvider:           true,
-				WorkspaceSymbolProvider: true,
-				DefinitionProvider:      true,
-				ReferencesProvider:      true,
-				DocumentSymbolProvider:  true,
+				ColorProvider: false,
+				HoverProvider: true,
+				WorkspaceSymbolProvider: lsp.WorkspaceSymbolOptions{
+					ResolveProvider: true,
+				},
+				DefinitionProvider:     true,
+				ReferencesProvider:     true,
+				DocumentSymbolProvider: true,
 				SignatureHelpProvider: lsp.SignatureHelpOptions{
 					TriggerCharacters: []string{"(", ","},
 				},
 				CodeLensProvider: lsp.CodeLensOptions{
-					ResolveProvider: false,
+					ResolveProvider: true,
+				},
+				ExecuteCommandProvider: lsp.ExecuteCommandOptions{
+					Commands: serverCommandList,
 				},
 				DocumentLinkProvider: lsp.DocumentLinkOptions{
 					ResolveProvider: false,
@@ -158,26 +181,26 @@ func (l *LspServer) initialCheckProject(ctx context.Context, checkFlagList []boo
 	workspaceFolderNum int, workspaceFolder []lsp.WorkspaceFolder, isLocal bool, ignoreFileOrDir []string,
 	ignoreFileOrDirErr []string) error {
 	// 目录管理统一设置vscodeRoot目录
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	vscodeRoot := dirManager.GetVsRootDir()
 	timeBegin := time.Now()
 
 	// 尝试读取vscode目录下面的luahelper.json工程配置文件
-	if readErr := common.GConfig.ReadConfig(vscodeRoot, ".vscode/luahelper.json", checkFlagList, ignoreFileOrDir,
+	if readErr := l.conf.ReadConfig(vscodeRoot, ".vscode/luahelper.json", checkFlagList, ignoreFileOrDir,
 		ignoreFileOrDirErr); readErr != nil {
 		return readErr
 	}
 
 	if isLocal {
 		// 为本地运行，没有插件前端，插件前端无法传递额外的Lua文件夹，忽略系统的模块和变量
-		common.GConfig.InsertIngoreSystemModule()
+		l.conf.InsertIngoreSystemModule()
 	}
 
 	// 加入 mooc 忽略的全局变量名
-	common.GConfig.MoocInsertIngoreSystemModule()
+	l.conf.MoocInsertIngoreSystemModule()
 
 	//  忽略系统的注解类型
-	common.GConfig.InsertIngoreSystemAnnotateType()
+	l.conf.InsertIngoreSystemAnnotateType()
 
 	dirManager.InitMainDir()
 	for _, oneFloder := range workspaceFolder {
@@ -208,11 +231,11 @@ func (l *LspServer) initialCheckProject(ctx context.Context, checkFlagList []boo
 
 	// 补全所有的入口文件
 	var entryFileList []string
-	for _, luaFile := range common.GConfig.ProjectFiles {
+	for _, luaFile := range l.conf.ProjectFiles {
 		luaFile = pathpre.GetRemovePreStr(luaFile)
 		entryFileList = append(entryFileList, dirManager.GetCompletePath(mainDir, luaFile))
 	}
-	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList)
+	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList, l.conf)
 	allProject.HandleCheck()
 
 	// 工程路径变量设置到Glsp侧
@@ -222,13 +245,16 @@ func (l *LspServer) initialCheckProject(ctx context.Context, checkFlagList []boo
 	// 设置向中心服需要统计的信息
 	l.SetOnlineReportParam(clientType, allProject.GetAllFileNumber(), (int)(costMsTime), workspaceFolderNum)
 
-	// 启动一个协程来向中心服务器上报信息
-	go l.UDPReportOnline()
+	// 开启了统计上报时，启动一个协程来上报信息
+	if l.getTelemetrySink() != nil {
+		log.Go(l.runTelemetryReport)
+	}
 	return nil
 }
 
 // 初始化时，获取其他后缀关联到的lua 类型  (associalList string[])
-func getInitAssociationList(associationInitData interface{}) (associalList []string) {
+// 关联到注册了的lua方言时，例如关联到luau，同时返回文件匹配模式对应的方言
+func getInitAssociationList(associationInitData interface{}) (associalList []string, dialectMap map[string]string) {
 	assMap, flag := associationInitData.(map[string]interface{})
 	if !flag {
 		return
@@ -241,16 +267,47 @@ func getInitAssociationList(associationInitData interface{}) (associalList []str
 		}
 
 		strValue = strings.ToLower(strValue)
-		if strValue != "lua" {
+		if strValue != "lua" && !parser.IsDialect(strValue) {
 			continue
 		}
 
 		associalList = append(associalList, strType)
+		if strValue != "lua" {
+			if dialectMap == nil {
+				dialectMap = map[string]string{}
+			}
+			dialectMap[strType] = strValue
+		}
 	}
 
 	return
 }
 
+// defaultEmbedAssociations 客户端没有配置时，默认分析嵌入了lua代码的文件
+var defaultEmbedAssociations = map[string]string{
+	"*.conf": parser.DialectNginx,
+	"*.md":   parser.DialectMarkdown,
+	"*.html": parser.DialectHTML,
+}
+
+// mergeEmbedAssociations 合并嵌入了lua代码的文件关联与files.associations中关联到的方言，files.associations中的配置优先
+// embedMap 为nil表示客户端没有配置，使用默认的关联；配置为空时不分析嵌入的lua代码
+func mergeEmbedAssociations(embedMap map[string]string, dialectMap map[string]string) map[string]string {
+	if embedMap == nil {
+		embedMap = defaultEmbedAssociations
+	}
+
+	mergeMap := map[string]string{}
+	for pattern, name := range embedMap {
+		mergeMap[pattern] = strings.ToLower(name)
+	}
+	for pattern, name := range dialectMap {
+		mergeMap[pattern] = name
+	}
+
+	return mergeMap
+}
+
 // getDefaultIntialOptions 获取默认的初始化参数
 func getDefaultIntialOptions() (initOptions *InitializationOptions) {
 	initOptions = &InitializationOptions{
@@ -282,6 +339,17 @@ func getDefaultIntialOptions() (initOptions *InitializationOptions) {
 		CheckConstAssign:               false,
 		CheckFuncParamType:             false,
 		CheckFuncReturnType:            false,
+		CheckMoocExtension:             false,
+		CheckMoocDuplicateMethod:       false,
+		CheckMoocSelfSuper:             false,
+		CheckMoocImportNum:             false,
+		CheckMoocUnreachable:           false,
+		CheckMoocDuplicateCase:         false,
+		CheckUnreachable:               false,
+		CheckLocalRedeclare:            false,
+		CheckShadowVar:                 false,
+		CheckShadowSys:                 false,
+		CheckLoopVarCapture:            false,
 	}
 
 	return initOptions
@@ -317,6 +385,23 @@ func getCheckFlagList(initOptions *InitializationOptions) (checkFlagList []bool)
 		initOptions.CheckConstAssign,
 		initOptions.CheckFuncParamType,
 		initOptions.CheckFuncReturnType,
+		false, // CheckErrorAssignType，只能在luahelper.json中开启
+		false, // CheckErrorBinopType，只能在luahelper.json中开启
+		false, // CheckErrorLocFuncNotCall，只能在luahelper.json中开启
+		false, // CheckErrorEnumValue，只能在luahelper.json中开启
+		false, // CheckErrorFieldNotWrite，只能在luahelper.json中开启
+		initOptions.CheckMoocExtension,
+		initOptions.CheckMoocDuplicateMethod,
+		initOptions.CheckMoocSelfSuper,
+		initOptions.CheckMoocImportNum,
+		initOptions.CheckMoocUnreachable,
+		initOptions.CheckMoocDuplicateCase,
+		initOptions.CheckUnreachable,
+		false, // CheckErrorGlobalFuncNotCall，只能在luahelper.json中开启
+		initOptions.CheckLocalRedeclare,
+		initOptions.CheckShadowVar,
+		initOptions.CheckShadowSys,
+		initOptions.CheckLoopVarCapture,
 	}
 
 	return checkFlagList
@@ -1,45 +1,154 @@
 package log
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 var (
-	debugLog *log.Logger
-	errorLog *log.Logger
-	LspLog   *log.Logger
+	LspLog *log.Logger
 )
 
 type logger = func(string, ...interface{})
 
 var GFileLog *os.File = nil
 
-// logFlag 为true表示开启日志
-func InitLog(logFlag bool) {
-	if !logFlag {
-		debugLog = nil
-		errorLog = nil
-		LspLog = nil
+// Level 日志的级别，只输出不低于设置级别的日志
+type Level int
+
+const (
+	LevelDebug Level = iota // 输出所有的日志
+	LevelInfo               // 输出普通的信息
+	LevelWarn               // 输出警告与错误
+	LevelError              // 只输出错误日志
+	LevelOff                // 关闭日志
+)
+
+// levelNames 日志级别的名称
+var levelNames = [...]string{"debug", "info", "warn", "error", "off"}
+
+// String 日志级别的名称
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelOff {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel 把字符串转换为日志的级别，支持 debug、info、warn、error、off
+func ParseLevel(str string) (Level, error) {
+	switch strings.ToLower(str) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "off", "none":
+		return LevelOff, nil
+	}
+
+	return LevelOff, fmt.Errorf("unknown log level: %s", str)
+}
+
+// Format 日志每一行的格式
+type Format int
+
+const (
+	FormatText   Format = iota // Debug 2006/01/02 15:04:05.000000 main.go:10: msg key=value
+	FormatJSON                 // {"time":"...","level":"debug","caller":"main.go:10","msg":"msg","key":"value"}
+	FormatLogfmt               // time=... level=debug caller=main.go:10 msg=msg key=value
+)
+
+// ParseFormat 把字符串转换为日志的格式，支持 text、json、logfmt
+func ParseFormat(str string) (Format, error) {
+	switch strings.ToLower(str) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	}
+
+	return FormatText, fmt.Errorf("unknown log format: %s", str)
+}
+
+// Entry 一条日志记录，Fields 为 key1, value1, key2, value2 ... 的结构化字段
+// Session 为日志所属的客户端连接，默认为输出日志的协程绑定的连接（见 BindSession），0表示不属于任何连接的公共日志
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Caller  string
+	Msg     string
+	Fields  []interface{}
+	Session int
+}
+
+// listenerInfo 日志的监听者，例如把日志转发给客户端的 window/logMessage
+type listenerInfo struct {
+	level Level
+	fn    func(entry *Entry)
+}
+
+// logState 日志的全局状态
+type logState struct {
+	mutex       sync.RWMutex
+	writeMutex  sync.Mutex // 多个协程同时写入时，每一行不会交错
+	writer      io.Writer
+	level       Level
+	format      Format
+	listenerID  int
+	listenerMap map[int]listenerInfo
+	minLevel    Level // 文件与监听者中最低的级别，低于该级别的日志直接丢弃
+}
+
+var gState = &logState{
+	level:       LevelOff,
+	listenerMap: map[int]listenerInfo{},
+	minLevel:    LevelOff,
+}
+
+// InitLog 初始化日志，logFile 为日志文件的路径，为 - 时输出到标准错误
+func InitLog(logFile string, level Level, format Format) error {
+	LspLog = nil
+	if level == LevelOff {
 		log.SetFlags(0)
-		return
+		gState.setWriter(nil, LevelOff, format)
+		return nil
 	}
 
 	log.SetFlags(log.Ldate | log.Lmicroseconds | log.Llongfile)
-	fileLog, err := os.OpenFile("log.txt", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 
-	if err != nil {
-		log.Fatalln("failed to open log file:", err)
-		return
+	var writer io.Writer = os.Stderr
+	if logFile != "-" {
+		fileLog, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %s", err.Error())
+		}
+
+		writer = fileLog
+		GFileLog = fileLog
 	}
 
-	debugLog = log.New(io.MultiWriter(fileLog), "Debug ", log.Ldate|log.Lmicroseconds|log.Lshortfile)
-	errorLog = log.New(io.MultiWriter(fileLog), "Error ", log.Ldate|log.Lmicroseconds|log.Lshortfile)
+	// jrpc2内部的日志只写入文件，不转发给监听者，防止转发的通知又产生日志
+	if level <= LevelDebug {
+		LspLog = log.New(writer, "Debug ", log.Ldate|log.Lmicroseconds|log.Lshortfile)
+	}
 
-	LspLog = debugLog
-	GFileLog = fileLog
+	gState.setWriter(writer, level, format)
+	return nil
 }
 
 // 是否关闭日志
@@ -49,18 +158,229 @@ func CloseLog() {
 	}
 }
 
+// AddListener 增加日志的监听者，不低于level的日志都会回调fn，返回删除该监听者的函数
+// fn 在输出日志的协程中调用，不能阻塞，也不能再输出日志
+func AddListener(level Level, fn func(entry *Entry)) (remove func()) {
+	s := gState
+	s.mutex.Lock()
+	s.listenerID++
+	id := s.listenerID
+	s.listenerMap[id] = listenerInfo{level: level, fn: fn}
+	s.updateMinLevel()
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		delete(s.listenerMap, id)
+		s.updateMinLevel()
+		s.mutex.Unlock()
+	}
+}
+
+// IsLevelEnabled 判断该级别的日志是否会被输出到文件或是监听者
+func IsLevelEnabled(level Level) bool {
+	gState.mutex.RLock()
+	defer gState.mutex.RUnlock()
+	return level != LevelOff && level >= gState.minLevel
+}
+
+// Debug 输出调试日志
 func Debug(format string, v ...interface{}) {
-	if debugLog == nil {
+	output(LevelDebug, format, v)
+}
+
+// Info 输出普通的信息
+func Info(format string, v ...interface{}) {
+	output(LevelInfo, format, v)
+}
+
+// Warn 输出警告
+func Warn(format string, v ...interface{}) {
+	output(LevelWarn, format, v)
+}
+
+// Error 输出错误
+func Error(format string, v ...interface{}) {
+	output(LevelError, format, v)
+}
+
+// Log 输出结构化的日志，keyvals 为 key1, value1, key2, value2 ...
+func Log(level Level, msg string, keyvals ...interface{}) {
+	if !IsLevelEnabled(level) {
 		return
 	}
 
-	debugLog.Output(2, fmt.Sprintf(format, v...))
+	gState.emit(&Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Caller:  getCaller(2),
+		Msg:     msg,
+		Fields:  keyvals,
+		Session: CurSession(),
+	})
 }
 
-func Error(format string, v ...interface{}) {
-	if errorLog == nil {
+// SessionLog 输出属于指定客户端连接的结构化日志，监听者根据Session过滤
+func SessionLog(session int, level Level, msg string, keyvals ...interface{}) {
+	if !IsLevelEnabled(level) {
 		return
 	}
 
-	errorLog.Output(2, fmt.Sprintf(format, v...))
+	gState.emit(&Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Caller:  getCaller(2),
+		Msg:     msg,
+		Fields:  keyvals,
+		Session: session,
+	})
+}
+
+func output(level Level, format string, v []interface{}) {
+	if !IsLevelEnabled(level) {
+		return
+	}
+
+	gState.emit(&Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Caller:  getCaller(3),
+		Msg:     fmt.Sprintf(format, v...),
+		Session: CurSession(),
+	})
+}
+
+// getCaller 获取调用者的文件名与行号
+func getCaller(calldepth int) string {
+	_, file, line, ok := runtime.Caller(calldepth)
+	if !ok {
+		return "???:0"
+	}
+	return filepath.Base(file) + ":" + strconv.Itoa(line)
+}
+
+func (s *logState) setWriter(writer io.Writer, level Level, format Format) {
+	s.mutex.Lock()
+	s.writer = writer
+	s.level = level
+	s.format = format
+	s.updateMinLevel()
+	s.mutex.Unlock()
+}
+
+// updateMinLevel 重新计算最低的级别，调用者需要持有锁
+func (s *logState) updateMinLevel() {
+	s.minLevel = LevelOff
+	if s.writer != nil {
+		s.minLevel = s.level
+	}
+
+	for _, listener := range s.listenerMap {
+		if listener.level < s.minLevel {
+			s.minLevel = listener.level
+		}
+	}
+}
+
+// emit 把一条日志写入文件，并通知监听者
+func (s *logState) emit(entry *Entry) {
+	s.mutex.RLock()
+	var fnVec []func(entry *Entry)
+	for _, listener := range s.listenerMap {
+		if entry.Level >= listener.level {
+			fnVec = append(fnVec, listener.fn)
+		}
+	}
+
+	if s.writer != nil && entry.Level >= s.level {
+		bits := FormatEntry(entry, s.format)
+		s.writeMutex.Lock()
+		s.writer.Write(bits)
+		s.writeMutex.Unlock()
+	}
+	s.mutex.RUnlock()
+
+	for _, fn := range fnVec {
+		fn(entry)
+	}
+}
+
+// FormatEntry 按照格式把日志记录转换为一行，包含结尾的换行
+func FormatEntry(entry *Entry, format Format) []byte {
+	fields := entry.Fields
+	if entry.Session != 0 {
+		fields = append([]interface{}{"session", entry.Session}, fields...)
+	}
+
+	var sb strings.Builder
+	switch format {
+	case FormatJSON:
+		sb.WriteString(`{"time":`)
+		writeJSONValue(&sb, entry.Time.Format(time.RFC3339Nano))
+		sb.WriteString(`,"level":`)
+		writeJSONValue(&sb, entry.Level.String())
+		sb.WriteString(`,"caller":`)
+		writeJSONValue(&sb, entry.Caller)
+		sb.WriteString(`,"msg":`)
+		writeJSONValue(&sb, entry.Msg)
+		forEachField(fields, func(key string, value interface{}) {
+			sb.WriteByte(',')
+			writeJSONValue(&sb, key)
+			sb.WriteByte(':')
+			writeJSONValue(&sb, value)
+		})
+		sb.WriteString("}\n")
+	case FormatLogfmt:
+		sb.WriteString("time=" + entry.Time.Format(time.RFC3339Nano))
+		sb.WriteString(" level=" + entry.Level.String())
+		sb.WriteString(" caller=" + entry.Caller)
+		sb.WriteString(" msg=" + logfmtValue(entry.Msg))
+		forEachField(fields, func(key string, value interface{}) {
+			sb.WriteString(" " + key + "=" + logfmtValue(value))
+		})
+		sb.WriteByte('\n')
+	default:
+		levelStr := entry.Level.String()
+		sb.WriteString(strings.ToUpper(levelStr[:1]) + levelStr[1:] + " ")
+		sb.WriteString(entry.Time.Format("2006/01/02 15:04:05.000000") + " ")
+		sb.WriteString(entry.Caller + ": " + entry.Msg)
+		forEachField(fields, func(key string, value interface{}) {
+			sb.WriteString(" " + key + "=" + logfmtValue(value))
+		})
+		sb.WriteByte('\n')
+	}
+
+	return []byte(sb.String())
+}
+
+// forEachField 遍历结构化的字段，最后落单的值，key为 !BADKEY
+func forEachField(keyvals []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 >= len(keyvals) {
+			fn("!BADKEY", keyvals[i])
+			break
+		}
+		fn(fmt.Sprint(keyvals[i]), keyvals[i+1])
+	}
+}
+
+func writeJSONValue(sb *strings.Builder, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	bits, err := json.Marshal(value)
+	if err != nil {
+		bits, _ = json.Marshal(fmt.Sprint(value))
+	}
+	sb.Write(bits)
+}
+
+// logfmtValue 转换为logfmt的值，包含空格、等号或引号时加上引号
+func logfmtValue(value interface{}) string {
+	str := fmt.Sprint(value)
+	if str == "" || strings.ContainsAny(str, " =\"\t\r\n") {
+		return strconv.Quote(str)
+	}
+	return str
 }
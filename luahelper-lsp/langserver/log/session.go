@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// sessionState 协程与客户端连接的绑定关系
+// 处理客户端请求的协程绑定到该连接后，协程中通过 Debug、Info 等输出的日志都属于这个连接
+// 请求中创建的协程通过 Go 启动，继承创建者绑定的连接
+type sessionState struct {
+	mutex      sync.RWMutex
+	sessionMap map[uint64]int // 协程ID对应的客户端连接
+}
+
+var gSession = &sessionState{
+	sessionMap: map[uint64]int{},
+}
+
+// BindSession 把当前协程绑定到客户端连接session，返回恢复之前绑定的函数，需要在同一个协程中调用
+func BindSession(session int) (unbind func()) {
+	goID := getGoroutineID()
+
+	gSession.mutex.Lock()
+	oldSession, oldFlag := gSession.sessionMap[goID]
+	if session == 0 {
+		delete(gSession.sessionMap, goID)
+	} else {
+		gSession.sessionMap[goID] = session
+	}
+	gSession.mutex.Unlock()
+
+	return func() {
+		gSession.mutex.Lock()
+		if oldFlag {
+			gSession.sessionMap[goID] = oldSession
+		} else {
+			delete(gSession.sessionMap, goID)
+		}
+		gSession.mutex.Unlock()
+	}
+}
+
+// CurSession 获取当前协程绑定的客户端连接，没有绑定时返回0
+func CurSession() int {
+	gSession.mutex.RLock()
+	empty := len(gSession.sessionMap) == 0
+	gSession.mutex.RUnlock()
+	if empty {
+		return 0
+	}
+
+	goID := getGoroutineID()
+	gSession.mutex.RLock()
+	defer gSession.mutex.RUnlock()
+	return gSession.sessionMap[goID]
+}
+
+// Go 创建协程执行fn，新的协程绑定到当前协程的客户端连接，fn中输出的日志仍然属于这个连接
+func Go(fn func()) {
+	session := CurSession()
+	go func() {
+		if session != 0 {
+			defer BindSession(session)()
+		}
+		fn()
+	}()
+}
+
+// getGoroutineID 获取当前协程的ID，runtime.Stack 输出的第一行为 goroutine 123 [running]:
+func getGoroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	fields := bytes.Fields(bytes.TrimPrefix(buf[:n], []byte("goroutine ")))
+	if len(fields) == 0 {
+		return 0
+	}
+
+	goID, _ := strconv.ParseUint(string(fields[0]), 10, 64)
+	return goID
+}
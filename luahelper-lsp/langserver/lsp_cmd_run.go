@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 
-	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/pathpre"
 )
@@ -14,12 +13,10 @@ func (l *LspServer) RunLocalDiagnostices(localpath string) {
 	RootPath := "file://" + localpath
 	RootURI := localpath
 
-	pathpre.InitialRootURIAndPath(string(RootURI), string(RootPath))
-
 	log.Debug("Initialize ..., rootDir=%s, rooturl=%s", RootPath, RootURI)
 
 	vscodeRoot := pathpre.VscodeURIToString(string(RootURI))
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	dirManager.SetVSRootDir(vscodeRoot)
 
 	initOptions := &InitializationOptions{
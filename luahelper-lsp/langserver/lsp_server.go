@@ -5,18 +5,25 @@ import (
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
+	"luahelper-lsp/langserver/metrics"
 	"luahelper-lsp/langserver/pathpre"
 	lsp "luahelper-lsp/langserver/protocol"
+	"luahelper-lsp/langserver/telemetry"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/channel"
 	"github.com/yinfei8/jrpc2/handler"
 )
 
 // 插件定义的版本号
 var clientVerStr string = "0.2.29"
 
+// lastSessionID 最后创建的连接的序号，每个连接的序号不同
+var lastSessionID int32
+
 type serverState int
 
 const (
@@ -31,6 +38,9 @@ type LspServer struct {
 	// 与客户端json rpc2通信的对象
 	server *jrpc2.Server
 
+	// 当前客户端连接的配置与缓存，每个连接独立一份
+	conf *common.GlobalConfig
+
 	// 管理所有Lua工程的对象
 	project *check.AllProject
 
@@ -46,8 +56,10 @@ type LspServer struct {
 	// 请求互斥锁
 	requestMutex sync.Mutex
 
-	// 向中心服务器，需要上报统计的信息
-	onlineReport OnlineReport
+	// 需要上报统计的信息，以及上报的Sink，没有配置上报地址时Sink为nil
+	reportMutex   sync.Mutex
+	onlineReport  OnlineReport
+	telemetrySink telemetry.Sink
 
 	// 最后一次获取文档着色功能的时间
 	colorTime int64
@@ -55,20 +67,42 @@ type LspServer struct {
 	// 是否处理过ChangeConfiguration 标记
 	changeConfFlag bool
 
-	// 是否允许向中心服务器上报自己的使用
-	enableReport bool
+	// 最近一次工程符号查找的结果与版本，workspaceSymbol/resolve 时根据序号获取位置
+	workspaceSymbolVec     []common.FileSymbolStruct
+	workspaceSymbolVersion int
+
+	// 客户端是否支持延迟获取工程符号的位置，以及支持的符号类型
+	workspaceSymbolResolve bool
+	symbolKindSet          map[lsp.SymbolKind]struct{}
+
+	// 客户端是否支持 workspace/applyEdit，服务器的命令生成的修改可以直接让客户端应用
+	applyEditSupport bool
+
+	// 客户端设置的跟踪级别，以及转发日志给客户端的监听
+	traceMutex        sync.Mutex
+	traceValue        string
+	removeLogListener func()
+	traceReqMap       map[string]*requestTrace // 已经处理完、等待返回结果的请求，key为请求的ID
+
+	// 连接的序号，输出的日志带上序号，转发日志给客户端时只转发自己连接的日志
+	sessionID int
+
+	// 性能指标，连接建立时注册到全局用于输出Prometheus指标，连接断开时取消注册
+	metrics           *metrics.Registry
+	unregisterMetrics func()
+
+	// 连接断开或是退出时，只释放一次会话的资源
+	releaseOnce sync.Once
 
 	stateMu sync.Mutex
 	state   serverState
 }
 
-// 管理指针
-var lspServer *LspServer = nil
-
-// CreateLspServer 创建全局Glsp管理对象
+// CreateLspServer 创建Glsp管理对象，每个客户端连接创建一个
 func CreateLspServer() *LspServer {
-	lspServer = &LspServer{
+	lspServer := &LspServer{
 		server:             nil,
+		conf:               common.CreateGlobalConfig(),
 		project:            nil,
 		fileErrorMap:       map[string][]common.CheckError{},
 		fileChangeErrorMap: map[string][]common.CheckError{},
@@ -82,7 +116,12 @@ func CreateLspServer() *LspServer {
 		},
 		colorTime:      0,
 		changeConfFlag: false,
+		traceValue:     traceOff,
+		traceReqMap:    map[string]*requestTrace{},
+		metrics:        metrics.NewRegistry(),
+		sessionID:      int(atomic.AddInt32(&lastSessionID, 1)),
 	}
+	lspServer.metrics.AddCollector(lspServer.collectProjectMetrics)
 
 	return lspServer
 }
@@ -90,8 +129,24 @@ func CreateLspServer() *LspServer {
 // CreateServer 创建server
 func CreateServer() *jrpc2.Server {
 	lspServer := CreateLspServer()
+	lspServer.initServer()
+	return lspServer.server
+}
 
-	lspServer.server = jrpc2.NewServer(handler.Map{
+// Serve 在ch上运行lsp服务，直到连接断开，之后释放会话的资源
+func (g *LspServer) Serve(ch channel.Channel) error {
+	if g.server == nil {
+		g.initServer()
+	}
+	defer g.release()
+
+	g.server.Start(ch)
+	return g.server.Wait()
+}
+
+// initServer 创建与客户端通信的json rpc2对象，注册所有请求的处理函数
+func (lspServer *LspServer) initServer() {
+	handlerMap := handler.Map{
 		"initialize":                          handler.New(lspServer.Initialize),
 		"initialized":                         handler.New(lspServer.Initialized),
 		"textDocument/didChange":              handler.New(lspServer.TextDocumentDidChange),
@@ -107,6 +162,7 @@ func CreateServer() *jrpc2.Server {
 		"textDocument/signatureHelp":          handler.New(lspServer.TextDocumentSignatureHelp),
 		"textDocument/documentColor":          handler.New(lspServer.TextDocumentColor),
 		"textDocument/codeLens":               handler.New(lspServer.TextDocumentCodeLens),
+		"codeLens/resolve":                    handler.New(lspServer.CodeLensResolve),
 		"textDocument/documentLink":           handler.New(lspServer.TextDocumentdocumentLink),
 		"textDocument/completion":             handler.New(lspServer.TextDocumentComplete),
 		"completionItem/resolve":              handler.New(lspServer.TextDocumentCompleteResolve),
@@ -114,18 +170,37 @@ func CreateServer() *jrpc2.Server {
 		"workspace/didChangeWorkspaceFolders": handler.New(lspServer.WorkspaceChangeWorkspaceFolders),
 		"workspace/didChangeWatchedFiles":     handler.New(lspServer.WorkspaceChangeWatchedFiles),
 		"workspace/symbol":                    handler.New(lspServer.WorkspaceSymbolRequest),
+		"workspaceSymbol/resolve":             handler.New(lspServer.WorkspaceSymbolResolve),
+		"workspace/executeCommand":            handler.New(lspServer.ExecuteCommand),
 		"luahelper/getVarColor":               handler.New(lspServer.TextDocumentGetVarColor),
 		"luahelper/getOnlineReq":              handler.New(lspServer.GetOnlineReq),
+		"luahelper/moocToLua":                 handler.New(lspServer.MoocToLua),
+		"luahelper/mapStackTrace":             handler.New(lspServer.MapStackTrace),
+		"luahelper/stats":                     handler.New(lspServer.GetStats),
 		"$/cancelRequest":                     handler.New(lspServer.CancelRequest),
+		"$/setTrace":                          handler.New(lspServer.SetTrace),
 		"shutdown":                            handler.New(lspServer.Shutdown),
 		"exit":                                handler.New(lspServer.Exit),
-	}, &jrpc2.ServerOptions{
+	}
+
+	lspServer.server = jrpc2.NewServer(&traceAssigner{l: lspServer, handlerMap: handlerMap}, &jrpc2.ServerOptions{
 		AllowPush:   true,
 		Concurrency: 4,
 		Logger:      log.LspLog,
+		RPCLog:      &traceLogger{l: lspServer},
 	})
+	lspServer.unregisterMetrics = metrics.Register(lspServer.metrics)
+}
 
-	return lspServer.server
+// release 释放会话的资源：停止转发日志、关闭统计上报、取消注册性能指标
+func (g *LspServer) release() {
+	g.releaseOnce.Do(func() {
+		g.setTraceValue(traceOff)
+		g.closeTelemetry()
+		if g.unregisterMetrics != nil {
+			g.unregisterMetrics()
+		}
+	})
 }
 
 // getAllProject 获取CheckProject
@@ -133,6 +208,11 @@ func (g *LspServer) getAllProject() *check.AllProject {
 	return g.project
 }
 
+// getConfig 获取当前连接的配置
+func (g *LspServer) getConfig() *common.GlobalConfig {
+	return g.conf
+}
+
 // getFileCache 获取文件缓冲map
 func (g *LspServer) getFileCache() *lspcommon.FileMapCache {
 	return g.fileCache
@@ -151,10 +231,10 @@ func (g *LspServer) isCanHighlight() bool {
 }
 
 // setConfigSet 设置配置
-func setConfigSet(referenceMaxNum int, referenceDefine bool) {
-	common.GConfig.ReferenceDefineFlag = referenceDefine
+func (g *LspServer) setConfigSet(referenceMaxNum int, referenceDefine bool) {
+	g.conf.ReferenceDefineFlag = referenceDefine
 	if referenceMaxNum > 0 {
-		common.GConfig.ReferenceMaxNum = referenceMaxNum
+		g.conf.ReferenceMaxNum = referenceMaxNum
 	}
 }
 
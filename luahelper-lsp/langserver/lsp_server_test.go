@@ -0,0 +1,48 @@
+package langserver
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSessionIsolation(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath1, _ := filepath.Abs(paths + "../testdata/hover")
+	strRootPath2, _ := filepath.Abs(paths + "../testdata/shadow")
+
+	lspServer1 := createLspTest(strRootPath1, "file://"+strRootPath1)
+	lspServer2 := createLspTest(strRootPath2, "file://"+strRootPath2)
+
+	conf1 := lspServer1.getConfig()
+	conf2 := lspServer2.getConfig()
+	if conf1 == conf2 {
+		t.Fatalf("two sessions share the same config")
+	}
+
+	// 每个连接的工作区目录互不影响
+	if rootDir := conf1.GetDirManager().GetVsRootDir(); rootDir != strRootPath1 {
+		t.Fatalf("session1 root dir=%s, expect=%s", rootDir, strRootPath1)
+	}
+	if rootDir := conf2.GetDirManager().GetVsRootDir(); rootDir != strRootPath2 {
+		t.Fatalf("session2 root dir=%s, expect=%s", rootDir, strRootPath2)
+	}
+
+	// 一个连接关联的方言，不影响其他的连接
+	conf1.SetClientDialectMap(map[string]string{"*.openresty": "nginx"})
+	if !conf1.IsHandleAsLua("/tmp/site.openresty") {
+		t.Fatalf("session1 not handle .openresty file")
+	}
+	if conf2.IsHandleAsLua("/tmp/site.openresty") {
+		t.Fatalf("session2 handle .openresty file of session1")
+	}
+
+	// 工程的分析结果只包含自己工作区的文件
+	for strFile := range lspServer2.getAllProject().GetAllFileErrorInfo() {
+		if rel, err := filepath.Rel(strRootPath2, strFile); err != nil || rel[0] == '.' {
+			t.Fatalf("session2 has file=%s out of workspace", strFile)
+		}
+	}
+}
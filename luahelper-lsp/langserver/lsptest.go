@@ -4,15 +4,17 @@ import (
 	"context"
 	"luahelper-lsp/langserver/check/common"
 	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
 
 	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/channel"
 	"github.com/yinfei8/jrpc2/handler"
 )
 
 func createLspTest(strRootPath string, strRootUri string) *LspServer{
-	common.GlobalConfigDefautInit()
-	common.GConfig.IntialGlobalVar()
-	
 	lspServer := CreateLspServer()
 	lspServer.server = jrpc2.NewServer(handler.Map{}, &jrpc2.ServerOptions{
 		AllowPush:   false,
@@ -33,9 +35,6 @@ func createLspTest(strRootPath string, strRootUri string) *LspServer{
 }
 
 func createLspTestWithPlugin(strRootPath string, strRootUri string, pluginPath string) *LspServer{
-	common.GlobalConfigDefautInit()
-	common.GConfig.IntialGlobalVar()
-	
 	lspServer := CreateLspServer()
 	lspServer.server = jrpc2.NewServer(handler.Map{}, &jrpc2.ServerOptions{
 		AllowPush:   false,
@@ -58,3 +57,91 @@ func createLspTestWithPlugin(strRootPath string, strRootUri string, pluginPath s
 	lspServer.Initialize(context, initializeParams)
 	return lspServer
 }
+
+func createLspTestWithOptions(strRootPath string, strRootUri string, initOptions *InitializationOptions) *LspServer{
+	lspServer := CreateLspServer()
+	lspServer.server = jrpc2.NewServer(handler.Map{}, &jrpc2.ServerOptions{
+		AllowPush:   false,
+		Concurrency: 1,
+	})
+
+	context := context.Background()
+	initializeParams := InitializeParams{
+		InitializeParams : lsp.InitializeParams {
+			InnerInitializeParams : lsp.InnerInitializeParams{
+				RootPath : strRootPath,
+				RootURI: lsp.DocumentURI(strRootUri),
+			},
+		},
+		InitializationOptions: initOptions,
+	}
+	lspServer.Initialize(context, initializeParams)
+	return lspServer
+}
+
+// startLspTestServer 通过内存中的连接启动完整的lsp服务，返回服务与连接的客户端，测试结束时关闭连接
+func startLspTestServer(t *testing.T, clientOptions *jrpc2.ClientOptions) (*LspServer, *jrpc2.Client) {
+	lspServer := CreateLspServer()
+	lspServer.initServer()
+
+	cch, sch := channel.Direct()
+	lspServer.server.Start(sch)
+	client := jrpc2.NewClient(cch, clientOptions)
+	t.Cleanup(func() {
+		client.Close()
+		lspServer.server.Wait()
+		lspServer.release()
+	})
+
+	return lspServer, client
+}
+
+// checkErrorLine 期望的一个告警：所在的行号（从1开始）与错误的类型
+type checkErrorLine struct {
+	line    int
+	errType common.CheckErrorType
+}
+
+// assertFileErrors 判断工程分析后，expectMap中每个文件的告警与期望的完全一致，key为文件名，value为期望的告警
+// 只比较errTypeVec中的错误类型，其他类型的告警忽略，errTypeVec为空时比较所有的类型；value为空表示该文件不应该有这些类型的告警
+func assertFileErrors(t *testing.T, lspServer *LspServer, errTypeVec []common.CheckErrorType,
+	expectMap map[string][]checkErrorLine) {
+	t.Helper()
+
+	errTypeMap := map[common.CheckErrorType]bool{}
+	for _, errType := range errTypeVec {
+		errTypeMap[errType] = true
+	}
+
+	fileErrMap := map[string][]checkErrorLine{}
+	for strFile, errVec := range lspServer.getAllProject().GetAllFileErrorInfo() {
+		_, strName := filepath.Split(strFile)
+		for _, oneErr := range errVec {
+			if len(errTypeMap) == 0 || errTypeMap[oneErr.ErrType] {
+				fileErrMap[strName] = append(fileErrMap[strName], checkErrorLine{oneErr.Loc.StartLine, oneErr.ErrType})
+			}
+		}
+	}
+
+	sortFunc := func(errVec []checkErrorLine) {
+		sort.Slice(errVec, func(i, j int) bool {
+			if errVec[i].line != errVec[j].line {
+				return errVec[i].line < errVec[j].line
+			}
+			return errVec[i].errType < errVec[j].errType
+		})
+	}
+
+	for strName, expectVec := range expectMap {
+		errVec := fileErrMap[strName]
+		sortFunc(errVec)
+		sortFunc(expectVec)
+		if len(errVec) == 0 && len(expectVec) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(errVec, expectVec) {
+			t.Fatalf("file %s errors=%v, expect=%v", strName, errVec, expectVec)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 服务器内部的性能指标，每个客户端的连接创建一个Registry，记录请求的耗时直方图；
+// 分析阶段的耗时、缓存的命中等由Collector在输出时采集。
+// 所有注册了的Registry可以通过Handler以Prometheus的文本格式输出，每个连接的指标带上session标签
+
+// DefaultBuckets 请求耗时直方图默认的桶上界，单位毫秒
+var DefaultBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// 指标的类型，与Prometheus的TYPE一致
+const (
+	TypeGauge     = "gauge"
+	TypeCounter   = "counter"
+	TypeHistogram = "histogram"
+)
+
+// Histogram 耗时的直方图
+type Histogram struct {
+	mutex  sync.Mutex
+	bounds []float64 // 每个桶的上界，从小到大
+	counts []uint64  // 每个桶的数量，最后一个为超过所有上界的数量
+	count  uint64
+	sum    float64
+	max    float64
+}
+
+// NewHistogram 创建直方图，bounds为每个桶的上界，需要从小到大
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe 记录一个值
+func (h *Histogram) Observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	index := sort.SearchFloat64s(h.bounds, value)
+	h.counts[index]++
+	h.count++
+	h.sum += value
+	if value > h.max {
+		h.max = value
+	}
+}
+
+// Bucket 直方图的一个桶，Count为小于等于上界的累计数量
+type Bucket struct {
+	Le    float64 `json:"Le"`
+	Count uint64  `json:"Count"`
+}
+
+// HistogramSnapshot 直方图的快照，分位数是按照桶的上界估算的
+type HistogramSnapshot struct {
+	Count   uint64   `json:"Count"`
+	Sum     float64  `json:"Sum"`
+	Mean    float64  `json:"Mean"`
+	Max     float64  `json:"Max"`
+	P50     float64  `json:"P50"`
+	P90     float64  `json:"P90"`
+	P99     float64  `json:"P99"`
+	Buckets []Bucket `json:"Buckets"`
+}
+
+// Snapshot 获取直方图当前的快照
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	snapshot := HistogramSnapshot{
+		Count:   h.count,
+		Sum:     h.sum,
+		Max:     h.max,
+		Buckets: make([]Bucket, len(h.bounds)),
+	}
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		snapshot.Buckets[i] = Bucket{Le: bound, Count: cumulative}
+	}
+
+	if h.count > 0 {
+		snapshot.Mean = h.sum / float64(h.count)
+		snapshot.P50 = h.quantile(0.5)
+		snapshot.P90 = h.quantile(0.9)
+		snapshot.P99 = h.quantile(0.99)
+	}
+	return snapshot
+}
+
+// quantile 估算分位数，返回所在桶的上界，超过所有上界时返回最大值
+func (h *Histogram) quantile(q float64) float64 {
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		if cumulative >= rank {
+			return math.Min(bound, h.max)
+		}
+	}
+	return h.max
+}
+
+// Sample 采集到的一个指标值
+type Sample struct {
+	Name   string   // 指标的名称
+	Type   string   // TypeGauge 或是 TypeCounter
+	Labels []string // 标签，依次为名称和值
+	Value  float64
+}
+
+// Collector 输出指标时调用，采集当前的指标值
+type Collector func() []Sample
+
+// Registry 一个客户端连接的所有指标
+type Registry struct {
+	id         int
+	mutex      sync.Mutex
+	labelMap   map[string]string                // 直方图的名称 -> 区分直方图的标签名
+	histograms map[string]map[string]*Histogram // 直方图的名称 -> 标签值 -> 直方图
+	collectors []Collector
+}
+
+// NewRegistry 创建一个Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		labelMap:   map[string]string{},
+		histograms: map[string]map[string]*Histogram{},
+	}
+}
+
+// Observe 在名称为name、标签labelName为labelValue的直方图中记录一个值，直方图不存在时按DefaultBuckets创建
+func (r *Registry) Observe(name, labelName, labelValue string, value float64) {
+	r.mutex.Lock()
+	valueMap, ok := r.histograms[name]
+	if !ok {
+		valueMap = map[string]*Histogram{}
+		r.histograms[name] = valueMap
+		r.labelMap[name] = labelName
+	}
+
+	h, ok := valueMap[labelValue]
+	if !ok {
+		h = NewHistogram(DefaultBuckets)
+		valueMap[labelValue] = h
+	}
+	r.mutex.Unlock()
+
+	h.Observe(value)
+}
+
+// Snapshot 获取名称为name的所有直方图的快照，key为标签值
+func (r *Registry) Snapshot(name string) map[string]HistogramSnapshot {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	snapshotMap := map[string]HistogramSnapshot{}
+	for labelValue, h := range r.histograms[name] {
+		snapshotMap[labelValue] = h.Snapshot()
+	}
+	return snapshotMap
+}
+
+// Reset 清空所有的直方图
+func (r *Registry) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.labelMap = map[string]string{}
+	r.histograms = map[string]map[string]*Histogram{}
+}
+
+// AddCollector 增加一个采集函数
+func (r *Registry) AddCollector(collector Collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// 所有注册的Registry，用于输出Prometheus的指标
+var registryState struct {
+	mutex       sync.Mutex
+	nextID      int
+	registryMap map[int]*Registry
+}
+
+// Register 注册Registry，输出的指标带上session标签，返回的函数用于取消注册
+func Register(r *Registry) (unregister func()) {
+	registryState.mutex.Lock()
+	defer registryState.mutex.Unlock()
+
+	if registryState.registryMap == nil {
+		registryState.registryMap = map[int]*Registry{}
+	}
+	registryState.nextID++
+	r.id = registryState.nextID
+	registryState.registryMap[r.id] = r
+
+	id := r.id
+	return func() {
+		registryState.mutex.Lock()
+		defer registryState.mutex.Unlock()
+		delete(registryState.registryMap, id)
+	}
+}
+
+// getRegistryList 获取所有注册的Registry，按注册的顺序
+func getRegistryList() []*Registry {
+	registryState.mutex.Lock()
+	defer registryState.mutex.Unlock()
+
+	registryList := make([]*Registry, 0, len(registryState.registryMap))
+	for _, r := range registryState.registryMap {
+		registryList = append(registryList, r)
+	}
+	sort.Slice(registryList, func(i, j int) bool {
+		return registryList[i].id < registryList[j].id
+	})
+	return registryList
+}
+
+// family 同一个名称的所有指标
+type family struct {
+	metricType string
+	lineList   []string
+}
+
+// promWriter 按名称汇总所有的指标行，同一个名称只输出一次TYPE
+type promWriter struct {
+	familyMap map[string]*family
+}
+
+func (p *promWriter) add(name, metricType, suffix string, labels []string, value float64) {
+	f, ok := p.familyMap[name]
+	if !ok {
+		f = &family{metricType: metricType}
+		p.familyMap[name] = f
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(name)
+	buf.WriteString(suffix)
+	if len(labels) >= 2 {
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(labels[i])
+			buf.WriteString("=")
+			buf.WriteString(strconv.Quote(labels[i+1]))
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	f.lineList = append(f.lineList, buf.String())
+}
+
+func (p *promWriter) addHistogram(name string, labels []string, snapshot HistogramSnapshot) {
+	for _, bucket := range snapshot.Buckets {
+		p.add(name, TypeHistogram, "_bucket", append(labels, "le", formatFloat(bucket.Le)), float64(bucket.Count))
+	}
+	p.add(name, TypeHistogram, "_bucket", append(labels, "le", "+Inf"), float64(snapshot.Count))
+	p.add(name, TypeHistogram, "_sum", labels, snapshot.Sum)
+	p.add(name, TypeHistogram, "_count", labels, float64(snapshot.Count))
+}
+
+func (p *promWriter) writeTo(w io.Writer) error {
+	nameList := make([]string, 0, len(p.familyMap))
+	for name := range p.familyMap {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+
+	for _, name := range nameList {
+		f := p.familyMap[name]
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n%s\n", name, f.metricType,
+			strings.Join(f.lineList, "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// collect 输出Registry所有的指标，labels为每个指标附加的标签
+func (r *Registry) collect(p *promWriter, labels []string) {
+	r.mutex.Lock()
+	type histogramItem struct {
+		name      string
+		labelName string
+		value     string
+		h         *Histogram
+	}
+	var itemList []histogramItem
+	for name, valueMap := range r.histograms {
+		for labelValue, h := range valueMap {
+			itemList = append(itemList, histogramItem{name, r.labelMap[name], labelValue, h})
+		}
+	}
+	collectors := append([]Collector{}, r.collectors...)
+	r.mutex.Unlock()
+
+	sort.Slice(itemList, func(i, j int) bool {
+		if itemList[i].name != itemList[j].name {
+			return itemList[i].name < itemList[j].name
+		}
+		return itemList[i].value < itemList[j].value
+	})
+	for _, item := range itemList {
+		itemLabels := append(append([]string{}, labels...), item.labelName, item.value)
+		p.addHistogram(item.name, itemLabels, item.h.Snapshot())
+	}
+
+	for _, collector := range collectors {
+		for _, sample := range collector() {
+			sampleLabels := append(append([]string{}, labels...), sample.Labels...)
+			p.add(sample.Name, sample.Type, "", sampleLabels, sample.Value)
+		}
+	}
+}
+
+// WritePrometheus 以Prometheus的文本格式输出所有注册的Registry的指标，以及进程的内存指标
+func WritePrometheus(w io.Writer) error {
+	p := &promWriter{
+		familyMap: map[string]*family{},
+	}
+
+	for _, r := range getRegistryList() {
+		r.collect(p, []string{"session", strconv.Itoa(r.id)})
+	}
+
+	for _, sample := range MemorySamples() {
+		p.add(sample.Name, sample.Type, "", sample.Labels, sample.Value)
+	}
+
+	return p.writeTo(w)
+}
+
+// MemoryStats 进程的内存使用情况
+type MemoryStats struct {
+	HeapAlloc    uint64 `json:"HeapAlloc"`    // 堆上正在使用的字节数
+	HeapInuse    uint64 `json:"HeapInuse"`    // 堆上已经使用的span的字节数
+	Sys          uint64 `json:"Sys"`          // 从系统申请的字节数
+	TotalAlloc   uint64 `json:"TotalAlloc"`   // 累计分配的字节数
+	NumGC        uint32 `json:"NumGC"`        // GC的次数
+	NumGoroutine int    `json:"NumGoroutine"` // 协程的数量
+}
+
+// ReadMemoryStats 获取进程的内存使用情况
+func ReadMemoryStats() MemoryStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return MemoryStats{
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		Sys:          memStats.Sys,
+		TotalAlloc:   memStats.TotalAlloc,
+		NumGC:        memStats.NumGC,
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+}
+
+// MemorySamples 进程内存使用情况的指标
+func MemorySamples() []Sample {
+	memStats := ReadMemoryStats()
+	return []Sample{
+		{Name: "luahelper_memory_heap_alloc_bytes", Type: TypeGauge, Value: float64(memStats.HeapAlloc)},
+		{Name: "luahelper_memory_heap_inuse_bytes", Type: TypeGauge, Value: float64(memStats.HeapInuse)},
+		{Name: "luahelper_memory_sys_bytes", Type: TypeGauge, Value: float64(memStats.Sys)},
+		{Name: "luahelper_memory_alloc_bytes_total", Type: TypeCounter, Value: float64(memStats.TotalAlloc)},
+		{Name: "luahelper_gc_total", Type: TypeCounter, Value: float64(memStats.NumGC)},
+		{Name: "luahelper_goroutines", Type: TypeGauge, Value: float64(memStats.NumGoroutine)},
+	}
+}
+
+// Handler 输出Prometheus指标的http处理函数
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		var buf bytes.Buffer
+		if err := WritePrometheus(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,47 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestMoocCheck(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/mooc/check"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+
+	lspServer := createLspTestWithOptions(strRootPath, strRootURI, &InitializationOptions{
+		AllEnable:                true,
+		CheckMoocExtension:       true,
+		CheckMoocDuplicateMethod: true,
+		CheckMoocSelfSuper:       true,
+		CheckMoocImportNum:       true,
+		CheckMoocUnreachable:     true,
+		CheckMoocDuplicateCase:   true,
+	})
+
+	moocTypeVec := []common.CheckErrorType{common.CheckErrorMoocExtension, common.CheckErrorMoocDuplicateMethod,
+		common.CheckErrorMoocSelfSuper, common.CheckErrorMoocImportNum, common.CheckErrorMoocUnreachable,
+		common.CheckErrorMoocDuplicateCase}
+	assertFileErrors(t, lspServer, moocTypeVec, map[string][]checkErrorLine{
+		"check.mooc": {
+			{4, common.CheckErrorMoocDuplicateMethod},
+			{11, common.CheckErrorMoocExtension},
+			{19, common.CheckErrorMoocSelfSuper},
+			{21, common.CheckErrorMoocImportNum},
+			{27, common.CheckErrorMoocUnreachable},
+			{33, common.CheckErrorMoocDuplicateCase},
+		},
+		"extension.mooc": {{4, common.CheckErrorMoocExtension}},
+	})
+
+	// bar.mooc 没有任何告警
+	assertFileErrors(t, lspServer, nil, map[string][]checkErrorLine{
+		"bar.mooc": nil,
+	})
+}
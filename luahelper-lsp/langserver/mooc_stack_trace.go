@@ -0,0 +1,230 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/parser"
+	"luahelper-lsp/langserver/check/compiler/printer"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 把MoonCake转换成的Lua代码运行时的堆栈，映射回MoonCake文件的行号
+// 堆栈中的 file.lua:123: 转换成 file.mooc:45:，file.lua需要与MoonCake文件在同一个目录
+// file.lua为LuaHelper转换成的代码时（luahelper/moocToLua与 -mooctolua -luaout），行号是准确的
+// 其他编译器（例如MoonCake编译器）生成的代码，按行中的名称与常量对齐到MoonCake文件的行，行号是尽量匹配的，堆栈最后会加上提示
+// 找不到file.lua时不转换，堆栈最后同样会加上提示
+
+// luaTraceRegexp 匹配堆栈中的 file.lua:123: 与 <file.lua:123>，支持windows的盘符
+var luaTraceRegexp = regexp.MustCompile(`((?:[A-Za-z]:)?[^\s:"'<>()\[\]]+)\.lua:(\d+)([:>])`)
+
+// MapStackTraceParams luahelper/mapStackTrace 请求的参数
+type MapStackTraceParams struct {
+	Trace string `json:"trace"` // 粘贴的堆栈内容
+}
+
+// StackTraceLink 转换后堆栈中的一个可以点击的位置
+type StackTraceLink struct {
+	TraceLine   int          `json:"traceLine"`   // 在转换后堆栈中的行，从0开始
+	StartColumn int          `json:"startColumn"` // 在这一行中开始的列
+	EndColumn   int          `json:"endColumn"`   // 在这一行中结束的列
+	Location    lsp.Location `json:"location"`    // 对应的MoonCake文件位置
+	Approximate bool         `json:"approximate"` // 行号是否为尽量匹配的，Lua文件不是LuaHelper转换成的代码时为true
+}
+
+// MapStackTraceReturn luahelper/mapStackTrace 请求的返回
+type MapStackTraceReturn struct {
+	Trace string           `json:"trace"` // 转换后的堆栈
+	Links []StackTraceLink `json:"links"` // 所有转换了的位置
+}
+
+// stackTraceMapper 堆栈的转换
+type stackTraceMapper struct {
+	getContent   func(strFile string) ([]byte, bool) // 获取文件的内容
+	rootDirVec   []string                            // 相对路径时，查找的根目录
+	sourceMapMap map[string]*printer.SourceMap       // 已经创建的行号映射，key为MoonCake文件，找不到Lua文件时为nil
+	noteVec      []string                            // 需要在堆栈最后提示的信息，例如行号是尽量匹配的
+}
+
+// MapStackTrace 把堆栈中的Lua文件行号转换成MoonCake文件的行号
+func (l *LspServer) MapStackTrace(ctx context.Context, vs MapStackTraceParams) (traceReturn MapStackTraceReturn,
+	err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	fileCache := l.getFileCache()
+	dirManager := l.conf.GetDirManager()
+	mapper := createStackTraceMapper(func(strFile string) ([]byte, bool) {
+		if contents, found := fileCache.GetFileContent(strFile); found {
+			return contents, true
+		}
+		return readFileContent(strFile)
+	}, []string{dirManager.GetMainDir(), dirManager.GetVsRootDir()})
+
+	traceReturn = mapper.mapTrace(vs.Trace)
+	log.Debug("MapStackTrace links num=%d", len(traceReturn.Links))
+	return
+}
+
+// RunMapStackTrace 命令行模式，转换文件中的堆栈后输出，strFile为-时从标准输入读取
+func RunMapStackTrace(strFile string) {
+	var data []byte
+	var err error
+	if strFile == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(strFile)
+	}
+	if err != nil {
+		fmt.Printf("read trace=%s error: %s\n", strFile, err.Error())
+		return
+	}
+
+	curDir, _ := os.Getwd()
+	mapper := createStackTraceMapper(readFileContent, []string{curDir})
+	traceReturn := mapper.mapTrace(string(data))
+	fmt.Print(traceReturn.Trace)
+}
+
+func createStackTraceMapper(getContent func(strFile string) ([]byte, bool), rootDirVec []string) *stackTraceMapper {
+	return &stackTraceMapper{
+		getContent:   getContent,
+		rootDirVec:   rootDirVec,
+		sourceMapMap: map[string]*printer.SourceMap{},
+	}
+}
+
+// mapTrace 转换整个堆栈，找不到对应MoonCake文件的位置保持不变，需要提示的信息加在堆栈的最后
+func (s *stackTraceMapper) mapTrace(strTrace string) (traceReturn MapStackTraceReturn) {
+	traceLines := strings.Split(strTrace, "\n")
+	for i, traceLine := range traceLines {
+		var buf strings.Builder
+		lastIndex := 0
+		for _, match := range luaTraceRegexp.FindAllStringSubmatchIndex(traceLine, -1) {
+			luaPre := traceLine[match[2]:match[3]]
+			luaLine, _ := strconv.Atoi(traceLine[match[4]:match[5]])
+			moocFile, moocLine, approximate := s.mapLocation(luaPre, luaLine)
+			if moocFile == "" {
+				continue
+			}
+
+			buf.WriteString(traceLine[lastIndex:match[0]])
+			startColumn := buf.Len()
+			buf.WriteString(fmt.Sprintf("%s:%d", moocFile, moocLine))
+			endColumn := buf.Len()
+			buf.WriteString(traceLine[match[6]:match[7]])
+			lastIndex = match[1]
+
+			traceReturn.Links = append(traceReturn.Links, StackTraceLink{
+				TraceLine:   i,
+				StartColumn: startColumn,
+				EndColumn:   endColumn,
+				Location: lsp.Location{
+					URI: lsp.DocumentURI(pathpre.StringToVscodeURI(moocFile)),
+					Range: lsp.Range{
+						Start: lsp.Position{Line: uint32(moocLine - 1)},
+						End:   lsp.Position{Line: uint32(moocLine - 1)},
+					},
+				},
+				Approximate: approximate,
+			})
+		}
+		buf.WriteString(traceLine[lastIndex:])
+		traceLines[i] = buf.String()
+	}
+
+	// 提示加在堆栈的最后，堆栈以换行结尾时保留结尾的换行
+	if lastIndex := len(traceLines) - 1; len(s.noteVec) > 0 && traceLines[lastIndex] == "" {
+		traceLines = append(append(traceLines[:lastIndex:lastIndex], s.noteVec...), "")
+	} else {
+		traceLines = append(traceLines, s.noteVec...)
+	}
+	traceReturn.Trace = strings.Join(traceLines, "\n")
+	return traceReturn
+}
+
+// mapLocation 获取Lua文件的行对应的MoonCake文件与行号，luaPre为去掉.lua后缀的文件路径
+// approximate 为true时表示Lua文件不是LuaHelper转换成的代码，行号是尽量匹配的
+func (s *stackTraceMapper) mapLocation(luaPre string, luaLine int) (moocFile string, moocLine int, approximate bool) {
+	moocFile = s.findFile(luaPre + ".mooc")
+	if moocFile == "" {
+		return "", 0, false
+	}
+
+	sourceMap, ok := s.sourceMapMap[moocFile]
+	if !ok {
+		sourceMap = s.createSourceMap(moocFile)
+		s.sourceMapMap[moocFile] = sourceMap
+	}
+	if sourceMap == nil {
+		return "", 0, false
+	}
+
+	moocLine = sourceMap.GetSourceLine(luaLine)
+	if moocLine == 0 {
+		return "", 0, false
+	}
+	return moocFile, moocLine, sourceMap.IsApproximate()
+}
+
+// createSourceMap 创建MoonCake文件的行号映射，找不到同目录下的Lua文件时返回nil
+// Lua文件不是LuaHelper转换成的代码时，创建尽量匹配的映射，都会在堆栈最后加上提示
+func (s *stackTraceMapper) createSourceMap(moocFile string) *printer.SourceMap {
+	luaFile := strings.TrimSuffix(moocFile, ".mooc") + ".lua"
+	luaContents, found := s.getContent(luaFile)
+	if !found {
+		log.Debug("createSourceMap strFile=%s not find", luaFile)
+		s.noteVec = append(s.noteVec, fmt.Sprintf("note: %s not found, lines of %s are not mapped", luaFile, moocFile))
+		return nil
+	}
+
+	contents, _ := s.getContent(moocFile)
+	block, _, _ := parser.CreateParser(contents, moocFile).BeginAnalyze()
+	sourceMap := printer.CreateLuaSourceMap(block, contents, luaContents)
+	if sourceMap.IsApproximate() {
+		log.Debug("createSourceMap strFile=%s is not converted by luahelper, map approximately", luaFile)
+		s.noteVec = append(s.noteVec, fmt.Sprintf("note: %s is not converted by luahelper, lines of %s are approximate",
+			luaFile, moocFile))
+	}
+
+	return sourceMap
+}
+
+// findFile 查找存在的文件，相对路径时依次在根目录下查找
+func (s *stackTraceMapper) findFile(strFile string) string {
+	fileVec := []string{}
+	if filepath.IsAbs(strFile) {
+		fileVec = append(fileVec, strFile)
+	} else {
+		for _, rootDir := range s.rootDirVec {
+			if rootDir != "" {
+				fileVec = append(fileVec, filepath.Join(rootDir, strFile))
+			}
+		}
+	}
+
+	for _, oneFile := range fileVec {
+		oneFile = pathpre.GeConvertPathFormat(oneFile)
+		if _, found := s.getContent(oneFile); found {
+			return oneFile
+		}
+	}
+
+	return ""
+}
+
+// readFileContent 从磁盘读取文件的内容
+func readFileContent(strFile string) ([]byte, bool) {
+	contents, err := ioutil.ReadFile(strFile)
+	if err != nil {
+		return nil, false
+	}
+	return contents, true
+}
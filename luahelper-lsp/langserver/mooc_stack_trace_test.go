@@ -0,0 +1,65 @@
+package langserver
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMapStackTrace(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/mooc"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	mapper := createStackTraceMapper(readFileContent, []string{strRootPath})
+	strTrace := "lua: trace.lua:10: attempt to index a nil value\nstack traceback:\n\ttrace.lua:10: in function <trace.lua:6>\n\tother.lua:3: in main chunk"
+	traceReturn := mapper.mapTrace(strTrace)
+
+	traceLines := strings.Split(traceReturn.Trace, "\n")
+	if !strings.HasSuffix(traceLines[0], "trace.mooc:6: attempt to index a nil value") {
+		t.Fatalf("map trace err, line=%s", traceLines[0])
+	}
+
+	if !strings.HasSuffix(traceLines[2], "trace.mooc:2>") {
+		t.Fatalf("map function trace err, line=%s", traceLines[2])
+	}
+
+	if traceLines[3] != "\tother.lua:3: in main chunk" {
+		t.Fatalf("not mooc file trace err, line=%s", traceLines[3])
+	}
+
+	if len(traceReturn.Links) != 3 {
+		t.Fatalf("map trace links len err, len=%d", len(traceReturn.Links))
+	}
+
+	oneLink := traceReturn.Links[0]
+	if oneLink.Location.Range.Start.Line != 5 || traceLines[0][oneLink.StartColumn:oneLink.EndColumn] !=
+		filepath.ToSlash(filepath.Join(strRootPath, "trace.mooc"))+":6" {
+		t.Fatalf("map trace link err")
+	}
+
+	// MoonCake编译器生成的Lua代码，行号按行中的名称与常量尽量匹配，堆栈最后加上提示
+	strTrace = "lua: compiled.lua:9: attempt to index a nil value\n\tcompiled.lua:12: in main chunk"
+	traceReturn = mapper.mapTrace(strTrace)
+	traceLines = strings.Split(traceReturn.Trace, "\n")
+	if len(traceLines) != 3 || !strings.HasSuffix(traceLines[0], "compiled.mooc:6: attempt to index a nil value") ||
+		!strings.HasSuffix(traceLines[1], "compiled.mooc:9: in main chunk") ||
+		!strings.Contains(traceLines[2], "compiled.lua is not converted by luahelper") {
+		t.Fatalf("map other compiler trace err, trace=%s", traceReturn.Trace)
+	}
+
+	if len(traceReturn.Links) != 2 || !traceReturn.Links[0].Approximate {
+		t.Fatalf("map other compiler trace links err, links=%v", traceReturn.Links)
+	}
+
+	// 找不到Lua文件，不转换，堆栈最后加上提示
+	strTrace = "lua: nolua.lua:2: error"
+	traceReturn = createStackTraceMapper(readFileContent, []string{strRootPath}).mapTrace(strTrace)
+	traceLines = strings.Split(traceReturn.Trace, "\n")
+	if len(traceLines) != 2 || traceLines[0] != strTrace || !strings.Contains(traceLines[1], "nolua.lua not found") {
+		t.Fatalf("map not found lua trace err, trace=%s", traceReturn.Trace)
+	}
+}
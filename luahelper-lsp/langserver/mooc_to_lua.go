@@ -0,0 +1,110 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/parser"
+	"luahelper-lsp/langserver/check/compiler/printer"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+	"strings"
+)
+
+// 查看MoonCake文件转换成的Lua代码，用于确认defer、guard、continue等语法实际转换成的代码
+
+// MoocToLuaParams luahelper/moocToLua 请求的参数
+type MoocToLuaParams struct {
+	URI   string     `json:"uri"`
+	Range *lsp.Range `json:"range,omitempty"` // 为空时转换整个文件，否则只转换与这个范围有交集的顶层语句
+}
+
+// MoocToLuaReturn luahelper/moocToLua 请求的返回
+type MoocToLuaReturn struct {
+	Code    string   `json:"code"`    // 转换后的Lua代码
+	LineMap []int    `json:"lineMap"` // Lua代码每一行对应的原文件行号，从0开始，-1表示没有对应的行
+	Errors  []string `json:"errors"`  // 原文件的语法错误
+}
+
+// MoocToLua 获取MoonCake文件转换成的Lua代码
+func (l *LspServer) MoocToLua(ctx context.Context, vs MoocToLuaParams) (moocReturn MoocToLuaReturn, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	strFile := pathpre.VscodeURIToString(vs.URI)
+	contents, found := l.getFileCache().GetFileContent(strFile)
+	if !found {
+		contents, err = ioutil.ReadFile(strFile)
+		if err != nil {
+			log.Error("MoocToLua read strFile=%s error", strFile)
+			return
+		}
+	}
+
+	startLine, endLine := 0, 0
+	if vs.Range != nil {
+		startLine = int(vs.Range.Start.Line) + 1
+		endLine = int(vs.Range.End.Line) + 1
+	}
+
+	moocReturn = convertMoocToLua(strFile, contents, startLine, endLine)
+	return
+}
+
+// convertMoocToLua 解析文件后输出成Lua代码，startLine与endLine从1开始，为0时转换整个文件
+func convertMoocToLua(strFile string, contents []byte, startLine int, endLine int) (moocReturn MoocToLuaReturn) {
+	block, _, errList := parser.CreateParser(contents, strFile).BeginAnalyze()
+	for _, oneErr := range errList {
+		moocReturn.Errors = append(moocReturn.Errors, fmt.Sprintf("line=%d, %s", oneErr.Loc.StartLine, oneErr.ErrStr))
+	}
+
+	code, lineVec := printer.PrintLua(block, startLine, endLine)
+	moocReturn.Code = code
+	moocReturn.LineMap = make([]int, len(lineVec))
+	for i, line := range lineVec {
+		moocReturn.LineMap[i] = line - 1
+	}
+
+	return moocReturn
+}
+
+// RunMoocToLua 命令行模式，输出文件转换成的Lua代码，每一行前面为对应的原文件行号
+// strRange 为行的范围，例如 10-20，为空时转换整个文件
+// strOut 不为空时，只把Lua代码写入到这个文件，运行时的堆栈可以用 -maptrace 映射回原文件的行号
+func RunMoocToLua(strFile string, strRange string, strOut string) {
+	contents, err := ioutil.ReadFile(strFile)
+	if err != nil {
+		fmt.Printf("read file=%s error: %s\n", strFile, err.Error())
+		return
+	}
+
+	startLine, endLine := 0, 0
+	if strRange != "" {
+		if _, err := fmt.Sscanf(strRange, "%d-%d", &startLine, &endLine); err != nil {
+			fmt.Printf("range=%s error, should be like 10-20\n", strRange)
+			return
+		}
+	}
+
+	moocReturn := convertMoocToLua(strFile, contents, startLine, endLine)
+	for _, strErr := range moocReturn.Errors {
+		fmt.Printf("-- syntax error: %s\n", strErr)
+	}
+
+	if strOut != "" {
+		if err := ioutil.WriteFile(strOut, []byte(moocReturn.Code), 0644); err != nil {
+			fmt.Printf("write file=%s error: %s\n", strOut, err.Error())
+		}
+		return
+	}
+
+	codeLines := strings.Split(strings.TrimSuffix(moocReturn.Code, "\n"), "\n")
+	for i, codeLine := range codeLines {
+		if i < len(moocReturn.LineMap) && moocReturn.LineMap[i] >= 0 {
+			fmt.Printf("%5d | %s\n", moocReturn.LineMap[i]+1, codeLine)
+		} else {
+			fmt.Printf("%5s | %s\n", "", codeLine)
+		}
+	}
+}
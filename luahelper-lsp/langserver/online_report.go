@@ -22,6 +22,8 @@ type OnlineReportReturn struct {
 
 // SetOnlineReportParam 连接初始化时，同步客户端的类型，以及这个工程的所包含的lua文件数量
 func (l *LspServer) SetOnlineReportParam(clientType string, fileNumber int, costMsTime int, workspaceFolderNum int) {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
 	l.onlineReport.ClientType = clientType
 	l.onlineReport.FileNumber = fileNumber
 	l.onlineReport.CostMsTime = costMsTime
@@ -30,20 +32,28 @@ func (l *LspServer) SetOnlineReportParam(clientType string, fileNumber int, cost
 
 // SetLuaFileNumber 更新工程lua文件的数量
 func (l *LspServer) SetLuaFileNumber(fileNumber int) {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
 	l.onlineReport.FileNumber = fileNumber
 }
 
 // GetOnlineReportData 获取向中心服统计的数据
 func (l *LspServer) GetOnlineReportData() OnlineReport {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
 	return l.onlineReport
 }
 
 // SetFirstReportFlag 设置是否第一次上报标记
 func (l *LspServer) SetFirstReportFlag(flag int) {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
 	l.onlineReport.FirstReport = flag
 }
 
 // SetReportOtherInfo 设置上报的其他信息
 func (l *LspServer) SetReportOtherInfo() {
+	l.reportMutex.Lock()
+	defer l.reportMutex.Unlock()
 	l.onlineReport.OsType = runtime.GOOS
 }
\ No newline at end of file
@@ -31,16 +31,6 @@ func (l *LspServer) CancelRequest(ctx context.Context, vs lsp.CancelParams) erro
 	// return nil
 }
 
-// TextDocumentCodeLens 请求
-func (l *LspServer) TextDocumentCodeLens(ctx context.Context, vs lsp.CodeLensParams) (edit []lsp.CodeLens, err error) {
-	return
-}
-
-// TextDocumentdocumentLink 请求
-func (l *LspServer) TextDocumentdocumentLink(ctx context.Context, vs lsp.DocumentLinkParams) (edit []lsp.DocumentLink, err error) {
-	return
-}
-
 // SourceParams 请求的原参数
 type SourceParams struct {
 	Roots []string `json:"roots,omitempty"`
@@ -53,14 +43,16 @@ type ComletionParams struct {
 
 // ProjectParams 工程的设置
 type BaseParams struct {
-	Rootdir              string   `json:"Rootdir,omitempty"`
-	IgnoreFileOrDir      []string `json:"IgnoreFileOrDir,omitempty"`
-	IgnoreFileOrDirError []string `json:"IgnoreFileOrDirError,omitempty"`
-	RequirePathSeparator string   `json:"RequirePathSeparator,omitempty"`
-	ReferenceMaxNum      int      `json:"ReferenceMaxNum,omitempty"`
-	ReferenceDefineFlag  bool     `json:"ReferenceIncudeDefine,omitempty"`
-	PreviewFieldsNum     int      `json:"PreviewFieldsNum,omitempty"`
-	EnableReport         bool     `json:"Report,omitempty"`
+	Rootdir               string            `json:"Rootdir,omitempty"`
+	IgnoreFileOrDir       []string          `json:"IgnoreFileOrDir,omitempty"`
+	IgnoreFileOrDirError  []string          `json:"IgnoreFileOrDirError,omitempty"`
+	RequirePathSeparator  string            `json:"RequirePathSeparator,omitempty"`
+	ReferenceMaxNum       int               `json:"ReferenceMaxNum,omitempty"`
+	ReferenceDefineFlag   bool              `json:"ReferenceIncudeDefine,omitempty"`
+	PreviewFieldsNum      int               `json:"PreviewFieldsNum,omitempty"`
+	WorkspaceSymbolMaxNum int               `json:"WorkspaceSymbolMaxNum,omitempty"`
+	BustedPath            string            `json:"BustedPath,omitempty"`
+	EmbedAssociations     map[string]string `json:"EmbedAssociations,omitempty"`
 }
 
 // WarnParams 引用的设置
@@ -91,6 +83,17 @@ type WarnParams struct {
 	CheckConstAssign               bool `json:"CheckConstAssign,omitempty"`
 	CheckFuncParamType             bool `json:"CheckFuncParamType,omitempty"`
 	CheckFuncReturnType            bool `json:"CheckFuncReturnType,omitempty"`
+	CheckMoocExtension             bool `json:"CheckMoocExtension,omitempty"`
+	CheckMoocDuplicateMethod       bool `json:"CheckMoocDuplicateMethod,omitempty"`
+	CheckMoocSelfSuper             bool `json:"CheckMoocSelfSuper,omitempty"`
+	CheckMoocImportNum             bool `json:"CheckMoocImportNum,omitempty"`
+	CheckMoocUnreachable           bool `json:"CheckMoocUnreachable,omitempty"`
+	CheckMoocDuplicateCase         bool `json:"CheckMoocDuplicateCase,omitempty"`
+	CheckUnreachable               bool `json:"CheckUnreachable,omitempty"`
+	CheckLocalRedeclare            bool `json:"CheckLocalRedeclare,omitempty"`
+	CheckShadowVar                 bool `json:"CheckShadowVar,omitempty"`
+	CheckShadowSys                 bool `json:"CheckShadowSys,omitempty"`
+	CheckLoopVarCapture            bool `json:"CheckLoopVarCapture,omitempty"`
 }
 
 // LuahelperParams 整体的设置
@@ -136,8 +139,8 @@ type DidChangeWatchedFilesRegistrationOptions struct {
 	Watchers []FileSystemWatcher `json:"Watchers,omitempty"`
 }
 
-// 获取其他后缀关联到的lua 类型  (associalList string[])
-func getAssociationList(associationData interface{}) (associalList []string) {
+// 获取其他后缀关联到的lua 类型  (associalList string[])，以及关联到的lua方言
+func getAssociationList(associationData interface{}) (associalList []string, dialectMap map[string]string) {
 	oneFilesData, ok := associationData.(map[string]interface{})
 	if !ok {
 		return
@@ -154,11 +157,12 @@ func getAssociationList(associationData interface{}) (associalList []string) {
 // ChangeConfiguration 修改配置请求
 func (l *LspServer) ChangeConfiguration(ctx context.Context, vs ChangeConfigurationParams) error {
 	base := vs.Settings.Luahelper.Base
-	setConfigSet(base.ReferenceMaxNum, base.ReferenceDefineFlag)
-	l.enableReport = base.EnableReport
+	l.setConfigSet(base.ReferenceMaxNum, base.ReferenceDefineFlag)
 
 	// 设置预览table成员的数量
-	common.GConfig.SetPreviewFieldsNum(base.PreviewFieldsNum)
+	l.conf.SetPreviewFieldsNum(base.PreviewFieldsNum)
+	l.conf.SetWorkspaceSymbolMaxNum(base.WorkspaceSymbolMaxNum)
+	l.conf.SetBustedPath(base.BustedPath)
 	if !l.changeConfFlag {
 		l.changeConfFlag = true
 		return nil
@@ -167,20 +171,21 @@ func (l *LspServer) ChangeConfiguration(ctx context.Context, vs ChangeConfigurat
 	l.requestMutex.Lock()
 	defer l.requestMutex.Unlock()
 
-	if common.GConfig.ReadJSONFlag {
+	if l.conf.ReadJSONFlag {
 		return nil
 	}
 
-	associalList := getAssociationList(vs.Settings.Files)
-	common.GConfig.SetAssocialList(associalList)
+	associalList, dialectMap := getAssociationList(vs.Settings.Files)
+	l.conf.SetAssocialList(associalList)
+	l.conf.SetClientDialectMap(mergeEmbedAssociations(base.EmbedAssociations, dialectMap))
 
 	// 按顺序插入
 	checkFlagList := getWarnCheckList(&vs.Settings.Luahelper.WarnParam)
 
-	common.GConfig.HandleChangeCheckList(checkFlagList, base.IgnoreFileOrDir, base.IgnoreFileOrDirError)
+	l.conf.HandleChangeCheckList(checkFlagList, base.IgnoreFileOrDir, base.IgnoreFileOrDirError)
 
 	// 设置require其他lua文件的路径分割
-	common.GConfig.SetRequirePathSeparator(base.RequirePathSeparator)
+	l.conf.SetRequirePathSeparator(base.RequirePathSeparator)
 
 	return l.handleChange(ctx)
 }
@@ -199,7 +204,7 @@ func (l *LspServer) clearLspServer(ctx context.Context) {
 func (l *LspServer) handleChange(ctx context.Context) error {
 	l.clearLspServer(ctx)
 
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	mainDir := dirManager.GetMainDir()
 
 	var checkList []string
@@ -213,11 +218,11 @@ func (l *LspServer) handleChange(ctx context.Context) error {
 
 	// 补全所有的入口文件
 	var entryFileList []string
-	for _, luaFile := range common.GConfig.ProjectFiles {
+	for _, luaFile := range l.conf.ProjectFiles {
 		luaFile = pathpre.GetRemovePreStr(luaFile)
 		entryFileList = append(entryFileList, dirManager.GetCompletePath(mainDir, luaFile))
 	}
-	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList)
+	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList, l.conf)
 	allProject.HandleCheck()
 
 	// 工程路径变量设置到Glsp侧
@@ -243,6 +248,7 @@ func (l *LspServer) Shutdown(ctx context.Context) error {
 // Exit 退出了
 func (l *LspServer) Exit(ctx context.Context) error {
 	log.Debug("Exit")
+	l.release()
 	return nil
 }
 
@@ -275,6 +281,23 @@ func getWarnCheckList(warnParam *WarnParams) (checkFlagList []bool) {
 		warnParam.CheckConstAssign,
 		warnParam.CheckFuncParamType,
 		warnParam.CheckFuncReturnType,
+		false, // CheckErrorAssignType，只能在luahelper.json中开启
+		false, // CheckErrorBinopType，只能在luahelper.json中开启
+		false, // CheckErrorLocFuncNotCall，只能在luahelper.json中开启
+		false, // CheckErrorEnumValue，只能在luahelper.json中开启
+		false, // CheckErrorFieldNotWrite，只能在luahelper.json中开启
+		warnParam.CheckMoocExtension,
+		warnParam.CheckMoocDuplicateMethod,
+		warnParam.CheckMoocSelfSuper,
+		warnParam.CheckMoocImportNum,
+		warnParam.CheckMoocUnreachable,
+		warnParam.CheckMoocDuplicateCase,
+		warnParam.CheckUnreachable,
+		false, // CheckErrorGlobalFuncNotCall，只能在luahelper.json中开启
+		warnParam.CheckLocalRedeclare,
+		warnParam.CheckShadowVar,
+		warnParam.CheckShadowSys,
+		warnParam.CheckLoopVarCapture,
 	}
 
 	return checkFlagList
@@ -5,25 +5,22 @@ import (
 	"strings"
 )
 
-// preFixStr 工程项目的前缀，windows平台的前缀是 file:///， mac平台的前缀是file://
-var preFixStr string = "file:///"
-
-// InitialRootURIAndPath 初始化前缀
-func InitialRootURIAndPath(rootURI, rootPath string) {
-	rootURI, _ = url.QueryUnescape(rootURI)
-	rootURI = strings.Replace(rootURI, "\\", "/", -1)
-	
-	rootPath, _ = url.QueryUnescape(rootPath)
-	rootPath = strings.Replace(rootPath, "\\", "/", -1)
-
-	if len(rootURI) < 8 {
-		return
+// uriPreStr 文件URI的前缀，windows平台的路径为 file:///g%3A/luaproject，mac与linux平台的路径为 file:///home/luaproject
+// 前缀后面的路径以盘符开头时为windows平台，因此不需要保存工程根目录的URI来判断平台，多个客户端连接时也不会相互影响
+const uriPreStr = "file://"
+
+// isWindowsDrivePath 判断路径是否以windows的盘符开头，例如 g:/luaproject 或是 g%3A/luaproject
+func isWindowsDrivePath(strPath string) bool {
+	if len(strPath) < 2 {
+		return false
 	}
 
-	subRootURI := rootURI[7:]
-	if subRootURI == rootPath {
-		preFixStr = "file://"
+	ch := strPath[0]
+	if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') {
+		return false
 	}
+
+	return strPath[1] == ':' || strings.HasPrefix(strings.ToUpper(strPath[1:]), "%3A")
 }
 
 // GetRemovePreStr 如果字符串以 ./为前缀，去除掉前缀
@@ -37,9 +34,13 @@ func GetRemovePreStr(str string) string {
 
 // VscodeURIToString 插件传入的路径转换
 // vscode 传来的路径： file:///g%3A/luaproject
-// 统一转换为：g%3A/luaproject，去掉前缀的file:///，并且都是这样的/../
+// 统一转换为：g:/luaproject，去掉前缀的file:///，并且都是这样的/../
+// mac与linux平台的路径 file:///home/luaproject 转换为 /home/luaproject
 func VscodeURIToString(strURL string) string {
-	fileURL := strings.Replace(strURL, preFixStr, "", 1)
+	fileURL := strings.Replace(strURL, uriPreStr, "", 1)
+	if strings.HasPrefix(fileURL, "/") && isWindowsDrivePath(fileURL[1:]) {
+		fileURL = fileURL[1:]
+	}
 	fileURL, _ = url.QueryUnescape(fileURL)
 	fileURL = strings.Replace(string(fileURL), "\\", "/", -1)
 
@@ -49,9 +50,11 @@ func VscodeURIToString(strURL string) string {
 // StringToVscodeURI 文件真实路径转换成类似的 file:///g%3A/luaproject/src/tutorial.lua"
 func StringToVscodeURI(strPath string) string {
 	strPath = strings.Replace(string(strPath), "\\", "/", -1)
-	strEncode := strPath
-	strURI := preFixStr + strEncode
-	return strURI
+	if strings.HasPrefix(strPath, "/") {
+		return uriPreStr + strPath
+	}
+
+	return uriPreStr + "/" + strPath
 }
 
 // GeConvertPathFormat 文件路径统一为
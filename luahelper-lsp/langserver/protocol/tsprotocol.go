@@ -532,7 +532,7 @@ type CodeLens struct {
 	/**
 	 * The command this code lens represents.
 	 */
-	Command Command `json:"command,omitempty"`
+	Command *Command `json:"command,omitempty"`
 	/**
 	 * A data entry field that is preserved on a code lens item between
 	 * a [CodeLensRequest](#CodeLensRequest) and a [CodeLensResolveRequest]
@@ -2570,7 +2570,7 @@ type InnerServerCapabilities struct {
 	/**
 	 * The server provides workspace symbol support.
 	 */
-	WorkspaceSymbolProvider bool/*boolean | WorkspaceSymbolOptions*/ `json:"workspaceSymbolProvider,omitempty"`
+	WorkspaceSymbolProvider interface{}/*boolean | WorkspaceSymbolOptions*/ `json:"workspaceSymbolProvider,omitempty"`
 	/**
 	 * The server provides document formatting.
 	 */
@@ -4693,15 +4693,80 @@ type WorkspaceSymbolClientCapabilities struct {
 		 */
 		ValueSet []SymbolTag `json:"valueSet"`
 	} `json:"tagSupport,omitempty"`
+	/**
+	 * The client support partial workspace symbols. The client will send the
+	 * request `workspaceSymbol/resolve` to the server to resolve additional
+	 * properties.
+	 *
+	 * @since 3.17.0
+	 */
+	ResolveSupport struct {
+		/**
+		 * The properties that a client can resolve lazily. Usually
+		 * `location.range`
+		 */
+		Properties []string `json:"properties"`
+	} `json:"resolveSupport,omitempty"`
 }
 
 /**
  * Server capabilities for a [WorkspaceSymbolRequest](#WorkspaceSymbolRequest).
  */
 type WorkspaceSymbolOptions struct {
+	/**
+	 * The server provides support to resolve additional
+	 * information for a workspace symbol.
+	 *
+	 * @since 3.17.0
+	 */
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
 	WorkDoneProgressOptions
 }
 
+/**
+ * A special workspace symbol that supports locations without a range
+ *
+ * @since 3.17.0
+ */
+type WorkspaceSymbol struct {
+	/**
+	 * The name of this symbol.
+	 */
+	Name string `json:"name"`
+	/**
+	 * The kind of this symbol.
+	 */
+	Kind SymbolKind `json:"kind"`
+	/**
+	 * Tags for this completion item.
+	 */
+	Tags []SymbolTag `json:"tags,omitempty"`
+	/**
+	 * The name of the symbol containing this symbol.
+	 */
+	ContainerName string `json:"containerName,omitempty"`
+	/**
+	 * The location of the symbol. Whether a server is allowed to
+	 * return a location without a range depends on the client
+	 * capability `workspace.symbol.resolveSupport`.
+	 */
+	Location WorkspaceSymbolLocation `json:"location"`
+	/**
+	 * A data entry field that is preserved on a workspace symbol between a
+	 * workspace symbol request and a workspace symbol resolve request.
+	 */
+	Data interface{} `json:"data,omitempty"`
+}
+
+/**
+ * The location of a workspace symbol, the range is omitted when it will be
+ * resolved lazily by `workspaceSymbol/resolve`.
+ */
+type WorkspaceSymbolLocation struct {
+	URI   DocumentURI `json:"uri"`
+	Range *Range      `json:"range,omitempty"`
+}
+
 /**
  * The parameters of a [WorkspaceSymbolRequest](#WorkspaceSymbolRequest).
  */
@@ -27,3 +27,25 @@ func (l *LspServer)sendDiagnostics(ctx context.Context, diagnostics lsp.PublishD
 		log.Debug("PushShowMessage error=%v", err)
 	}
 }
+
+// pushShowMessage 给客户端推送弹出显示的提示消息
+func (l *LspServer) pushShowMessage(ctx context.Context, messageType lsp.MessageType, strContent string) {
+	err := l.server.Notify(ctx, "window/showMessage", lsp.ShowMessageParams{
+		Type:    messageType,
+		Message: strContent,
+	})
+	if err != nil {
+		log.Debug("pushShowMessage error=%v", err)
+	}
+}
+
+// pushLogMessage 给客户端推送输出到日志窗口的消息
+func (l *LspServer) pushLogMessage(ctx context.Context, messageType lsp.MessageType, strContent string) {
+	err := l.server.Notify(ctx, "window/logMessage", lsp.LogMessageParams{
+		Type:    messageType,
+		Message: strContent,
+	})
+	if err != nil {
+		log.Debug("pushLogMessage error=%v", err)
+	}
+}
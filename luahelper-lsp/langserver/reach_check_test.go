@@ -0,0 +1,36 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReachCheck(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/reach"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+
+	lspServer := createLspTestWithOptions(strRootPath, strRootURI, &InitializationOptions{
+		AllEnable:        true,
+		CheckUnreachable: true,
+	})
+
+	reachTypeVec := []common.CheckErrorType{common.CheckErrorUnreachable, common.CheckErrorGlobalFuncNotCall}
+	assertFileErrors(t, lspServer, reachTypeVec, map[string][]checkErrorLine{
+		"reach.lua": {
+			{7, common.CheckErrorUnreachable},
+			{14, common.CheckErrorUnreachable},
+			{17, common.CheckErrorUnreachable},
+			{22, common.CheckErrorGlobalFuncNotCall},
+			{26, common.CheckErrorUnreachable},
+			{30, common.CheckErrorUnreachable},
+			{39, common.CheckErrorUnreachable},
+		},
+		"other.lua": nil,
+	})
+}
@@ -0,0 +1,46 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestShadowCheck(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/shadow"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+
+	lspServer := createLspTestWithOptions(strRootPath, strRootURI, &InitializationOptions{
+		AllEnable:           true,
+		CheckLocalRedeclare: true,
+		CheckShadowVar:      true,
+		CheckShadowSys:      true,
+		CheckLoopVarCapture: true,
+	})
+
+	shadowTypeVec := []common.CheckErrorType{common.CheckErrorLocalRedeclare, common.CheckErrorShadowVar,
+		common.CheckErrorShadowSys, common.CheckErrorLoopVarCapture}
+	assertFileErrors(t, lspServer, shadowTypeVec, map[string][]checkErrorLine{
+		"shadow.lua": {
+			{2, common.CheckErrorLocalRedeclare},
+			{7, common.CheckErrorShadowVar},
+			{11, common.CheckErrorShadowVar},
+			{12, common.CheckErrorShadowVar},
+			{18, common.CheckErrorShadowSys},
+			{23, common.CheckErrorShadowVar},
+			{24, common.CheckErrorShadowVar},
+			{34, common.CheckErrorLoopVarCapture},
+			{39, common.CheckErrorLoopVarCapture},
+		},
+		// global.lua中的gShadowName在当前文件中告警，shadow_global.lua中的遮蔽其他文件的全局变量
+		"global.lua":        {{4, common.CheckErrorShadowVar}},
+		"shadow_global.lua": {{1, common.CheckErrorShadowVar}},
+		// switch生成的临时变量不告警
+		"switch.mooc": nil,
+	})
+}
@@ -0,0 +1,105 @@
+package langserver
+
+import (
+	"context"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/metrics"
+)
+
+// 服务器的性能统计，客户端通过 luahelper/stats 获取，也可以通过 -pprof 地址的 /metrics 以Prometheus的格式获取
+// 用于区分慢在哪里：Check 为整体分析各阶段的耗时，Requests 为每种请求的耗时（例如 textDocument/references 为引用查找）
+
+// 请求耗时直方图的名称与标签
+const (
+	requestDurationMetric = "luahelper_request_duration_ms"
+	requestMethodLabel    = "method"
+)
+
+// StatsParams luahelper/stats 的参数
+type StatsParams struct {
+	Reset bool `json:"Reset"` // 获取后是否清空请求的耗时统计
+}
+
+// StatsReturn luahelper/stats 的返回
+type StatsReturn struct {
+	Check    check.CheckStats                     `json:"Check"`    // 最近一次整体分析的统计
+	Requests map[string]metrics.HistogramSnapshot `json:"Requests"` // key为请求的方法，耗时的单位为毫秒
+	Caches   map[string]common.CacheStats         `json:"Caches"`   // key为缓存的名称
+	Memory   metrics.MemoryStats                  `json:"Memory"`   // 进程的内存使用情况
+}
+
+// GetStats 获取服务器的性能统计
+func (l *LspServer) GetStats(ctx context.Context, vs StatsParams) (statsReturn StatsReturn, err error) {
+	statsReturn.Check, statsReturn.Caches = l.getProjectStats()
+	statsReturn.Requests = l.metrics.Snapshot(requestDurationMetric)
+	statsReturn.Memory = metrics.ReadMemoryStats()
+
+	if vs.Reset {
+		l.metrics.Reset()
+	}
+	return
+}
+
+// getProjectStats 获取工程的分析与缓存统计，还没有初始化工程时为空
+func (l *LspServer) getProjectStats() (checkStats check.CheckStats, cacheMap map[string]common.CacheStats) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	cacheMap = map[string]common.CacheStats{}
+	project := l.getAllProject()
+	if project == nil {
+		return
+	}
+
+	return project.GetCheckStats(), project.GetCacheStats()
+}
+
+// observeRequest 记录一次请求的耗时
+func (l *LspServer) observeRequest(method string, costMs float64) {
+	l.metrics.Observe(requestDurationMetric, requestMethodLabel, method, costMs)
+}
+
+// collectProjectMetrics 采集工程的分析与缓存指标，输出Prometheus指标时调用
+func (l *LspServer) collectProjectMetrics() []metrics.Sample {
+	checkStats, cacheMap := l.getProjectStats()
+
+	sampleList := []metrics.Sample{
+		{Name: "luahelper_check_total", Type: metrics.TypeCounter, Value: float64(checkStats.CheckNum)},
+		{Name: "luahelper_check_files", Type: metrics.TypeGauge, Value: float64(checkStats.FileNum)},
+	}
+
+	phaseMap := map[string]int64{
+		"all":    checkStats.AllMs,
+		"first":  checkStats.FirstMs,
+		"parse":  checkStats.ParseMs,
+		"second": checkStats.SecondMs,
+		"third":  checkStats.ThirdMs,
+	}
+	for _, phase := range []string{"all", "first", "parse", "second", "third"} {
+		sampleList = append(sampleList, metrics.Sample{
+			Name:   "luahelper_check_phase_duration_ms",
+			Type:   metrics.TypeGauge,
+			Labels: []string{"phase", phase},
+			Value:  float64(phaseMap[phase]),
+		})
+	}
+
+	for _, name := range []string{check.CacheFileLRU, check.CacheParseSnapshot, check.CacheComplete} {
+		cacheStats, ok := cacheMap[name]
+		if !ok {
+			continue
+		}
+
+		labels := []string{"cache", name}
+		sampleList = append(sampleList,
+			metrics.Sample{Name: "luahelper_cache_hits_total", Type: metrics.TypeCounter, Labels: labels,
+				Value: float64(cacheStats.Hits)},
+			metrics.Sample{Name: "luahelper_cache_misses_total", Type: metrics.TypeCounter, Labels: labels,
+				Value: float64(cacheStats.Misses)},
+			metrics.Sample{Name: "luahelper_cache_size", Type: metrics.TypeGauge, Labels: labels,
+				Value: float64(cacheStats.Size)})
+	}
+
+	return sampleList
+}
@@ -0,0 +1,95 @@
+package langserver
+
+import (
+	"bytes"
+	"context"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/metrics"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+func TestGetStats(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/complete"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+
+	reqVec, err := jrpc2.ParseRequests([]byte(`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{}}`))
+	if err != nil {
+		t.Fatalf("parse request err=%s", err.Error())
+	}
+	h := handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		return nil, nil
+	})
+	lspServer.traceRequest(context.Background(), reqVec[0], h)
+	lspServer.traceRequest(context.Background(), reqVec[0], h)
+
+	statsReturn, _ := lspServer.GetStats(context.Background(), StatsParams{Reset: true})
+	if statsReturn.Check.CheckNum != 1 || statsReturn.Check.FileNum == 0 {
+		t.Fatalf("check stats error: %v", statsReturn.Check)
+	}
+	if statsReturn.Check.AllMs < statsReturn.Check.FirstMs {
+		t.Fatalf("check all time=%d less than first time=%d", statsReturn.Check.AllMs, statsReturn.Check.FirstMs)
+	}
+	if hover, ok := statsReturn.Requests["textDocument/hover"]; !ok || hover.Count != 2 {
+		t.Fatalf("request stats error: %v", statsReturn.Requests)
+	}
+	for _, name := range []string{check.CacheFileLRU, check.CacheParseSnapshot, check.CacheComplete} {
+		if _, ok := statsReturn.Caches[name]; !ok {
+			t.Fatalf("cache %s stats not find", name)
+		}
+	}
+	if statsReturn.Memory.HeapAlloc == 0 || statsReturn.Memory.NumGoroutine == 0 {
+		t.Fatalf("memory stats error: %v", statsReturn.Memory)
+	}
+
+	// 获取后清空了请求的统计
+	statsReturn, _ = lspServer.GetStats(context.Background(), StatsParams{})
+	if len(statsReturn.Requests) != 0 {
+		t.Fatalf("request stats not reset: %v", statsReturn.Requests)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	lspServer := CreateLspServer()
+	lspServer.observeRequest("textDocument/hover", 3)
+	lspServer.observeRequest("textDocument/hover", 30000)
+
+	unregister := metrics.Register(lspServer.metrics)
+	var buf bytes.Buffer
+	if err := metrics.WritePrometheus(&buf); err != nil {
+		t.Fatalf("write prometheus err=%s", err.Error())
+	}
+	unregister()
+
+	strMetrics := buf.String()
+	for _, expectStr := range []string{
+		"# TYPE luahelper_request_duration_ms histogram\n",
+		`luahelper_request_duration_ms_bucket{session="`,
+		`method="textDocument/hover",le="5"} 1` + "\n",
+		`method="textDocument/hover",le="+Inf"} 2` + "\n",
+		`method="textDocument/hover"} 30003` + "\n",
+		"# TYPE luahelper_memory_heap_alloc_bytes gauge\n",
+	} {
+		if !strings.Contains(strMetrics, expectStr) {
+			t.Fatalf("prometheus metrics not contain %s:\n%s", expectStr, strMetrics)
+		}
+	}
+
+	// 取消注册后不再输出这个连接的指标
+	buf.Reset()
+	metrics.WritePrometheus(&buf)
+	if strings.Contains(buf.String(), "luahelper_request_duration_ms") {
+		t.Fatalf("unregistered metrics still output:\n%s", buf.String())
+	}
+}
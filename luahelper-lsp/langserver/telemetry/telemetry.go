@@ -0,0 +1,207 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"luahelper-lsp/langserver/log"
+)
+
+// 插件使用情况的统计上报，默认不上报，只有客户端显式配置了上报的地址才会创建对应的Sink，例如：
+// udp://host:port          通过udp发送给统计服务器，服务器会回包当前的在线人数
+// file:///path/report.log  追加写入本地的文件，每一条为一行json，用于离线的环境
+
+// Sink 统计信息上报的接口
+type Sink interface {
+	// Name 返回上报的类型，例如 udp、file
+	Name() string
+
+	// Send 上报一条统计信息
+	Send(data []byte) error
+
+	// Close 关闭上报
+	Close() error
+}
+
+// OnlineCounter 能够获取在线人数的Sink实现该接口
+type OnlineCounter interface {
+	OnlineNum() int
+}
+
+// CreateSink 根据上报的地址创建Sink，地址为空时返回nil，表示不上报
+func CreateSink(endpoint string) (Sink, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid telemetry endpoint %s: %s", endpoint, err.Error())
+	}
+
+	switch u.Scheme {
+	case "udp":
+		return createUDPSink(u.Host)
+	case "file":
+		strPath := u.Path
+		if u.Host != "" {
+			strPath = u.Host + u.Path
+		}
+		// windows的路径，例如 file:///c:/report.log
+		if len(strPath) > 2 && strPath[0] == '/' && strPath[2] == ':' {
+			strPath = strPath[1:]
+		}
+		return createFileSink(strPath)
+	}
+
+	return nil, fmt.Errorf("unsupported telemetry endpoint %s, expect udp:// or file://", endpoint)
+}
+
+// udpSink 通过udp上报给统计服务器
+type udpSink struct {
+	conn      net.Conn
+	mutex     sync.Mutex
+	onlineNum int
+	closed    bool
+}
+
+// onlineReturn 统计服务器的回包
+type onlineReturn struct {
+	Num int `json:"Num"` // 所有在线的人数
+}
+
+func createUDPSink(address string) (*udpSink, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &udpSink{
+		conn: conn,
+	}
+
+	// 创建协程，收取udp的在线回包数据
+	go sink.handleRecv()
+	return sink, nil
+}
+
+// Name 上报的类型
+func (s *udpSink) Name() string {
+	return "udp"
+}
+
+// Send 上报一条统计信息
+func (s *udpSink) Send(data []byte) error {
+	_, err := s.conn.Write(data)
+	return err
+}
+
+// Close 关闭连接，收包的协程也会退出
+func (s *udpSink) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+	return s.conn.Close()
+}
+
+// OnlineNum 统计服务器返回的在线人数
+func (s *udpSink) OnlineNum() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.onlineNum
+}
+
+func (s *udpSink) isClosed() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.closed
+}
+
+// handleRecv 处理udp的回包，获取在线人数
+func (s *udpSink) handleRecv() {
+	var reportReturn onlineReturn
+	data := make([]byte, 2048)
+	for {
+		msgRead, err := s.conn.Read(data)
+		if err != nil {
+			// 连接关闭或是非临时性的错误，继续读取也会一直失败，协程直接退出
+			if s.isClosed() || !isTemporaryErr(err) {
+				log.Debug("handleRecv exit, err:%s", err.Error())
+				return
+			}
+			continue
+		}
+
+		if msgRead == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(data[0:msgRead], &reportReturn); err != nil {
+			log.Debug("handleRecv error:%s", err.Error())
+			continue
+		}
+
+		log.Debug("handleRecv ok, num=%d", reportReturn.Num)
+		s.mutex.Lock()
+		s.onlineNum = reportReturn.Num
+		s.mutex.Unlock()
+	}
+}
+
+// isTemporaryErr 判断读取的错误是否为临时性的，临时性的错误可以继续读取
+func isTemporaryErr(err error) bool {
+	if errors.Is(err, net.ErrClosed) {
+		return false
+	}
+
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Temporary()
+}
+
+// fileSink 追加写入本地的文件
+type fileSink struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+func createFileSink(strPath string) (*fileSink, error) {
+	if strPath == "" {
+		return nil, fmt.Errorf("empty telemetry file path")
+	}
+
+	file, err := os.OpenFile(strPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSink{
+		file: file,
+	}, nil
+}
+
+// Name 上报的类型
+func (s *fileSink) Name() string {
+	return "file"
+}
+
+// Send 写入一行统计信息
+func (s *fileSink) Send(data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err := s.file.Write(append(append([]byte{}, data...), '\n'))
+	return err
+}
+
+// Close 关闭文件
+func (s *fileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,164 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/lspcommon"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+)
+
+// codeLensData 引用次数的CodeLens延迟获取时，保存在CodeLens上的数据，为查找引用的位置
+type codeLensData struct {
+	URI      lsp.DocumentURI `json:"URI"`
+	Position lsp.Position    `json:"Position"`
+}
+
+// TextDocumentCodeLens 文件中函数与class上显示引用次数，引用次数在codeLens/resolve中延迟获取
+// busted风格的describe与it测试块上显示运行测试
+func (l *LspServer) TextDocumentCodeLens(ctx context.Context, vs lsp.CodeLensParams) (lensVec []lsp.CodeLens, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	lensVec = []lsp.CodeLens{}
+	strFile := pathpre.VscodeURIToString(string(vs.TextDocument.URI))
+	project := l.getAllProject()
+	if project == nil || !project.IsNeedHandle(strFile) {
+		log.Debug("not need to handle strFile=%s", strFile)
+		return
+	}
+
+	// 1) 函数与class的引用次数
+	fileSymbolVec := project.FindFileAllSymbol(strFile)
+	lensVec = appendReferenceLens(lensVec, vs.TextDocument.URI, fileSymbolVec)
+
+	// 2) 测试块的运行测试
+	for _, testBlock := range project.FindFileTestBlocks(strFile) {
+		lensVec = append(lensVec, getRunTestLens(vs.TextDocument.URI, testBlock))
+	}
+
+	return
+}
+
+// CodeLensResolve 获取CodeLens上的引用次数
+func (l *LspServer) CodeLensResolve(ctx context.Context, vs lsp.CodeLens) (lens lsp.CodeLens, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	lens = vs
+	if lens.Command != nil || lens.Data == nil {
+		return
+	}
+
+	var data codeLensData
+	bits, _ := json.Marshal(lens.Data)
+	if err := json.Unmarshal(bits, &data); err != nil {
+		log.Error("CodeLensResolve data error=%s", err.Error())
+		return lens, nil
+	}
+
+	referenceNum := l.getReferenceNum(data.URI, data.Position)
+	title := fmt.Sprintf("%d references", referenceNum)
+	if referenceNum == 1 {
+		title = "1 reference"
+	}
+
+	lens.Command = &lsp.Command{
+		Title: title,
+	}
+	lens.Data = nil
+	return lens, nil
+}
+
+// getReferenceNum 获取指定位置变量的引用次数，不包含变量定义的位置
+func (l *LspServer) getReferenceNum(uri lsp.DocumentURI, pos lsp.Position) int {
+	comResult := l.beginFileRequest(uri, pos)
+	if !comResult.result {
+		return 0
+	}
+
+	if len(comResult.contents) == 0 || comResult.offset >= len(comResult.contents) {
+		return 0
+	}
+
+	project := l.getAllProject()
+	varStruct := project.GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character,
+		comResult.strFile)
+	if !varStruct.ValidFlag {
+		log.Error("getReferenceNum varStruct.ValidFlag not valid")
+		return 0
+	}
+
+	// 实例冒号调用的方法需要推导变量的类型，按变量名查找引用时找不到，单独查找后去重
+	colonReferVecs := project.FindColonCallReferences(comResult.strFile, &varStruct)
+	referenVecs := project.FindReferences(comResult.strFile, &varStruct, common.CRSReference)
+	referenVecs = append(referenVecs, colonReferVecs...)
+
+	referenceNum := 0
+	referMap := map[string]bool{}
+	for _, referVarInfo := range referenVecs {
+		if referVarInfo.StrFile == comResult.strFile &&
+			referVarInfo.Loc.IsInLocStruct((int)(pos.Line)+1, (int)(pos.Character)) {
+			continue
+		}
+
+		strKey := fmt.Sprintf("%s:%d:%d", referVarInfo.StrFile, referVarInfo.Loc.StartLine, referVarInfo.Loc.StartColumn)
+		if referMap[strKey] {
+			continue
+		}
+		referMap[strKey] = true
+		referenceNum++
+	}
+	return referenceNum
+}
+
+// appendReferenceLens 函数与关联了变量的class，生成引用次数的CodeLens，子符号也一起生成
+func appendReferenceLens(lensVec []lsp.CodeLens, uri lsp.DocumentURI,
+	fileSymbolVec []common.FileSymbolStruct) []lsp.CodeLens {
+	for _, oneSymbol := range fileSymbolVec {
+		switch oneSymbol.Kind {
+		case common.IKFunction, common.IKMethod, common.IKClass, common.IKAnnotateClass:
+			if oneSymbol.VarInfo == nil {
+				break
+			}
+
+			// CodeLens只占符号的第一行，引用在变量名的位置查找
+			ra := lspcommon.LocToRange(&oneSymbol.Loc)
+			ra.End = ra.Start
+			varRange := lspcommon.LocToRange(&oneSymbol.VarInfo.Loc)
+			lensVec = append(lensVec, lsp.CodeLens{
+				Range: ra,
+				Data: codeLensData{
+					URI:      uri,
+					Position: varRange.Start,
+				},
+			})
+		}
+
+		lensVec = appendReferenceLens(lensVec, uri, oneSymbol.Children)
+	}
+
+	return lensVec
+}
+
+// getRunTestLens 生成测试块上运行测试的CodeLens，点击后通过workspace/executeCommand执行
+func getRunTestLens(uri lsp.DocumentURI, testBlock check.TestBlockStruct) lsp.CodeLens {
+	ra := lspcommon.LocToRange(&testBlock.Loc)
+	ra.End = ra.Start
+
+	uriArg, _ := json.Marshal(uri)
+	nameArg, _ := json.Marshal(testBlock.FullName)
+	groupArg, _ := json.Marshal(testBlock.IsGroup)
+	return lsp.CodeLens{
+		Range: ra,
+		Command: &lsp.Command{
+			Title:     "run test",
+			Command:   runTestCommand,
+			Arguments: []json.RawMessage{uriArg, nameArg, groupArg},
+		},
+	}
+}
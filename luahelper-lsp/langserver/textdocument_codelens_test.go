@@ -0,0 +1,133 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func createCodeLensLspTest(t *testing.T) (*LspServer, string) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/codelens"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+
+	for _, fileName := range []string{"counter.lua", "counter_spec.lua"} {
+		data, err := ioutil.ReadFile(strRootPath + "/" + fileName)
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", fileName, err.Error())
+		}
+
+		openParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:  lsp.DocumentURI(strRootURI + "/" + fileName),
+				Text: string(data),
+			},
+		}
+		if err := lspServer.TextDocumentDidOpen(context.Background(), openParams); err != nil {
+			t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+		}
+	}
+
+	return lspServer, strRootURI
+}
+
+func getCodeLens(t *testing.T, lspServer *LspServer, uri string) []lsp.CodeLens {
+	lensVec, err := lspServer.TextDocumentCodeLens(context.Background(), lsp.CodeLensParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(uri),
+		},
+	})
+	if err != nil {
+		t.Fatalf("TextDocumentCodeLens error, uri=%s, err=%s", uri, err.Error())
+	}
+	return lensVec
+}
+
+func TestCodeLensReferences(t *testing.T) {
+	lspServer, strRootURI := createCodeLensLspTest(t)
+	lensVec := getCodeLens(t, lspServer, strRootURI+"/counter.lua")
+
+	// key为CodeLens所在的行，值为引用次数的标题，class的范围包含上面的---@class注解，CodeLens在注解的第一行
+	expectMap := map[uint32]string{
+		0:  "7 references",
+		3:  "3 references",
+		7:  "3 references",
+		11: "2 references",
+	}
+	for _, lens := range lensVec {
+		if lens.Command != nil {
+			t.Fatalf("reference lens in line %d should resolve lazily", lens.Range.Start.Line)
+		}
+
+		resolveLens, _ := lspServer.CodeLensResolve(context.Background(), lens)
+		if resolveLens.Command == nil || resolveLens.Data != nil {
+			t.Fatalf("reference lens in line %d not resolve", lens.Range.Start.Line)
+		}
+
+		expect, ok := expectMap[lens.Range.Start.Line]
+		if !ok {
+			continue
+		}
+		if resolveLens.Command.Title != expect {
+			t.Fatalf("lens in line %d title=%s, expect=%s", lens.Range.Start.Line, resolveLens.Command.Title, expect)
+		}
+		delete(expectMap, lens.Range.Start.Line)
+	}
+
+	if len(expectMap) != 0 {
+		t.Fatalf("reference lens not find, left=%v", expectMap)
+	}
+}
+
+func TestCodeLensRunTest(t *testing.T) {
+	lspServer, strRootURI := createCodeLensLspTest(t)
+	lensVec := getCodeLens(t, lspServer, strRootURI+"/counter_spec.lua")
+
+	expectList := []struct {
+		line    uint32
+		name    string
+		isGroup bool
+	}{
+		{2, "Counter", true},
+		{3, "Counter starts at zero", false},
+		{7, "Counter add", true},
+		{8, "Counter add adds the number", false},
+	}
+
+	runLensVec := []lsp.CodeLens{}
+	for _, lens := range lensVec {
+		if lens.Command != nil && lens.Command.Command == runTestCommand {
+			runLensVec = append(runLensVec, lens)
+		}
+	}
+	if len(runLensVec) != len(expectList) {
+		t.Fatalf("run test lens len=%d, expect=%d", len(runLensVec), len(expectList))
+	}
+
+	for i, expect := range expectList {
+		lens := runLensVec[i]
+		var name string
+		var isGroup bool
+		json.Unmarshal(lens.Command.Arguments[1], &name)
+		json.Unmarshal(lens.Command.Arguments[2], &isGroup)
+		if lens.Range.Start.Line != expect.line || name != expect.name || isGroup != expect.isGroup {
+			t.Fatalf("run test lens %d line=%d name=%s group=%v, expect %v", i, lens.Range.Start.Line, name,
+				isGroup, expect)
+		}
+	}
+
+	if filter := getBustedFilter("Counter add", true); filter != "^Counter add " {
+		t.Fatalf("busted group filter=%s", filter)
+	}
+	if filter := getBustedFilter("adds 1+1 (ok)", false); filter != "^adds 1%+1 %(ok%)$" {
+		t.Fatalf("busted test filter=%s", filter)
+	}
+}
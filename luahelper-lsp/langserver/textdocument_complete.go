@@ -3,7 +3,6 @@ package langserver
 import (
 	"context"
 	"fmt"
-	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/codingconv"
 	"luahelper-lsp/langserver/log"
@@ -109,7 +108,7 @@ func (l *LspServer) TextDocumentComplete(ctx context.Context, vs lsp.CompletionP
 		compVar.OnelyParamQuotesFlag = true
 	} else {
 		// 5.4) 按照.进行分割字符串
-		compVar, flag = getComplelteStruct(preCompStr, (int)(comResult.pos.Line), (int)(comResult.pos.Character), comResult.strFile)
+		compVar, flag = l.getComplelteStruct(preCompStr, (int)(comResult.pos.Line), (int)(comResult.pos.Character), comResult.strFile)
 		if !flag {
 			return
 		}
@@ -326,7 +325,7 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 	// 1) 定义为匹配的引入其他文件的字符串
 	referNameStr := ""
 	matchReferStr := ""
-	referFileTypes := common.GConfig.GetAllReferFileTypes()
+	referFileTypes := l.conf.GetAllReferFileTypes()
 	for _, strOne := range referFileTypes {
 		regexpStr := strOne + ` *?(\()? *?[\"|\'][0-9a-zA-Z_/|.]*`
 		regRefer := regexp.MustCompile(regexpStr)
@@ -347,7 +346,7 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 	if matchReferStr == "" {
 		return
 	}
-	referType := common.StrToReferType(referNameStr)
+	referType := l.conf.StrToReferType(referNameStr)
 	if referType == common.ReferNotValid {
 		return
 	}
@@ -369,7 +368,7 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 }
 
 // 字符串进行拆分
-func getComplelteStruct(str string, line, character int, strFile string) (completeVar common.CompleteVarStruct, flag bool) {
+func (l *LspServer) getComplelteStruct(str string, line, character int, strFile string) (completeVar common.CompleteVarStruct, flag bool) {
 	lastEmptyFlag := false
 	colonFlag := false
 	lastCh := str[len(str)-1]
@@ -454,7 +453,7 @@ func getComplelteStruct(str string, line, character int, strFile string) (comple
 		}
 	}
 
-	varStruct := check.StrToDefineVarStruct(strContent, strFile)
+	varStruct := l.getAllProject().StrToDefineVarStruct(strContent, strFile)
 	if !varStruct.ValidFlag {
 		return
 	}
@@ -666,7 +665,7 @@ func (l *LspServer) TextDocumentCompleteResolve(ctx context.Context, vs lsp.Comp
 	beforeHasTag := project.GetCompleteCache().GetBeforeHashtag()
 
 	// json snippet 里面不能包含. 不然任意地方输入 . 时候会激活响应的snippet
-	if snippetItem, ok := common.GConfig.CompSnippetMap[vs.Label]; ok {
+	if snippetItem, ok := l.conf.CompSnippetMap[vs.Label]; ok {
 		compItem.InsertText = snippetItem.InsertText
 		compItem.InsertTextFormat = lsp.SnippetTextFormat
 		strDetail = snippetItem.Detail
@@ -3215,3 +3215,332 @@ func TestCompleteVarAndAnnotate(t *testing.T) {
 		}
 	}
 }
+
+// 没有返回值注解的函数，代码补全时推导多处return语句的返回值
+func TestCompleteInferReturn(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/complete"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_infer_return.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	changeRange := lsp.Range{
+		Start: lsp.Position{
+			Line:      9,
+			Character: 0,
+		},
+	}
+	changeRange.End = changeRange.Start
+	changParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{
+				Range:       &changeRange,
+				RangeLength: 0,
+				Text:        "p.",
+			},
+		},
+	}
+	lspServer.TextDocumentDidChange(context, changParams)
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: lsp.Position{
+				Line:      9,
+				Character: 2,
+			},
+		},
+		Context: lsp.CompletionContext{
+			TriggerKind: lsp.CompletionTriggerKind(1),
+		},
+	}
+
+	completionReturn, err2 := lspServer.TextDocumentComplete(context, completionParams)
+	if err2 != nil {
+		t.Fatalf("complete file:%s err=%s", fileName, err2.Error())
+	}
+
+	completionListTmp, _ := completionReturn.(CompletionListTmp)
+	for _, resultStr := range []string{"name", "hp"} {
+		findFlag := false
+		for _, oneCompReturn := range completionListTmp.Items {
+			if resultStr == oneCompReturn.Label {
+				findFlag = true
+				break
+			}
+		}
+
+		if !findFlag {
+			t.Fatalf("not find complete str=%s", resultStr)
+		}
+	}
+}
+
+func TestCompleteMetatable(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/complete"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_metatable.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	inputList := []string{"obj.", "Proxy.", "inst:"}
+	resultList := [][]string{
+		{"baseFunc", "grandFunc", "new"},
+		{"baseFunc", "grandFunc"},
+		{"classFunc"},
+	}
+
+	for index, strInput := range inputList {
+		openParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:  lsp.DocumentURI(fileName),
+				Text: string(data) + strInput,
+			},
+		}
+		err1 := lspServer.TextDocumentDidOpen(context, openParams)
+		if err1 != nil {
+			t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+		}
+
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+				Position: lsp.Position{
+					Line:      19,
+					Character: uint32(len(strInput)),
+				},
+			},
+			Context: lsp.CompletionContext{
+				TriggerKind: lsp.CompletionTriggerKind(1),
+			},
+		}
+
+		completionReturn, err2 := lspServer.TextDocumentComplete(context, completionParams)
+		if err2 != nil {
+			t.Fatalf("complete file:%s err=%s", fileName, err2.Error())
+		}
+
+		completionListTmp, _ := completionReturn.(CompletionListTmp)
+		for _, resultStr := range resultList[index] {
+			findFlag := false
+			for _, oneCompReturn := range completionListTmp.Items {
+				if resultStr == oneCompReturn.Label {
+					findFlag = true
+					break
+				}
+			}
+
+			if !findFlag {
+				t.Fatalf("not find complete str=%s, input=%s", resultStr, strInput)
+			}
+		}
+	}
+}
+
+func TestCompleteMoocClass(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/complete"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_mooc_class.mooc"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	// 在Dog的init函数中插入一行
+	inputList := []string{"self.", "Self.", "Super."}
+	resultList := [][]string{
+		{"age", "bark", "name", "speak", "create"},
+		{"age", "bark", "name", "speak", "create"},
+		{"name", "speak", "create"},
+	}
+
+	lineVec := strings.Split(string(data), "\n")
+	for index, strInput := range inputList {
+		strText := strings.Join(lineVec[0:18], "\n") + "\n" + strInput + "\n" + strings.Join(lineVec[18:], "\n")
+		openParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:  lsp.DocumentURI(fileName),
+				Text: strText,
+			},
+		}
+		err1 := lspServer.TextDocumentDidOpen(context, openParams)
+		if err1 != nil {
+			t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+		}
+
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+				Position: lsp.Position{
+					Line:      18,
+					Character: uint32(len(strInput)),
+				},
+			},
+			Context: lsp.CompletionContext{
+				TriggerKind: lsp.CompletionTriggerKind(1),
+			},
+		}
+
+		completionReturn, err2 := lspServer.TextDocumentComplete(context, completionParams)
+		if err2 != nil {
+			t.Fatalf("complete file:%s err=%s", fileName, err2.Error())
+		}
+
+		completionListTmp, _ := completionReturn.(CompletionListTmp)
+		for _, resultStr := range resultList[index] {
+			findFlag := false
+			for _, oneCompReturn := range completionListTmp.Items {
+				if resultStr == oneCompReturn.Label {
+					findFlag = true
+					break
+				}
+			}
+
+			if !findFlag {
+				t.Fatalf("not find complete str=%s, input=%s", resultStr, strInput)
+			}
+		}
+	}
+}
+
+// 运算符元方法推导出来的类型，代码补全时获取class的成员
+func TestCompleteOperator(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/complete"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_operator.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	changeRange := lsp.Range{
+		Start: lsp.Position{
+			Line:      22,
+			Character: 0,
+		},
+	}
+	changeRange.End = changeRange.Start
+	changParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{
+				Range:       &changeRange,
+				RangeLength: 0,
+				Text:        "m2.",
+			},
+		},
+	}
+	lspServer.TextDocumentDidChange(context, changParams)
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: lsp.Position{
+				Line:      22,
+				Character: 3,
+			},
+		},
+		Context: lsp.CompletionContext{
+			TriggerKind: lsp.CompletionTriggerKind(1),
+		},
+	}
+
+	completionReturn, err2 := lspServer.TextDocumentComplete(context, completionParams)
+	if err2 != nil {
+		t.Fatalf("complete file:%s err=%s", fileName, err2.Error())
+	}
+
+	completionListTmp, _ := completionReturn.(CompletionListTmp)
+	for _, resultStr := range []string{"value", "new"} {
+		findFlag := false
+		for _, oneCompReturn := range completionListTmp.Items {
+			if resultStr == oneCompReturn.Label {
+				findFlag = true
+				break
+			}
+		}
+
+		if !findFlag {
+			t.Fatalf("not find complete str=%s", resultStr)
+		}
+	}
+}
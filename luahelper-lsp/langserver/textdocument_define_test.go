@@ -578,4 +578,115 @@ func TestProjectDefine7(t *testing.T) {
 		res0.End.Line != resultRange.End.Line || res0.End.Character != resultRange.End.Character {
 		t.Fatalf("location error")
 	}
-}
\ No newline at end of file
+}
+func TestProjectDefineMetatable(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/define"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_metatable.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	onePosition := lsp.Position{
+		Line:      7,
+		Character: 6,
+	}
+
+	defineParams := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+		Position: onePosition,
+	}
+
+	resLocationList, err2 := lspServer.TextDocumentDefine(context, defineParams)
+	if err2 != nil {
+		t.Fatalf("define error")
+	}
+	if len(resLocationList) != 1 {
+		t.Fatalf("location size error")
+	}
+
+	if resLocationList[0].Range.Start.Line != 1 {
+		t.Fatalf("location error, line=%d", resLocationList[0].Range.Start.Line)
+	}
+}
+
+func TestProjectDefineMoocSuper(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/define"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test_mooc_class.mooc"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	// Super.init 与 self.speak 都定义在父类Animal中
+	positionList := []lsp.Position{
+		{Line: 12, Character: 15},
+		{Line: 13, Character: 22},
+	}
+	resultLineList := []uint32{2, 5}
+
+	for index, onePosition := range positionList {
+		defineParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePosition,
+		}
+
+		resLocationList, err2 := lspServer.TextDocumentDefine(context, defineParams)
+		if err2 != nil {
+			t.Fatalf("define error")
+		}
+		if len(resLocationList) != 1 {
+			t.Fatalf("location size error, index=%d, size=%d", index, len(resLocationList))
+		}
+
+		if resLocationList[0].Range.Start.Line != resultLineList[index] {
+			t.Fatalf("location error, index=%d, line=%d", index, resLocationList[0].Range.Start.Line)
+		}
+	}
+}
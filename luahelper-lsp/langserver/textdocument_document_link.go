@@ -0,0 +1,37 @@
+package langserver
+
+import (
+	"context"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/lspcommon"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+)
+
+// TextDocumentdocumentLink 文件中引用其他文件的地方与注释中的url，生成可以点击的链接
+func (l *LspServer) TextDocumentdocumentLink(ctx context.Context, vs lsp.DocumentLinkParams) (linkVec []lsp.DocumentLink, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	linkVec = []lsp.DocumentLink{}
+	strFile := pathpre.VscodeURIToString(string(vs.TextDocument.URI))
+	project := l.getAllProject()
+	if project == nil || !project.IsNeedHandle(strFile) {
+		log.Debug("not need to handle strFile=%s", strFile)
+		return
+	}
+
+	for _, oneLink := range project.FindFileDocumentLinks(strFile) {
+		link := lsp.DocumentLink{
+			Range:  lspcommon.LocToRange(&oneLink.Loc),
+			Target: oneLink.URL,
+		}
+		if oneLink.StrFile != "" {
+			link.Target = string(lspcommon.GetFileDocumentURI(oneLink.StrFile))
+			link.Tooltip = oneLink.StrFile
+		}
+		linkVec = append(linkVec, link)
+	}
+
+	return
+}
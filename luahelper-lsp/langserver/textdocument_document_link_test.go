@@ -0,0 +1,73 @@
+package langserver
+
+import (
+	"context"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func getDocumentLinks(t *testing.T, fileName string) ([]lsp.DocumentLink, string) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/documentlink"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+
+	linkVec, err := lspServer.TextDocumentdocumentLink(context.Background(), lsp.DocumentLinkParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(strRootURI + "/" + fileName),
+		},
+	})
+	if err != nil {
+		t.Fatalf("TextDocumentdocumentLink error, file=%s, err=%s", fileName, err.Error())
+	}
+	return linkVec, strRootURI
+}
+
+func TestDocumentLinkLua(t *testing.T) {
+	linkVec, strRootURI := getDocumentLinks(t, "main.lua")
+
+	// dofile("missing.lua") 没有找到文件，不生成链接
+	expectList := []struct {
+		line      uint32
+		character uint32
+		target    string
+	}{
+		{0, 9, "https://www.lua.org/manual/5.4/manual.html"},
+		{0, 62, "http://example.com/a?b=1"},
+		{1, 21, strRootURI + "/util.lua"},
+		{2, 23, strRootURI + "/lib/helper.lua"},
+	}
+	if len(linkVec) != len(expectList) {
+		t.Fatalf("link len=%d, expect=%d", len(linkVec), len(expectList))
+	}
+
+	for i, expect := range expectList {
+		link := linkVec[i]
+		if link.Range.Start.Line != expect.line || link.Range.Start.Character != expect.character ||
+			link.Target != expect.target {
+			t.Fatalf("link %d start=(%d, %d) target=%s, expect %v", i, link.Range.Start.Line,
+				link.Range.Start.Character, link.Target, expect)
+		}
+	}
+
+	// url结尾的标点不属于链接
+	if linkVec[1].Range.End.Character != 86 {
+		t.Fatalf("url link end=%d, expect=86", linkVec[1].Range.End.Character)
+	}
+}
+
+func TestDocumentLinkMooc(t *testing.T) {
+	linkVec, strRootURI := getDocumentLinks(t, "app.mooc")
+	if len(linkVec) == 0 {
+		t.Fatalf("mooc import link not find")
+	}
+
+	if linkVec[0].Target != strRootURI+"/util.lua" || linkVec[0].Range.Start.Character != 17 {
+		t.Fatalf("mooc import link target=%s start=%d", linkVec[0].Target, linkVec[0].Range.Start.Character)
+	}
+}
@@ -3,11 +3,12 @@ package langserver
 import (
 	"context"
 	"luahelper-lsp/langserver/check"
-	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/parser"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/pathpre"
 	lsp "luahelper-lsp/langserver/protocol"
 	"luahelper-lsp/langserver/strbytesconv"
+	"strings"
 	"time"
 )
 
@@ -25,7 +26,7 @@ func (l *LspServer) TextDocumentDidOpen(ctx context.Context, vs lsp.DidOpenTextD
 	}
 
 	// 判断是否已经当成lua处理
-	if !common.GConfig.IsHandleAsLua(strFile) {
+	if !l.conf.IsHandleAsLua(strFile) {
 		log.Debug("not need to handle same as lua strFile=%s", strFile)
 		return nil
 	}
@@ -93,7 +94,7 @@ func (l *LspServer) TextDocumentDidChange(ctx context.Context, vs lsp.DidChangeT
 		return nil
 	}
 
-	errList := project.HandleFileChangeAnalysis(strFile, contents)
+	errList := project.HandleFileChangeAnalysis(strFile, contents, getChangeRangeVec(vs.ContentChanges))
 	if len(errList) > 0 {
 		l.InsertChangeFileErr(ctx, strFile, errList)
 		// 设置文件修改的时间
@@ -109,6 +110,66 @@ func (l *LspServer) TextDocumentDidChange(ctx context.Context, vs lsp.DidChangeT
 	return nil
 }
 
+// lineEdit 一次修改的行范围
+type lineEdit struct {
+	startLine  int // 修改的开始行
+	endLine    int // 修改之前的结束行
+	newEndLine int // 修改之后的结束行
+}
+
+// restoreLine 把这次修改之后的行号还原为修改之前的行号，修改插入的行还原为修改的开始行或是结束行
+func (e lineEdit) restoreLine(line int, startFlag bool) int {
+	if line < e.startLine {
+		return line
+	}
+
+	if line <= e.newEndLine {
+		if startFlag {
+			return e.startLine
+		}
+		return e.endLine
+	}
+
+	return line - (e.newEndLine - e.endLine)
+}
+
+// getChangeRangeVec 获取修改的行范围，用于增量解析，返回的是修改之前内容的行号
+// 多个修改时，后面修改的位置是基于前面修改之后的内容，需要依次撤销前面的修改，还原为修改之前的行号
+// 有没有指定范围的全量修改时，返回nil整体重新解析
+func getChangeRangeVec(contentChanges []lsp.TextDocumentContentChangeEvent) []parser.ChangeRange {
+	if len(contentChanges) == 0 {
+		return nil
+	}
+
+	changeVec := make([]parser.ChangeRange, 0, len(contentChanges))
+	editVec := make([]lineEdit, 0, len(contentChanges))
+	for _, change := range contentChanges {
+		if change.Range == nil {
+			return nil
+		}
+
+		oneEdit := lineEdit{
+			startLine: int(change.Range.Start.Line) + 1,
+			endLine:   int(change.Range.End.Line) + 1,
+		}
+		oneEdit.newEndLine = oneEdit.startLine + strings.Count(change.Text, "\n")
+
+		startLine, endLine := oneEdit.startLine, oneEdit.endLine
+		for i := len(editVec) - 1; i >= 0; i-- {
+			startLine = editVec[i].restoreLine(startLine, true)
+			endLine = editVec[i].restoreLine(endLine, false)
+		}
+
+		changeVec = append(changeVec, parser.ChangeRange{
+			StartLine: startLine,
+			EndLine:   endLine,
+		})
+		editVec = append(editVec, oneEdit)
+	}
+
+	return changeVec
+}
+
 // WorkspaceChangeWatchedFiles 整个工程目录lua文件的变化
 func (l *LspServer) WorkspaceChangeWatchedFiles(ctx context.Context, vs lsp.DidChangeWatchedFilesParams) error {
 	l.requestMutex.Lock()
@@ -124,7 +185,7 @@ func (l *LspServer) WorkspaceChangeWatchedFiles(ctx context.Context, vs lsp.DidC
 			continue
 		}
 
-		if !common.GConfig.IsHandleAsLua(strFile) {
+		if !l.conf.IsHandleAsLua(strFile) {
 			log.Debug("not need to handle same as lua strFile=%s", strFile)
 			continue
 		}
@@ -177,7 +238,7 @@ func (l *LspServer) TextDocumentDidClose(ctx context.Context, vs lsp.DidCloseTex
 	l.ClearChangeFileErr(ctx, strFile)
 
 	// 非工程文件夹下的文件，清除所有的诊断错误
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	if !dirManager.IsInDir(strFile) {
 		l.ClearOneFileDiagnostic(ctx, strFile)
 		l.RemoveFile(strFile)
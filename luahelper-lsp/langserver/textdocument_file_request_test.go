@@ -2,10 +2,16 @@ package langserver
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/parser"
+	"luahelper-lsp/langserver/check/results"
 	lsp "luahelper-lsp/langserver/protocol"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"testing"
 )
 
@@ -38,3 +44,192 @@ func TestProjectOpen(t *testing.T) {
 		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
 	}
 }
+
+func TestGetChangeRangeVec(t *testing.T) {
+	createChange := func(startLine, endLine uint32, text string) lsp.TextDocumentContentChangeEvent {
+		return lsp.TextDocumentContentChangeEvent{
+			Range: &lsp.Range{
+				Start: lsp.Position{Line: startLine},
+				End:   lsp.Position{Line: endLine},
+			},
+			Text: text,
+		}
+	}
+
+	type changeTest struct {
+		changes []lsp.TextDocumentContentChangeEvent
+		expect  []parser.ChangeRange
+	}
+
+	testVec := []changeTest{
+		// 单个修改
+		{
+			changes: []lsp.TextDocumentContentChangeEvent{createChange(2, 3, "a")},
+			expect:  []parser.ChangeRange{{StartLine: 3, EndLine: 4}},
+		},
+		// 前面的修改插入了两行，后面修改的行号需要减去插入的行
+		{
+			changes: []lsp.TextDocumentContentChangeEvent{
+				createChange(1, 1, "a\nb\n"),
+				createChange(10, 10, "c"),
+			},
+			expect: []parser.ChangeRange{{StartLine: 2, EndLine: 2}, {StartLine: 9, EndLine: 9}},
+		},
+		// 前面的修改删除了三行，后面修改的行号需要加上删除的行
+		{
+			changes: []lsp.TextDocumentContentChangeEvent{
+				createChange(4, 7, ""),
+				createChange(8, 8, "c"),
+			},
+			expect: []parser.ChangeRange{{StartLine: 5, EndLine: 8}, {StartLine: 12, EndLine: 12}},
+		},
+		// 后面的修改在前面修改插入的内容中，还原为前面修改的范围
+		{
+			changes: []lsp.TextDocumentContentChangeEvent{
+				createChange(5, 6, "a\nb\nc"),
+				createChange(6, 7, "d"),
+			},
+			expect: []parser.ChangeRange{{StartLine: 6, EndLine: 7}, {StartLine: 6, EndLine: 7}},
+		},
+		// 后面的修改在前面修改的前面，行号不变
+		{
+			changes: []lsp.TextDocumentContentChangeEvent{
+				createChange(9, 9, "a\n"),
+				createChange(2, 3, "b"),
+			},
+			expect: []parser.ChangeRange{{StartLine: 10, EndLine: 10}, {StartLine: 3, EndLine: 4}},
+		},
+	}
+
+	for index, oneTest := range testVec {
+		changeVec := getChangeRangeVec(oneTest.changes)
+		if !reflect.DeepEqual(changeVec, oneTest.expect) {
+			t.Fatalf("index=%d, changeVec=%v, expect=%v", index, changeVec, oneTest.expect)
+		}
+	}
+
+	// 有全量修改时，整体重新解析
+	changes := []lsp.TextDocumentContentChangeEvent{createChange(1, 1, "a"), {Text: "local a = 1"}}
+	if changeVec := getChangeRangeVec(changes); changeVec != nil {
+		t.Fatalf("full change, changeVec=%v", changeVec)
+	}
+}
+
+// getFirstResultDesc 获取第一阶段分析结果的描述，用于比较只分析函数体与整体分析的结果是否一致
+func getFirstResultDesc(fileResult *results.FileResult) (descVec []string) {
+	for _, oneFunc := range fileResult.FuncIDVec {
+		descVec = append(descVec, fmt.Sprintf("func id=%d, lv=%d, loc=%v, params=%v, returns=%d", oneFunc.FuncID,
+			oneFunc.FuncLv, oneFunc.Loc, oneFunc.ParamList, len(oneFunc.ReturnVecs)))
+	}
+
+	var varVec []*common.VarInfo
+	for _, oneFunc := range fileResult.FuncIDVec {
+		for strName, varList := range oneFunc.MainScope.LocVarMap {
+			for _, varInfo := range varList.VarVec {
+				varVec = append(varVec, varInfo)
+				descVec = append(descVec, fmt.Sprintf("var name=%s, loc=%v, refer=%v", strName, varInfo.Loc,
+					common.GetExpLoc(varInfo.ReferExp)))
+			}
+		}
+	}
+	fileResult.MainFunc.MainScope.GetAllVarInfos(&varVec)
+	for _, varInfo := range varVec {
+		for strName, subVar := range varInfo.SubMaps {
+			descVec = append(descVec, fmt.Sprintf("sub name=%s, loc=%v, refer=%v", strName, subVar.Loc,
+				common.GetExpLoc(subVar.ReferExp)))
+		}
+	}
+
+	for strName, varInfo := range fileResult.GlobalMaps {
+		descVec = append(descVec, fmt.Sprintf("global name=%s, loc=%v", strName, varInfo.Loc))
+	}
+
+	sort.Strings(descVec)
+	return descVec
+}
+
+func TestChangeFuncBody(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/incremental"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "func_body.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err1 := lspServer.TextDocumentDidOpen(context, openParams); err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	changeFile := func(server *LspServer, changeRange *lsp.Range, text string) *results.FileResult {
+		changParams := lsp.DidChangeTextDocumentParams{
+			TextDocument: lsp.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+			},
+			ContentChanges: []lsp.TextDocumentContentChangeEvent{
+				{
+					Range: changeRange,
+					Text:  text,
+				},
+			},
+		}
+		server.TextDocumentDidChange(context, changParams)
+
+		fileStruct, _ := server.getAllProject().GetCacheFileStruct(fileName)
+		if fileStruct == nil || fileStruct.FileResult == nil {
+			t.Fatalf("change file:%s not find cache result", fileName)
+		}
+		return fileStruct.FileResult
+	}
+
+	createRange := func(line, startCh, endCh uint32) *lsp.Range {
+		return &lsp.Range{
+			Start: lsp.Position{Line: line, Character: startCh},
+			End:   lsp.Position{Line: line, Character: endCh},
+		}
+	}
+
+	// 第一次修改，整体分析
+	firstResult := changeFile(lspServer, createRange(1, 14, 15), "1")
+
+	// 修改M.get的函数体，函数体只读取了函数外部的局部变量，只重新分析函数体
+	changeResult := changeFile(lspServer, createRange(9, 18, 23), "add(x, 1) + x")
+	if changeResult != firstResult {
+		t.Fatalf("change func body of M.get, not reuse the result")
+	}
+
+	// 另外一个会话整体分析修改后的内容，结果需要一致
+	contents, _ := lspServer.getFileCache().GetFileContent(fileName)
+	fullServer := createLspTest(strRootPath, strRootURI)
+	if err1 := fullServer.TextDocumentDidOpen(context, openParams); err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+	fullResult := changeFile(fullServer, nil, string(contents))
+	changeDesc := getFirstResultDesc(changeResult)
+	fullDesc := getFirstResultDesc(fullResult)
+	if !reflect.DeepEqual(changeDesc, fullDesc) {
+		t.Fatalf("change func body result=%v\nfull result=%v", changeDesc, fullDesc)
+	}
+
+	// 修改M.set的函数体，函数体修改了函数外部的局部变量，整体重新分析
+	setResult := changeFile(lspServer, createRange(14, 12, 13), "x + 1")
+	if setResult == changeResult {
+		t.Fatalf("change func body of M.set, reuse the result")
+	}
+}
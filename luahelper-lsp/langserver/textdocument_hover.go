@@ -3,8 +3,6 @@ package langserver
 import (
 	"context"
 	"fmt"
-	"luahelper-lsp/langserver/check"
-	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/codingconv"
 	"luahelper-lsp/langserver/log"
 	lsp "luahelper-lsp/langserver/protocol"
@@ -80,7 +78,7 @@ func (l *LspServer) TextDocumentHover(ctx context.Context, vs lsp.TextDocumentPo
 // 获取鼠标悬停的字符串
 func (l *LspServer) getHoverStr(comResult commFileRequest) (lableStr, docStr, luaFileStr string) {
 	// 1) 判断是否普通悬停打开一个文件
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 	if showOpenFile := l.hoverOpenFile(comResult); showOpenFile != "" {
 		docStr = "lua file : " + dirManager.RemovePathDirPre(showOpenFile)
 		return
@@ -95,7 +93,7 @@ func (l *LspServer) getHoverStr(comResult commFileRequest) (lableStr, docStr, lu
 	}
 
 	// 3) 普通查找定义悬浮
-	varStruct := check.GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
+	varStruct := l.getAllProject().GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
 	if !varStruct.ValidFlag {
 		log.Error("TextDocumentDefine not valid")
 		return
@@ -108,7 +106,7 @@ func (l *LspServer) getHoverStr(comResult commFileRequest) (lableStr, docStr, lu
 
 // 判断是否悬停提示打开一个文件
 func (l *LspServer) hoverOpenFile(comResult commFileRequest) (fileName string) {
-	fileList := stringutil.GetOpenFileStr(comResult.contents, comResult.offset, (int)(comResult.pos.Character), common.GConfig.GetFrameReferFiles())
+	fileList := stringutil.GetOpenFileStr(comResult.contents, comResult.offset, (int)(comResult.pos.Character), l.conf.GetFrameReferFiles())
 	if len(fileList) == 0 {
 		return
 	}
@@ -144,7 +144,7 @@ func TestHover2(t *testing.T) {
 		Line:      4,
 		Character: 13,
 	})
-	resultList = append(resultList, "table")
+	resultList = append(resultList, "{ d: number, g: number }")
 
 	positionList = append(positionList, lsp.Position{
 		Line:      5,
@@ -156,7 +156,7 @@ func TestHover2(t *testing.T) {
 		Line:      6,
 		Character: 13,
 	})
-	resultList = append(resultList, "table")
+	resultList = append(resultList, "{ a: number, b: number }")
 
 	positionList = append(positionList, lsp.Position{
 		Line:      7,
@@ -218,7 +218,7 @@ func TestHover2(t *testing.T) {
 		Line:      27,
 		Character: 33,
 	})
-	resultList = append(resultList, "table")
+	resultList = append(resultList, "{ a: number, b: number }")
 
 	positionList = append(positionList, lsp.Position{
 		Line:      27,
@@ -293,7 +293,7 @@ func TestHover3(t *testing.T) {
 		Line:      20,
 		Character: 13,
 	})
-	resultList = append(resultList, "table")
+	resultList = append(resultList, "{ a: number, b: number }")
 
 	positionList = append(positionList, lsp.Position{
 		Line:      21,
@@ -305,7 +305,7 @@ func TestHover3(t *testing.T) {
 		Line:      22,
 		Character: 13,
 	})
-	resultList = append(resultList, "table")
+	resultList = append(resultList, "{ a: number, b: number }")
 
 	for index, onePoisiton := range positionList {
 		hoverParams := lsp.TextDocumentPositionParams{
@@ -924,8 +924,8 @@ func TestHover11(t *testing.T) {
 		Line:      5,
 		Character: 8,
 	})
-	resultList = append(resultList, "aaa: number = 1")
-	resultList = append(resultList, "bbb: number = 2")
+	resultList = append(resultList, "aaa: number")
+	resultList = append(resultList, "bbb: number")
 
 	for index, onePoisiton := range positionList {
 		hoverParams := lsp.TextDocumentPositionParams{
@@ -954,8 +954,8 @@ func TestHover11(t *testing.T) {
 		Line:      12,
 		Character: 8,
 	})
-	resultList = append(resultList, "ccc: number = 1")
-	resultList = append(resultList, "ddd: number = 2")
+	resultList = append(resultList, "ccc: number")
+	resultList = append(resultList, "ddd: number")
 
 	for index, onePoisiton := range positionList {
 		hoverParams := lsp.TextDocumentPositionParams{
@@ -984,10 +984,10 @@ func TestHover11(t *testing.T) {
 		Line:      13,
 		Character: 8,
 	})
-	resultList = append(resultList, "ccc: number = 1")
-	resultList = append(resultList, "ddd: number = 2")
-	resultList = append(resultList, "ccc: number = 1")
-	resultList = append(resultList, "ddd: number = 2")
+	resultList = append(resultList, "ccc: number")
+	resultList = append(resultList, "ddd: number")
+	resultList = append(resultList, "ccc: number")
+	resultList = append(resultList, "ddd: number")
 
 	for index, onePoisiton := range positionList {
 		hoverParams := lsp.TextDocumentPositionParams{
@@ -1050,8 +1050,8 @@ func TestHover12(t *testing.T) {
 		Line:      17,
 		Character: 8,
 	})
-	resultList = append(resultList, "a: number = 1")
-	resultList = append(resultList, "b: number = 2")
+	resultList = append(resultList, "a: number")
+	resultList = append(resultList, "b: number")
 
 	for index, onePoisiton := range positionList {
 		hoverParams := lsp.TextDocumentPositionParams{
@@ -1488,3 +1488,467 @@ func TestHoverFuncReturn(t *testing.T) {
 		}
 	}
 }
+
+// hover 没有返回值注解的函数，显示多处return推导的返回值类型
+func TestHoverInferReturn(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_infer_return.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      0,
+		Character: 18,
+	})
+	resultList = append(resultList, []string{"string|table|nil"})
+
+	// 函数有的return返回nil，推导出来的变量可能为nil
+	positionList = append(positionList, lsp.Position{
+		Line:      16,
+		Character: 6,
+	})
+	resultList = append(resultList, []string{"local p : string|nil"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      12,
+		Character: 18,
+	})
+	resultList = append(resultList, []string{"string = \"a\""})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
+
+func TestHoverTableShape(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_table_shape.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      4,
+		Character: 8,
+	})
+	resultList = append(resultList, []string{"{ x: number|string, name: string, hp: number }"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      5,
+		Character: 13,
+	})
+	resultList = append(resultList, []string{"number|string"})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
+
+func TestHoverCast(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_cast.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      10,
+		Character: 7,
+	})
+	resultList = append(resultList, []string{"CastPlayer", "hp: number"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      13,
+		Character: 7,
+	})
+	resultList = append(resultList, []string{"CastPlayer", "hp: number"})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
+
+func TestHoverMoocClass(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_mooc_class.mooc"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      5,
+		Character: 8,
+	})
+	resultList = append(resultList, []string{"function Animal:speak()"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      8,
+		Character: 15,
+	})
+	resultList = append(resultList, []string{"function Animal.create()"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      16,
+		Character: 15,
+	})
+	resultList = append(resultList, []string{"function Animal:init(name: any)"})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
+
+func TestHoverLuauType(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_luau_type.luau"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      2,
+		Character: 16,
+	})
+	resultList = append(resultList, []string{"a: number", "@param b? number", "->1. number"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      6,
+		Character: 7,
+	})
+	resultList = append(resultList, []string{"Point", "x: number"})
+
+	// 前一行为尾部注释时，类型注解仍然生效
+	positionList = append(positionList, lsp.Position{
+		Line:      16,
+		Character: 7,
+	})
+	resultList = append(resultList, []string{"Point", "x: number"})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
+
+func TestHoverEmbedNginx(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	lspServer.getConfig().SetClientDialectMap(map[string]string{"*.conf": "nginx"})
+
+	fileName := strRootPath + "/" + "hover_embed_nginx.conf"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	hoverParams := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+		Position: lsp.Position{
+			Line:      9,
+			Character: 21,
+		},
+	}
+	hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+	if err1 != nil {
+		t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+	}
+
+	hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+	for _, oneStr := range []string{"local function greet(name: string)", "hover_embed_nginx.conf"} {
+		if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+			t.Fatalf("hover error, not find str=%s, value=%s", oneStr, hoverMarkUpReturn1.Contents.Value)
+		}
+	}
+}
+
+// hover 二元运算的操作数定义了运算符元方法，显示元方法的结果类型
+func TestHoverOperator(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/hover"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "hover_operator.lua"
+	data, err := ioutil.ReadFile(fileName)
+
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err1 := lspServer.TextDocumentDidOpen(context, openParams)
+	if err1 != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err1.Error())
+	}
+
+	var resultList [][]string = [][]string{}
+	var positionList []lsp.Position = []lsp.Position{}
+	positionList = append(positionList, lsp.Position{
+		Line:      20,
+		Character: 6,
+	})
+	resultList = append(resultList, []string{"local m2 : Money", "value: number"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      21,
+		Character: 6,
+	})
+	resultList = append(resultList, []string{"local m3 : string"})
+
+	positionList = append(positionList, lsp.Position{
+		Line:      22,
+		Character: 6,
+	})
+	resultList = append(resultList, []string{"local n : number"})
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+
+		for _, oneStr := range resultList[index] {
+			if !strings.Contains(hoverMarkUpReturn1.Contents.Value, oneStr) {
+				t.Fatalf("hover error, not find str=%s, index=%d, value=%s", oneStr, index, hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
@@ -2,7 +2,6 @@ package langserver
 
 import (
 	"context"
-	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
@@ -21,7 +20,7 @@ func (l *LspServer) TextDocumentReferences(ctx context.Context, vs protocol.Refe
 	}
 
 	project := l.getAllProject()
-	varStruct := check.GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
+	varStruct := l.getAllProject().GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
 	if !varStruct.ValidFlag {
 		log.Error("TextDocumentReferences not valid")
 		return
@@ -42,7 +41,7 @@ func (l *LspServer) TextDocumentReferences(ctx context.Context, vs protocol.Refe
 	// 去掉前缀后的名字
 	referenVecs := project.FindReferences(comResult.strFile, &varStruct, common.CRSReference)
 	locList = make([]protocol.Location, 0, len(referenVecs))
-	referenceNum := common.GConfig.ReferenceMaxNum
+	referenceNum := l.conf.ReferenceMaxNum
 	for i, referVarInfo := range referenVecs {
 		if i >= referenceNum {
 			break
@@ -2,7 +2,6 @@ package langserver
 
 import (
 	"context"
-	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
@@ -22,7 +21,7 @@ func (l *LspServer) TextDocumentRename(ctx context.Context, vs lsp.RenameParams)
 	}
 
 	project := l.getAllProject()
-	varStruct := check.GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
+	varStruct := l.getAllProject().GetVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character, comResult.strFile)
 	if !varStruct.ValidFlag {
 		log.Error("TextDocumentRename varStruct.ValidFlag not valid")
 		return
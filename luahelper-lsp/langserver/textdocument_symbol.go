@@ -33,34 +33,17 @@ func transferSymbolVec(strFile string, level int, fileSymbolVec []common.FileSym
 	items = make([]lsp.DocumentSymbol, 0, vecLen)
 
 	isMooc := strings.HasSuffix(strFile, ".mooc")
-	lastFuncSymbol := lsp.DocumentSymbol{}
+	var lastFuncRange *lsp.Range
 
 	for _, oneSymbol := range fileSymbolVec {
 		ra := lspcommon.LocToRange(&oneSymbol.Loc)
 
 		fullName := oneSymbol.Name
 		if oneSymbol.ContainerName == "" {
-			if isMooc {
-				// mark 标记，或者丢掉多余的类前缀
-				if oneSymbol.Kind == common.IKAnnotateMark {
-					fullName = "---"
-				} else if level <= 0 {
-					fullName = "export " + fullName
-				} else {
-					idx := strings.Index(fullName, ".")
-					if idx > 0 {
-						if oneSymbol.Kind == common.IKFunction {
-							fullName = fullName[idx:]
-						} else {
-							fullName = fullName[idx+1:]
-						}
-					} else {
-						idx = strings.Index(fullName, ":")
-						if idx > 0 && oneSymbol.Kind == common.IKFunction {
-							fullName = fullName[idx:]
-						}
-					}
-				}
+			if isMooc && oneSymbol.Kind == common.IKAnnotateMark {
+				fullName = "---"
+			} else if isMooc && level <= 0 {
+				fullName = "export " + fullName
 			}
 		} else {
 			if oneSymbol.ContainerName == "local" {
@@ -74,7 +57,8 @@ func transferSymbolVec(strFile string, level int, fileSymbolVec []common.FileSym
 
 		symbol := lsp.DocumentSymbol{
 			Name:           fullName,
-			Kind:           lsp.Variable,
+			Kind:           getLspSymbolKind(oneSymbol.Kind),
+			Detail:         oneSymbol.Detail,
 			Range:          ra,
 			SelectionRange: ra,
 		}
@@ -82,31 +66,35 @@ func transferSymbolVec(strFile string, level int, fileSymbolVec []common.FileSym
 		if oneSymbol.Children != nil {
 			symbol.Children = transferSymbolVec(strFile, level+1, oneSymbol.Children)
 		}
-		if oneSymbol.Kind == common.IKAnnotateAlias {
+
+		switch oneSymbol.Kind {
+		case common.IKAnnotateAlias:
 			symbol.Kind = lsp.Interface
 			symbol.Detail = "annotate alias"
-		} else if oneSymbol.Kind == common.IKAnnotateClass {
-			symbol.Kind = lsp.Interface
-			symbol.Detail = "annotate class"
-		} else if oneSymbol.Kind == common.IKFunction {
-			symbol.Kind = lsp.Function
-			symbol.Detail = "function"
-			lastFuncSymbol = symbol
-		} else if oneSymbol.Kind == common.IKAnnotateMark {
-			symbol.Kind = lsp.Field
+		case common.IKAnnotateMark:
 			symbol.Detail = oneSymbol.Name
-		} else if len(oneSymbol.Children) != 0 {
-			symbol.Kind = lsp.Class
+		case common.IKFunction, common.IKMethod:
+			lastFuncRange = &symbol.Range
+		case common.IKClass:
+			if symbol.Detail == "" {
+				symbol.Detail = "class"
+			}
+		case common.IKModule, common.IKEnum:
 			symbol.Detail = "table"
-		} else {
-			symbol.Detail = "variable"
+		case common.IKVariable:
+			if len(oneSymbol.Children) != 0 {
+				symbol.Kind = lsp.Object
+				symbol.Detail = "table"
+			} else if symbol.Detail == "" {
+				symbol.Detail = "variable"
+			}
 		}
 
-		if symbol.Detail == "variable" &&
+		if oneSymbol.Kind == common.IKVariable && len(oneSymbol.Children) == 0 &&
 			oneSymbol.ContainerName == "local" &&
-			lastFuncSymbol.Detail == "function" &&
-			symbol.Range.Start.Line > lastFuncSymbol.Range.Start.Line &&
-			symbol.Range.End.Line < lastFuncSymbol.Range.End.Line {
+			lastFuncRange != nil &&
+			symbol.Range.Start.Line > lastFuncRange.Start.Line &&
+			symbol.Range.End.Line < lastFuncRange.End.Line {
 			// igonre local variable inside function
 		} else {
 			items = append(items, symbol)
@@ -115,6 +103,30 @@ func transferSymbolVec(strFile string, level int, fileSymbolVec []common.FileSym
 	return
 }
 
+// getLspSymbolKind 符号的类型转换为lsp的类型
+func getLspSymbolKind(kind common.ItemKind) lsp.SymbolKind {
+	switch kind {
+	case common.IKFunction:
+		return lsp.Function
+	case common.IKMethod:
+		return lsp.Method
+	case common.IKClass, common.IKAnnotateClass:
+		return lsp.Class
+	case common.IKAnnotateAlias:
+		return lsp.Interface
+	case common.IKField, common.IKAnnotateMark:
+		return lsp.Field
+	case common.IKEnum:
+		return lsp.Enum
+	case common.IKEnumMember:
+		return lsp.EnumMember
+	case common.IKModule:
+		return lsp.Module
+	}
+
+	return lsp.Variable
+}
+
 // for sort lsp.DocumentSymbol
 type lspSymbolSlice []lsp.DocumentSymbol
 
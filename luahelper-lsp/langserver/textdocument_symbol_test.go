@@ -0,0 +1,125 @@
+package langserver
+
+import (
+	"context"
+	lsp "luahelper-lsp/langserver/protocol"
+	"testing"
+)
+
+func getDocumentSymbols(t *testing.T, lspServer *LspServer, fileName string) []lsp.DocumentSymbol {
+	strFile := lspServer.getConfig().GetDirManager().GetMainDir() + "/" + fileName
+	items, err := lspServer.TextDocumentSymbol(context.Background(), lsp.DocumentSymbolParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI("file://" + strFile),
+		},
+	})
+	if err != nil {
+		t.Fatalf("TextDocumentSymbol error, file=%s, err=%s", fileName, err.Error())
+	}
+	return items
+}
+
+func findDocumentSymbol(items []lsp.DocumentSymbol, strName string) *lsp.DocumentSymbol {
+	for i := range items {
+		if items[i].Name == strName {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+func TestDocumentSymbolAnnotateClass(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+	items := getDocumentSymbols(t, lspServer, "animal.lua")
+
+	// ---@class 关联的table合并为一个类，不再单独显示注解的class
+	if findDocumentSymbol(items, "Animal") != nil {
+		t.Fatalf("annotate class Animal should merge into local Animal")
+	}
+
+	animal := findDocumentSymbol(items, "local Animal")
+	if animal == nil {
+		t.Fatalf("symbol local Animal not find")
+	}
+	if animal.Kind != lsp.Class || animal.Detail != "class Animal" {
+		t.Fatalf("local Animal kind=%d, detail=%s", animal.Kind, animal.Detail)
+	}
+
+	expectList := []struct {
+		name   string
+		kind   lsp.SymbolKind
+		detail string
+	}{
+		{"speak", lsp.Method, "function Animal:speak()"},
+		{"create", lsp.Function, "function Animal.create()"},
+		{"legs", lsp.Field, ""},
+	}
+	if len(animal.Children) != len(expectList) {
+		t.Fatalf("local Animal children len=%d, expect=%d", len(animal.Children), len(expectList))
+	}
+	for i, expect := range expectList {
+		child := animal.Children[i]
+		if child.Name != expect.name || child.Kind != expect.kind || child.Detail != expect.detail {
+			t.Fatalf("child %d name=%s kind=%d detail=%s, expect %v", i, child.Name, child.Kind, child.Detail, expect)
+		}
+	}
+
+	colorType := findDocumentSymbol(items, "ColorType")
+	if colorType == nil || colorType.Kind != lsp.Enum {
+		t.Fatalf("symbol ColorType is not enum")
+	}
+	for _, child := range colorType.Children {
+		if child.Kind != lsp.EnumMember {
+			t.Fatalf("ColorType member %s kind=%d", child.Name, child.Kind)
+		}
+	}
+
+	helper := findDocumentSymbol(items, "helperUtil")
+	if helper == nil || helper.Kind != lsp.Function || helper.Detail != "function helperUtil()" {
+		t.Fatalf("symbol helperUtil error")
+	}
+}
+
+func TestDocumentSymbolAnnotateClassRange(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+	items := getDocumentSymbols(t, lspServer, "baz.lua")
+
+	// ---@class 在第1行，local Baz 在第4行，符号的范围需要包含上面的注解块
+	baz := findDocumentSymbol(items, "local Baz")
+	if baz == nil {
+		t.Fatalf("symbol local Baz not find")
+	}
+	if baz.Range.Start.Line != 0 {
+		t.Fatalf("local Baz range start line=%d, expect=0", baz.Range.Start.Line)
+	}
+
+	for _, strName := range []string{"name", "n", "get"} {
+		child := findDocumentSymbol(baz.Children, strName)
+		if child == nil {
+			t.Fatalf("Baz member %s not find", strName)
+		}
+		if child.Range.Start.Line < baz.Range.Start.Line || child.Range.End.Line > baz.Range.End.Line {
+			t.Fatalf("Baz member %s range %v is out of %v", strName, child.Range, baz.Range)
+		}
+	}
+}
+
+func TestDocumentSymbolMoocClass(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+	items := getDocumentSymbols(t, lspServer, "shape.mooc")
+
+	shape := findDocumentSymbol(items, "export Shape")
+	if shape == nil || shape.Kind != lsp.Class {
+		t.Fatalf("symbol export Shape is not class")
+	}
+
+	sides := findDocumentSymbol(shape.Children, "sides")
+	if sides == nil || sides.Kind != lsp.Field {
+		t.Fatalf("Shape member sides error")
+	}
+
+	area := findDocumentSymbol(shape.Children, "area")
+	if area == nil || area.Kind != lsp.Method {
+		t.Fatalf("Shape member area error")
+	}
+}
@@ -0,0 +1,248 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"luahelper-lsp/langserver/log"
+	lsp "luahelper-lsp/langserver/protocol"
+	"time"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+// 客户端通过 $/setTrace 设置的跟踪级别
+const (
+	traceOff      = "off"
+	traceMessages = "messages"
+	traceVerbose  = "verbose"
+)
+
+// requestTraceMsg 每个请求处理完成后，结构化日志的消息
+const requestTraceMsg = "request"
+
+// verbose 跟踪时，请求参数最多输出的长度
+const traceParamsMaxLen = 1024
+
+// traceAssigner 包装所有请求的处理函数，记录每个请求的跟踪信息
+type traceAssigner struct {
+	l          *LspServer
+	handlerMap handler.Map
+}
+
+// Assign implements part of the jrpc2.Assigner interface.
+func (t *traceAssigner) Assign(ctx context.Context, method string) jrpc2.Handler {
+	h := t.handlerMap.Assign(ctx, method)
+	if h == nil {
+		return nil
+	}
+
+	// 处理请求的协程绑定到当前连接，请求中输出的日志只转发给这个连接的客户端
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		defer log.BindSession(t.l.sessionID)()
+		return t.l.traceRequest(ctx, req, h)
+	})
+}
+
+// Names implements part of the jrpc2.Assigner interface.
+func (t *traceAssigner) Names() []string {
+	return t.handlerMap.Names()
+}
+
+// SetTrace 客户端修改跟踪的级别
+func (l *LspServer) SetTrace(ctx context.Context, vs lsp.SetTraceParams) error {
+	l.setTraceValue(vs.Value)
+	return nil
+}
+
+// getTraceValue 获取当前的跟踪级别
+func (l *LspServer) getTraceValue() string {
+	l.traceMutex.Lock()
+	defer l.traceMutex.Unlock()
+	return l.traceValue
+}
+
+// setTraceValue 设置跟踪的级别，开启跟踪时把服务器的日志通过 window/logMessage 转发给客户端
+// messages 级别转发info及以上的日志，verbose 级别转发所有的日志
+func (l *LspServer) setTraceValue(traceValue string) {
+	if traceValue != traceMessages && traceValue != traceVerbose {
+		traceValue = traceOff
+	}
+
+	l.traceMutex.Lock()
+	defer l.traceMutex.Unlock()
+	if l.traceValue == traceValue {
+		return
+	}
+
+	l.traceValue = traceValue
+	if l.removeLogListener != nil {
+		l.removeLogListener()
+		l.removeLogListener = nil
+	}
+
+	switch traceValue {
+	case traceMessages:
+		l.removeLogListener = log.AddListener(log.LevelInfo, l.forwardLogMessage)
+	case traceVerbose:
+		l.removeLogListener = log.AddListener(log.LevelDebug, l.forwardLogMessage)
+	}
+}
+
+// forwardLogMessage 把一条服务器日志转发给客户端，这里不能再输出日志
+func (l *LspServer) forwardLogMessage(entry *log.Entry) {
+	// 请求的跟踪信息已经通过 $/logTrace 发送
+	if entry.Msg == requestTraceMsg || l.server == nil {
+		return
+	}
+
+	if !l.isSessionEntry(entry) {
+		return
+	}
+
+	messageType := lsp.Log
+	switch entry.Level {
+	case log.LevelError:
+		messageType = lsp.Error
+	case log.LevelWarn:
+		messageType = lsp.Warning
+	case log.LevelInfo:
+		messageType = lsp.Info
+	}
+
+	message := string(log.FormatEntry(entry, log.FormatText))
+	err := l.server.Notify(context.Background(), "window/logMessage", lsp.LogMessageParams{
+		Type:    messageType,
+		Message: message[:len(message)-1],
+	})
+
+	// 连接已经断开了，不再转发
+	if err == jrpc2.ErrConnClosed {
+		go l.setTraceValue(traceOff)
+	}
+}
+
+// isSessionEntry 判断日志是否需要转发给当前连接的客户端
+// 监听者是进程全局的，只转发属于当前连接的日志，处理请求的协程绑定了连接，请求中输出的日志都带有连接的序号
+// 不属于任何连接的公共日志，无法判断是哪一个客户端引起的，不转发
+func (l *LspServer) isSessionEntry(entry *log.Entry) bool {
+	return entry.Session == l.sessionID
+}
+
+// requestTrace 处理完成的请求的跟踪信息，请求的返回结果序列化后，再输出跟踪日志
+type requestTrace struct {
+	method string
+	id     string
+	file   string
+	params string
+	cost   time.Duration
+	errStr string
+}
+
+// traceLogger 实现jrpc2.RPCLogger，在返回结果发送给客户端之前，获取jrpc2序列化后的结果大小
+type traceLogger struct {
+	l *LspServer
+}
+
+// LogRequest implements part of the jrpc2.RPCLogger interface.
+func (t *traceLogger) LogRequest(ctx context.Context, req *jrpc2.Request) {
+}
+
+// LogResponse implements part of the jrpc2.RPCLogger interface.
+func (t *traceLogger) LogResponse(ctx context.Context, rsp *jrpc2.Response) {
+	t.l.traceMutex.Lock()
+	trace, ok := t.l.traceReqMap[rsp.ID()]
+	delete(t.l.traceReqMap, rsp.ID())
+	t.l.traceMutex.Unlock()
+
+	if ok {
+		t.l.outputRequestTrace(ctx, trace, len(rsp.ResultString()))
+	}
+}
+
+// traceRequest 处理请求，记录请求的耗时指标
+// 通知没有返回结果，直接输出跟踪信息；请求等jrpc2序列化返回结果后，在 traceLogger.LogResponse 中输出
+func (l *LspServer) traceRequest(ctx context.Context, req *jrpc2.Request, h jrpc2.Handler) (interface{}, error) {
+	beginTime := time.Now()
+	result, err := h.Handle(ctx, req)
+	cost := time.Since(beginTime)
+	l.observeRequest(req.Method(), float64(cost.Microseconds())/1000)
+
+	if l.getTraceValue() == traceOff && !log.IsLevelEnabled(log.LevelDebug) {
+		return result, err
+	}
+
+	trace := &requestTrace{
+		method: req.Method(),
+		id:     req.ID(),
+		file:   getRequestFile(req),
+		params: req.ParamString(),
+		cost:   cost,
+	}
+	if err != nil {
+		trace.errStr = err.Error()
+	}
+
+	if req.IsNotification() {
+		l.outputRequestTrace(ctx, trace, 0)
+		return result, err
+	}
+
+	l.traceMutex.Lock()
+	l.traceReqMap[trace.id] = trace
+	l.traceMutex.Unlock()
+	return result, err
+}
+
+// outputRequestTrace 输出请求的方法、ID、文件、耗时与返回结果的大小，开启跟踪时通过 $/logTrace 发送给客户端
+func (l *LspServer) outputRequestTrace(ctx context.Context, trace *requestTrace, resultSize int) {
+	costMs := trace.cost.Milliseconds()
+	log.SessionLog(l.sessionID, log.LevelDebug, requestTraceMsg, "method", trace.method, "id", trace.id,
+		"file", trace.file, "duration_ms", costMs, "error", trace.errStr, "result_size", resultSize)
+
+	traceValue := l.getTraceValue()
+	if traceValue == traceOff || l.server == nil {
+		return
+	}
+
+	var message string
+	if trace.id == "" {
+		message = fmt.Sprintf("Handled notification '%s' in %dms", trace.method, costMs)
+	} else {
+		message = fmt.Sprintf("Handled request '%s - (%s)' in %dms, result %d bytes", trace.method, trace.id,
+			costMs, resultSize)
+	}
+	if trace.errStr != "" {
+		message = message + ", error: " + trace.errStr
+	}
+
+	var verbose string
+	if traceValue == traceVerbose {
+		params := trace.params
+		if len(params) > traceParamsMaxLen {
+			params = params[:traceParamsMaxLen] + "..."
+		}
+		verbose = fmt.Sprintf("file: %s\nparams: %s", trace.file, params)
+	}
+
+	l.server.Notify(ctx, "$/logTrace", lsp.LogTraceParams{
+		Message: message,
+		Verbose: verbose,
+	})
+}
+
+// getRequestFile 获取请求参数中的文件
+func getRequestFile(req *jrpc2.Request) string {
+	var params struct {
+		TextDocument struct {
+			URI lsp.DocumentURI `json:"uri"`
+		} `json:"textDocument"`
+	}
+
+	if !req.HasParams() || json.Unmarshal([]byte(req.ParamString()), &params) != nil {
+		return ""
+	}
+
+	return string(params.TextDocument.URI)
+}
@@ -0,0 +1,200 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"luahelper-lsp/langserver/log"
+	lsp "luahelper-lsp/langserver/protocol"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+// startTraceServer 通过内存中的连接启动lsp服务，返回客户端，以及收集到的该连接的请求跟踪日志
+func startTraceServer(t *testing.T) (*LspServer, *jrpc2.Client, func() []*log.Entry) {
+	lspServer, client := startLspTestServer(t, nil)
+
+	var mutex sync.Mutex
+	var entryVec []*log.Entry
+	removeListener := log.AddListener(log.LevelDebug, func(entry *log.Entry) {
+		if entry.Msg == requestTraceMsg && entry.Session == lspServer.sessionID {
+			mutex.Lock()
+			entryVec = append(entryVec, entry)
+			mutex.Unlock()
+		}
+	})
+
+	t.Cleanup(removeListener)
+
+	return lspServer, client, func() []*log.Entry {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return entryVec
+	}
+}
+
+// getEntryFields 把日志的结构化字段转换为map
+func getEntryFields(entry *log.Entry) map[string]interface{} {
+	fieldMap := map[string]interface{}{}
+	for i := 0; i+1 < len(entry.Fields); i += 2 {
+		fieldMap[entry.Fields[i].(string)] = entry.Fields[i+1]
+	}
+	return fieldMap
+}
+
+func TestTraceRequest(t *testing.T) {
+	lspServer, client, getEntryVec := startTraceServer(t)
+
+	// 没有开启跟踪时，也记录jrpc2序列化后返回结果的大小
+	for _, traceValue := range []string{traceOff, traceVerbose} {
+		lspServer.setTraceValue(traceValue)
+		rsp, err := client.Call(context.Background(), "luahelper/stats", StatsParams{})
+		if err != nil {
+			t.Fatalf("call stats err=%s", err.Error())
+		}
+
+		entryVec := getEntryVec()
+		if len(entryVec) == 0 {
+			t.Fatalf("trace=%s, no request trace", traceValue)
+		}
+
+		fieldMap := getEntryFields(entryVec[len(entryVec)-1])
+		expectMap := map[string]interface{}{
+			"method":      "luahelper/stats",
+			"id":          rsp.ID(),
+			"file":        "",
+			"result_size": len(rsp.ResultString()),
+			"error":       "",
+		}
+		for key, value := range expectMap {
+			if fieldMap[key] != value {
+				t.Fatalf("trace=%s, field %s=%v, expect=%v", traceValue, key, fieldMap[key], value)
+			}
+		}
+	}
+	lspServer.setTraceValue(traceOff)
+}
+
+func TestTraceNotification(t *testing.T) {
+	lspServer := CreateLspServer()
+	lspServer.setTraceValue(traceMessages)
+	defer lspServer.setTraceValue(traceOff)
+
+	var entryVec []*log.Entry
+	removeListener := log.AddListener(log.LevelDebug, func(entry *log.Entry) {
+		if entry.Msg == requestTraceMsg && entry.Session == lspServer.sessionID {
+			entryVec = append(entryVec, entry)
+		}
+	})
+	defer removeListener()
+
+	reqVec, _ := jrpc2.ParseRequests([]byte(`{"jsonrpc":"2.0","method":"textDocument/didSave",` +
+		`"params":{"textDocument":{"uri":"file:///a.lua"}}}`))
+	h := handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		return nil, nil
+	})
+	lspServer.traceRequest(context.Background(), reqVec[0], h)
+
+	// 通知没有返回结果，处理完直接输出跟踪信息
+	if len(entryVec) != 1 {
+		t.Fatalf("notification trace num=%d, expect=1", len(entryVec))
+	}
+	fieldMap := getEntryFields(entryVec[0])
+	if fieldMap["file"] != "file:///a.lua" || fieldMap["result_size"] != 0 {
+		t.Fatalf("notification trace fields error: %v", fieldMap)
+	}
+}
+
+func TestTraceSessionEntry(t *testing.T) {
+	lspServer := CreateLspServer()
+	otherServer := CreateLspServer()
+
+	if !lspServer.isSessionEntry(&log.Entry{Session: lspServer.sessionID}) {
+		t.Fatalf("entry of own session should be forwarded")
+	}
+	if lspServer.isSessionEntry(&log.Entry{Session: otherServer.sessionID}) {
+		t.Fatalf("entry of other session should not be forwarded")
+	}
+	if lspServer.isSessionEntry(&log.Entry{}) {
+		t.Fatalf("common entry should not be forwarded")
+	}
+
+	var mutex sync.Mutex
+	sessionMap := map[string]int{}
+	removeListener := log.AddListener(log.LevelDebug, func(entry *log.Entry) {
+		mutex.Lock()
+		sessionMap[entry.Msg] = entry.Session
+		mutex.Unlock()
+	})
+	defer removeListener()
+
+	// 处理请求的协程绑定连接后，普通的日志也属于该连接，创建的协程继承绑定的连接
+	unbind := log.BindSession(lspServer.sessionID)
+	log.Debug("trace session bind")
+	done := make(chan struct{})
+	log.Go(func() {
+		log.Info("trace session go")
+		close(done)
+	})
+	<-done
+	unbind()
+	log.Debug("trace session unbind")
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	expectMap := map[string]int{
+		"trace session bind":   lspServer.sessionID,
+		"trace session go":     lspServer.sessionID,
+		"trace session unbind": 0,
+	}
+	for msg, session := range expectMap {
+		if sessionMap[msg] != session {
+			t.Fatalf("log %s session=%d, expect=%d", msg, sessionMap[msg], session)
+		}
+	}
+}
+
+func TestSetTrace(t *testing.T) {
+	lspServer := CreateLspServer()
+
+	lspServer.SetTrace(context.Background(), lsp.SetTraceParams{Value: "verbose"})
+	if lspServer.getTraceValue() != traceVerbose || lspServer.removeLogListener == nil {
+		t.Fatalf("set trace verbose fail")
+	}
+	if !log.IsLevelEnabled(log.LevelDebug) {
+		t.Fatalf("debug log not enabled when trace is verbose")
+	}
+
+	// 非法的值当成关闭
+	lspServer.SetTrace(context.Background(), lsp.SetTraceParams{Value: "unknown"})
+	if lspServer.getTraceValue() != traceOff || lspServer.removeLogListener != nil {
+		t.Fatalf("set trace off fail")
+	}
+}
+
+func TestFormatEntry(t *testing.T) {
+	entry := &log.Entry{
+		Time:   time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:  log.LevelInfo,
+		Caller: "main.go:10",
+		Msg:    "hello world",
+		Fields: []interface{}{"method", "initialize", "duration_ms", 12},
+	}
+
+	var jsonMap map[string]interface{}
+	if err := json.Unmarshal(log.FormatEntry(entry, log.FormatJSON), &jsonMap); err != nil {
+		t.Fatalf("json format err=%s", err.Error())
+	}
+	if jsonMap["level"] != "info" || jsonMap["msg"] != "hello world" || jsonMap["duration_ms"] != float64(12) {
+		t.Fatalf("json format error: %v", jsonMap)
+	}
+
+	strLogfmt := string(log.FormatEntry(entry, log.FormatLogfmt))
+	expectStr := "time=2021-01-02T03:04:05Z level=info caller=main.go:10 msg=\"hello world\" method=initialize duration_ms=12\n"
+	if strLogfmt != expectStr {
+		t.Fatalf("logfmt format=%s, expect=%s", strLogfmt, expectStr)
+	}
+}
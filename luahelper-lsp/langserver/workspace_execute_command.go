@@ -0,0 +1,347 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/lspcommon"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 服务器支持的命令，任何客户端都可以通过 workspace/executeCommand 执行
+const (
+	// runTestCommand 运行busted测试，参数为文件的uri、测试的全名、是否为测试组
+	runTestCommand = "luahelper.runTest"
+
+	// reindexCommand 重新分析整个工程，没有参数，返回工程lua文件的数量
+	reindexCommand = "luahelper.reindex"
+
+	// reloadConfigCommand 重新读取luahelper.json配置文件，并重新分析整个工程，返回是否读取到了配置文件
+	reloadConfigCommand = "luahelper.reloadConfig"
+
+	// generateAnnotationsCommand 当前文件没有注解的函数生成注解桩代码，参数为文件的uri，返回生成的修改
+	generateAnnotationsCommand = "luahelper.generateAnnotations"
+
+	// exportSymbolsCommand 导出工程所有的符号，参数为可选的导出文件路径，没有路径时直接返回符号列表
+	exportSymbolsCommand = "luahelper.exportSymbols"
+
+	// showRequirePathCommand 显示引用其他文件时查找的所有目录
+	showRequirePathCommand = "luahelper.showRequirePath"
+)
+
+// serverCommandList 初始化时告诉客户端服务器支持的所有命令
+var serverCommandList = []string{
+	runTestCommand,
+	reindexCommand,
+	reloadConfigCommand,
+	generateAnnotationsCommand,
+	exportSymbolsCommand,
+	showRequirePathCommand,
+}
+
+// 运行busted测试的超时时间，超时后结束测试的进程，防止测试死循环时进程一直存在
+const bustedTimeout = 5 * time.Minute
+
+// ExecuteCommand 执行客户端请求的命令
+func (l *LspServer) ExecuteCommand(ctx context.Context, vs lsp.ExecuteCommandParams) (result interface{}, err error) {
+	log.Debug("ExecuteCommand command=%s", vs.Command)
+
+	switch vs.Command {
+	case runTestCommand:
+		return l.runTest(vs.Arguments)
+	case reindexCommand:
+		return l.reindex(ctx)
+	case reloadConfigCommand:
+		return l.reloadConfig(ctx)
+	case generateAnnotationsCommand:
+		return l.generateAnnotations(ctx, vs.Arguments)
+	case exportSymbolsCommand:
+		return l.exportSymbols(vs.Arguments)
+	case showRequirePathCommand:
+		return l.showRequirePath(ctx)
+	}
+
+	log.Error("ExecuteCommand unknown command=%s", vs.Command)
+	return nil, fmt.Errorf("unknown command: %s", vs.Command)
+}
+
+// runTest 在后台运行busted测试，测试的输出推送到客户端的日志窗口，结果弹出提示
+func (l *LspServer) runTest(arguments []json.RawMessage) (result interface{}, err error) {
+	var uri lsp.DocumentURI
+	var testName string
+	if len(arguments) < 2 || json.Unmarshal(arguments[0], &uri) != nil || json.Unmarshal(arguments[1], &testName) != nil {
+		return nil, fmt.Errorf("%s expect arguments: uri, test name, is group", runTestCommand)
+	}
+
+	isGroup := false
+	if len(arguments) > 2 {
+		json.Unmarshal(arguments[2], &isGroup)
+	}
+
+	strFile := pathpre.VscodeURIToString(string(uri))
+	workDir := l.conf.GetDirManager().GetMainDir()
+	bustedPath, filter := l.conf.BustedPath, getBustedFilter(testName, isGroup)
+	log.Go(func() { l.runBustedTest(bustedPath, strFile, filter, testName, workDir) })
+	return nil, nil
+}
+
+// runBustedTest 执行busted命令运行测试
+// bustedPath 为busted命令的路径，通过luahelper.base.BustedPath配置
+func (l *LspServer) runBustedTest(bustedPath string, strFile string, filter string, testName string, workDir string) {
+	ctx := context.Background()
+	if _, err := exec.LookPath(bustedPath); err != nil {
+		l.pushShowMessage(ctx, lsp.Error, fmt.Sprintf("run test %s failed, %s not find", testName, bustedPath))
+		return
+	}
+
+	cmdCtx, cancel := context.WithTimeout(ctx, bustedTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(cmdCtx, bustedPath, "--filter="+filter, strFile)
+	cmd.Dir = workDir
+	output, err := cmd.CombinedOutput()
+	l.pushLogMessage(ctx, lsp.Log, string(output))
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		l.pushShowMessage(ctx, lsp.Error, fmt.Sprintf("test %s timeout after %s", testName, bustedTimeout))
+		return
+	}
+	if err != nil {
+		log.Debug("runBustedTest test=%s, err=%s", testName, err.Error())
+		l.pushShowMessage(ctx, lsp.Error, fmt.Sprintf("test %s failed", testName))
+		return
+	}
+
+	l.pushShowMessage(ctx, lsp.Info, fmt.Sprintf("test %s passed", testName))
+}
+
+// getBustedFilter 获取busted --filter的Lua模式，测试组匹配所有以它开头的测试，单个测试完全匹配
+func getBustedFilter(testName string, isGroup bool) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for _, ch := range testName {
+		if strings.ContainsRune("^$()%.[]*+-?", ch) {
+			builder.WriteRune('%')
+		}
+		builder.WriteRune(ch)
+	}
+
+	if isGroup {
+		builder.WriteString(" ")
+	} else {
+		builder.WriteString("$")
+	}
+	return builder.String()
+}
+
+// reindex 重新分析整个工程，返回工程lua文件的数量
+func (l *LspServer) reindex(ctx context.Context) (result interface{}, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	if err := l.handleChange(ctx); err != nil {
+		return nil, err
+	}
+
+	fileNumber := l.getAllProject().GetAllFileNumber()
+	l.SetLuaFileNumber(fileNumber)
+	return fileNumber, nil
+}
+
+// reloadConfig 重新读取luahelper.json配置文件，并重新分析整个工程
+// 配置文件不存在时使用默认的配置，返回是否读取到了配置文件
+func (l *LspServer) reloadConfig(ctx context.Context) (result interface{}, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	vscodeRoot := l.conf.GetDirManager().GetVsRootDir()
+	if readErr := l.conf.ReloadConfig(vscodeRoot, ".vscode/luahelper.json"); readErr != nil {
+		log.Error("reloadConfig read luahelper.json err: %s", readErr.Error())
+		l.pushShowMessage(ctx, lsp.Error, "read luahelper.json error: "+readErr.Error())
+		return nil, readErr
+	}
+
+	// 配置文件中的BaseDir可能修改了，重新获取主目录
+	l.conf.GetDirManager().InitMainDir()
+	if err := l.handleChange(ctx); err != nil {
+		return nil, err
+	}
+
+	return l.conf.ReadJSONFlag, nil
+}
+
+// generateAnnotations 当前文件没有注解的函数生成注解桩代码
+// 客户端支持 workspace/applyEdit 时直接应用修改，同时返回生成的修改，不支持的客户端可以自己应用
+func (l *LspServer) generateAnnotations(ctx context.Context, arguments []json.RawMessage) (result interface{}, err error) {
+	var uri lsp.DocumentURI
+	if len(arguments) < 1 || json.Unmarshal(arguments[0], &uri) != nil {
+		return nil, fmt.Errorf("%s expect arguments: uri", generateAnnotationsCommand)
+	}
+
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	strFile := pathpre.VscodeURIToString(string(uri))
+	project := l.getAllProject()
+	if project == nil || !project.IsNeedHandle(strFile) {
+		return nil, fmt.Errorf("%s not in the workspace", strFile)
+	}
+
+	contents, found := l.getFileCache().GetFileContent(strFile)
+	if !found {
+		contents, _ = ioutil.ReadFile(strFile)
+	}
+	lineVec := strings.Split(string(contents), "\n")
+
+	textEdits := []lsp.TextEdit{}
+	for _, oneStub := range project.GetFileAnnotateStubs(strFile) {
+		// 注解的缩进与函数定义的行一致
+		indent := ""
+		if oneStub.Line-1 < len(lineVec) {
+			lineStr := lineVec[oneStub.Line-1]
+			indent = lineStr[:len(lineStr)-len(strings.TrimLeft(lineStr, " \t"))]
+		}
+
+		var builder strings.Builder
+		for _, oneLine := range oneStub.Lines {
+			builder.WriteString(indent + oneLine + "\n")
+		}
+
+		pos := lsp.Position{Line: uint32(oneStub.Line - 1)}
+		textEdits = append(textEdits, lsp.TextEdit{
+			Range: lsp.Range{
+				Start: pos,
+				End:   pos,
+			},
+			NewText: builder.String(),
+		})
+	}
+
+	edit := lsp.WorkspaceEdit{
+		Changes: map[string][]lsp.TextEdit{
+			string(uri): textEdits,
+		},
+	}
+	if len(textEdits) > 0 && l.applyEditSupport {
+		// 持有请求锁时不能等待客户端的回应，在后台发送
+		log.Go(func() { l.pushApplyEdit(context.Background(), "generate annotations", edit) })
+	}
+
+	return edit, nil
+}
+
+// pushApplyEdit 请求客户端应用修改
+func (l *LspServer) pushApplyEdit(ctx context.Context, label string, edit lsp.WorkspaceEdit) {
+	_, err := l.server.Callback(ctx, "workspace/applyEdit", lsp.ApplyWorkspaceEditParams{
+		Label: label,
+		Edit:  edit,
+	})
+	if err != nil {
+		log.Debug("pushApplyEdit error=%v", err)
+	}
+}
+
+// exportSymbolStruct 导出的一个符号
+type exportSymbolStruct struct {
+	Name          string         `json:"name"`
+	Kind          lsp.SymbolKind `json:"kind"`
+	ContainerName string         `json:"containerName,omitempty"`
+	Detail        string         `json:"detail,omitempty"`
+	File          string         `json:"file"`
+	Range         lsp.Range      `json:"range"`
+}
+
+// exportSymbols 导出工程所有文件的符号，子符号的containerName为所属符号的名称
+// 参数中有文件路径时，写入到文件中返回导出的数量，否则直接返回符号列表
+func (l *LspServer) exportSymbols(arguments []json.RawMessage) (result interface{}, err error) {
+	var strPath string
+	if len(arguments) > 0 && json.Unmarshal(arguments[0], &strPath) != nil {
+		return nil, fmt.Errorf("%s expect arguments: export file path", exportSymbolsCommand)
+	}
+
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	symbolVec := []exportSymbolStruct{}
+	project := l.getAllProject()
+	if project == nil {
+		return symbolVec, nil
+	}
+
+	fileList := []string{}
+	for strFile := range project.GetAllFilesMap() {
+		fileList = append(fileList, strFile)
+	}
+	sort.Strings(fileList)
+
+	for _, strFile := range fileList {
+		symbolVec = appendExportSymbols(symbolVec, project.FindFileAllSymbol(strFile), strFile, "")
+	}
+
+	if strPath == "" {
+		return symbolVec, nil
+	}
+
+	data, err := json.MarshalIndent(symbolVec, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(strPath, data, 0644); err != nil {
+		log.Error("exportSymbols write file=%s err: %s", strPath, err.Error())
+		return nil, err
+	}
+
+	return len(symbolVec), nil
+}
+
+// appendExportSymbols 展开所有的子符号
+func appendExportSymbols(symbolVec []exportSymbolStruct, fileSymbolVec []common.FileSymbolStruct, strFile string,
+	containerName string) []exportSymbolStruct {
+	for _, oneSymbol := range fileSymbolVec {
+		symbolVec = append(symbolVec, exportSymbolStruct{
+			Name:          oneSymbol.Name,
+			Kind:          getLspSymbolKind(oneSymbol.Kind),
+			ContainerName: containerName,
+			Detail:        oneSymbol.Detail,
+			File:          strFile,
+			Range:         lspcommon.LocToRange(&oneSymbol.Loc),
+		})
+
+		symbolVec = appendExportSymbols(symbolVec, oneSymbol.Children, strFile, oneSymbol.Name)
+	}
+
+	return symbolVec
+}
+
+// requirePathResult 引用其他文件时的查找信息
+type requirePathResult struct {
+	SearchDirs    []string `json:"searchDirs"`
+	PathSeparator string   `json:"pathSeparator"`
+	MatchPath     bool     `json:"matchPath"`
+}
+
+// showRequirePath 显示引用其他文件时按顺序查找的所有目录，以及require的路径分割符
+func (l *LspServer) showRequirePath(ctx context.Context) (result interface{}, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	pathResult := requirePathResult{
+		SearchDirs:    l.conf.GetDirManager().GetRequireSearchDirs(),
+		PathSeparator: l.conf.PathSeparator,
+		MatchPath:     l.conf.ReferMatchPathFlag,
+	}
+	if pathResult.SearchDirs == nil {
+		pathResult.SearchDirs = []string{}
+	}
+
+	l.pushShowMessage(ctx, lsp.Info, fmt.Sprintf("require search path: %s, separator: %s",
+		strings.Join(pathResult.SearchDirs, "; "), pathResult.PathSeparator))
+	return pathResult, nil
+}
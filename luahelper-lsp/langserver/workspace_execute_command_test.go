@@ -0,0 +1,163 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func createCommandLspTest(t *testing.T) (*LspServer, string) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/command"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	return lspServer, strRootURI
+}
+
+func executeCommand(lspServer *LspServer, command string, arguments ...interface{}) (interface{}, error) {
+	var rawArguments []json.RawMessage
+	for _, oneArgument := range arguments {
+		data, _ := json.Marshal(oneArgument)
+		rawArguments = append(rawArguments, data)
+	}
+
+	return lspServer.ExecuteCommand(context.Background(), lsp.ExecuteCommandParams{
+		Command:   command,
+		Arguments: rawArguments,
+	})
+}
+
+func TestExecuteCommandError(t *testing.T) {
+	lspServer, _ := createCommandLspTest(t)
+
+	if _, err := executeCommand(lspServer, "luahelper.notExist"); err == nil {
+		t.Fatalf("unknown command should return error")
+	}
+
+	if _, err := executeCommand(lspServer, generateAnnotationsCommand); err == nil {
+		t.Fatalf("%s without uri should return error", generateAnnotationsCommand)
+	}
+
+	if _, err := executeCommand(lspServer, exportSymbolsCommand, 1); err == nil {
+		t.Fatalf("%s with error path should return error", exportSymbolsCommand)
+	}
+}
+
+func TestExecuteCommandGenerateAnnotations(t *testing.T) {
+	lspServer, strRootURI := createCommandLspTest(t)
+	uri := strRootURI + "/stub.lua"
+
+	result, err := executeCommand(lspServer, generateAnnotationsCommand, uri)
+	if err != nil {
+		t.Fatalf("%s err=%s", generateAnnotationsCommand, err.Error())
+	}
+
+	edit, ok := result.(lsp.WorkspaceEdit)
+	if !ok {
+		t.Fatalf("%s result is not workspace edit", generateAnnotationsCommand)
+	}
+
+	// greet已经有注解，noop没有参数与返回值，都不生成
+	expectList := []struct {
+		line    uint32
+		newText string
+	}{
+		{2, "---@param a any\n---@param b any\n---@return any\n"},
+		{12, "---@param ... any\n"},
+		{16, "---@param a any\n---@param b any\n---@return any\n---@return any\n"},
+	}
+
+	textEdits := edit.Changes[uri]
+	if len(textEdits) != len(expectList) {
+		t.Fatalf("text edits len=%d, expect=%d", len(textEdits), len(expectList))
+	}
+	for i, expect := range expectList {
+		if textEdits[i].Range.Start.Line != expect.line || textEdits[i].NewText != expect.newText {
+			t.Fatalf("edit %d line=%d text=%q, expect %v", i, textEdits[i].Range.Start.Line, textEdits[i].NewText,
+				expect)
+		}
+	}
+}
+
+func TestExecuteCommandExportSymbols(t *testing.T) {
+	lspServer, _ := createCommandLspTest(t)
+
+	result, err := executeCommand(lspServer, exportSymbolsCommand)
+	if err != nil {
+		t.Fatalf("%s err=%s", exportSymbolsCommand, err.Error())
+	}
+
+	symbolVec, ok := result.([]exportSymbolStruct)
+	if !ok || len(symbolVec) == 0 {
+		t.Fatalf("%s result is empty", exportSymbolsCommand)
+	}
+
+	findGreet := false
+	for _, oneSymbol := range symbolVec {
+		if oneSymbol.Name == "greet" && oneSymbol.ContainerName == "M" {
+			findGreet = true
+		}
+	}
+	if !findGreet {
+		t.Fatalf("%s not find symbol greet", exportSymbolsCommand)
+	}
+
+	strPath := filepath.Join(os.TempDir(), "luahelper_export_symbols.json")
+	defer os.Remove(strPath)
+	result, err = executeCommand(lspServer, exportSymbolsCommand, strPath)
+	if err != nil {
+		t.Fatalf("%s to file err=%s", exportSymbolsCommand, err.Error())
+	}
+	if result != len(symbolVec) {
+		t.Fatalf("%s to file num=%v, expect=%d", exportSymbolsCommand, result, len(symbolVec))
+	}
+
+	data, _ := ioutil.ReadFile(strPath)
+	var fileSymbolVec []exportSymbolStruct
+	if err := json.Unmarshal(data, &fileSymbolVec); err != nil || len(fileSymbolVec) != len(symbolVec) {
+		t.Fatalf("%s read export file error", exportSymbolsCommand)
+	}
+}
+
+func TestExecuteCommandShowRequirePath(t *testing.T) {
+	lspServer, _ := createCommandLspTest(t)
+
+	result, err := executeCommand(lspServer, showRequirePathCommand)
+	if err != nil {
+		t.Fatalf("%s err=%s", showRequirePathCommand, err.Error())
+	}
+
+	pathResult := result.(requirePathResult)
+	mainDir := lspServer.getConfig().GetDirManager().GetMainDir()
+	if len(pathResult.SearchDirs) == 0 || pathResult.SearchDirs[0] != mainDir {
+		t.Fatalf("%s search dirs=%v, main dir=%s", showRequirePathCommand, pathResult.SearchDirs, mainDir)
+	}
+	if pathResult.PathSeparator != "." {
+		t.Fatalf("%s path separator=%s", showRequirePathCommand, pathResult.PathSeparator)
+	}
+}
+
+func TestExecuteCommandReindex(t *testing.T) {
+	lspServer, _ := createCommandLspTest(t)
+
+	result, err := executeCommand(lspServer, reindexCommand)
+	if err != nil || result != 1 {
+		t.Fatalf("%s result=%v, err=%v", reindexCommand, result, err)
+	}
+
+	result, err = executeCommand(lspServer, reloadConfigCommand)
+	if err != nil || result != false {
+		t.Fatalf("%s result=%v, err=%v", reloadConfigCommand, result, err)
+	}
+	if lspServer.getAllProject().GetAllFileNumber() != 1 {
+		t.Fatalf("%s file number=%d", reloadConfigCommand, lspServer.getAllProject().GetAllFileNumber())
+	}
+}
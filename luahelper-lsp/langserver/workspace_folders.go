@@ -4,7 +4,6 @@ import (
 	"context"
 
 	"luahelper-lsp/langserver/check"
-	"luahelper-lsp/langserver/check/common"
 
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/pathpre"
@@ -29,7 +28,7 @@ func (l *LspServer)WorkspaceChangeWorkspaceFolders(ctx context.Context, params l
 func (l *LspServer)addWorkspaceFolder(ctx context.Context, dirpath string) {
 	l.requestMutex.Lock()
 	defer l.requestMutex.Unlock()
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 
 	// 若增加的是当前workspace 文件夹中包含的子文件夹， 则不需要做任何处理
 	if dirManager.IsDirExistWorkspace(dirpath) {
@@ -72,7 +71,7 @@ func (l *LspServer)addWorkspaceFolder(ctx context.Context, dirpath string) {
 func (l *LspServer)removeWorkspaceFolder(ctx context.Context, dirpath string) {
 	l.requestMutex.Lock()
 	defer l.requestMutex.Unlock()
-	dirManager := common.GConfig.GetDirManager()
+	dirManager := l.conf.GetDirManager()
 
 	if !dirManager.RemoveOneSubDir(dirpath) {
 		log.Debug("remove error :%s", dirpath)	
@@ -2,41 +2,128 @@ package langserver
 
 import (
 	"context"
+	"encoding/json"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
 	lsp "luahelper-lsp/langserver/protocol"
 )
 
-// WorkspaceSymbolRequest 全工程符合查找提示，返回多个符合
-func (l *LspServer) WorkspaceSymbolRequest(ctx context.Context, vs lsp.WorkspaceSymbolParams) (items []lsp.SymbolInformation, err error) {
+// workspaceSymbolData 工程符号延迟获取位置时，保存在符号上的数据，对应最近一次查找结果的序号
+type workspaceSymbolData struct {
+	Index   int `json:"Index"`
+	Version int `json:"Version"`
+}
+
+// WorkspaceSymbolRequest 全工程符号模糊查找，返回按匹配程度排序后的多个符号
+// 客户端支持 workspaceSymbol/resolve 时，只返回符号的文件，具体的位置延迟获取
+func (l *LspServer) WorkspaceSymbolRequest(ctx context.Context, vs lsp.WorkspaceSymbolParams) (items []lsp.WorkspaceSymbol, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	items = []lsp.WorkspaceSymbol{}
 	project := l.getAllProject()
-	fileSymbolVec := project.FindWorkspaceAllSymbol(vs.Query)
+	if project == nil {
+		return
+	}
 
-	vecLen := len(fileSymbolVec)
-	items = make([]lsp.SymbolInformation, 0, vecLen)
+	fileSymbolVec := project.FindWorkspaceAllSymbol(vs.Query, l.conf.WorkspaceSymbolMaxNum)
 
-	for _, oneSymbol := range fileSymbolVec {
-		loc := lsp.Location{
-			URI:   lspcommon.GetFileDocumentURI(oneSymbol.FileName),
-			Range: lspcommon.LocToRange(&oneSymbol.Loc),
-		}
+	// 缓存这次查找的结果，用于延迟获取位置
+	l.workspaceSymbolVersion++
+	l.workspaceSymbolVec = fileSymbolVec
 
-		item := lsp.SymbolInformation{
+	for index, oneSymbol := range fileSymbolVec {
+		item := lsp.WorkspaceSymbol{
 			Name:          oneSymbol.Name,
-			Kind:          lsp.Variable,
-			Location:      loc,
+			Kind:          l.getWorkspaceSymbolKind(oneSymbol.Kind),
 			ContainerName: oneSymbol.ContainerName,
+			Location: lsp.WorkspaceSymbolLocation{
+				URI: lspcommon.GetFileDocumentURI(oneSymbol.FileName),
+			},
 		}
 
-		if oneSymbol.Kind == common.IKFunction {
-			item.Kind = lsp.Function
-		} else if oneSymbol.Kind == common.IKAnnotateClass {
-			item.Kind = lsp.Interface
-		} else if oneSymbol.Kind == common.IKAnnotateAlias {
-			item.Kind = lsp.Interface
+		if l.workspaceSymbolResolve {
+			item.Data = workspaceSymbolData{
+				Index:   index,
+				Version: l.workspaceSymbolVersion,
+			}
+		} else {
+			ra := lspcommon.LocToRange(&oneSymbol.Loc)
+			item.Location.Range = &ra
 		}
 		items = append(items, item)
 	}
 
 	return
 }
+
+// WorkspaceSymbolResolve 获取工程符号的具体位置
+func (l *LspServer) WorkspaceSymbolResolve(ctx context.Context, vs lsp.WorkspaceSymbol) (item lsp.WorkspaceSymbol, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	item = vs
+	if item.Location.Range != nil || item.Data == nil {
+		return
+	}
+
+	var data workspaceSymbolData
+	bits, _ := json.Marshal(item.Data)
+	if err := json.Unmarshal(bits, &data); err != nil {
+		log.Error("WorkspaceSymbolResolve data error=%s", err.Error())
+		return item, nil
+	}
+
+	// 已经有了新的查找，结果不再对应
+	if data.Version != l.workspaceSymbolVersion || data.Index < 0 || data.Index >= len(l.workspaceSymbolVec) {
+		log.Debug("WorkspaceSymbolResolve symbol expired, name=%s", item.Name)
+		return item, nil
+	}
+
+	oneSymbol := &l.workspaceSymbolVec[data.Index]
+	ra := lspcommon.LocToRange(&oneSymbol.Loc)
+	item.Location = lsp.WorkspaceSymbolLocation{
+		URI:   lspcommon.GetFileDocumentURI(oneSymbol.FileName),
+		Range: &ra,
+	}
+	item.Data = nil
+	return item, nil
+}
+
+// getWorkspaceSymbolKind 符号的类型转换为lsp的类型，客户端不支持的类型转换为相近的类型
+func (l *LspServer) getWorkspaceSymbolKind(kind common.ItemKind) lsp.SymbolKind {
+	symbolKind := getLspSymbolKind(kind)
+	if !l.isSupportSymbolKind(symbolKind) {
+		if symbolKind == lsp.EnumMember {
+			return lsp.Field
+		}
+		return lsp.Variable
+	}
+	return symbolKind
+}
+
+// isSupportSymbolKind 客户端是否支持符号的类型，客户端没有指定时只支持 File 到 Array 的类型
+func (l *LspServer) isSupportSymbolKind(symbolKind lsp.SymbolKind) bool {
+	if len(l.symbolKindSet) == 0 {
+		return symbolKind >= lsp.File && symbolKind <= lsp.Array
+	}
+
+	_, ok := l.symbolKindSet[symbolKind]
+	return ok
+}
+
+// setWorkspaceSymbolCapabilities 根据客户端的能力，设置工程符号查找返回的方式
+func (l *LspServer) setWorkspaceSymbolCapabilities(symbolCapabilities *lsp.WorkspaceSymbolClientCapabilities) {
+	l.symbolKindSet = map[lsp.SymbolKind]struct{}{}
+	for _, symbolKind := range symbolCapabilities.SymbolKind.ValueSet {
+		l.symbolKindSet[symbolKind] = struct{}{}
+	}
+
+	l.workspaceSymbolResolve = false
+	for _, property := range symbolCapabilities.ResolveSupport.Properties {
+		if property == "location.range" {
+			l.workspaceSymbolResolve = true
+		}
+	}
+}
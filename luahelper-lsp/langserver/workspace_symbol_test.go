@@ -0,0 +1,114 @@
+package langserver
+
+import (
+	"context"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func createSymbolLspTest(t *testing.T) *LspServer {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/symbol"
+	strRootPath, _ = filepath.Abs(strRootPath)
+	strRootURI := "file://" + strRootPath
+	return createLspTest(strRootPath, strRootURI)
+}
+
+func findWorkspaceSymbol(items []lsp.WorkspaceSymbol, strName string) *lsp.WorkspaceSymbol {
+	for i := range items {
+		if items[i].Name == strName {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+func TestWorkspaceSymbolKind(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+	lspServer.symbolKindSet = map[lsp.SymbolKind]struct{}{}
+	for kind := lsp.File; kind <= lsp.TypeParameter; kind++ {
+		lspServer.symbolKindSet[kind] = struct{}{}
+	}
+
+	items, _ := lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: ""})
+	expectMap := map[string]lsp.SymbolKind{
+		"Animal":        lsp.Class,
+		"Animal.speak":  lsp.Method,
+		"Animal.create": lsp.Function,
+		"Animal.legs":   lsp.Field,
+		"ColorType":     lsp.Enum,
+		"ColorType.red": lsp.EnumMember,
+		"helperUtil":    lsp.Function,
+		"animal":        lsp.Module,
+		"Shape":         lsp.Class,
+		"shape":         lsp.Module,
+	}
+	for strName, kind := range expectMap {
+		item := findWorkspaceSymbol(items, strName)
+		if item == nil {
+			t.Fatalf("symbol %s not find", strName)
+		}
+		if item.Kind != kind {
+			t.Fatalf("symbol %s kind=%d, expect=%d", strName, item.Kind, kind)
+		}
+		if item.Location.Range == nil {
+			t.Fatalf("symbol %s range is empty", strName)
+		}
+	}
+
+	// 客户端不支持EnumMember时，转换为Field
+	lspServer.symbolKindSet = map[lsp.SymbolKind]struct{}{}
+	items, _ = lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: "ColorType.red"})
+	if item := findWorkspaceSymbol(items, "ColorType.red"); item == nil || item.Kind != lsp.Field {
+		t.Fatalf("symbol ColorType.red kind error: %v", item)
+	}
+}
+
+func TestWorkspaceSymbolFuzzy(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+
+	// 驼峰与子序列匹配，不匹配的符号不返回
+	items, _ := lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: "hU"})
+	if findWorkspaceSymbol(items, "helperUtil") == nil {
+		t.Fatalf("fuzzy symbol helperUtil not find")
+	}
+	if findWorkspaceSymbol(items, "ColorType") != nil {
+		t.Fatalf("not match symbol ColorType is returned")
+	}
+
+	// 完全匹配的排在前面
+	items, _ = lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: "Animal"})
+	if len(items) == 0 || items[0].Name != "Animal" {
+		t.Fatalf("exact match symbol Animal is not the first: %v", items)
+	}
+
+	// 返回的最大数量
+	lspServer.getConfig().SetWorkspaceSymbolMaxNum(2)
+	items, _ = lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: ""})
+	if len(items) != 2 {
+		t.Fatalf("symbol num=%d, expect=2", len(items))
+	}
+}
+
+func TestWorkspaceSymbolResolve(t *testing.T) {
+	lspServer := createSymbolLspTest(t)
+
+	symbolCapabilities := lsp.WorkspaceSymbolClientCapabilities{}
+	symbolCapabilities.ResolveSupport.Properties = []string{"location.range"}
+	lspServer.setWorkspaceSymbolCapabilities(&symbolCapabilities)
+
+	items, _ := lspServer.WorkspaceSymbolRequest(context.Background(), lsp.WorkspaceSymbolParams{Query: "helperUtil"})
+	item := findWorkspaceSymbol(items, "helperUtil")
+	if item == nil || item.Location.Range != nil || item.Data == nil {
+		t.Fatalf("symbol helperUtil location should be resolved lazily: %v", item)
+	}
+
+	resolveItem, _ := lspServer.WorkspaceSymbolResolve(context.Background(), *item)
+	if resolveItem.Location.Range == nil || resolveItem.Location.Range.Start.Line != 19 {
+		t.Fatalf("resolve symbol helperUtil location error: %v", resolveItem.Location)
+	}
+}
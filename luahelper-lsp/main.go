@@ -2,56 +2,145 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strconv"
 	"sync"
 
 	"luahelper-lsp/langserver"
-	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/metrics"
 
 	"github.com/yinfei8/jrpc2/channel"
 )
 
+// 运行的模式，与 -mode 的数值对应
+const (
+	modeCheck    = 0 // 本地校验工程的错误
+	modeStdio    = 1 // 通过标准输入输出与客户端通信
+	modeSocket   = 2 // 通过网络与客户端通信
+	modeMapTrace = 3 // 把堆栈中Lua文件的行号转换成MoonCake文件的行号后退出
+)
+
+// 命名的子命令，例如 luahelper-lsp serve -port 7778
+var subCommandMap = map[string]int{
+	"serve":    modeSocket,
+	"check":    modeCheck,
+	"maptrace": modeMapTrace,
+}
+
 func main() {
-	modeFlag := flag.Int("mode", 0, "mode type, 0 is run cmd, 1 is local rpc, 2 is socket rpc")
+	// 第一个参数为子命令时，后面的参数才是选项
+	args := os.Args[1:]
+	subCommand := ""
+	if len(args) > 0 {
+		if _, ok := subCommandMap[args[0]]; ok {
+			subCommand = args[0]
+			args = args[1:]
+		}
+	}
+
+	modeFlag := flag.Int("mode", 0, "mode type, 0 is run cmd, 1 is local rpc, 2 is socket rpc, 3 is map trace")
 	logFlag := flag.Int("logflag", 0, "0 is not open log, 1 is open log")
 	localpath := flag.String("localpath", "", "local project path")
-	flag.Parse()
+	moocToLua := flag.String("mooctolua", "", "print the lua code converted from the file, then exit")
+	moocRange := flag.String("range", "", "line range of -mooctolua, like 10-20")
+	luaOut := flag.String("luaout", "", "write the lua code of -mooctolua to the file without line numbers")
+	mapTrace := flag.String("maptrace", "", "map the lua stack trace in the file (- is stdin) to mooc lines, then exit; same as the maptrace subcommand")
+	stdioFlag := flag.Bool("stdio", false, "communicate with the client over stdin and stdout")
+	host := flag.String("host", "localhost", "tcp host to listen on in socket mode")
+	port := flag.Int("port", 7778, "tcp port to listen on in socket mode")
+	socketPath := flag.String("socket", "", "unix domain socket path to listen on instead of tcp in socket mode")
+	logFile := flag.String("logfile", "log.txt", "log file path, - is stderr")
+	logLevel := flag.String("loglevel", "", "log level: debug, info, warn, error or off (default off, debug in socket mode)")
+	logFormat := flag.String("logformat", "text", "log format: text, json or logfmt")
+	pprofAddr := flag.String("pprof", "", "address to serve pprof on, like localhost:6060, empty is disabled")
+	prometheusFlag := flag.Bool("prometheus", false, "also serve prometheus metrics on /metrics of the -pprof address")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [serve|check|maptrace] [options] [project path|trace file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(args)
 
-	// 是否开启日志
-	enableLog := false
-	if *logFlag == 1 {
-		enableLog = true
+	mode := *modeFlag
+	if subCommand != "" {
+		mode = subCommandMap[subCommand]
+	}
+	if *stdioFlag {
+		mode = modeStdio
 	}
 
-	// socket rpc时，默认开启日志，方便定位问题
-	if *modeFlag == 2 {
-		enableLog = true
+	// check 子命令可以直接跟工程的路径
+	if mode == modeCheck && *localpath == "" && flag.NArg() > 0 {
+		*localpath = flag.Arg(0)
 	}
 
-	// 开启日志时，才开启pprof
-	if enableLog {
-		go func() {
-			http.ListenAndServe("localhost:6060", nil)
-		}()
+	// maptrace 子命令可以直接跟堆栈的文件，没有时从标准输入读取
+	if mode == modeMapTrace && *mapTrace == "" {
+		*mapTrace = "-"
+		if flag.NArg() > 0 {
+			*mapTrace = flag.Arg(0)
+		}
+	}
+
+	// 日志的级别，没有设置时：socket rpc默认开启日志，方便定位问题
+	level := log.LevelOff
+	if *logFlag == 1 || mode == modeSocket {
+		level = log.LevelDebug
+	}
+	if *logLevel != "" {
+		var err error
+		if level, err = log.ParseLevel(*logLevel); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+	}
+
+	format, err := log.ParseFormat(*logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
 	}
 
 	// 初始化为日志系统
-	log.InitLog(enableLog)
+	if err := log.InitLog(*logFile, level, format); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if *prometheusFlag && *pprofAddr == "" {
+		fmt.Fprintln(os.Stderr, "-prometheus requires -pprof address")
+		os.Exit(2)
+	}
 
-	common.GlobalConfigDefautInit()
-	common.GConfig.IntialGlobalVar()
+	if *pprofAddr != "" {
+		if *prometheusFlag {
+			http.Handle("/metrics", metrics.Handler())
+		}
+		go func() {
+			if err := http.ListenAndServe(*pprofAddr, nil); err != nil {
+				log.Error("pprof listen: %v", err)
+			}
+		}()
+	}
 
-	//*modeFlag = 0
-	if *modeFlag == 1 {
+	if *moocToLua != "" {
+		langserver.RunMoocToLua(*moocToLua, *moocRange, *luaOut)
+	} else if *mapTrace != "" {
+		langserver.RunMapStackTrace(*mapTrace)
+	} else if mode == modeStdio {
 		cmdRPC()
-	} else if *modeFlag == 2 {
-		socketRPC()
-	} else if *modeFlag == 0 {
+	} else if mode == modeSocket {
+		if *socketPath != "" {
+			socketRPC("unix", *socketPath)
+		} else {
+			socketRPC("tcp", net.JoinHostPort(*host, strconv.Itoa(*port)))
+		}
+	} else if mode == modeCheck {
 		runLocalDiagnostices(*localpath)
 	}
 }
@@ -59,30 +148,37 @@ func main() {
 // cmd 的方式运行rpc
 func cmdRPC() {
 	log.Debug("local stat running ....")
-	Server := langserver.CreateServer()
-	Server.Start(channel.Header("")(os.Stdin, os.Stdout))
+	lspServer := langserver.CreateLspServer()
 
 	log.Debug("Server started ....")
-	if err := Server.Wait(); err != nil {
+	if err := lspServer.Serve(channel.Header("")(os.Stdin, os.Stdout)); err != nil {
 		log.Debug("Server exited: %v", err)
 	}
 	log.Debug("Server exited return")
 }
 
-// 网络的方式运行rpc
-func socketRPC() {
-	log.Debug("socket running ....")
-	lst, err := net.Listen("tcp", "localhost:7778")
+// 网络的方式运行rpc，network 为 tcp 或是 unix
+func socketRPC(network, address string) {
+	log.Debug("socket running ...., network=%s, address=%s", network, address)
+
+	// unix socket的文件在上次退出时可能没有删除
+	if network == "unix" {
+		if fileInfo, err := os.Stat(address); err == nil && fileInfo.Mode()&os.ModeSocket != 0 {
+			os.Remove(address)
+		}
+	}
+
+	lst, err := net.Listen(network, address)
 	if err != nil {
 		log.Error("Listen: %v", err)
-		return
+		fmt.Fprintf(os.Stderr, "listen %s %s: %v\n", network, address, err)
+		os.Exit(1)
 	}
 
 	var wg sync.WaitGroup
 	for {
 		conn, err := lst.Accept()
 		log.Debug("accept new conn ....")
-		Server := langserver.CreateServer()
 		if err != nil {
 			if channel.IsErrClosing(err) {
 				err = nil
@@ -93,12 +189,12 @@ func socketRPC() {
 			log.Error("Error accepting new connection: %v", err)
 			return
 		}
+		lspServer := langserver.CreateLspServer()
 		ch := channel.Header("")(conn, conn)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			Server.Start(ch)
-			if err := Server.Wait(); err != nil && err != io.EOF {
+			if err := lspServer.Serve(ch); err != nil && err != io.EOF {
 				log.Debug("Server exited: %v", err)
 			}
 
@@ -0,0 +1,20 @@
+---@class Counter
+local Counter = {}
+
+function Counter.new()
+    return setmetatable({ value = 0 }, { __index = Counter })
+end
+
+function Counter:add(num)
+    self.value = self.value + num
+end
+
+local function double(num)
+    return num * 2
+end
+
+local c = Counter.new()
+c:add(double(1))
+c:add(double(2))
+
+return Counter
@@ -0,0 +1,15 @@
+local Counter = require("counter")
+
+describe("Counter", function()
+    it("starts at zero", function()
+        assert.are.equal(0, Counter.new().value)
+    end)
+
+    describe("add", function()
+        it("adds the number", function()
+            local c = Counter.new()
+            c:add(2)
+            assert.are.equal(2, c.value)
+        end)
+    end)
+end)
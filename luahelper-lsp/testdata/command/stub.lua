@@ -0,0 +1,28 @@
+local M = {}
+
+function M.add(a, b)
+    return a + b
+end
+
+---@param name string
+---@return string
+function M.greet(name)
+    return "hello " .. name
+end
+
+function M:log(...)
+    print(self, ...)
+end
+
+local function divmod(a, b)
+    if b == 0 then
+        return nil
+    end
+    return a // b, a % b
+end
+
+function M.noop()
+end
+
+M.divmod = divmod
+return M
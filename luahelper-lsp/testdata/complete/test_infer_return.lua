@@ -0,0 +1,10 @@
+local function makePlayer(id)
+    if id == 0 then
+        return unknownPlayer
+    end
+
+    return { name = "a", hp = 100 }
+end
+
+local p = makePlayer(1)
+
@@ -0,0 +1,19 @@
+local Grand = {}
+function Grand.grandFunc() end
+
+local Base = setmetatable({}, {__index = Grand})
+Base.__index = Base
+function Base.new()
+    local o = setmetatable({}, Base)
+    return o
+end
+function Base:baseFunc() end
+
+local obj = Base.new()
+
+local Proxy = {}
+setmetatable(Proxy, {__index = function(t, k) return Base[k] end})
+
+local Class = setmetatable({}, {__call = function(cls) return setmetatable({}, {__index = cls}) end})
+function Class:classFunc() end
+local inst = Class()
@@ -0,0 +1,23 @@
+---@class Money
+---@field value number
+local M = {}
+M.__index = M
+
+---@return Money
+function M.new(v)
+    return setmetatable({ value = v }, M)
+end
+
+function M.__add(a, b)
+    return M.new(a.value + b)
+end
+
+---@return string
+function M.__concat(a, b)
+    return tostring(a.value) .. b
+end
+
+local m1 = M.new(1)
+local m2 = m1 + 2
+local m3 = m1 .. "yuan"
+
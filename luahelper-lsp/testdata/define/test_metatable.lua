@@ -0,0 +1,8 @@
+local Grand = {}
+function Grand.grandFunc() end
+
+local Base = setmetatable({}, {__index = Grand})
+Base.__index = Base
+
+local obj = setmetatable({}, Base)
+obj.grandFunc()
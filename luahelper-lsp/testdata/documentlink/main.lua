@@ -0,0 +1,6 @@
+-- docs: https://www.lua.org/manual/5.4/manual.html, see also http://example.com/a?b=1.
+local util = require("util")
+local helper = require "lib.helper"
+dofile("missing.lua")
+
+util.run(helper)
@@ -0,0 +1,7 @@
+local util = {}
+
+function util.run(helper)
+    return helper
+end
+
+return util
@@ -0,0 +1,14 @@
+local t = { x = 1 }
+t.name = "a"
+print(t.x, t.name)
+print(t.y)
+print(t.size)
+
+local k = "z"
+local d = { [k] = 1 }
+print(d.w)
+
+local function read(p)
+    return p.never
+end
+read(t)
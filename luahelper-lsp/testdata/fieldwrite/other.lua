@@ -0,0 +1,3 @@
+local o = {}
+o.size = 10
+return o
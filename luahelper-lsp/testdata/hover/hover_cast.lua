@@ -0,0 +1,14 @@
+---@class CastPlayer
+---@field hp number
+local CastPlayer = {}
+
+local function getObj()
+    return nil
+end
+
+local obj = getObj()
+---@cast obj CastPlayer
+print(obj)
+
+local other = getObj() --[[@as CastPlayer]]
+print(other)
@@ -0,0 +1,17 @@
+local function makePlayer(id)
+    if id == 0 then
+        return "none"
+    end
+
+    if id == 1 then
+        return nil
+    end
+
+    return { name = "a", hp = 100 }
+end
+
+local function makeName()
+    return "a"
+end
+
+local p = makePlayer(1)
@@ -0,0 +1,6 @@
+local shape = { x = 1, name = "a" }
+shape.x = "b"
+shape.hp = 100
+
+print(shape)
+print(shape.x)
@@ -0,0 +1,18 @@
+local M = {}
+local count = 0
+
+local function add(a, b)
+    local sum = a + b
+    return sum
+end
+
+function M.get(x)
+    local value = x * 2
+    return value
+end
+
+function M.set(x)
+    count = x
+end
+
+return M
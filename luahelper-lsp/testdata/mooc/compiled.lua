@@ -0,0 +1,12 @@
+local Foo = { __tn = 'Foo', __tk = 'class', __st = nil }
+do
+	local __st = nil
+	local __ct = Foo
+	__ct.__ct = __ct
+	__ct.__ic = { Foo = true }
+	function __ct:bar(a)
+		if not a then return end
+		print(a.b)
+	end
+end
+return Foo
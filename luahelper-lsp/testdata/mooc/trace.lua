@@ -0,0 +1,13 @@
+Foo = {}
+do -- class
+    local Self = Foo
+    local Super = nil
+    local __st = nil
+    function Foo:bar(a)
+        if not a then
+            return
+        end
+        print(a.b)
+    end
+end
+return Foo
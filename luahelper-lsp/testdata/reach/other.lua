@@ -0,0 +1 @@
+usedFunc()
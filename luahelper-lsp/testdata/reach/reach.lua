@@ -0,0 +1,40 @@
+local function check(a)
+    if a then
+        return 1
+    else
+        error("invalid")
+    end
+    print("after if")
+end
+
+function usedFunc()
+    for i = 1, 10 do
+        if i > 5 then
+            break
+            print(i)
+        end
+        goto continue
+        print("skip")
+        ::continue::
+    end
+end
+
+function unusedFunc()
+    while true do
+        check(1)
+    end
+    print("after loop")
+end
+
+if false then
+    print("never")
+end
+
+do
+    local error = print
+    error("not exit")
+    print("reachable")
+end
+
+os.exit(0)
+print("after exit")
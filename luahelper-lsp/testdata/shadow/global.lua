@@ -0,0 +1,8 @@
+gShadowName = 1
+
+local function show()
+    local gShadowName = 2
+    return gShadowName
+end
+
+return show
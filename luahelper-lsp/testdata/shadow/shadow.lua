@@ -0,0 +1,51 @@
+local a = 1
+local a = 2
+
+gName = 1
+
+local function test(p)
+    local p = 3
+    local q = p or 1
+    local p2 = p2
+    if q then
+        local a = 4
+        local gName = 5
+        local q = q + 1
+    end
+    return a, q, gName
+end
+
+local table = {}
+local string = string
+local pairs = pairs
+
+for i = 1, 10 do
+    local i = 2
+    for i = 1, 2 do
+        print(i)
+    end
+end
+
+local funcs = {}
+for i = 1, 3 do
+    funcs[i] = function()
+        return i
+    end
+    i = i + 1
+end
+
+for _, v in ipairs(funcs) do
+    local f = function()
+        v = nil
+    end
+    f()
+end
+
+for k = 1, 3 do
+    k = k + 1
+    print(k)
+end
+
+local _ = 1
+local _ = 2
+return table, test, a
@@ -0,0 +1,2 @@
+local gShadowName = 3
+return gShadowName
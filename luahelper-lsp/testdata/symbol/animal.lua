@@ -0,0 +1,23 @@
+---@class Animal
+local Animal = {}
+
+function Animal:speak()
+    return "..."
+end
+
+function Animal.create()
+    return setmetatable({}, {__index = Animal})
+end
+
+Animal.legs = 4
+
+---@type enum table
+ColorType = {
+    red = 1,
+    green = 2,
+}
+
+function helperUtil()
+end
+
+return Animal
@@ -0,0 +1,10 @@
+---@class Baz
+---@field name string
+---@field n number
+local Baz = {}
+
+function Baz:get()
+    return self.n
+end
+
+return Baz
@@ -28,6 +28,8 @@ let luadoc = require('../client/3rd/vscode-lua-doc/extension.js');
 
 const LANGUAGE_ID = 'lua';
 const LANGUAGE_MID = 'mooc';
+// 可能嵌入了lua代码的语言，例如 nginx 配置中的 content_by_lua_block，markdown 中的 lua 代码块
+const EMBED_LANGUAGE_IDS = ['nginx', 'markdown', 'html'];
 
 export let savedContext: vscode.ExtensionContext;
 let client: LanguageClient;
@@ -188,6 +190,12 @@ async function doStartServer() {
         requirePathSeparator = <string><any>requirePathSeparatorConfig;
     }
 
+    let telemetryEndpointConfig = vscode.workspace.getConfiguration("luahelper.base", null).get("telemetryEndpoint");
+    var telemetryEndpoint: string = "";
+    if (telemetryEndpointConfig !== undefined) {
+        telemetryEndpoint = <string><any>telemetryEndpointConfig;
+    }
+
     let lspLogConfig = vscode.workspace.getConfiguration("luahelper.base", null).get("lspserverLog");
     var lspLogFlag = false;
     if (lspLogConfig !== undefined) {
@@ -213,11 +221,26 @@ async function doStartServer() {
         }
     }
 
+    // 嵌入了lua代码的文件关联，key为文件的匹配模式，value为方言的名称，例如 "*.conf": "nginx"
+    let embedAssociationsConfig: { [key: string]: string } | undefined = vscode.workspace.getConfiguration("luahelper.base", null).get("EmbedAssociations");
+    if (embedAssociationsConfig === undefined) {
+        embedAssociationsConfig = {};
+    }
+
+    var documentSelector: { scheme: string, language?: string, pattern?: string }[] = [{ scheme: 'file', language: LANGUAGE_ID }, { scheme: 'file', language: LANGUAGE_MID }];
+    for (const language of EMBED_LANGUAGE_IDS) {
+        documentSelector.push({ scheme: 'file', language: language });
+    }
+    for (const key of Object.keys(embedAssociationsConfig)) {
+        documentSelector.push({ scheme: 'file', pattern: "**/" + key });
+        filesWatchers.push(vscode.workspace.createFileSystemWatcher("**/" + key));
+    }
+
     let ignoreFileOrDirArr: string[] | undefined = vscode.workspace.getConfiguration("luahelper.base", null).get("ignoreFileOrDir");
     let ignoreFileOrDirErrArr: string[] | undefined = vscode.workspace.getConfiguration("luahelper.base", null).get("ignoreFileOrDirError");
 
     const clientOptions: LanguageClientOptions = {
-        documentSelector: [{ scheme: 'file', language: LANGUAGE_ID }, { scheme: 'file', language: LANGUAGE_MID }],
+        documentSelector: documentSelector,
         synchronize: {
             configurationSection: ["luahelper", "files.associations"],
             fileEvents: filesWatchers,
@@ -226,6 +249,7 @@ async function doStartServer() {
             client: 'vsc',
             PluginPath: savedContext.extensionPath,
             FileAssociationsConfig: fileAssociationsConfig,
+            EmbedAssociationsConfig: embedAssociationsConfig,
             AllEnable: getWarnCheckFlag("AllEnable"),
             CheckSyntax: getWarnCheckFlag("CheckSyntax"),
             CheckNoDefine: getWarnCheckFlag("CheckNoDefine"),
@@ -252,10 +276,21 @@ async function doStartServer() {
             CheckConstAssign: getWarnCheckFlag("CheckConstAssign"),
             CheckFuncParamType: getWarnCheckFlag("CheckFuncParamType"),
             CheckFuncReturnType: getWarnCheckFlag("CheckFuncReturnType"),
+            CheckMoocExtension: getWarnCheckFlag("CheckMoocExtension"),
+            CheckMoocDuplicateMethod: getWarnCheckFlag("CheckMoocDuplicateMethod"),
+            CheckMoocSelfSuper: getWarnCheckFlag("CheckMoocSelfSuper"),
+            CheckMoocImportNum: getWarnCheckFlag("CheckMoocImportNum"),
+            CheckMoocUnreachable: getWarnCheckFlag("CheckMoocUnreachable"),
+            CheckMoocDuplicateCase: getWarnCheckFlag("CheckMoocDuplicateCase"),
+            CheckUnreachable: getWarnCheckFlag("CheckUnreachable"),
+            CheckLocalRedeclare: getWarnCheckFlag("CheckLocalRedeclare"),
+            CheckShadowVar: getWarnCheckFlag("CheckShadowVar"),
+            CheckShadowSys: getWarnCheckFlag("CheckShadowSys"),
+            CheckLoopVarCapture: getWarnCheckFlag("CheckLoopVarCapture"),
             IgnoreFileOrDir: ignoreFileOrDirArr,
             IgnoreFileOrDirError: ignoreFileOrDirErrArr,
             RequirePathSeparator: requirePathSeparator,
-	    EnableReport: false,
+            TelemetryEndpoint: telemetryEndpoint,
         },
         markdown: {
             isTrusted: true,
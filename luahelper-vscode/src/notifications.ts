@@ -29,4 +29,6 @@ export interface GetOnlineParams {
 
 export interface GetOnlineReturn {
     Num: number;// 所有在线的人数
+    Enabled: boolean;// 是否开启了统计上报
+    Sink?: string;// 上报的方式，例如 udp、file
 }
\ No newline at end of file